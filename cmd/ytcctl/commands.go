@@ -5,19 +5,22 @@ import (
 	"ytc/internal/api/controller/ytcctlcontroller/clean"
 	"ytc/internal/api/controller/ytcctlcontroller/collect"
 	"ytc/internal/api/controller/ytcctlcontroller/daemon"
+	"ytc/internal/api/controller/ytcctlcontroller/keygen"
 	"ytc/internal/api/controller/ytcctlcontroller/report"
 	"ytc/internal/api/controller/ytcctlcontroller/strategy"
+	"ytc/internal/api/controller/ytcctlcontroller/verify"
 	"ytc/internal/api/controller/ytcctlcontroller/yasdb"
 )
 
 type App struct {
 	flags.Globals
 
-	Collect collect.CollectCmd `cmd:"collect" name:"collect" help:"The collect command is used to gather trace data."`
-	// TODO: remove hidden:"true" when commands are supported
-	Daemon   daemon.DaemonCmd     `cmd:"daemon"   name:"daemon"   hidden:"true" help:"The daemon command is used to manage the life cycle of ytcd."`
-	Strategy strategy.StrategyCmd `cmd:"strategy" name:"strategy" hidden:"true" help:"The strategy command is used to manage the collector strategy."`
-	Clean    clean.CleanCmd       `cmd:"clean"    name:"clean"    hidden:"true" help:"The clean command is used to clean related processes."`
-	YasdbCmd yasdb.YasdbCmd       `cmd:"yasdb"    name:"yasdb"    hidden:"true" help:"The yasdb command is used to manage yasshandb information."`
-	Report   report.ReportCmd     `cmd:"report"   name:"report"   hidden:"true" help:"The report command is used to generate new reports from collection result."`
+	Collect  collect.CollectCmd   `cmd:"collect"  name:"collect"  help:"The collect command is used to gather trace data."`
+	Report   report.ReportCmd     `cmd:"report"   name:"report"   help:"The report command is used to generate new reports from collection result."`
+	Daemon   daemon.DaemonCmd     `cmd:"daemon"   name:"daemon"   help:"The daemon command is used to manage the life cycle of ytcd."`
+	Strategy strategy.StrategyCmd `cmd:"strategy" name:"strategy" help:"The strategy command is used to manage the collector strategy."`
+	YasdbCmd yasdb.YasdbCmd       `cmd:"yasdb"    name:"yasdb"    help:"The yasdb command is used to manage yashandb connection profiles."`
+	Clean    clean.CleanCmd       `cmd:"clean"    name:"clean"    help:"The clean command is used to remove expired collection results and the leftovers of interrupted collections."`
+	Verify   verify.VerifyCmd     `cmd:"verify"   name:"verify"   help:"The verify command is used to check a collection result against the manifest in it."`
+	Keygen   keygen.KeygenCmd     `cmd:"keygen"   name:"keygen"   help:"The keygen command is used to generate the key pair to encrypt the collection results."`
 }
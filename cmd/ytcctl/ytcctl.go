@@ -3,15 +3,20 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"ytc/commons/flags"
 	"ytc/commons/std"
 	"ytc/defs/compiledef"
 	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
 	"ytc/defs/runtimedef"
 	"ytc/log"
+	"ytc/utils/pwdutil"
 
 	"git.yasdb.com/go/yaserr"
 	"github.com/alecthomas/kong"
@@ -22,19 +27,48 @@ const (
 	_APP_DESCRIPTION = "Ytcctl is used to manage the yashan trace collector."
 )
 
+// flags whose values should never be written to console.out
+var _sensitiveFlags = []string{"--yasdb-password"}
+
 func main() {
 	var app App
 	options := flags.NewAppOptions(_APP_NAME, _APP_DESCRIPTION, compiledef.GetAPPVersion())
-	ctx := kong.Parse(&app, options...)
+	ctx := parse(&app, options...)
 	if err := initApp(app); err != nil {
 		ctx.FatalIfErrorf(err)
 	}
+	os.Exit(run(ctx, &app.Globals))
+}
+
+// parse is the same as kong.Parse, but exits with EXIT_CODE_INVALID_INPUT when the command line is invalid.
+func parse(app *App, options ...kong.Option) *kong.Context {
+	parser := kong.Must(app, options...)
+	ctx, err := parser.Parse(os.Args[1:])
+	if err != nil {
+		parser.Exit = func(int) { os.Exit(constdef.EXIT_CODE_INVALID_INPUT) }
+		parser.FatalIfErrorf(err)
+	}
+	return ctx
+}
+
+// run executes the selected command and returns the exit code of the process.
+func run(ctx *kong.Context, binds ...interface{}) int {
 	finalize := std.GetRedirecter().RedirectStd()
 	defer finalize()
-	std.WriteToFile(fmt.Sprintf("execute: %s %s\n", _APP_NAME, strings.Join(ctx.Args, " ")))
-	if err := ctx.Run(); err != nil {
+	args := pwdutil.DesensitizeArgs(ctx.Args, _sensitiveFlags...)
+	std.WriteToFile(fmt.Sprintf("execute: %s %s\n", _APP_NAME, strings.Join(args, " ")))
+	if err := ctx.Run(binds...); err != nil {
+		var exitErr *errdef.ErrExit
+		if errors.As(err, &exitErr) {
+			if exitErr.Err != nil {
+				fmt.Println(yaserr.Unwrap(exitErr.Err))
+			}
+			return exitErr.Code
+		}
 		fmt.Println(yaserr.Unwrap(err))
+		return constdef.EXIT_CODE_FAILED
 	}
+	return constdef.EXIT_CODE_SUCCESS
 }
 
 func initLogger(logPath, level string) error {
@@ -2,8 +2,10 @@ package main
 
 import (
 	"ytc/commons/flags"
+	"ytc/internal/api/controller/ytcdcontroller/daemon"
 )
 
 type App struct {
 	flags.Globals
+	daemon.DaemonCmd
 }
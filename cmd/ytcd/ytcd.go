@@ -7,6 +7,7 @@ import (
 	"ytc/defs/compiledef"
 	"ytc/defs/confdef"
 	"ytc/defs/runtimedef"
+	"ytc/log"
 
 	"github.com/alecthomas/kong"
 )
@@ -23,11 +24,19 @@ func main() {
 	if err := initApp(app); err != nil {
 		ctx.FatalIfErrorf(err)
 	}
-	if err := ctx.Run(); err != nil {
+	if err := ctx.Run(&app.Globals); err != nil {
 		ctx.FatalIfErrorf(err)
 	}
 }
 
+func initLogger(logPath, level string) error {
+	optFuncs := []log.OptFunc{
+		log.SetLogPath(logPath),
+		log.SetLevel(level),
+	}
+	return log.InitLogger(_APP_NAME, log.NewLogOption(optFuncs...))
+}
+
 func initApp(app App) error {
 	if err := runtimedef.InitRuntime(); err != nil {
 		return err
@@ -35,5 +44,8 @@ func initApp(app App) error {
 	if err := confdef.InitConf(app.Config); err != nil {
 		return err
 	}
+	if err := initLogger(runtimedef.GetLogPath(), confdef.GetYTCConf().LogLevel); err != nil {
+		return err
+	}
 	return nil
 }
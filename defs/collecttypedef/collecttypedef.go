@@ -1,12 +1,17 @@
 package collecttypedef
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
+	"ytc/defs/regexdef"
 	"ytc/defs/timedef"
+	"ytc/utils/pwdutil"
 )
 
 const (
@@ -23,7 +28,17 @@ const (
 	WT_DISK    WorkloadType = "disk"
 )
 
-const PACKAGE_NAME_PREFIX = "ytc"
+const (
+	PACKAGE_NAME_PREFIX         = "ytc"
+	CLUSTER_PACKAGE_NAME_PREFIX = "ytc-cluster" // the combined package of the nodes collected by 'ytcctl collect --hosts'
+)
+
+const (
+	INSTANCES_DIR_NAME = "instances" // the DB-level items of the instances are collected into instances/<name> of the package
+	INSTANCE_KEY_SEP   = "@"
+
+	_yasdb_item_prefix = "YashanDB-"
+)
 
 var (
 	ErrKnownType = errors.New("unknow collect type")
@@ -47,17 +62,38 @@ var (
 )
 
 type CollectParam struct {
-	StartTime       time.Time `json:"startTime"`
-	EndTime         time.Time `json:"endTime"`
-	Output          string    `json:"output"`
-	YasdbHome       string    `json:"yasdbHome"`
-	YasdbData       string    `json:"yasdbData"`
-	YasdbUser       string    `json:"yasdbUser"`
-	YasdbPassword   string    `json:"yasdbPassword"`
-	Include         []string  `json:"include"`
-	Exclude         []string  `json:"exclude"`
-	BeginTime       time.Time `json:"-"`
-	YasdbHomeOSUser string    `json:"-"`
+	StartTime       time.Time      `json:"startTime"`
+	EndTime         time.Time      `json:"endTime"`
+	Output          string         `json:"output"`
+	YasdbHome       string         `json:"yasdbHome"`
+	YasdbData       string         `json:"yasdbData"`
+	YasdbUser       string         `json:"yasdbUser"`
+	YasdbPassword   pwdutil.Secret `json:"-"`
+	YasdbAddress    string         `json:"yasdbAddress,omitempty"` // the DB-level items are collected over TCP from it if it is not empty
+	Include         []string       `json:"include"`
+	Exclude         []string       `json:"exclude"`
+	Items           []string       `json:"items,omitempty"`     // only these items are collected if not empty
+	SkipItems       []string       `json:"skipItems,omitempty"` // these items are never collected
+	Instances       []Instance     `json:"instances,omitempty"` // the instances of the multi-instance collection
+	Instance        string         `json:"instance,omitempty"`  // the instance whose DB-level items are collected with the param
+	BeginTime       time.Time      `json:"-"`
+	YasdbHomeOSUser string         `json:"-"`
+
+	ctx      context.Context // cancelled when the collection is interrupted
+	itemCtxs *itemContexts   // the contexts of the running items, which are done when they time out
+	host     *CollectParam   // the param of the host which the param of an instance shares the contexts with
+}
+
+type itemContexts struct {
+	mtx  sync.Mutex
+	ctxs map[string]context.Context
+}
+
+// Instance is one of the YashanDB instances on the host collected in a single run.
+type Instance struct {
+	Name      string `json:"name"`
+	YasdbHome string `json:"yasdbHome"`
+	YasdbData string `json:"yasdbData"`
 }
 
 type WorkloadItem map[string]interface{}
@@ -67,13 +103,36 @@ type WorkloadOutput map[int64]WorkloadItem
 type WorkloadType string
 
 func GetTypeFullName(s string) string {
-	full, ok := typeFullName[s]
+	t, instance := ParseInstanceKey(s)
+	full, ok := typeFullName[t]
 	if !ok {
-		full = s
+		full = t
+	}
+	if len(instance) != 0 {
+		return GenInstanceKey(full, instance)
 	}
 	return full
 }
 
+// GenInstanceKey returns the key of the module or item of the instance, such as 'diag@db1'.
+func GenInstanceKey(name, instance string) string {
+	return name + INSTANCE_KEY_SEP + instance
+}
+
+// ParseInstanceKey splits the key into the module or item and the instance, the instance is empty if it is not an instance key.
+func ParseInstanceKey(key string) (name, instance string) {
+	fields := strings.SplitN(key, INSTANCE_KEY_SEP, 2)
+	if len(fields) < 2 {
+		return key, ""
+	}
+	return fields[0], fields[1]
+}
+
+// IsYasdbItem reports whether the item is a DB-level one, which is collected once per instance in the multi-instance collection.
+func IsYasdbItem(item string) bool {
+	return strings.HasPrefix(item, _yasdb_item_prefix)
+}
+
 func (c *CollectParam) GetPackageTimestamp() string {
 	// use begin time as timestamp
 	return c.BeginTime.Format(timedef.TIME_FORMAT_IN_FILE)
@@ -83,6 +142,143 @@ func (c *CollectParam) GetPackageName() string {
 	return fmt.Sprintf("%s-%s", PACKAGE_NAME_PREFIX, c.GetPackageTimestamp())
 }
 
+// SetContext sets the context of the collection, the collect funcs and their child processes stop when it is done.
+func (c *CollectParam) SetContext(ctx context.Context) {
+	c.ctx = ctx
+	c.itemCtxs = &itemContexts{ctxs: make(map[string]context.Context)}
+}
+
+// SetItemContext sets the context of the running item, it is derived from the context of the collection and done when
+// the item times out. The item of an instance is keyed by the instance, such as 'YashanDB-AlertLog@db1'.
+// The context is removed with nil after the item returns.
+func (c *CollectParam) SetItemContext(key string, ctx context.Context) {
+	if c.itemCtxs == nil {
+		return
+	}
+	c.itemCtxs.mtx.Lock()
+	defer c.itemCtxs.mtx.Unlock()
+	if ctx == nil {
+		delete(c.itemCtxs.ctxs, key)
+		return
+	}
+	c.itemCtxs.ctxs[key] = ctx
+}
+
+// ItemContext returns the context of the item, the child processes and the copies of the item stop when it is done.
+// It is the context of the collection if the item is not running.
+func (c *CollectParam) ItemContext(item string) context.Context {
+	if c.host != nil {
+		return c.host.itemContext(GenInstanceKey(item, c.Instance))
+	}
+	return c.itemContext(item)
+}
+
+func (c *CollectParam) itemContext(key string) context.Context {
+	if c.itemCtxs == nil {
+		return c.Context()
+	}
+	c.itemCtxs.mtx.Lock()
+	defer c.itemCtxs.mtx.Unlock()
+	if ctx, ok := c.itemCtxs.ctxs[key]; ok {
+		return ctx
+	}
+	return c.Context()
+}
+
+func (c *CollectParam) Context() context.Context {
+	if c.host != nil {
+		return c.host.Context()
+	}
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// IsRemote reports whether the DB-level items are collected over TCP from YasdbAddress, the items which need the files
+// or processes of the instance are not applicable then.
+func (c *CollectParam) IsRemote() bool {
+	return len(c.YasdbAddress) != 0
+}
+
+// IsMultiInstance reports whether the DB-level items are collected once per instance of Instances.
+func (c *CollectParam) IsMultiInstance() bool {
+	return len(c.Instances) != 0
+}
+
+// AddInstance adds the instance of YASDB_HOME and YASDB_DATA to Instances, it is named by the base name of YASDB_DATA,
+// and the duplicated name is suffixed with the sequence, such as 'yasdb-2'.
+func (c *CollectParam) AddInstance(yasdbHome, yasdbData string) Instance {
+	base := regexdef.InstanceNameInvalidCharRegex.ReplaceAllString(path.Base(path.Clean(yasdbData)), "_")
+	name := base
+	for i := 2; c.hasInstance(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	instance := Instance{Name: name, YasdbHome: yasdbHome, YasdbData: yasdbData}
+	c.Instances = append(c.Instances, instance)
+	return instance
+}
+
+func (c *CollectParam) hasInstance(name string) bool {
+	for _, instance := range c.Instances {
+		if instance.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ForInstance returns the param to collect the DB-level items of the instance, the files are collected into its dir.
+func (c *CollectParam) ForInstance(instance Instance) *CollectParam {
+	param := *c
+	param.YasdbHome = instance.YasdbHome
+	param.YasdbData = instance.YasdbData
+	param.Instances = nil
+	param.Instance = instance.Name
+	param.host = c
+	return &param
+}
+
+// HasYasdbItems reports whether the DB-level items are collected with the param, they are not in the param of the host
+// of the multi-instance collection.
+func (c *CollectParam) HasYasdbItems() bool {
+	return !c.IsMultiInstance()
+}
+
+// HasHostItems reports whether the host-level items are collected with the param, they are not in the param of an instance.
+func (c *CollectParam) HasHostItems() bool {
+	return len(c.Instance) == 0
+}
+
+// GetInstanceDir returns the dir of the instance relative to the package dir, it is empty for the host-level items.
+func (c *CollectParam) GetInstanceDir() string {
+	if len(c.Instance) == 0 {
+		return ""
+	}
+	return path.Join(INSTANCES_DIR_NAME, c.Instance)
+}
+
+// IsItemSelected reports whether the item is selected by the items and skip items. In the multi-instance collection,
+// the param of the host only selects the host-level items and the param of an instance only selects the DB-level items.
+func (c *CollectParam) IsItemSelected(item string) bool {
+	if IsYasdbItem(item) && !c.HasYasdbItems() || !IsYasdbItem(item) && !c.HasHostItems() {
+		return false
+	}
+	if len(c.Items) != 0 && !containsItem(c.Items, item) {
+		return false
+	}
+	return !containsItem(c.SkipItems, item)
+}
+
+func containsItem(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *CollectParam) GenPackageRelativePath(p string) string {
-	return path.Join(c.GetPackageName(), p)
+	return path.Join(c.GetPackageName(), c.GetInstanceDir(), p)
 }
@@ -0,0 +1,69 @@
+package collecttypedef_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ytc/defs/collecttypedef"
+)
+
+func TestMultiInstance(t *testing.T) {
+	param := &collecttypedef.CollectParam{BeginTime: time.Date(2023, 1, 1, 12, 0, 0, 0, time.Local)}
+	for _, data := range []string{"/data/yasdb/db1", "/data2/yasdb/db1/", "/data/yasdb/db 2"} {
+		param.AddInstance("/home/yasdb", data)
+	}
+	names := []string{"db1", "db1-2", "db_2"}
+	for i, instance := range param.Instances {
+		if instance.Name != names[i] {
+			t.Errorf("expected instance name %s, got %s", names[i], instance.Name)
+		}
+	}
+	instance := param.ForInstance(param.Instances[1])
+	if instance.YasdbData != "/data2/yasdb/db1/" || instance.IsMultiInstance() {
+		t.Errorf("unexpected param of instance: %+v", instance)
+	}
+	if p := instance.GenPackageRelativePath("yasdb/log/alert.log"); p != "ytc-20230101120000/instances/db1-2/yasdb/log/alert.log" {
+		t.Errorf("unexpected package relative path: %s", p)
+	}
+	cases := []struct {
+		param    *collecttypedef.CollectParam
+		item     string
+		selected bool
+	}{
+		{param: param, item: "Host-CPU", selected: true},
+		{param: param, item: "YashanDB-AlertLog", selected: false},
+		{param: instance, item: "Host-CPU", selected: false},
+		{param: instance, item: "YashanDB-AlertLog", selected: true},
+		{param: &collecttypedef.CollectParam{}, item: "YashanDB-AlertLog", selected: true},
+	}
+	for _, c := range cases {
+		if c.param.IsItemSelected(c.item) != c.selected {
+			t.Errorf("%s of instance '%s' should be selected: %v", c.item, c.param.Instance, c.selected)
+		}
+	}
+	if name, instance := collecttypedef.ParseInstanceKey(collecttypedef.GenInstanceKey(collecttypedef.TYPE_DIAG, "db1")); name != collecttypedef.TYPE_DIAG || instance != "db1" {
+		t.Errorf("unexpected instance key: %s %s", name, instance)
+	}
+}
+
+func TestItemContext(t *testing.T) {
+	param := &collecttypedef.CollectParam{}
+	instance := param.ForInstance(param.AddInstance("/home/yasdb", "/data/yasdb/db1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	param.SetContext(ctx)
+	itemCtx, stop := context.WithCancel(ctx)
+	param.SetItemContext(collecttypedef.GenInstanceKey("YashanDB-AlertLog", "db1"), itemCtx)
+	stop()
+	if instance.ItemContext("YashanDB-AlertLog").Err() == nil {
+		t.Errorf("the item of the instance should be stopped with its context")
+	}
+	if param.ItemContext("YashanDB-AlertLog").Err() != nil || instance.ItemContext("YashanDB-RunLog").Err() != nil {
+		t.Errorf("the other items should run with the context of the collection")
+	}
+	param.SetItemContext(collecttypedef.GenInstanceKey("YashanDB-AlertLog", "db1"), nil)
+	if instance.ItemContext("YashanDB-AlertLog").Err() != nil {
+		t.Errorf("the context of the item should be removed")
+	}
+}
@@ -0,0 +1,160 @@
+package confdef
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ytc/defs/collecttypedef"
+	"ytc/defs/errdef"
+	"ytc/utils/stringutil"
+	"ytc/utils/timeutil"
+)
+
+const (
+	SCHEDULE_ACCESS_POLICY_YES  = "yes"
+	SCHEDULE_ACCESS_POLICY_SKIP = "skip"
+	SCHEDULE_ACCESS_POLICY_FAIL = "fail"
+)
+
+const (
+	_schedule_at_format = "15:04"
+)
+
+var (
+	_schedule_type_help   = "you can choose one or more of (base|diag|perf), split with ','"
+	_schedule_at_help     = "it should be a time of day such as '02:00'"
+	_schedule_policy_help = "you can choose one of (yes|skip|fail)"
+)
+
+// Schedule is a collection which is run periodically by ytcd.
+type Schedule struct {
+	Name         string `toml:"name"`
+	Type         string `toml:"type"`
+	Interval     string `toml:"interval"`      // run every <interval>, such as '1d', '1h'
+	At           string `toml:"at"`            // align the runs to a time of day, such as '02:00'
+	Range        string `toml:"range"`         // the time range of each collection, default value is <interval>
+	Include      string `toml:"include"`       // the same as 'ytcctl collect --include'
+	Exclude      string `toml:"exclude"`       // the same as 'ytcctl collect --exclude'
+	Credentials  string `toml:"credentials"`   // the same as 'ytcctl collect --credentials'
+	AccessPolicy string `toml:"access_policy"` // how to deal with the inaccessible items, choose one of (yes|skip|fail)
+}
+
+func (s Schedule) GetInterval() (time.Duration, error) {
+	return timeutil.GetDuration(s.Interval)
+}
+
+func (s Schedule) GetRange() (time.Duration, error) {
+	if stringutil.IsEmpty(s.Range) {
+		return s.GetInterval()
+	}
+	return timeutil.GetDuration(s.Range)
+}
+
+func (s Schedule) GetTypes() map[string]struct{} {
+	types := make(map[string]struct{})
+	for _, t := range strings.Split(s.Type, stringutil.STR_COMMA) {
+		types[strings.TrimSpace(t)] = struct{}{}
+	}
+	return types
+}
+
+func (s Schedule) GetAccessPolicy() string {
+	if stringutil.IsEmpty(s.AccessPolicy) {
+		return SCHEDULE_ACCESS_POLICY_YES
+	}
+	return s.AccessPolicy
+}
+
+// NextRun returns the first run time which is after <after>.
+func (s Schedule) NextRun(after time.Time) (time.Time, error) {
+	interval, err := s.GetInterval()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if stringutil.IsEmpty(s.At) {
+		return after.Add(interval), nil
+	}
+	at, err := time.Parse(_schedule_at_format, s.At)
+	if err != nil {
+		return time.Time{}, err
+	}
+	anchor := time.Date(after.Year(), after.Month(), after.Day(), at.Hour(), at.Minute(), 0, 0, after.Location())
+	diff := after.Sub(anchor)
+	n := diff / interval
+	if diff < 0 && diff%interval != 0 {
+		n--
+	}
+	return anchor.Add((n + 1) * interval), nil
+}
+
+// Validate checks the schedule, <collect> is used to check the range.
+func (s Schedule) Validate(collect Collect) error {
+	if stringutil.IsEmpty(s.Name) {
+		return fmt.Errorf("the name of schedule should not be empty")
+	}
+	if err := s.validateType(); err != nil {
+		return err
+	}
+	if _, err := s.GetInterval(); err != nil {
+		return errdef.NewErrYtcFlag(s.key("interval"), s.Interval, nil, _strategy_duration_help)
+	}
+	if !stringutil.IsEmpty(s.At) {
+		if _, err := time.Parse(_schedule_at_format, s.At); err != nil {
+			return errdef.NewErrYtcFlag(s.key("at"), s.At, nil, _schedule_at_help)
+		}
+	}
+	r, err := s.GetRange()
+	if err != nil {
+		return errdef.NewErrYtcFlag(s.key("range"), s.Range, nil, _strategy_duration_help)
+	}
+	min, max, err := collect.GetMinAndMaxDur()
+	if err != nil {
+		return err
+	}
+	if r > max {
+		return errdef.NewGreaterMaxDur(collect.MaxDuration)
+	}
+	if r < min {
+		return errdef.NewLessMinDur(collect.MinDuration)
+	}
+	switch s.GetAccessPolicy() {
+	case SCHEDULE_ACCESS_POLICY_YES, SCHEDULE_ACCESS_POLICY_SKIP, SCHEDULE_ACCESS_POLICY_FAIL:
+	default:
+		return errdef.NewErrYtcFlag(s.key("access_policy"), s.AccessPolicy, nil, _schedule_policy_help)
+	}
+	return nil
+}
+
+func (s Schedule) validateType() error {
+	valid := map[string]struct{}{
+		collecttypedef.TYPE_BASE: {},
+		collecttypedef.TYPE_DIAG: {},
+		collecttypedef.TYPE_PERF: {},
+	}
+	for t := range s.GetTypes() {
+		if _, ok := valid[t]; !ok {
+			return errdef.NewErrYtcFlag(s.key("type"), s.Type, nil, _schedule_type_help)
+		}
+	}
+	return nil
+}
+
+func (s Schedule) key(field string) string {
+	return fmt.Sprintf("schedule[%s].%s", s.Name, field)
+}
+
+// ValidateSchedules checks all the schedules, the names of them should be unique.
+func (s Strategy) ValidateSchedules() error {
+	names := make(map[string]struct{})
+	for _, schedule := range s.Schedules {
+		if err := schedule.Validate(s.Collect); err != nil {
+			return err
+		}
+		if _, ok := names[schedule.Name]; ok {
+			return fmt.Errorf("the name of schedule %s is duplicated", schedule.Name)
+		}
+		names[schedule.Name] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package confdef_test
+
+import (
+	"testing"
+	"time"
+
+	"ytc/defs/confdef"
+)
+
+func TestScheduleNextRun(t *testing.T) {
+	now := time.Date(2023, 6, 1, 10, 30, 0, 0, time.Local)
+	cases := []struct {
+		schedule confdef.Schedule
+		expected time.Time
+	}{
+		{confdef.Schedule{Interval: "1h"}, time.Date(2023, 6, 1, 11, 30, 0, 0, time.Local)},
+		{confdef.Schedule{Interval: "1d", At: "02:00"}, time.Date(2023, 6, 2, 2, 0, 0, 0, time.Local)},
+		{confdef.Schedule{Interval: "1d", At: "23:00"}, time.Date(2023, 6, 1, 23, 0, 0, 0, time.Local)},
+		{confdef.Schedule{Interval: "1h", At: "00:15"}, time.Date(2023, 6, 1, 11, 15, 0, 0, time.Local)},
+		{confdef.Schedule{Interval: "1d", At: "10:30"}, time.Date(2023, 6, 2, 10, 30, 0, 0, time.Local)},
+	}
+	for _, c := range cases {
+		next, err := c.schedule.NextRun(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !next.Equal(c.expected) {
+			t.Errorf("interval: %s at: %s, expected %s, got %s", c.schedule.Interval, c.schedule.At, c.expected, next)
+		}
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	collect := confdef.Collect{MinDuration: "1m", MaxDuration: "30d"}
+	valid := confdef.Schedule{Name: "nightly", Type: "base,perf", Interval: "1d", At: "02:00"}
+	if err := valid.Validate(collect); err != nil {
+		t.Fatal(err)
+	}
+	invalids := []confdef.Schedule{
+		{Type: "base", Interval: "1d"},
+		{Name: "t", Type: "base,none", Interval: "1d"},
+		{Name: "t", Type: "base", Interval: "0d"},
+		{Name: "t", Type: "base", Interval: "1d", At: "25:00"},
+		{Name: "t", Type: "base", Interval: "1d", Range: "60d"},
+		{Name: "t", Type: "base", Interval: "1d", AccessPolicy: "prompt"},
+	}
+	for _, s := range invalids {
+		if err := s.Validate(collect); err == nil {
+			t.Errorf("schedule %+v should be invalid", s)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package confdef
 
 import (
+	"fmt"
 	"path"
 	"regexp"
 	"strings"
@@ -8,42 +9,103 @@ import (
 
 	"ytc/defs/errdef"
 	"ytc/defs/runtimedef"
+	"ytc/utils/sizeutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/timeutil"
 
+	"git.yasdb.com/go/yaserr"
 	"git.yasdb.com/go/yasutil/fs"
 	"github.com/BurntSushi/toml"
 )
 
+// what to do when the free space of collect.output is less than the estimated size of the collection
 const (
-	_default_awr_timeout_minute = 10
+	DISK_SPACE_POLICY_FAIL      = "fail"      // refuse to collect
+	DISK_SPACE_POLICY_DOWNGRADE = "downgrade" // skip the core dumps and then the largest items until the rest fit
+	DISK_SPACE_POLICY_IGNORE    = "ignore"    // only warn
+)
+
+// the built-in rules of the redaction
+const (
+	REDACT_RULE_PASSWORD = "password" // the passwords in the yasql commands, the key-value pairs and the urls
+	REDACT_RULE_IP       = "ip"       // the IPv4 addresses except the loopback ones
+	REDACT_RULE_HOSTNAME = "hostname" // the names of the local host and the hosts in /etc/hosts
+	REDACT_RULE_EMAIL    = "email"
+)
+
+const (
+	_default_awr_timeout_minute   = 10
+	_default_item_timeout_minute  = 60
+	_default_process_number_limit = 4
+	_default_slow_sql_top_n       = 20
 )
 
 var _strategyConf Strategy
 
+var RedactRules = []string{REDACT_RULE_PASSWORD, REDACT_RULE_IP, REDACT_RULE_HOSTNAME, REDACT_RULE_EMAIL}
+
 type Collect struct {
-	Range              string `toml:"range"`
-	Output             string `toml:"output"`
-	MaxDuration        string `toml:"max_duration"`
-	MinDuration        string `toml:"min_duration"`
-	ScrapeInterval     int    `toml:"scrape_interval"`
-	ScrapeTimes        int    `toml:"scrape_times"`
-	ProcessNumberLimit int    `toml:"process_number_limit"`
-	SarDir             string `toml:"sar_dir"`
-	CoreFileKey        string `toml:"core_file_key"`
-	CoreDumpPath       string `toml:"core_dump_path"`
-	NetworkIODiscard   string `toml:"network_io_discard"`
-	AWRTimeout         string `toml:"awr_timeout"`
+	Range                string `toml:"range"`
+	Output               string `toml:"output"`
+	MaxDuration          string `toml:"max_duration"`
+	MinDuration          string `toml:"min_duration"`
+	ScrapeInterval       int    `toml:"scrape_interval"`
+	ScrapeTimes          int    `toml:"scrape_times"`
+	ProcessNumberLimit   int    `toml:"process_number_limit"`
+	SarDir               string `toml:"sar_dir"`
+	CoreFileKey          string `toml:"core_file_key"`
+	CoreDumpPath         string `toml:"core_dump_path"`
+	NetworkIODiscard     string `toml:"network_io_discard"`
+	AWRTimeout           string `toml:"awr_timeout"`
+	ItemTimeout          string `toml:"item_timeout"`
+	ItemTimeouts         string `toml:"item_timeouts"`
+	Items                string `toml:"items"`
+	SkipItems            string `toml:"skip_items"`
+	Streaming            bool   `toml:"streaming"`
+	DiskSpacePolicy      string `toml:"disk_space_policy"`
+	MaxPackageSize       string `toml:"max_package_size"`
+	MaxCoreFiles         int    `toml:"max_core_files"`
+	MaxCoreSize          string `toml:"max_core_size"`
+	MaxLogBytesPerItem   string `toml:"max_log_bytes_per_item"`
+	Encrypt              bool   `toml:"encrypt"`
+	EncryptPublicKey     string `toml:"encrypt_public_key"`
+	SlowSQLStripLiterals bool   `toml:"slow_sql_strip_literals"` // replace the sql text of the slow sql with its fingerprint
 }
 
 type Report struct {
-	Type   string `toml:"type"`
-	Output string `toml:"output"`
+	Type        string `toml:"type"`
+	Output      string `toml:"output"`
+	SlowSQLTopN int    `toml:"slow_sql_top_n"` // the number of the slow sql fingerprints in the report, 0 means the default
+}
+
+// Clean is the retention of the collection results in collect.output, the empty items are not limited.
+type Clean struct {
+	MaxAge   string `toml:"max_age"`
+	MaxCount int    `toml:"max_count"`
+	MaxSize  string `toml:"max_size"`
+}
+
+// Redact replaces the sensitive data in the text files of the package before packing.
+type Redact struct {
+	Enable bool         `toml:"enable"`
+	Rules  string       `toml:"rules"` // the built-in rules split with ',', all of them are used if it is empty
+	Custom []RedactRule `toml:"custom"`
+}
+
+// RedactRule is a custom rule of the redaction. Only the group named secret is replaced if the pattern has one,
+// and the matches are replaced with the tokens which are consistent across files if Replacement is empty.
+type RedactRule struct {
+	Name        string `toml:"name"`
+	Pattern     string `toml:"pattern"`
+	Replacement string `toml:"replacement"`
 }
 
 type Strategy struct {
-	Collect Collect `toml:"collect"`
-	Report  Report  `toml:"report"`
+	Collect   Collect    `toml:"collect"`
+	Report    Report     `toml:"report"`
+	Clean     Clean      `toml:"clean"`
+	Redact    Redact     `toml:"redact"`
+	Schedules []Schedule `toml:"schedule"`
 }
 
 func GetStrategyConf() Strategy {
@@ -54,15 +116,18 @@ func initStrategyConf(strategyConf string) error {
 	if !fs.IsFileExist(strategyConf) {
 		return &errdef.ErrFileNotFound{Fname: strategyConf}
 	}
-	if _, err := toml.DecodeFile(strategyConf, &_strategyConf); err != nil {
+	// decode into a new one, so the current conf is kept when failed to reload
+	var conf Strategy
+	if _, err := toml.DecodeFile(strategyConf, &conf); err != nil {
 		return &errdef.ErrFileParseFailed{Fname: strategyConf, Err: err}
 	}
-	if !path.IsAbs(_strategyConf.Collect.Output) {
-		_strategyConf.Collect.Output = path.Join(runtimedef.GetYTCHome(), _strategyConf.Collect.Output)
+	if !path.IsAbs(conf.Collect.Output) {
+		conf.Collect.Output = path.Join(runtimedef.GetYTCHome(), conf.Collect.Output)
 	}
-	if !path.IsAbs(_strategyConf.Report.Output) {
-		_strategyConf.Report.Output = path.Join(runtimedef.GetYTCHome(), _strategyConf.Report.Output)
+	if !path.IsAbs(conf.Report.Output) {
+		conf.Report.Output = path.Join(runtimedef.GetYTCHome(), conf.Report.Output)
 	}
+	_strategyConf = conf
 	return nil
 }
 
@@ -112,6 +177,36 @@ func (c Collect) GetNetworkIODiscard() []string {
 	return strings.Split(c.NetworkIODiscard, stringutil.STR_COMMA)
 }
 
+// GetEncryptPublicKey returns the absolute path of the public key file to encrypt the results with,
+// it is empty if not set and then the passphrase is used.
+func (c Collect) GetEncryptPublicKey() string {
+	if stringutil.IsEmpty(c.EncryptPublicKey) || path.IsAbs(c.EncryptPublicKey) {
+		return c.EncryptPublicKey
+	}
+	return path.Join(runtimedef.GetYTCHome(), c.EncryptPublicKey)
+}
+
+// GetItems returns the only items to collect, all the items are collected if it is empty.
+func (c Collect) GetItems() []string {
+	return SplitItems(c.Items)
+}
+
+// GetSkipItems returns the items which are never collected.
+func (c Collect) GetSkipItems() []string {
+	return SplitItems(c.SkipItems)
+}
+
+// SplitItems splits the items separated by ',', the blank ones are dropped. The flags of ytcctl are parsed with it too,
+// so that they are the same as the strategy.
+func SplitItems(s string) (items []string) {
+	for _, item := range strings.Split(s, stringutil.STR_COMMA) {
+		if item = strings.TrimSpace(item); !stringutil.IsEmpty(item) {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
 func IsDiscardNetwork(name string) bool {
 	discards := strings.Split(_strategyConf.Collect.NetworkIODiscard, stringutil.STR_COMMA)
 	for _, discard := range discards {
@@ -135,3 +230,108 @@ func (c Collect) GetAWRTimeout() (t time.Duration) {
 	}
 	return
 }
+
+// GetProcessNumberLimit returns the max number of the items which are collected at the same time.
+func (c Collect) GetProcessNumberLimit() int {
+	if c.ProcessNumberLimit <= 0 {
+		return _default_process_number_limit
+	}
+	return c.ProcessNumberLimit
+}
+
+// GetItemTimeout returns the timeout of collecting the item, the one in item_timeouts is preferred.
+func (c Collect) GetItemTimeout(item string) time.Duration {
+	timeouts, err := c.GetItemTimeouts()
+	if err == nil {
+		if t, ok := timeouts[item]; ok {
+			return t
+		}
+	}
+	t, err := timeutil.GetDuration(c.ItemTimeout)
+	if err != nil {
+		return time.Minute * _default_item_timeout_minute
+	}
+	return t
+}
+
+// GetItemTimeouts parses item_timeouts, such as 'YashanDB-AWR=10m,YashanDB-CoreDump=2h'.
+func (c Collect) GetItemTimeouts() (map[string]time.Duration, error) {
+	res := make(map[string]time.Duration)
+	for _, field := range SplitItems(c.ItemTimeouts) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s should be in the format of '<item>=<timeout>'", field)
+		}
+		t, err := timeutil.GetDuration(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, yaserr.Wrapf(err, "parse timeout of %s", field)
+		}
+		res[strings.TrimSpace(kv[0])] = t
+	}
+	return res, nil
+}
+
+// GetDiskSpacePolicy returns downgrade if disk_space_policy is not set.
+func (c Collect) GetDiskSpacePolicy() string {
+	if stringutil.IsEmpty(c.DiskSpacePolicy) {
+		return DISK_SPACE_POLICY_DOWNGRADE
+	}
+	return c.DiskSpacePolicy
+}
+
+// GetMaxPackageSize returns the bytes of max_package_size, 0 means no limit. It is a best-effort limit checked against
+// the estimated size before collecting, the package is not truncated to it.
+func (c Collect) GetMaxPackageSize() (int64, error) {
+	return parseLimitSize(c.MaxPackageSize)
+}
+
+// GetMaxCoreSize returns the bytes of max_core_size, 0 means no limit.
+func (c Collect) GetMaxCoreSize() (int64, error) {
+	return parseLimitSize(c.MaxCoreSize)
+}
+
+// GetMaxLogBytesPerItem returns the bytes of max_log_bytes_per_item, 0 means no limit.
+func (c Collect) GetMaxLogBytesPerItem() (int64, error) {
+	return parseLimitSize(c.MaxLogBytesPerItem)
+}
+
+func parseLimitSize(s string) (int64, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	return sizeutil.ParseSize(s)
+}
+
+// GetMaxAge returns 0 if max_age is not set.
+func (c Clean) GetMaxAge() (time.Duration, error) {
+	if len(c.MaxAge) == 0 {
+		return 0, nil
+	}
+	return timeutil.GetDuration(c.MaxAge)
+}
+
+// GetMaxSize returns the bytes of max_size, 0 is returned if max_size is not set.
+func (c Clean) GetMaxSize() (int64, error) {
+	return parseLimitSize(c.MaxSize)
+}
+
+// GetRules returns the built-in rules to use, all of them by default.
+func (r Redact) GetRules() []string {
+	if stringutil.IsEmpty(r.Rules) {
+		return RedactRules
+	}
+	var rules []string
+	for _, rule := range strings.Split(r.Rules, stringutil.STR_COMMA) {
+		if rule = strings.TrimSpace(rule); len(rule) != 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (r Report) GetSlowSQLTopN() int {
+	if r.SlowSQLTopN <= 0 {
+		return _default_slow_sql_top_n
+	}
+	return r.SlowSQLTopN
+}
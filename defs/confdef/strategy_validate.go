@@ -0,0 +1,257 @@
+package confdef
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"ytc/defs/errdef"
+	"ytc/defs/regexdef"
+	"ytc/utils/stringutil"
+	"ytc/utils/timeutil"
+
+	"github.com/BurntSushi/toml"
+)
+
+// the default values used when the items are not set in strategy
+const (
+	_default_range        = "24h"
+	_default_max_duration = "24h"
+	_default_min_duration = "1m"
+	_default_awr_timeout  = "10m"
+	_default_item_timeout = "1h"
+	_default_report_type  = "txt"
+)
+
+var (
+	_strategy_duration_help = "it should be such as '1M', '1d', '1h', '1m' and the number before (M|d|h|m) is greater than 0"
+	_strategy_report_types  = []string{"txt", "md", "html"}
+
+	DiskSpacePolicies = []string{DISK_SPACE_POLICY_FAIL, DISK_SPACE_POLICY_DOWNGRADE, DISK_SPACE_POLICY_IGNORE}
+)
+
+// ParseStrategy decodes and validates the content of a strategy file, unknown keys are not allowed.
+func ParseStrategy(data []byte) (Strategy, error) {
+	var strategy Strategy
+	meta, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&strategy)
+	if err != nil {
+		return strategy, err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) != 0 {
+		var keys []string
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		return strategy, fmt.Errorf("unknown keys: %s", strings.Join(keys, stringutil.STR_COMMA))
+	}
+	if err := strategy.Validate(); err != nil {
+		return strategy, err
+	}
+	return strategy, nil
+}
+
+// WithDefaults returns a copy of strategy, in which the empty items are filled with the default values.
+func (s Strategy) WithDefaults() Strategy {
+	fill := func(v *string, def string) {
+		if stringutil.IsEmpty(*v) {
+			*v = def
+		}
+	}
+	fill(&s.Collect.Range, _default_range)
+	fill(&s.Collect.MaxDuration, _default_max_duration)
+	fill(&s.Collect.MinDuration, _default_min_duration)
+	fill(&s.Collect.AWRTimeout, _default_awr_timeout)
+	fill(&s.Collect.ItemTimeout, _default_item_timeout)
+	s.Collect.ProcessNumberLimit = s.Collect.GetProcessNumberLimit()
+	s.Collect.DiskSpacePolicy = s.Collect.GetDiskSpacePolicy()
+	fill(&s.Report.Type, _default_report_type)
+	s.Report.SlowSQLTopN = s.Report.GetSlowSQLTopN()
+	var schedules []Schedule
+	for _, schedule := range s.Schedules {
+		fill(&schedule.Range, schedule.Interval)
+		fill(&schedule.AccessPolicy, SCHEDULE_ACCESS_POLICY_YES)
+		schedules = append(schedules, schedule)
+	}
+	s.Schedules = schedules
+	return s
+}
+
+// Validate checks the values of strategy, such as durations, regexes and paths.
+func (s Strategy) Validate() error {
+	if err := s.Collect.validate(); err != nil {
+		return err
+	}
+	if err := s.Report.validate(); err != nil {
+		return err
+	}
+	if err := s.Clean.validate(); err != nil {
+		return err
+	}
+	if err := s.Redact.validate(); err != nil {
+		return err
+	}
+	return s.ValidateSchedules()
+}
+
+func (c Collect) validate() error {
+	durations := []struct {
+		key   string
+		value string
+	}{
+		{key: "collect.range", value: c.Range},
+		{key: "collect.max_duration", value: c.MaxDuration},
+		{key: "collect.min_duration", value: c.MinDuration},
+		{key: "collect.awr_timeout", value: c.AWRTimeout},
+		{key: "collect.item_timeout", value: c.ItemTimeout},
+	}
+	for _, d := range durations {
+		if stringutil.IsEmpty(d.value) {
+			continue
+		}
+		if _, err := timeutil.GetDuration(d.value); err != nil {
+			return errdef.NewErrYtcFlag(d.key, d.value, nil, _strategy_duration_help)
+		}
+	}
+	min, max, err := c.GetMinAndMaxDur()
+	if err != nil {
+		return err
+	}
+	if min > max {
+		return fmt.Errorf("collect.min_duration: %s should not be greater than collect.max_duration: %s", c.MinDuration, c.MaxDuration)
+	}
+	if !stringutil.IsEmpty(c.Range) {
+		if r := c.GetRange(); r > max || r < min {
+			return fmt.Errorf("collect.range: %s should be between collect.min_duration and collect.max_duration", c.Range)
+		}
+	}
+	if c.ScrapeInterval <= 0 {
+		return errdef.NewErrYtcFlag("collect.scrape_interval", fmt.Sprint(c.ScrapeInterval), nil, "it should be greater than 0")
+	}
+	if c.ScrapeTimes <= 0 {
+		return errdef.NewErrYtcFlag("collect.scrape_times", fmt.Sprint(c.ScrapeTimes), nil, "it should be greater than 0")
+	}
+	if c.ProcessNumberLimit < 0 {
+		return errdef.NewErrYtcFlag("collect.process_number_limit", fmt.Sprint(c.ProcessNumberLimit), nil, "it should not be less than 0, 0 means the default value")
+	}
+	if _, err := c.GetItemTimeouts(); err != nil {
+		return errdef.NewErrYtcFlag("collect.item_timeouts", c.ItemTimeouts, []string{"YashanDB-AWR=10m,YashanDB-CoreDump=2h"}, _strategy_duration_help)
+	}
+	switch c.GetDiskSpacePolicy() {
+	case DISK_SPACE_POLICY_FAIL, DISK_SPACE_POLICY_DOWNGRADE, DISK_SPACE_POLICY_IGNORE:
+	default:
+		return errdef.NewErrYtcFlag("collect.disk_space_policy", c.DiskSpacePolicy, DiskSpacePolicies, "")
+	}
+	if c.MaxCoreFiles < 0 {
+		return errdef.NewErrYtcFlag("collect.max_core_files", fmt.Sprint(c.MaxCoreFiles), nil, "it should not be less than 0, 0 means no limit")
+	}
+	sizes := []struct {
+		key   string
+		value string
+	}{
+		{key: "collect.max_package_size", value: c.MaxPackageSize},
+		{key: "collect.max_core_size", value: c.MaxCoreSize},
+		{key: "collect.max_log_bytes_per_item", value: c.MaxLogBytesPerItem},
+	}
+	for _, s := range sizes {
+		if _, err := parseLimitSize(s.value); err != nil {
+			return errdef.NewErrYtcFlag(s.key, s.value, nil, err.Error())
+		}
+	}
+	if !stringutil.IsEmpty(c.Output) && !regexdef.PathRegex.MatchString(c.Output) {
+		return errdef.NewErrYtcFlag("collect.output", c.Output, nil, errdef.ErrPathFormat.Error())
+	}
+	if !stringutil.IsEmpty(c.EncryptPublicKey) && !regexdef.PathRegex.MatchString(c.EncryptPublicKey) {
+		return errdef.NewErrYtcFlag("collect.encrypt_public_key", c.EncryptPublicKey, nil, errdef.ErrPathFormat.Error())
+	}
+	absPaths := []struct {
+		key   string
+		value string
+	}{
+		{key: "collect.sar_dir", value: c.SarDir},
+		{key: "collect.core_dump_path", value: c.CoreDumpPath},
+	}
+	for _, p := range absPaths {
+		if !stringutil.IsEmpty(p.value) && !path.IsAbs(p.value) {
+			return errdef.NewErrYtcFlag(p.key, p.value, nil, "it should be an absolute path")
+		}
+	}
+	if !stringutil.IsEmpty(c.CoreFileKey) {
+		if _, err := regexp.Compile(c.CoreFileKey); err != nil {
+			return errdef.NewErrYtcFlag("collect.core_file_key", c.CoreFileKey, nil, err.Error())
+		}
+	}
+	if !stringutil.IsEmpty(c.NetworkIODiscard) {
+		for _, discard := range c.GetNetworkIODiscard() {
+			if _, err := regexp.Compile(discard); err != nil {
+				return errdef.NewErrYtcFlag("collect.network_io_discard", discard, nil, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func (r Report) validate() error {
+	if r.SlowSQLTopN < 0 {
+		return errdef.NewErrYtcFlag("report.slow_sql_top_n", fmt.Sprint(r.SlowSQLTopN), nil, "it should not be less than 0, 0 means the default value")
+	}
+	if !stringutil.IsEmpty(r.Output) && !regexdef.PathRegex.MatchString(r.Output) {
+		return errdef.NewErrYtcFlag("report.output", r.Output, nil, errdef.ErrPathFormat.Error())
+	}
+	if stringutil.IsEmpty(r.Type) {
+		return nil
+	}
+	valid := make(map[string]struct{})
+	for _, t := range _strategy_report_types {
+		valid[t] = struct{}{}
+	}
+	for _, t := range strings.Split(r.Type, stringutil.STR_COMMA) {
+		if _, ok := valid[strings.TrimSpace(t)]; !ok {
+			return errdef.NewErrYtcFlag("report.type", r.Type, nil, "you can choose one or more of (txt|md|html), split with ','")
+		}
+	}
+	return nil
+}
+
+func (c Clean) validate() error {
+	if _, err := c.GetMaxAge(); err != nil {
+		return errdef.NewErrYtcFlag("clean.max_age", c.MaxAge, nil, _strategy_duration_help)
+	}
+	if c.MaxCount < 0 {
+		return errdef.NewErrYtcFlag("clean.max_count", fmt.Sprint(c.MaxCount), nil, "it should not be less than 0")
+	}
+	if _, err := c.GetMaxSize(); err != nil {
+		return errdef.NewErrYtcFlag("clean.max_size", c.MaxSize, nil, err.Error())
+	}
+	return nil
+}
+
+func (r Redact) validate() error {
+	names := make(map[string]struct{})
+	for _, rule := range RedactRules {
+		names[rule] = struct{}{}
+	}
+	for _, rule := range r.GetRules() {
+		if _, ok := names[rule]; !ok {
+			return errdef.NewErrYtcFlag("redact.rules", rule, RedactRules, "")
+		}
+	}
+	for _, rule := range r.Custom {
+		if stringutil.IsEmpty(rule.Name) {
+			return errdef.NewErrYtcFlag("redact.custom.name", rule.Name, nil, "it should not be empty")
+		}
+		if _, ok := names[rule.Name]; ok {
+			return errdef.NewErrYtcFlag("redact.custom.name", rule.Name, nil, "it should be unique and different from the built-in rules")
+		}
+		names[rule.Name] = struct{}{}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return errdef.NewErrYtcFlag("redact.custom.pattern", rule.Pattern, nil, err.Error())
+		}
+		if re.MatchString(stringutil.STR_EMPTY) {
+			return errdef.NewErrYtcFlag("redact.custom.pattern", rule.Pattern, nil, "it should not match the empty string")
+		}
+	}
+	return nil
+}
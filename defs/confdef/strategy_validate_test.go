@@ -0,0 +1,40 @@
+package confdef_test
+
+import (
+	"os"
+	"testing"
+
+	"ytc/defs/confdef"
+)
+
+func TestParseStrategy(t *testing.T) {
+	data, err := os.ReadFile("../../config/strategy.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := confdef.ParseStrategy(data); err != nil {
+		t.Fatalf("the default strategy should be valid: %s", err)
+	}
+	invalids := map[string]string{
+		"unknown key":   "[collect]\nscrape_interval = 1\nscrape_times = 1\nrnage = \"1d\"\n",
+		"duration":      "[collect]\nscrape_interval = 1\nscrape_times = 1\nrange = \"1x\"\n",
+		"range":         "[collect]\nscrape_interval = 1\nscrape_times = 1\nrange = \"2d\"\nmax_duration = \"1d\"\n",
+		"regexp":        "[collect]\nscrape_interval = 1\nscrape_times = 1\nnetwork_io_discard = \"^lo$,(\"\n",
+		"relative path": "[collect]\nscrape_interval = 1\nscrape_times = 1\nsar_dir = \"sa\"\n",
+		"report type":   "[collect]\nscrape_interval = 1\nscrape_times = 1\n[report]\ntype = \"pdf\"\n",
+		"item timeout":  "[collect]\nscrape_interval = 1\nscrape_times = 1\nitem_timeouts = \"YashanDB-AWR:10m\"\n",
+		"clean size":    "[collect]\nscrape_interval = 1\nscrape_times = 1\n[clean]\nmax_size = \"1P\"\n",
+		"space policy":  "[collect]\nscrape_interval = 1\nscrape_times = 1\ndisk_space_policy = \"skip\"\n",
+		"core files":    "[collect]\nscrape_interval = 1\nscrape_times = 1\nmax_core_files = -1\n",
+		"log bytes":     "[collect]\nscrape_interval = 1\nscrape_times = 1\nmax_log_bytes_per_item = \"10 MB\"\n",
+		"slow sql top":  "[collect]\nscrape_interval = 1\nscrape_times = 1\n[report]\nslow_sql_top_n = -1\n",
+		"redact rule":   "[collect]\nscrape_interval = 1\nscrape_times = 1\n[redact]\nrules = \"ip,phone\"\n",
+		"redact regexp": "[collect]\nscrape_interval = 1\nscrape_times = 1\n[[redact.custom]]\nname = \"phone\"\npattern = \"1[3-9\\\\d{9}\"\n",
+		"redact empty":  "[collect]\nscrape_interval = 1\nscrape_times = 1\n[[redact.custom]]\nname = \"phone\"\npattern = \"\\\\d*\"\n",
+	}
+	for name, content := range invalids {
+		if _, err := confdef.ParseStrategy([]byte(content)); err == nil {
+			t.Errorf("%s: strategy should be invalid", name)
+		}
+	}
+}
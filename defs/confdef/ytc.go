@@ -10,11 +10,18 @@ import (
 	"github.com/BurntSushi/toml"
 )
 
+const (
+	_default_profile_path = "./config/yasdb_profile.toml"
+)
+
 var _ytcConf Ytc
 
 type Ytc struct {
 	StrategyPath string `toml:"strategy_path"`
+	ProfilePath  string `toml:"profile_path"`
 	LogLevel     string `toml:"log_level"`
+	SigningKey   string `toml:"signing_key"`  // the ed25519 private key to sign the collection results, they are not signed if it is empty
+	TrustedKeys  string `toml:"trusted_keys"` // the ed25519 public keys trusted by 'ytcctl verify'
 }
 
 func GetYTCConf() Ytc {
@@ -28,11 +35,25 @@ func initYTCConf(ytcConf string) error {
 	if !fs.IsFileExist(ytcConf) {
 		return &errdef.ErrFileNotFound{Fname: ytcConf}
 	}
-	if _, err := toml.DecodeFile(ytcConf, &_ytcConf); err != nil {
+	// decode into a new one, so the current conf is kept when failed to reload
+	var conf Ytc
+	if _, err := toml.DecodeFile(ytcConf, &conf); err != nil {
 		return &errdef.ErrFileParseFailed{Fname: ytcConf, Err: err}
 	}
-	if !path.IsAbs(_ytcConf.StrategyPath) {
-		_ytcConf.StrategyPath = path.Join(runtimedef.GetYTCHome(), _ytcConf.StrategyPath)
+	if !path.IsAbs(conf.StrategyPath) {
+		conf.StrategyPath = path.Join(runtimedef.GetYTCHome(), conf.StrategyPath)
+	}
+	if len(conf.ProfilePath) == 0 {
+		conf.ProfilePath = _default_profile_path
+	}
+	if !path.IsAbs(conf.ProfilePath) {
+		conf.ProfilePath = path.Join(runtimedef.GetYTCHome(), conf.ProfilePath)
+	}
+	for _, p := range []*string{&conf.SigningKey, &conf.TrustedKeys} {
+		if len(*p) != 0 && !path.IsAbs(*p) {
+			*p = path.Join(runtimedef.GetYTCHome(), *p)
+		}
 	}
+	_ytcConf = conf
 	return nil
 }
@@ -1 +1,6 @@
 package constdef
+
+const (
+	// the passphrase to encrypt and decrypt the collection results, it is never written into any file
+	YTC_ENCRYPT_PASSPHRASE = "YTC_ENCRYPT_PASSPHRASE"
+)
@@ -0,0 +1,13 @@
+package constdef
+
+// Exit codes of ytcctl, scripts can rely on them to tell why a run stopped.
+const (
+	EXIT_CODE_SUCCESS            = 0 // the command completed
+	EXIT_CODE_FAILED             = 1 // unexpected failure, e.g. results could not be packed
+	EXIT_CODE_INVALID_INPUT      = 2 // invalid flags, environment variables or credentials file
+	EXIT_CODE_INACCESSIBLE       = 3 // some items are inaccessible and the collection was not continued
+	EXIT_CODE_NOTHING_TO_COLLECT = 4 // no item is left to collect
+	EXIT_CODE_CANCELLED          = 5 // the collection was interrupted, the finished items were packed
+	EXIT_CODE_NO_SPACE           = 6 // the free space of the output is not enough for the collection
+	EXIT_CODE_VERIFY_FAILED      = 7 // the package does not match its manifest
+)
@@ -5,4 +5,5 @@ const (
 	YASDB_DATA     = "YASDB_DATA"
 	YASDB_USER     = "YASDB_USER"
 	YASDB_PASSWORD = "YASDB_PASSWORD"
+	YASDB_ADDRESS  = "YASDB_ADDRESS"
 )
@@ -1,7 +1,36 @@
 package errdef
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"git.yasdb.com/go/yasutil/size"
+)
 
 var (
-	ErrNoneCollectTtem = errors.New("no collection items will be collected, skip this collection")
+	ErrNoneCollectTtem    = errors.New("no collection items will be collected, skip this collection")
+	ErrNotContinueCollect = errors.New("some validations failed, not continue collect")
+	ErrInaccessibleItems  = errors.New("some collection items are inaccessible, stop collecting because of --fail-on-inaccessible")
+	ErrCollectCancelled   = errors.New("the collection has been cancelled, only the finished items are packed")
+	ErrClusterNodeFailed  = errors.New("the collection failed on some nodes, see ytc-cluster-index.txt in the package for details")
 )
+
+// ErrInsufficientDiskSpace means the free space of the output is less than the estimated size of the collection.
+type ErrInsufficientDiskSpace struct {
+	Output   string
+	Free     int64
+	Required int64
+}
+
+func NewErrInsufficientDiskSpace(output string, free, required int64) *ErrInsufficientDiskSpace {
+	return &ErrInsufficientDiskSpace{
+		Output:   output,
+		Free:     free,
+		Required: required,
+	}
+}
+
+func (e *ErrInsufficientDiskSpace) Error() string {
+	return fmt.Sprintf("the free space of %s is %s, but the collection needs about %s, you can clean it, choose another output or skip some items",
+		e.Output, size.GenHumanReadableSize(float64(e.Free), 2), size.GenHumanReadableSize(float64(e.Required), 2))
+}
@@ -0,0 +1,27 @@
+package errdef
+
+import "fmt"
+
+// ErrExit carries the exit code that the process should terminate with.
+type ErrExit struct {
+	Code int
+	Err  error
+}
+
+func NewErrExit(code int, err error) *ErrExit {
+	return &ErrExit{
+		Code: code,
+		Err:  err,
+	}
+}
+
+func (e *ErrExit) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit with code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ErrExit) Unwrap() error {
+	return e.Err
+}
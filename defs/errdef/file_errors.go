@@ -3,6 +3,7 @@ package errdef
 import (
 	"errors"
 	"fmt"
+	"os"
 )
 
 var (
@@ -31,6 +32,11 @@ type ErrPermissionDenied struct {
 	FileName string
 }
 
+type ErrInsecureFileMode struct {
+	FileName string
+	Mode     os.FileMode
+}
+
 func NewErrCmdNotExist(cmd string) *ErrCmdNotExist {
 	return &ErrCmdNotExist{
 		Cmd: cmd,
@@ -50,6 +56,13 @@ func NewErrPermissionDenied(user string, path string) *ErrPermissionDenied {
 	}
 }
 
+func NewErrInsecureFileMode(path string, mode os.FileMode) *ErrInsecureFileMode {
+	return &ErrInsecureFileMode{
+		FileName: path,
+		Mode:     mode,
+	}
+}
+
 func (e *ErrFileNotFound) Error() string {
 	return fmt.Sprintf("%s is not existed", e.Fname)
 }
@@ -69,3 +82,7 @@ func (e *ErrCmdNeedRoot) Error() string {
 func (e *ErrPermissionDenied) Error() string {
 	return fmt.Sprintf("the current user %s does not have permission to: %s", e.User, e.FileName)
 }
+
+func (e *ErrInsecureFileMode) Error() string {
+	return fmt.Sprintf("the permission of %s is %s, it should not be accessible by group or others, please chmod it to 0600", e.FileName, e.Mode.Perm())
+}
@@ -0,0 +1,24 @@
+package errdef
+
+import "fmt"
+
+// ErrInvalidStrategy means a strategy file can not be used, it will not be written.
+type ErrInvalidStrategy struct {
+	Fname string
+	Err   error
+}
+
+func NewErrInvalidStrategy(fname string, err error) *ErrInvalidStrategy {
+	return &ErrInvalidStrategy{
+		Fname: fname,
+		Err:   err,
+	}
+}
+
+func (e *ErrInvalidStrategy) Error() string {
+	return fmt.Sprintf("invalid strategy %s: %s", e.Fname, e.Err)
+}
+
+func (e *ErrInvalidStrategy) Unwrap() error {
+	return e.Err
+}
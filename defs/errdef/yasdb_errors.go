@@ -1,5 +1,7 @@
 package errdef
 
+import "fmt"
+
 type ErrYasdbProcessNotFound struct {
 }
 
@@ -10,3 +12,20 @@ func NewErrYasdbProcessNotFound() *ErrYasdbProcessNotFound {
 func (e *ErrYasdbProcessNotFound) Error() string {
 	return "yasdb process not found"
 }
+
+// ErrProfileNotFound means the yasdb profile has not been set.
+type ErrProfileNotFound struct {
+	Name  string
+	Fname string
+}
+
+func NewErrProfileNotFound(name, fname string) *ErrProfileNotFound {
+	return &ErrProfileNotFound{
+		Name:  name,
+		Fname: fname,
+	}
+}
+
+func (e *ErrProfileNotFound) Error() string {
+	return fmt.Sprintf("yasdb profile %s not found in %s, please set it by 'ytcctl yasdb set --profile %s'", e.Name, e.Fname, e.Name)
+}
@@ -14,6 +14,15 @@ const (
 
 	space_format     = `\s+`
 	key_value_format = `^([^=]+)=(.*)$`
+
+	// ytc-20060102150405.json
+	ytc_data_file_format = `^ytc-(\d{14})\.json$`
+
+	// the chars which can not be in the name of an instance
+	instance_name_invalid_char_format = `[^\w.-]+`
+
+	// the name of a node in the inventory of the cluster collection, it is used as a dir name
+	node_name_format = `^[\w][\w.-]*$`
 )
 
 var (
@@ -23,4 +32,8 @@ var (
 	SpaceRegex        = regexp.MustCompile(space_format)
 	YasdbProcessRegex = regexp.MustCompile(yasdb_process_format)
 	KeyValueRegex     = regexp.MustCompile(key_value_format)
+	YtcDataFileRegex  = regexp.MustCompile(ytc_data_file_format)
+
+	InstanceNameInvalidCharRegex = regexp.MustCompile(instance_name_invalid_char_format)
+	NodeNameRegex                = regexp.MustCompile(node_name_format)
 )
@@ -0,0 +1,17 @@
+package runtimedef
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	// set to the pid of ytcctl or ytcd in the environment of the child processes, such as yasql and sar,
+	// so that the ones left by an interrupted run can be found
+	ENV_YTC_PARENT_PID = "YTC_PARENT_PID"
+)
+
+// GetChildEnv returns the environment variable which marks a child process started by the current process.
+func GetChildEnv() string {
+	return fmt.Sprintf("%s=%d", ENV_YTC_PARENT_PID, os.Getpid())
+}
@@ -17,6 +17,8 @@ const (
 	_DIR_NAME_LOG     = "log"
 	_DIR_NAME_STATIC  = "static"
 	_DIR_NAME_SCRIPTS = "scripts"
+	_DIR_NAME_BIN     = "bin"
+	_DIR_NAME_RUN     = "run"
 )
 
 var _ytcHome string
@@ -37,6 +39,15 @@ func GetScriptsPath() string {
 	return path.Join(_ytcHome, _DIR_NAME_SCRIPTS)
 }
 
+func GetBinPath() string {
+	return path.Join(_ytcHome, _DIR_NAME_BIN)
+}
+
+// GetRunPath returns the dir of the runtime files of ytcd, such as the pid file.
+func GetRunPath() string {
+	return path.Join(_ytcHome, _DIR_NAME_RUN)
+}
+
 func setYTCHome(v string) {
 	_ytcHome = v
 }
@@ -1,9 +1,82 @@
 package clean
 
+import (
+	"fmt"
+	"path"
+
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/defs/runtimedef"
+	cleanhandler "ytc/internal/api/handler/ytcctlhandler/clean"
+	ytcclean "ytc/internal/modules/ytc/clean"
+	"ytc/utils/sizeutil"
+	"ytc/utils/stringutil"
+)
+
+const (
+	_max_count_unset = -1 // --max-count is not given
+)
+
 type CleanCmd struct {
+	Output    string `name:"output"    short:"o" help:"The output dir of the collection results, default value is collect.output in strategy."`
+	MaxAge    string `name:"max-age"   help:"Remove the results older than it, such as '30d', '12h', default value is clean.max_age in strategy."`
+	MaxCount  int    `name:"max-count" default:"-1" help:"Keep at most the number of the newest results, 0 means no limit as clean.max_count in strategy, default value is clean.max_count in strategy."`
+	All       bool   `name:"all"       help:"Remove all the results, the other retention flags and strategy are ignored."`
+	MaxSize   string `name:"max-size"  help:"Keep the newest results within the total size, such as '500M', '10G', default value is clean.max_size in strategy."`
+	DryRun    bool   `name:"dry-run"   help:"Only list the results, working dirs and processes to clean, nothing is removed or killed."`
+	Resumable bool   `name:"resumable" help:"Remove the working dirs of the interrupted collections which can be finished by 'ytcctl collect --resume' too."`
+	Yes       bool   `name:"yes"       short:"y" help:"Kill the processes left by the exited ytcctl or ytcd without confirmation."`
 }
 
 // [Interface Func]
 func (c CleanCmd) Run() error {
-	return nil
+	retention, err := c.getRetention()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	handler := cleanhandler.NewCleanHandler(c.getOutput(), retention, c.DryRun)
+	handler.Resumable = c.Resumable
+	handler.Yes = c.Yes
+	return handler.Clean()
+}
+
+// getRetention merges the flags and clean in strategy, the flags take precedence.
+func (c CleanCmd) getRetention() (retention ytcclean.Retention, err error) {
+	clean := confdef.GetStrategyConf().Clean
+	if !stringutil.IsEmpty(c.MaxAge) {
+		clean.MaxAge = c.MaxAge
+	}
+	if c.MaxCount != _max_count_unset {
+		clean.MaxCount = c.MaxCount
+	}
+	retention.KeepNone = c.All
+	if !stringutil.IsEmpty(c.MaxSize) {
+		clean.MaxSize = c.MaxSize
+	}
+	if retention.MaxAge, err = clean.GetMaxAge(); err != nil {
+		err = errdef.NewErrYtcFlag("max-age", clean.MaxAge, []string{"30d", "12h"}, "the number before (M|d|h|m) should be greater than 0")
+		return
+	}
+	if clean.MaxCount < 0 {
+		err = errdef.NewErrYtcFlag("max-count", fmt.Sprint(clean.MaxCount), nil, "it should not be less than 0")
+		return
+	}
+	retention.MaxCount = clean.MaxCount
+	if retention.MaxSize, err = clean.GetMaxSize(); err != nil {
+		err = errdef.NewErrYtcFlag("max-size", clean.MaxSize, []string{"500M", "10G"}, sizeutil.ErrSizeInvalid.Error())
+		return
+	}
+	return
+}
+
+func (c CleanCmd) getOutput() string {
+	output := c.Output
+	if stringutil.IsEmpty(output) {
+		output = confdef.GetStrategyConf().Collect.Output
+	}
+	if !path.IsAbs(output) {
+		output = path.Join(runtimedef.GetYTCHome(), output)
+	}
+	return path.Clean(output)
 }
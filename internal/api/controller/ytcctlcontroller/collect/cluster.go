@@ -0,0 +1,185 @@
+package collect
+
+import (
+	"fmt"
+	"time"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/collecttypedef"
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/defs/runtimedef"
+	"ytc/defs/timedef"
+	clusterhandler "ytc/internal/api/handler/ytcctlhandler/cluster"
+	ytccluster "ytc/internal/modules/ytc/cluster"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+)
+
+const (
+	f_hosts    = "hosts"
+	f_include  = "include"
+	f_exclude  = "exclude"
+	f_parallel = "parallel"
+)
+
+const (
+	_hosts_exclusive_help = "it can not be used with --%s, remove one of them"
+)
+
+// collectCluster collects the nodes of the inventory over ssh and combines their results into one cluster package.
+func (c *CollectCmd) collectCluster() error {
+	c.fillDefault()
+	if err := c.validateCluster(); err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	encryptKey, err := c.encryptKey()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	inventory, err := ytccluster.LoadInventory(c.Hosts)
+	if err != nil {
+		log.Controller.Errorf("load inventory err: %s", err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, errdef.NewErrYtcFlag(f_hosts, c.Hosts, nil, err.Error()))
+	}
+	env, err := c.getClusterYasdbEnv()
+	if err != nil {
+		log.Controller.Errorf("get yasdb env err: %s", err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	for _, node := range inventory.Nodes {
+		fillEmpty(&node.YasdbHome, env.YasdbHome)
+		fillEmpty(&node.YasdbData, env.YasdbData)
+		fillEmpty(&node.YasdbUser, env.YasdbUser)
+	}
+	start, end, err := c.getStartAndEnd()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	opts := &ytccluster.Options{
+		Name:          fmt.Sprintf("%s-%s", collecttypedef.CLUSTER_PACKAGE_NAME_PREFIX, time.Now().Format(timedef.TIME_FORMAT_IN_FILE)),
+		Start:         start,
+		End:           end,
+		Args:          c.clusterArgs(),
+		Redact:        c.Redact || confdef.GetStrategyConf().Redact.Enable,
+		Parallel:      c.Parallel,
+		YasdbPassword: env.YasdbPassword,
+		SSHPassword:   pwdutil.NewSecret(c.SSHPassword),
+		LocalYTCHome:  runtimedef.GetYTCHome(),
+	}
+	if opts.Parallel == 0 {
+		opts.Parallel = confdef.GetStrategyConf().Collect.GetProcessNumberLimit()
+	}
+	log.Controller.Infof("collect cluster %s of %s with args: %v, redact: %t, parallel: %d", opts.Name, c.Hosts, opts.Args, opts.Redact, opts.Parallel)
+	handler := clusterhandler.NewClusterHandler(c.Hosts, inventory, opts, c.Output)
+	handler.EncryptKey = encryptKey
+	handler.CancelOnSignal = true
+	if err := handler.Collect(); err != nil {
+		log.Controller.Errorf(err.Error())
+		switch err {
+		case errdef.ErrCollectCancelled:
+			fmt.Println(bashdef.WithYellow(err.Error()))
+		case errdef.ErrClusterNodeFailed:
+			fmt.Println(bashdef.WithRed(err.Error()))
+		default:
+			fmt.Println(err.Error())
+		}
+		return errdef.NewErrExit(collectExitCode(err), nil)
+	}
+	return nil
+}
+
+// validateCluster checks the flags of the cluster collection, the flags of the collection on the host are not allowed.
+func (c *CollectCmd) validateCluster() error {
+	for _, f := range []struct {
+		name  string
+		given bool
+	}{
+		{f_instances, !stringutil.IsEmpty(trimSpace(c.Instances))},
+		{f_yasdb_address, !stringutil.IsEmpty(trimSpace(c.YasdbAddress))},
+		{f_include, !stringutil.IsEmpty(trimSpace(c.Include))},
+		{f_exclude, !stringutil.IsEmpty(trimSpace(c.Exclude))},
+	} {
+		if f.given {
+			return errdef.NewErrYtcFlag(f_hosts, c.Hosts, nil, fmt.Sprintf(_hosts_exclusive_help, f.name))
+		}
+	}
+	if c.Parallel < 0 {
+		return errdef.NewErrYtcFlag(f_parallel, fmt.Sprint(c.Parallel), nil, "it should not be less than 0, 0 means the default value")
+	}
+	if err := c.validateType(); err != nil {
+		return err
+	}
+	if err := c.validateRange(); err != nil {
+		return err
+	}
+	if err := c.validateStartAndEnd(); err != nil {
+		return err
+	}
+	if err := c.validateOutput(); err != nil {
+		return err
+	}
+	if err := c.validateItems(); err != nil {
+		return err
+	}
+	return c.validateSpacePolicy()
+}
+
+// getClusterYasdbEnv gets the yasdb env shared by the nodes from flags, environment variables, profile and
+// credentials file in turn, the ones in the inventory take precedence.
+func (c *CollectCmd) getClusterYasdbEnv() (*yasdb.YasdbEnv, error) {
+	env := &yasdb.YasdbEnv{
+		YasdbHome:     trimSpace(c.YasdbHome),
+		YasdbData:     trimSpace(c.YasdbData),
+		YasdbUser:     trimSpace(c.YasdbUser),
+		YasdbPassword: pwdutil.NewSecret(trimSpace(c.YasdbPassword)),
+	}
+	if !stringutil.IsEmpty(c.Profile) {
+		if err := env.FillFromProfile(confdef.GetYTCConf().ProfilePath, c.Profile); err != nil {
+			return nil, err
+		}
+	}
+	if !stringutil.IsEmpty(c.Credentials) {
+		if err := env.FillFromCredentials(c.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// clusterArgs returns the flags of ytcctl collect on each node, the collection on the nodes always runs without
+// interaction. --encrypt is not given to the nodes, since the cluster package is encrypted instead. --redact is given
+// by the node with its own token prefix.
+func (c *CollectCmd) clusterArgs() []string {
+	args := []string{"--type", c.Type}
+	if !stringutil.IsEmpty(c.Items) {
+		args = append(args, "--items", c.Items)
+	}
+	if !stringutil.IsEmpty(c.SkipItems) {
+		args = append(args, "--skip-items", c.SkipItems)
+	}
+	switch {
+	case c.SkipInaccessible:
+		args = append(args, "--skip-inaccessible")
+	case c.FailOnInaccessible:
+		args = append(args, "--fail-on-inaccessible")
+	default:
+		args = append(args, "--yes")
+	}
+	if c.Streaming {
+		args = append(args, "--streaming")
+	}
+	if !stringutil.IsEmpty(c.SpacePolicy) {
+		args = append(args, "--disk-space-policy", c.SpacePolicy)
+	}
+	return args
+}
+
+func fillEmpty(value *string, def string) {
+	if stringutil.IsEmpty(*value) {
+		*value = def
+	}
+}
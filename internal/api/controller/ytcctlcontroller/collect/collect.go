@@ -2,6 +2,7 @@ package collect
 
 import (
 	"fmt"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -9,10 +10,12 @@ import (
 	"ytc/defs/bashdef"
 	"ytc/defs/collecttypedef"
 	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
 	"ytc/defs/errdef"
 	ytcctlhandler "ytc/internal/api/handler/ytcctlhandler/collect"
 	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
+	"ytc/utils/cryptoutil"
 	"ytc/utils/fileutil"
 	"ytc/utils/jsonutil"
 	"ytc/utils/stringutil"
@@ -23,26 +26,59 @@ import (
 )
 
 type CollectGlobal struct {
-	Type    string `name:"type"   short:"t" default:"base,diag,perf" help:"The type of collection, choose one or more of (base|diag|perf) and split with ','."`
-	Range   string `name:"range"  short:"r" help:"The time range of the collection, such as '1M', '1d', '1h', '1m'. If <range> is given, <start> and <end> will be discard."`
-	Start   string `name:"start"  short:"s" help:"The start datetime of the collection, such as 'yyyy-MM-dd', 'yyyy-MM-dd-hh', 'yyyy-MM-dd-hh-mm'"`
-	End     string `name:"end"    short:"e" help:"The end timestamp of the collection, such as 'yyyy-MM-dd', 'yyyy-MM-dd-hh', 'yyyy-MM-dd-hh-mm',, default value is current datetime."`
-	Output  string `name:"output" short:"o" help:"The output dir of the collection."`
-	Include string `name:"include" help:"Files or directories that need to be additionally collected, it is absolute path and split with ',', such as '/tmp' or '/tmp,/root,/example.txt'."`
-	Exclude string `name:"exclude" help:"Files or directories that no need to be additionally collected, it is absolute path and split with ',', such as '/tmp' or '/tmp,/root,/example.txt'."`
+	Type              string `name:"type"   short:"t" default:"base,diag,perf" help:"The type of collection, choose one or more of (base|diag|perf) and split with ','."`
+	Range             string `name:"range"  short:"r" help:"The time range of the collection, such as '1M', '1d', '1h', '1m'. If <range> is given, <start> and <end> will be discard."`
+	Start             string `name:"start"  short:"s" help:"The start datetime of the collection, such as 'yyyy-MM-dd', 'yyyy-MM-dd-hh', 'yyyy-MM-dd-hh-mm'"`
+	End               string `name:"end"    short:"e" help:"The end timestamp of the collection, such as 'yyyy-MM-dd', 'yyyy-MM-dd-hh', 'yyyy-MM-dd-hh-mm',, default value is current datetime."`
+	Output            string `name:"output" short:"o" help:"The output dir of the collection."`
+	Include           string `name:"include" help:"Files or directories that need to be additionally collected, it is absolute path and split with ',', such as '/tmp' or '/tmp,/root,/example.txt'."`
+	Exclude           string `name:"exclude" help:"Files or directories that no need to be additionally collected, it is absolute path and split with ',', such as '/tmp' or '/tmp,/root,/example.txt'."`
+	Items             string `name:"items" help:"Only collect these items, split with ',', such as 'YashanDB-AWR,YashanDB-SlowSQL'. The default value is collect.items of the strategy."`
+	SkipItems         string `name:"skip-items" help:"Never collect these items, split with ',', such as 'Host-BashHistory,YashanDB-CoreDump'. The default value is collect.skip_items of the strategy."`
+	Plan              bool   `name:"plan"        xor:"plan" help:"Only print the files, directories, sql statements and commands that the collection will read, copy, query and execute, nothing is collected or written. It runs without interaction."`
+	PlanFormat        string `name:"plan-format" default:"table" help:"The format of the plan, choose one of (table|json)."`
+	Streaming         bool   `name:"streaming"   help:"Archive the files of each item into the result package once it is done instead of copying all of them before packing, so that the peak disk usage is about the package size plus the size of the largest item, since each item is staged on disk before it is archived. The items are collected one by one and the collection can not be resumed. The default value is collect.streaming of the strategy."`
+	SpacePolicy       string `name:"disk-space-policy" help:"What to do when the free space of the output is less than the estimated size, choose one of (fail|downgrade|ignore). The downgrade skips the core dumps and then the largest items until the rest fit. The default value is collect.disk_space_policy of the strategy."`
+	Encrypt           bool   `name:"encrypt"     help:"Encrypt the result package into ytc-*.tar.gz.enc with <encrypt-public-key> if it is given, otherwise with <encrypt-passphrase>. The default value is collect.encrypt of the strategy."`
+	EncryptPublicKey  string `name:"encrypt-public-key" type:"existingfile" help:"The public key file generated by 'ytcctl keygen' to encrypt with, only the holder of the private key can decrypt the result. The default value is collect.encrypt_public_key of the strategy."`
+	EncryptPassphrase string `name:"encrypt-passphrase" env:"YTC_ENCRYPT_PASSPHRASE" json:"-" help:"The passphrase to encrypt with, it is visible in the process list, prefer the environment variable."`
+	Redact            bool   `name:"redact"      help:"Redact the passwords, IP addresses, host names, emails and the matches of the custom rules in the text files of the result package with the rules of the strategy. The mapping of the tokens is saved next to the package and is not packed. The default value is redact.enable of the strategy."`
+	RedactTokenPrefix string `name:"redact-token-prefix" hidden:"" help:"Namespace the redaction tokens with the prefix, such as 'REDACTED_NODE1_IP_1', it is given to the nodes by the cluster collection."`
+	Instances         string `name:"instances"   help:"Collect several YashanDB instances on the host in one run, 'auto' for all the running instances, or their YASDB_DATA split with ',', such as '/data/yasdb/db1,/data/yasdb/db2'. The host-level items are collected once and the DB-level items once per instance with the same yasdb user and password, the instances are named by the base names of YASDB_DATA. The multi-instance collection can not be resumed."`
+	Resume            string `name:"resume"      xor:"plan" help:"Resume the interrupted collection in the package dir, such as './results/ytc-20230101120000'. The stored collect param is used and the completed items are skipped, the password is given in the same way as the non-interactive mode."`
+	Hosts             string `name:"hosts"       xor:"plan" type:"existingfile" help:"Collect the nodes of the inventory file over ssh with the same time window, such as './hosts.toml'. ytc is pushed to the nodes without ytc_home, the results of the nodes are pulled back and combined into ytc-cluster-*.tar.gz with a per-node index. It runs without interaction, the yasdb password is given in the same way as the non-interactive mode and shared by the nodes."`
+	SSHPassword       string `name:"ssh-password" env:"YTC_SSH_PASSWORD" json:"-" help:"The ssh password of the nodes given by <hosts>, it is tried after the identity file and the ssh agent. It is visible in the process list, prefer the environment variable."`
+	Parallel          int    `name:"parallel"    help:"The max number of the nodes given by <hosts> which are collected at the same time. The default value is collect.process_number_limit of the strategy."`
 }
 
 type CollectCmd struct {
 	CollectGlobal
+	CollectHeadless
+
+	itemsFromStrategy bool // --items is not given and collect.items of the strategy is used
 }
 
 // [Interface Func]
 func (c *CollectCmd) Run() error {
+	if !stringutil.IsEmpty(c.Resume) {
+		return c.resume()
+	}
+	if !stringutil.IsEmpty(c.Hosts) {
+		return c.collectCluster()
+	}
 	c.fillDefault()
 	if err := c.validate(); err != nil {
-		return err
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	encryptKey, err := c.encryptKey()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	yasdbEnv, code, err := c.getYasdbEnv()
+	if err != nil {
+		log.Controller.Errorf("get yasdb env err: %s", err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
 	}
-	yasdbEnv, code := c.openYasdbCollectForm()
 	if code != terminalutil.FORM_EXIT_CONTINUE {
 		c.Quit()
 		return nil
@@ -50,27 +86,88 @@ func (c *CollectCmd) Run() error {
 	collectParam, err := c.genCollcterParam(yasdbEnv)
 	if err != nil {
 		log.Controller.Errorf("get collect info err %s", err.Error())
-		return err
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	if err := c.addInstances(collectParam); err != nil {
+		log.Controller.Errorf("get instances err: %s", err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
 	}
 	types, err := c.getTypes()
 	if err != nil {
-		return err
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
 	}
 	handler, err := ytcctlhandler.NewCollecterHandler(types, collectParam)
 	if err != nil {
 		return err
 	}
+	handler.AccessPolicy = c.accessPolicy()
+	handler.CancelOnSignal = true
+	handler.Streaming = c.Streaming || confdef.GetStrategyConf().Collect.Streaming
+	handler.Redact = c.Redact || confdef.GetStrategyConf().Redact.Enable
+	handler.RedactTokenPrefix = c.RedactTokenPrefix
+	if !stringutil.IsEmpty(c.SpacePolicy) {
+		handler.SpacePolicy = c.SpacePolicy
+	}
+	handler.EncryptKey = encryptKey
 	log.Controller.Debugf("from validate res :%s, ", jsonutil.ToJSONString(YasdbValidate))
+	if c.Plan {
+		return handler.Plan(YasdbValidate).Print(c.PlanFormat)
+	}
+	return c.collect(handler)
+}
+
+func (c *CollectCmd) collect(handler *ytcctlhandler.CollecterHandler) error {
 	if err := handler.Collect(YasdbValidate); err != nil {
 		log.Controller.Errorf(err.Error())
+		if err == errdef.ErrCollectCancelled {
+			fmt.Println(bashdef.WithYellow(err.Error()))
+			return errdef.NewErrExit(collectExitCode(err), nil)
+		}
 		if err == errdef.ErrNoneCollectTtem {
 			fmt.Println(bashdef.WithBlue(err.Error()))
 		}
 		fmt.Println("Stopping Collect...")
+		return errdef.NewErrExit(collectExitCode(err), nil)
 	}
 	return nil
 }
 
+// encryptKey returns the key to encrypt the result package with, it is nil if the package should not be encrypted.
+func (c *CollectCmd) encryptKey() (*cryptoutil.EncryptKey, error) {
+	strategy := confdef.GetStrategyConf().Collect
+	if !c.Encrypt && !strategy.Encrypt {
+		return nil, nil
+	}
+	publicKeyFile := c.EncryptPublicKey
+	if stringutil.IsEmpty(publicKeyFile) {
+		publicKeyFile = strategy.GetEncryptPublicKey()
+	}
+	key, err := ytcctlhandler.NewEncryptKey(publicKeyFile, c.EncryptPassphrase)
+	if err == cryptoutil.ErrKeyNotGiven {
+		return nil, errdef.NewErrYtcFlag(f_encrypt, "true", nil, _encrypt_help)
+	}
+	return key, err
+}
+
+func (c *CollectCmd) getYasdbEnv() (*yasdb.YasdbEnv, int, error) {
+	if !c.isHeadless() {
+		var profile *yasdb.YasdbEnv
+		if !stringutil.IsEmpty(c.Profile) {
+			var err error
+			if profile, err = yasdb.LoadProfile(confdef.GetYTCConf().ProfilePath, c.Profile); err != nil {
+				return nil, terminalutil.FORM_EXIT_NOT_CONTINUE, err
+			}
+		}
+		yasdbEnv, code := c.openYasdbCollectForm(profile)
+		return yasdbEnv, code, nil
+	}
+	yasdbEnv, err := c.getYasdbEnvHeadless()
+	if err != nil {
+		return nil, terminalutil.FORM_EXIT_NOT_CONTINUE, err
+	}
+	return yasdbEnv, terminalutil.FORM_EXIT_CONTINUE, nil
+}
+
 func (c *CollectCmd) Quit() {
 	fmt.Println("Quit Collect")
 }
@@ -87,6 +184,9 @@ func (c *CollectCmd) genCollcterParam(env *yasdb.YasdbEnv) (*collecttypedef.Coll
 	log.Controller.Infof("YASDB_HOME: %s", env.YasdbHome)
 	log.Controller.Infof("YASDB_DATA: %s", env.YasdbData)
 	log.Controller.Infof("YASDB_USER: %s", env.YasdbUser)
+	if env.IsRemote() {
+		log.Controller.Infof("YASDB_ADDRESS: %s", env.YasdbAddress)
+	}
 	return &collecttypedef.CollectParam{
 		StartTime:       start,
 		EndTime:         end,
@@ -95,13 +195,52 @@ func (c *CollectCmd) genCollcterParam(env *yasdb.YasdbEnv) (*collecttypedef.Coll
 		YasdbData:       env.YasdbData,
 		YasdbUser:       env.YasdbUser,
 		YasdbPassword:   env.YasdbPassword,
+		YasdbAddress:    env.YasdbAddress,
 		Include:         c.getExtraPath(c.Include),
 		Exclude:         c.getExtraPath(c.Exclude),
+		Items:           confdef.SplitItems(c.Items),
+		SkipItems:       confdef.SplitItems(c.SkipItems),
 		BeginTime:       time.Now(),
 		YasdbHomeOSUser: owner.Username,
 	}, nil
 }
 
+// addInstances adds the instances of <instances> to the param, the running ones are discovered if it is 'auto'.
+// YASDB_HOME of an instance is the one of its running process, or the YASDB_HOME of the param if it is not running.
+func (c *CollectCmd) addInstances(param *collecttypedef.CollectParam) error {
+	value := trimSpace(c.Instances)
+	if stringutil.IsEmpty(value) {
+		return nil
+	}
+	if param.IsRemote() {
+		return errdef.NewErrYtcFlag(f_instances, value, nil, _instances_remote_help)
+	}
+	var envs []*yasdb.YasdbEnv
+	if value == _instances_auto {
+		discovered, err := yasdb.DiscoverInstances()
+		if err != nil {
+			return yaserr.Wrapf(err, "discover yasdb instances")
+		}
+		if len(discovered) == 0 {
+			return errdef.NewErrYtcFlag(f_instances, value, nil, _instances_not_found_help)
+		}
+		envs = discovered
+	} else {
+		for _, data := range confdef.SplitItems(value) {
+			data = path.Clean(data)
+			envs = append(envs, &yasdb.YasdbEnv{YasdbHome: yasdb.GetYasdbHomeFromProcess(data), YasdbData: data})
+		}
+	}
+	for _, env := range envs {
+		if stringutil.IsEmpty(env.YasdbHome) {
+			env.YasdbHome = param.YasdbHome
+		}
+		instance := param.AddInstance(env.YasdbHome, env.YasdbData)
+		log.Controller.Infof("instance %s: YASDB_HOME: %s, YASDB_DATA: %s", instance.Name, instance.YasdbHome, instance.YasdbData)
+	}
+	return nil
+}
+
 func (c *CollectCmd) getStartAndEnd() (start time.Time, end time.Time, err error) {
 	defer func() {
 		end = end.Add(time.Minute)
@@ -193,3 +332,18 @@ func (c *CollectCmd) getExtraPath(value string) (res []string) {
 	}
 	return
 }
+
+func collectExitCode(err error) int {
+	switch err {
+	case errdef.ErrNoneCollectTtem:
+		return constdef.EXIT_CODE_NOTHING_TO_COLLECT
+	case errdef.ErrNotContinueCollect, errdef.ErrInaccessibleItems:
+		return constdef.EXIT_CODE_INACCESSIBLE
+	case errdef.ErrCollectCancelled:
+		return constdef.EXIT_CODE_CANCELLED
+	}
+	if _, ok := err.(*errdef.ErrInsufficientDiskSpace); ok {
+		return constdef.EXIT_CODE_NO_SPACE
+	}
+	return constdef.EXIT_CODE_FAILED
+}
@@ -3,14 +3,13 @@ package collect
 import (
 	"fmt"
 	"os"
-	"path"
 	"strings"
 
 	constdef "ytc/defs/constants"
 	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
-	"ytc/utils/processutil"
+	"ytc/utils/pwdutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/terminalutil"
 )
@@ -21,7 +20,6 @@ const (
 
 	FORM_HEADER = "Enter Yashan Trace Collector Data"
 
-	base_yasdb_process_format       = `.*yasdb (?i:(nomount|mount|open))`
 	yasdb_internal_data_not_collect = "yasdb internal data will not be collected, are you sure to continue?"
 )
 
@@ -29,13 +27,24 @@ var (
 	YasdbValidate error
 )
 
-func (c *CollectCmd) openYasdbCollectForm() (*yasdb.YasdbEnv, int) {
+// openYasdbCollectForm opens the form filled with flags, profile, environment variables and yasdb process in turn.
+func (c *CollectCmd) openYasdbCollectForm(profile *yasdb.YasdbEnv) (*yasdb.YasdbEnv, int) {
 	yasdbHome, yasdbData := yasdbPath()
+	var yasdbUser, yasdbPassword string
+	if profile != nil {
+		yasdbHome = firstNotEmpty(profile.YasdbHome, yasdbHome)
+		yasdbData = firstNotEmpty(profile.YasdbData, yasdbData)
+		yasdbUser = profile.YasdbUser
+		yasdbPassword = profile.YasdbPassword.Reveal()
+	}
+	yasdbHome = firstNotEmpty(c.YasdbHome, yasdbHome)
+	yasdbData = firstNotEmpty(c.YasdbData, yasdbData)
+	yasdbUser = firstNotEmpty(c.YasdbUser, yasdbUser)
 	var opts []terminalutil.WithOption
 	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddInput(constdef.YASDB_HOME, yasdbHome, validatePath) })
 	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddInput(constdef.YASDB_DATA, yasdbData, validatePath) })
-	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddInput(constdef.YASDB_USER, "", nil) })
-	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddPassword(constdef.YASDB_PASSWORD, "", nil) })
+	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddInput(constdef.YASDB_USER, yasdbUser, nil) })
+	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddPassword(constdef.YASDB_PASSWORD, yasdbPassword, nil) })
 	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddButton(SAVE, saveFunc) })
 	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddButton(QUIT, quitFunc) })
 	form := terminalutil.NewCollectFrom(FORM_HEADER, opts...)
@@ -56,7 +65,7 @@ func getYasdbEnvFromForm(c *terminalutil.CollectForm) (*yasdb.YasdbEnv, error) {
 		YasdbHome:     trimSpace(labelMap[constdef.YASDB_HOME]),
 		YasdbData:     trimSpace(labelMap[constdef.YASDB_DATA]),
 		YasdbUser:     trimSpace(labelMap[constdef.YASDB_USER]),
-		YasdbPassword: trimSpace(labelMap[constdef.YASDB_PASSWORD]),
+		YasdbPassword: pwdutil.NewSecret(trimSpace(labelMap[constdef.YASDB_PASSWORD])),
 	}, nil
 }
 
@@ -100,10 +109,19 @@ func trimSpace(s string) string {
 	return strings.TrimSpace(s)
 }
 
+func firstNotEmpty(values ...string) string {
+	for _, v := range values {
+		if v = trimSpace(v); !stringutil.IsEmpty(v) {
+			return v
+		}
+	}
+	return ""
+}
+
 func yasdbPath() (yasdbHome, yasdbData string) {
 	yasdbData = os.Getenv(constdef.YASDB_DATA)
 	yasdbHome = os.Getenv(constdef.YASDB_HOME)
-	processYasdbHome, processYasdbData := yasdbPathFromProcess()
+	processYasdbHome, processYasdbData := yasdb.GetYasdbPathFromProcess()
 	if stringutil.IsEmpty(yasdbHome) {
 		yasdbHome = processYasdbHome
 	}
@@ -112,27 +130,3 @@ func yasdbPath() (yasdbHome, yasdbData string) {
 	}
 	return
 }
-
-func yasdbPathFromProcess() (yasdbHome string, yasdbData string) {
-	processes, err := processutil.ListAnyUserProcessByCmdline(base_yasdb_process_format, true)
-	if err != nil {
-		return
-	}
-	if len(processes) == 0 {
-		return
-	}
-	for _, p := range processes {
-		fields := strings.Split(p.ReadableCmdline, "-D")
-		if len(fields) < 2 {
-			continue
-		}
-		yasdbData = trimSpace(fields[1])
-		full := trimSpace(p.FullCommand)
-		if !path.IsAbs(full) {
-			return
-		}
-		yasdbHome = path.Dir(path.Dir(full))
-		return
-	}
-	return
-}
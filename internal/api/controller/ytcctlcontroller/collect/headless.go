@@ -0,0 +1,80 @@
+package collect
+
+import (
+	"ytc/defs/confdef"
+	ytcctlhandler "ytc/internal/api/handler/ytcctlhandler/collect"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+)
+
+type CollectHeadless struct {
+	YasdbHome          string `name:"yasdb-home"           env:"YASDB_HOME"     help:"The YASDB_HOME used in non-interactive mode."`
+	YasdbData          string `name:"yasdb-data"           env:"YASDB_DATA"     help:"The YASDB_DATA used in non-interactive mode."`
+	YasdbUser          string `name:"yasdb-user"           env:"YASDB_USER"     help:"The yashandb user used in non-interactive mode."`
+	YasdbPassword      string `name:"yasdb-password"       env:"YASDB_PASSWORD" json:"-" help:"The yashandb password used in non-interactive mode, it is visible in the process list, prefer the environment variable or <credentials>."`
+	YasdbAddress       string `name:"yasdb-address"        env:"YASDB_ADDRESS"  help:"Collect the DB-level items over TCP from the yashandb of the address, such as '192.168.1.2:1688' or '[fe80::1]:1688', so that YASDB_DATA is not required. Only the items which query the database are collected, the others need the files or processes of the database host and are skipped. It is used in non-interactive mode."`
+	Profile            string `name:"profile"              help:"The yasdb profile set by 'ytcctl yasdb set', it is used in both interactive and non-interactive mode. Values given by flags or environment variables take precedence."`
+	Credentials        string `name:"credentials"          help:"A file of 'KEY=VALUE' lines with YASDB_HOME, YASDB_DATA, YASDB_USER, YASDB_PASSWORD and YASDB_ADDRESS, it must not be accessible by group or others. Values given by flags or environment variables take precedence."`
+	Yes                bool   `name:"yes"                  short:"y" xor:"access" help:"Run without interaction, continue when some items are inaccessible and still collect the items which can be force collected."`
+	SkipInaccessible   bool   `name:"skip-inaccessible"    xor:"access" help:"Run without interaction, continue when some items are inaccessible and skip all of them."`
+	FailOnInaccessible bool   `name:"fail-on-inaccessible" xor:"access" help:"Run without interaction, stop the collection when some items are inaccessible."`
+}
+
+// isHeadless reports whether the collection should run without any interaction, the plan is always printed without interaction.
+func (c *CollectCmd) isHeadless() bool {
+	return c.Yes || c.SkipInaccessible || c.FailOnInaccessible || c.Plan
+}
+
+func (c *CollectCmd) accessPolicy() ytcctlhandler.AccessPolicy {
+	switch {
+	case c.Yes:
+		return ytcctlhandler.ACCESS_POLICY_YES
+	case c.SkipInaccessible:
+		return ytcctlhandler.ACCESS_POLICY_SKIP
+	case c.FailOnInaccessible:
+		return ytcctlhandler.ACCESS_POLICY_FAIL
+	default:
+		return ytcctlhandler.ACCESS_POLICY_PROMPT
+	}
+}
+
+// getYasdbEnvHeadless gets yasdb env from flags, environment variables, profile and credentials file in turn.
+func (c *CollectCmd) getYasdbEnvHeadless() (*yasdb.YasdbEnv, error) {
+	env := &yasdb.YasdbEnv{
+		YasdbHome:     trimSpace(c.YasdbHome),
+		YasdbData:     trimSpace(c.YasdbData),
+		YasdbUser:     trimSpace(c.YasdbUser),
+		YasdbPassword: pwdutil.NewSecret(trimSpace(c.YasdbPassword)),
+		YasdbAddress:  trimSpace(c.YasdbAddress),
+	}
+	if !stringutil.IsEmpty(c.Profile) {
+		if err := env.FillFromProfile(confdef.GetYTCConf().ProfilePath, c.Profile); err != nil {
+			return nil, err
+		}
+	}
+	if !stringutil.IsEmpty(c.Credentials) {
+		if err := env.FillFromCredentials(c.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	env.FillFromProcess()
+	if err := env.ValidYasdbHome(); err != nil {
+		return nil, err
+	}
+	if err := validateYasdbAddress(env.YasdbAddress); err != nil {
+		return nil, err
+	}
+	if !env.IsRemote() {
+		if err := env.ValidYasdbData(); err != nil {
+			return nil, err
+		}
+	}
+	if err := env.ValidYasdbUserAndPwd(); err != nil {
+		// the same as the form, yasdb internal data will be checked by the access policy
+		log.Controller.Errorf("validate yasdb err: %s", err.Error())
+		YasdbValidate = err
+	}
+	return env, nil
+}
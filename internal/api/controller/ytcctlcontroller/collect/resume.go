@@ -0,0 +1,99 @@
+package collect
+
+import (
+	"path"
+
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/defs/runtimedef"
+	ytcctlhandler "ytc/internal/api/handler/ytcctlhandler/collect"
+	"ytc/internal/modules/ytc/collect/data"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+	"ytc/utils/fileutil"
+	"ytc/utils/jsonutil"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+const f_resume = "resume"
+
+// resume finishes the interrupted collection in the package dir with the stored collect param.
+func (c *CollectCmd) resume() error {
+	packageDir := c.Resume
+	if !path.IsAbs(packageDir) {
+		packageDir = path.Join(runtimedef.GetYTCHome(), packageDir)
+	}
+	encryptKey, err := c.encryptKey()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	state, err := data.LoadCollectState(path.Clean(packageDir))
+	if err != nil {
+		log.Controller.Errorf("load collect state err: %s", err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, errdef.NewErrYtcFlag(f_resume, c.Resume, nil, err.Error()))
+	}
+	param := state.CollectParam
+	log.Controller.Infof("resume collection: %s", jsonutil.ToJSONString(state))
+	env, err := c.getResumeYasdbEnv(param.YasdbHome, param.YasdbData, param.YasdbUser, param.YasdbAddress)
+	if err != nil {
+		log.Controller.Errorf("get yasdb env err: %s", err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	owner, err := fileutil.GetOwner(env.YasdbHome)
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, yaserr.Wrapf(err, "get os owner of yasdb home %s", env.YasdbHome))
+	}
+	param.YasdbPassword = env.YasdbPassword
+	param.YasdbHomeOSUser = owner.Username
+	handler, err := ytcctlhandler.NewCollecterHandler(state.TypeMap(), param)
+	if err != nil {
+		return err
+	}
+	handler.AccessPolicy = c.accessPolicy()
+	handler.CancelOnSignal = true
+	handler.EncryptKey = encryptKey
+	handler.Redact = c.Redact || confdef.GetStrategyConf().Redact.Enable
+	handler.Resume(state)
+	return c.collect(handler)
+}
+
+// getResumeYasdbEnv uses the stored yasdb env, the password is not stored and is got in the same way as the non-interactive mode.
+func (c *CollectCmd) getResumeYasdbEnv(yasdbHome, yasdbData, yasdbUser, yasdbAddress string) (*yasdb.YasdbEnv, error) {
+	env := &yasdb.YasdbEnv{
+		YasdbHome:     yasdbHome,
+		YasdbData:     yasdbData,
+		YasdbUser:     yasdbUser,
+		YasdbPassword: pwdutil.NewSecret(trimSpace(c.YasdbPassword)),
+		YasdbAddress:  yasdbAddress,
+	}
+	if stringutil.IsEmpty(env.YasdbUser) {
+		env.YasdbUser = trimSpace(c.YasdbUser)
+	}
+	if !stringutil.IsEmpty(c.Profile) {
+		if err := env.FillFromProfile(confdef.GetYTCConf().ProfilePath, c.Profile); err != nil {
+			return nil, err
+		}
+	}
+	if !stringutil.IsEmpty(c.Credentials) {
+		if err := env.FillFromCredentials(c.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	if err := env.ValidYasdbHome(); err != nil {
+		return nil, err
+	}
+	if !env.IsRemote() {
+		if err := env.ValidYasdbData(); err != nil {
+			return nil, err
+		}
+	}
+	if err := env.ValidYasdbUserAndPwd(); err != nil {
+		log.Controller.Errorf("validate yasdb err: %s", err.Error())
+		YasdbValidate = err
+	}
+	return env, nil
+}
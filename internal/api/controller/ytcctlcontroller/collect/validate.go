@@ -7,17 +7,21 @@ import (
 	"strings"
 	"time"
 
+	"ytc/defs/bashdef"
 	"ytc/defs/collecttypedef"
 	"ytc/defs/confdef"
 	"ytc/defs/errdef"
 	"ytc/defs/regexdef"
 	"ytc/defs/runtimedef"
+	ytcctlhandler "ytc/internal/api/handler/ytcctlhandler/collect"
+	ytccollect "ytc/internal/modules/ytc/collect"
 	"ytc/log"
 	"ytc/utils/fileutil"
 	"ytc/utils/jsonutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/timeutil"
 	"ytc/utils/userutil"
+	"ytc/utils/yasqlutil"
 
 	"git.yasdb.com/go/yasutil/fs"
 )
@@ -28,6 +32,23 @@ const (
 	f_start  = "start"
 	f_end    = "end"
 	f_output = "output"
+
+	f_items      = "items"
+	f_skip_items = "skip-items"
+
+	f_plan_format = "plan-format"
+
+	f_disk_space_policy = "disk-space-policy"
+
+	f_encrypt = "encrypt"
+
+	f_instances = "instances"
+
+	f_yasdb_address = "yasdb-address"
+)
+
+const (
+	_instances_auto = "auto"
 )
 
 var (
@@ -47,6 +68,26 @@ var (
 	_range_help = "you must ensure that the number before (M|d|h|m) is greater than 0"
 
 	_type_help = "you can choose one or more of (base|diag|perf), split with ',', such as 'base,diag,perf'."
+
+	_examples_instances = []string{
+		_instances_auto,
+		"/data/yasdb/db1,/data/yasdb/db2",
+	}
+
+	_instances_help = "give 'auto' for all the running instances, or the absolute paths of YASDB_DATA of the instances split with ','"
+
+	_instances_not_found_help = "no running yasdb process is found, give YASDB_DATA of the instances instead"
+
+	_instances_remote_help = "the instances on the host can not be collected over TCP, remove --yasdb-address or YASDB_ADDRESS"
+
+	_examples_yasdb_address = []string{
+		"192.168.1.2:1688",
+		"yasdb.example.com:1688",
+	}
+
+	_yasdb_address_headless_help = "it is used in non-interactive mode, give --yes, --skip-inaccessible or --fail-on-inaccessible"
+
+	_encrypt_help = "give the public key by --encrypt-public-key or collect.encrypt_public_key of the strategy, or the passphrase by the environment variable YTC_ENCRYPT_PASSPHRASE"
 )
 
 func (c *CollectCmd) validate() error {
@@ -65,9 +106,131 @@ func (c *CollectCmd) validate() error {
 	if err := c.validateIncludePath(); err != nil {
 		return err
 	}
+	if err := c.validateItems(); err != nil {
+		return err
+	}
+	if err := c.validatePlanFormat(); err != nil {
+		return err
+	}
+	if err := c.validateSpacePolicy(); err != nil {
+		return err
+	}
+	if err := c.validateInstances(); err != nil {
+		return err
+	}
+	if err := c.validateYasdbAddress(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *CollectCmd) validateYasdbAddress() error {
+	value := trimSpace(c.YasdbAddress)
+	if stringutil.IsEmpty(value) {
+		return nil
+	}
+	if !c.isHeadless() {
+		return errdef.NewErrYtcFlag(f_yasdb_address, value, nil, _yasdb_address_headless_help)
+	}
+	return validateYasdbAddress(value)
+}
+
+// validateYasdbAddress checks the address given by the flag, the environment variable or the credentials file.
+func validateYasdbAddress(address string) error {
+	if stringutil.IsEmpty(address) {
+		return nil
+	}
+	if _, _, err := yasqlutil.ParseAddress(address); err != nil {
+		return errdef.NewErrYtcFlag(f_yasdb_address, address, _examples_yasdb_address, err.Error())
+	}
 	return nil
 }
 
+func (c *CollectCmd) validateInstances() error {
+	value := trimSpace(c.Instances)
+	if stringutil.IsEmpty(value) || value == _instances_auto {
+		return nil
+	}
+	datas := confdef.SplitItems(value)
+	if len(datas) == 0 {
+		return errdef.NewErrYtcFlag(f_instances, value, _examples_instances, _instances_help)
+	}
+	for _, data := range datas {
+		if !path.IsAbs(data) {
+			return errdef.NewErrYtcFlag(f_instances, data, _examples_instances, _instances_help)
+		}
+		if _, err := os.Stat(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CollectCmd) validateSpacePolicy() error {
+	switch c.SpacePolicy {
+	case "", confdef.DISK_SPACE_POLICY_FAIL, confdef.DISK_SPACE_POLICY_DOWNGRADE, confdef.DISK_SPACE_POLICY_IGNORE:
+		return nil
+	}
+	return errdef.NewErrYtcFlag(f_disk_space_policy, c.SpacePolicy, confdef.DiskSpacePolicies, "")
+}
+
+func (c *CollectCmd) validateItems() error {
+	if item, ok := ytccollect.UnknownItem(confdef.SplitItems(c.SkipItems)); ok {
+		return errdef.NewErrYtcFlag(f_skip_items, item, nil, ytccollect.ItemsHelp())
+	}
+	items := confdef.SplitItems(c.Items)
+	if item, ok := ytccollect.UnknownItem(items); ok {
+		return errdef.NewErrYtcFlag(f_items, item, nil, ytccollect.ItemsHelp())
+	}
+	types := make(map[string]struct{})
+	for _, t := range strings.Split(c.Type, stringutil.STR_COMMA) {
+		types[t] = struct{}{}
+	}
+	if len(c.getExtraPath(c.Include)) != 0 {
+		types[collecttypedef.TYPE_EXTRA] = struct{}{}
+	}
+	itemTypes := ytccollect.ItemTypes()
+	var kept, skipped []string
+	for _, item := range items {
+		t := itemTypes[item]
+		if _, ok := types[t]; ok {
+			kept = append(kept, item)
+			continue
+		}
+		help := fmt.Sprintf("it belongs to type %s, which is not in --type: %s", t, c.Type)
+		if t == collecttypedef.TYPE_EXTRA {
+			help = "it is collected only when --include is given"
+		}
+		if !c.itemsFromStrategy {
+			return errdef.NewErrYtcFlag(f_items, item, nil, help)
+		}
+		// collect.items of the strategy is shared by the collections of all the types
+		log.Controller.Warnf("skip %s of collect.items in strategy, %s", item, help)
+		skipped = append(skipped, item)
+	}
+	if len(skipped) == 0 {
+		return nil
+	}
+	if len(kept) == 0 {
+		return errdef.NewErrYtcFlag("collect.items", c.Items, nil, fmt.Sprintf("none of them is in --type: %s, give --items or --type instead", c.Type))
+	}
+	// stderr keeps the plan in json clean
+	fmt.Fprintf(os.Stderr, "%s\n\n", bashdef.WithYellow(fmt.Sprintf("Skip %s of collect.items in strategy, which are not in --type: %s", strings.Join(skipped, stringutil.STR_COMMA), c.Type)))
+	c.Items = strings.Join(kept, stringutil.STR_COMMA)
+	return nil
+}
+
+func (c *CollectCmd) validatePlanFormat() error {
+	if !c.Plan {
+		return nil
+	}
+	switch c.PlanFormat {
+	case ytcctlhandler.PLAN_FORMAT_TABLE, ytcctlhandler.PLAN_FORMAT_JSON:
+		return nil
+	}
+	return errdef.NewErrYtcFlag(f_plan_format, c.PlanFormat, []string{ytcctlhandler.PLAN_FORMAT_TABLE, ytcctlhandler.PLAN_FORMAT_JSON}, "")
+}
+
 func (c *CollectCmd) validateType() error {
 	c.Type = trimSpace(c.Type)
 	resMap := make(map[string]struct{})
@@ -227,6 +390,10 @@ func (c *CollectCmd) validateOutput() error {
 		if !os.IsNotExist(err) {
 			return err
 		}
+		if c.Plan {
+			// nothing is written when printing the plan
+			return nil
+		}
 		if err := fs.Mkdir(output); err != nil {
 			log.Controller.Errorf("create output err: %s", err.Error())
 			if os.IsPermission(err) {
@@ -240,6 +407,14 @@ func (c *CollectCmd) validateOutput() error {
 }
 
 func (c *CollectCmd) fillDefault() {
+	strategy := confdef.GetStrategyConf()
+	if stringutil.IsEmpty(c.Items) {
+		c.Items = strategy.Collect.Items
+		c.itemsFromStrategy = !stringutil.IsEmpty(c.Items)
+	}
+	if stringutil.IsEmpty(c.SkipItems) {
+		c.SkipItems = strategy.Collect.SkipItems
+	}
 	if stringutil.IsEmpty(c.Output) {
 		c.Output = confdef.GetStrategyConf().Collect.Output
 	}
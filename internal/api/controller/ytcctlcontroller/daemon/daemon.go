@@ -1,5 +1,12 @@
 package daemon
 
+import (
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/log"
+)
+
 type DaemonCmd struct {
 	Start   startCmd   `cmd:"start"   name:"start"   help:"Start yashan trace collector daemon."`
 	Stop    stopCmd    `cmd:"stop"    name:"stop"    help:"Stop yashan trace collector daemon."`
@@ -12,3 +19,13 @@ type DaemonCmd struct {
 func (c DaemonCmd) Run() error {
 	return nil
 }
+
+// validateSchedules checks the schedules before starting or reloading the daemon,
+// so that the mistakes can be found immediately instead of in the log of ytcd.
+func validateSchedules() error {
+	if err := confdef.GetStrategyConf().ValidateSchedules(); err != nil {
+		log.Controller.Errorf("validate schedules err: %s", err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	return nil
+}
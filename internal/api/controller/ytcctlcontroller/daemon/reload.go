@@ -1,9 +1,17 @@
 package daemon
 
+import (
+	"ytc/commons/flags"
+	daemonhandler "ytc/internal/api/handler/ytcctlhandler/daemon"
+)
+
 type reloadCmd struct {
 }
 
 // [Interface Func]
-func (c reloadCmd) Run() error {
-	return nil
+func (c reloadCmd) Run(globals *flags.Globals) error {
+	if err := validateSchedules(); err != nil {
+		return err
+	}
+	return daemonhandler.NewDaemonHandler(globals.Config).Reload()
 }
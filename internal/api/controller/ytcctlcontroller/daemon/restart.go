@@ -1,10 +1,18 @@
 package daemon
 
+import (
+	"ytc/commons/flags"
+	daemonhandler "ytc/internal/api/handler/ytcctlhandler/daemon"
+)
+
 type restartCmd struct {
-	Force bool `name:"force" short:"f" help:"Use kill -9 to stop daeomn, then restart daemon."`
+	Force bool `name:"force" short:"f" help:"Use kill -9 to stop daemon if it is still running after a graceful stop, then restart daemon."`
 }
 
 // [Interface Func]
-func (c restartCmd) Run() error {
-	return nil
+func (c restartCmd) Run(globals *flags.Globals) error {
+	if err := validateSchedules(); err != nil {
+		return err
+	}
+	return daemonhandler.NewDaemonHandler(globals.Config).Restart(c.Force)
 }
@@ -1,9 +1,17 @@
 package daemon
 
+import (
+	"ytc/commons/flags"
+	daemonhandler "ytc/internal/api/handler/ytcctlhandler/daemon"
+)
+
 type startCmd struct {
 }
 
 // [Interface Func]
-func (c startCmd) Run() error {
-	return nil
+func (c startCmd) Run(globals *flags.Globals) error {
+	if err := validateSchedules(); err != nil {
+		return err
+	}
+	return daemonhandler.NewDaemonHandler(globals.Config).Start()
 }
@@ -1,9 +1,14 @@
 package daemon
 
+import (
+	"ytc/commons/flags"
+	daemonhandler "ytc/internal/api/handler/ytcctlhandler/daemon"
+)
+
 type statusCmd struct {
 }
 
 // [Interface Func]
-func (c statusCmd) Run() error {
-	return nil
+func (c statusCmd) Run(globals *flags.Globals) error {
+	return daemonhandler.NewDaemonHandler(globals.Config).Status()
 }
@@ -1,10 +1,15 @@
 package daemon
 
+import (
+	"ytc/commons/flags"
+	daemonhandler "ytc/internal/api/handler/ytcctlhandler/daemon"
+)
+
 type stopCmd struct {
-	Force bool `name:"force" short:"f" help:"Use kill -9 to stop daeomn."`
+	Force bool `name:"force" short:"f" help:"Use kill -9 to stop daemon if it is still running after a graceful stop."`
 }
 
 // [Interface Func]
-func (c stopCmd) Run() error {
-	return nil
+func (c stopCmd) Run(globals *flags.Globals) error {
+	return daemonhandler.NewDaemonHandler(globals.Config).Stop(c.Force)
 }
@@ -0,0 +1,28 @@
+package ytcctlcontroller
+
+import (
+	"ytc/utils/cryptoutil"
+	"ytc/utils/stringutil"
+)
+
+// DecryptFlags are used to read the encrypted collection results, ytc-*.tar.gz.enc.
+type DecryptFlags struct {
+	Key        string `name:"key"        type:"existingfile" help:"The private key file generated by 'ytcctl keygen', used when the collection result is encrypted with the public key."`
+	Passphrase string `name:"passphrase" env:"YTC_ENCRYPT_PASSPHRASE" json:"-" help:"The passphrase used when the collection result is encrypted with a passphrase, it is visible in the process list, prefer the environment variable."`
+}
+
+// DecryptKey returns nil if neither the key nor the passphrase is given, which is enough for the unencrypted results.
+func (f DecryptFlags) DecryptKey() (*cryptoutil.DecryptKey, error) {
+	if stringutil.IsEmpty(f.Key) && stringutil.IsEmpty(f.Passphrase) {
+		return nil, nil
+	}
+	key := &cryptoutil.DecryptKey{Passphrase: f.Passphrase}
+	if !stringutil.IsEmpty(f.Key) {
+		privateKey, err := cryptoutil.LoadPrivateKey(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey = privateKey
+	}
+	return key, nil
+}
@@ -0,0 +1,29 @@
+package keygen
+
+import (
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	keygenhandler "ytc/internal/api/handler/ytcctlhandler/keygen"
+)
+
+const (
+	f_type = "type"
+)
+
+type KeygenCmd struct {
+	Type   string `name:"type"   short:"t" default:"encrypt" help:"The usage of the key pair, choose one of (encrypt|sign). The encrypt keys are X25519 keys to encrypt the collection results, the sign keys are ed25519 keys to sign them."`
+	Output string `name:"output" short:"o" required:"" help:"The private key file to write, such as './ytc-vendor', the public key is written into <output>.pub. The existing files are not overwritten."`
+}
+
+// [Interface Func]
+func (c KeygenCmd) Run() error {
+	handler := keygenhandler.NewKeygenHandler(c.Output)
+	switch c.Type {
+	case keygenhandler.KEY_TYPE_ENCRYPT:
+		return handler.Keygen()
+	case keygenhandler.KEY_TYPE_SIGN:
+		return handler.KeygenSign()
+	default:
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, errdef.NewErrYtcFlag(f_type, c.Type, []string{keygenhandler.KEY_TYPE_ENCRYPT, keygenhandler.KEY_TYPE_SIGN}, ""))
+	}
+}
@@ -1,12 +1,84 @@
 package report
 
+import (
+	"path"
+	"strings"
+
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/defs/runtimedef"
+	"ytc/internal/api/controller/ytcctlcontroller"
+	reporthandler "ytc/internal/api/handler/ytcctlhandler/report"
+	"ytc/internal/modules/ytc/collect/resultgenner/reporter"
+	"ytc/utils/stringutil"
+)
+
+const (
+	f_type = "type"
+)
+
+var (
+	_type_help = "you can choose one or more of (txt|md|html), split with ',', such as 'txt,html'."
+)
+
 type ReportCmd struct {
-	Input  string `name:"input"  short:"i" help:"The collection result input."`
-	Type   string `name:"type"   short:"t" help:"Type of report generated, choose one from (txt)."`
+	ytcctlcontroller.DecryptFlags
+	Input  string `name:"input"  short:"i" required:"" help:"The collection result input, a ytc-*.tar.gz, an encrypted ytc-*.tar.gz.enc or an extracted directory."`
+	Type   string `name:"type"   short:"t" help:"Type of report generated, choose one or more of (txt|md|html) and split with ','."`
 	Output string `name:"output" short:"o" help:"The output dir of the report."`
 }
 
 // [Interface Func]
 func (c ReportCmd) Run() error {
-	return nil
+	c.fillDefault()
+	types, err := c.getTypes()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	key, err := c.DecryptKey()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	handler := reporthandler.NewReportHandler(c.Input, c.Output, types)
+	handler.Key = key
+	return handler.Report()
+}
+
+func (c *ReportCmd) fillDefault() {
+	strategy := confdef.GetStrategyConf()
+	if stringutil.IsEmpty(c.Type) {
+		c.Type = strategy.Report.Type
+	}
+	if stringutil.IsEmpty(c.Type) {
+		c.Type = string(reporter.REPORT_TYPE_TXT)
+	}
+	if stringutil.IsEmpty(c.Output) {
+		c.Output = strategy.Report.Output
+	}
+	if !path.IsAbs(c.Output) {
+		c.Output = path.Join(runtimedef.GetYTCHome(), c.Output)
+	}
+	c.Output = path.Clean(c.Output)
+}
+
+func (c *ReportCmd) getTypes() (types []reporter.ReportType, err error) {
+	valid := make(map[string]struct{})
+	for _, t := range reporter.REPORT_TYPES {
+		valid[string(t)] = struct{}{}
+	}
+	added := make(map[string]struct{})
+	for _, t := range strings.Split(c.Type, stringutil.STR_COMMA) {
+		t = strings.TrimSpace(t)
+		if _, ok := valid[t]; !ok {
+			err = errdef.NewErrYtcFlag(f_type, c.Type, nil, _type_help)
+			return
+		}
+		if _, ok := added[t]; ok {
+			continue
+		}
+		added[t] = struct{}{}
+		types = append(types, reporter.ReportType(t))
+	}
+	return
 }
@@ -1,9 +1,12 @@
 package strategy
 
 type exportCmd struct {
+	Output    string `arg:"" name:"output" help:"The file or directory to export to."`
+	Effective bool   `name:"effective" help:"Export the effective strategy in which the empty items are filled with the default values, instead of the strategy file."`
+	Force     bool   `name:"force" short:"f" help:"Overwrite the output file if it exists."`
 }
 
 // [Interface Func]
 func (c exportCmd) Run() error {
-	return nil
+	return newStrategyHandler().Export(c.Output, c.Effective, c.Force)
 }
@@ -1,9 +1,10 @@
 package strategy
 
 type replaceCmd struct {
+	File string `arg:"" name:"file" type:"existingfile" help:"The new strategy file, it is validated before replacing."`
 }
 
 // [Interface Func]
 func (c replaceCmd) Run() error {
-	return nil
+	return exitErr(newStrategyHandler().Replace(c.File))
 }
@@ -5,5 +5,5 @@ type showCmd struct {
 
 // [Interface Func]
 func (c showCmd) Run() error {
-	return nil
+	return newStrategyHandler().Show()
 }
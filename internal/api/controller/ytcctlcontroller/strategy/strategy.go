@@ -1,5 +1,14 @@
 package strategy
 
+import (
+	"errors"
+
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	strategyhandler "ytc/internal/api/handler/ytcctlhandler/strategy"
+)
+
 type StrategyCmd struct {
 	Show    showCmd    `cmd:"show"    name:"show"    help:"Show current strategy."`
 	Update  updateCmd  `cmd:"update"  name:"update"  help:"Update current strategy."`
@@ -11,3 +20,16 @@ type StrategyCmd struct {
 func (c StrategyCmd) Run() error {
 	return nil
 }
+
+func newStrategyHandler() *strategyhandler.StrategyHandler {
+	return strategyhandler.NewStrategyHandler(confdef.GetYTCConf().StrategyPath)
+}
+
+// exitErr returns the error with EXIT_CODE_INVALID_INPUT if the strategy is invalid.
+func exitErr(err error) error {
+	var invalid *errdef.ErrInvalidStrategy
+	if errors.As(err, &invalid) {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	return err
+}
@@ -1,9 +1,39 @@
 package strategy
 
+import (
+	"strings"
+
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/defs/regexdef"
+	strategyhandler "ytc/internal/api/handler/ytcctlhandler/strategy"
+)
+
+const (
+	f_key_value = "key=value"
+)
+
 type updateCmd struct {
+	KeyValues []string `arg:"" name:"key=value" help:"The items to update, such as 'collect.range=12h' 'report.type=txt,html' 'redact.enable=true'."`
 }
 
 // [Interface Func]
 func (c updateCmd) Run() error {
-	return nil
+	kvs, err := c.getKeyValues()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	return exitErr(newStrategyHandler().Update(kvs))
+}
+
+func (c updateCmd) getKeyValues() (kvs []strategyhandler.KeyValue, err error) {
+	for _, arg := range c.KeyValues {
+		matches := regexdef.KeyValueRegex.FindStringSubmatch(arg)
+		if len(matches) != 3 {
+			err = errdef.NewErrYtcFlag(f_key_value, arg, []string{"collect.range=12h"}, "")
+			return
+		}
+		kvs = append(kvs, strategyhandler.KeyValue{Key: strings.TrimSpace(matches[1]), Value: strings.TrimSpace(matches[2])})
+	}
+	return
 }
@@ -0,0 +1,38 @@
+package verify
+
+import (
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/internal/api/controller/ytcctlcontroller"
+	verifyhandler "ytc/internal/api/handler/ytcctlhandler/verify"
+	"ytc/utils/stringutil"
+)
+
+type VerifyCmd struct {
+	ytcctlcontroller.DecryptFlags
+	TrustedKeys      string `name:"trusted-keys"      type:"existingfile" help:"The file of the ed25519 public keys trusted to sign the collection results. The default value is trusted_keys of ytc.toml."`
+	RequireSignature bool   `name:"require-signature" help:"Fail if the collection result is not signed."`
+	Package          string `arg:"" name:"package" type:"existingfile" help:"The collection result to verify, such as './results/ytc-20230101120000.tar.gz', the signature <package>.sig next to it is checked if it exists."`
+}
+
+// [Interface Func]
+func (c VerifyCmd) Run() error {
+	key, err := c.DecryptKey()
+	if err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	handler := verifyhandler.NewVerifyHandler(c.Package, key)
+	handler.TrustedKeys = c.TrustedKeys
+	if stringutil.IsEmpty(handler.TrustedKeys) {
+		handler.TrustedKeys = confdef.GetYTCConf().TrustedKeys
+	}
+	handler.RequireSignature = c.RequireSignature
+	switch err = handler.Verify(); err {
+	case verifyhandler.ErrPackageNotMatch, verifyhandler.ErrSignatureNotValid:
+		return errdef.NewErrExit(constdef.EXIT_CODE_VERIFY_FAILED, nil)
+	case verifyhandler.ErrNoTrustedKeys:
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	return err
+}
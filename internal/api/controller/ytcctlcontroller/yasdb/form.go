@@ -0,0 +1,101 @@
+package yasdb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	constdef "ytc/defs/constants"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+	"ytc/utils/terminalutil"
+)
+
+const (
+	SAVE = "Save"
+	QUIT = "Quit"
+
+	form_header_format = "Set Yasdb Profile: %s"
+)
+
+// openProfileForm opens a form filled with env, the profile is validated before the form exits with FORM_EXIT_CONTINUE.
+func openProfileForm(name string, env *yasdb.YasdbEnv) (*yasdb.YasdbEnv, int) {
+	var opts []terminalutil.WithOption
+	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddInput(constdef.YASDB_HOME, env.YasdbHome, validatePath) })
+	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddInput(constdef.YASDB_DATA, env.YasdbData, validatePath) })
+	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddInput(constdef.YASDB_USER, env.YasdbUser, nil) })
+	opts = append(opts, func(c *terminalutil.CollectForm) {
+		c.AddPassword(constdef.YASDB_PASSWORD, env.YasdbPassword.Reveal(), nil)
+	})
+	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddButton(SAVE, saveFunc) })
+	opts = append(opts, func(c *terminalutil.CollectForm) { c.AddButton(QUIT, quitFunc) })
+	form := terminalutil.NewCollectFrom(fmt.Sprintf(form_header_format, name), opts...)
+	form.Start()
+	if form.ExitCode != terminalutil.FORM_EXIT_CONTINUE {
+		return nil, form.ExitCode
+	}
+	res, err := getYasdbEnvFromForm(form)
+	if err != nil {
+		log.Controller.Errorf("get yasdb env err: %s", err.Error())
+		return nil, terminalutil.FORM_EXIT_NOT_CONTINUE
+	}
+	return res, form.ExitCode
+}
+
+func getYasdbEnvFromForm(c *terminalutil.CollectForm) (*yasdb.YasdbEnv, error) {
+	labelMap, err := c.GetFormDataByLabels(constdef.YASDB_HOME, constdef.YASDB_DATA, constdef.YASDB_USER, constdef.YASDB_PASSWORD)
+	if err != nil {
+		return nil, err
+	}
+	return &yasdb.YasdbEnv{
+		YasdbHome:     strings.TrimSpace(labelMap[constdef.YASDB_HOME]),
+		YasdbData:     strings.TrimSpace(labelMap[constdef.YASDB_DATA]),
+		YasdbUser:     strings.TrimSpace(labelMap[constdef.YASDB_USER]),
+		YasdbPassword: pwdutil.NewSecret(strings.TrimSpace(labelMap[constdef.YASDB_PASSWORD])),
+	}, nil
+}
+
+func validatePath(label, value string) (bool, string) {
+	if stringutil.IsEmpty(value) {
+		return false, fmt.Sprintf("please enter %s", label)
+	}
+	if _, err := os.Stat(value); err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+// saveFunc only saves a profile which can connect to yasdb.
+func saveFunc(c *terminalutil.CollectForm) {
+	if err := c.Validate(); err != nil {
+		c.ShowTips(err.Error())
+		return
+	}
+	env, err := getYasdbEnvFromForm(c)
+	if err != nil {
+		log.Controller.Errorf("get yasdb env err: %s", err.Error())
+		return
+	}
+	if err := env.ValidYasdbUserAndPwd(); err != nil {
+		log.Controller.Errorf("validate yasdb err: %s", err.Error())
+		c.ShowTips(validateErrDesc(err))
+		return
+	}
+	c.Stop(terminalutil.FORM_EXIT_CONTINUE)
+}
+
+func quitFunc(c *terminalutil.CollectForm) {
+	c.Stop(terminalutil.FORM_EXIT_NOT_CONTINUE)
+}
+
+// validateErrDesc returns the description and tips of the error returned by ValidYasdbUserAndPwd.
+func validateErrDesc(err error) string {
+	desc, tips := ytccollectcommons.YasErrDescAndTips(err)
+	if stringutil.IsEmpty(tips) {
+		return desc
+	}
+	return strings.Join([]string{desc, tips}, ", ")
+}
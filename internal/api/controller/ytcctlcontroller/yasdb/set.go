@@ -1,10 +1,68 @@
 package yasdb
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+	"ytc/utils/terminalutil"
+)
+
 type setCmd struct {
-	DisableInteraction bool `name:"disable-interaction" short:"d" help:"Disable interaction edit mode."`
+	DisableInteraction bool   `name:"disable-interaction" short:"d" help:"Disable interaction edit mode."`
+	Profile            string `name:"profile"             short:"p" default:"default" help:"The name of the profile to set, only letters, digits, '_' and '-' are allowed."`
+	YasdbHome          string `name:"yasdb-home"          env:"YASDB_HOME"     help:"The YASDB_HOME of the profile."`
+	YasdbData          string `name:"yasdb-data"          env:"YASDB_DATA"     help:"The YASDB_DATA of the profile."`
+	YasdbUser          string `name:"yasdb-user"          env:"YASDB_USER"     help:"The yashandb user of the profile."`
+	YasdbPassword      string `name:"yasdb-password"      env:"YASDB_PASSWORD" json:"-" help:"The yashandb password of the profile, it is visible in the process list, prefer the environment variable or the interaction edit mode. It is encrypted with the machine id and the install key yasdb_profile.key next to the profile file, which is generated with mode 0600, the users who can read both files can decrypt it."`
 }
 
 // [Interface Func]
 func (c setCmd) Run() error {
-	return nil
+	if err := yasdb.ValidProfileName(c.Profile); err != nil {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	handler := newProfileHandler()
+	env, err := handler.Load(c.Profile)
+	if err != nil {
+		return err
+	}
+	if env == nil {
+		env = &yasdb.YasdbEnv{}
+	}
+	// values given by flags or environment variables take precedence over the current profile
+	c.overwrite(env)
+	env.FillFromProcess()
+	if !c.DisableInteraction {
+		var code int
+		env, code = openProfileForm(c.Profile, env)
+		if code != terminalutil.FORM_EXIT_CONTINUE {
+			fmt.Println("Quit Set")
+			return nil
+		}
+	} else if err := env.ValidYasdbUserAndPwd(); err != nil {
+		log.Controller.Errorf("validate profile %s err: %s", c.Profile, err.Error())
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, errors.New(validateErrDesc(err)))
+	}
+	return handler.Set(c.Profile, env)
+}
+
+func (c setCmd) overwrite(env *yasdb.YasdbEnv) {
+	set := func(v *string, value string) {
+		if value = strings.TrimSpace(value); !stringutil.IsEmpty(value) {
+			*v = value
+		}
+	}
+	set(&env.YasdbHome, c.YasdbHome)
+	set(&env.YasdbData, c.YasdbData)
+	set(&env.YasdbUser, c.YasdbUser)
+	if password := strings.TrimSpace(c.YasdbPassword); !stringutil.IsEmpty(password) {
+		env.YasdbPassword = pwdutil.NewSecret(password)
+	}
 }
@@ -1,9 +1,22 @@
 package yasdb
 
+import (
+	"errors"
+
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+)
+
 type showCmd struct {
+	Profile string `name:"profile" short:"p" help:"The name of the profile to show, all profiles are shown if it is not given."`
 }
 
 // [Interface Func]
 func (c showCmd) Run() error {
-	return nil
+	err := newProfileHandler().Show(c.Profile)
+	var notFound *errdef.ErrProfileNotFound
+	if errors.As(err, &notFound) {
+		return errdef.NewErrExit(constdef.EXIT_CODE_INVALID_INPUT, err)
+	}
+	return err
 }
@@ -1,5 +1,10 @@
 package yasdb
 
+import (
+	"ytc/defs/confdef"
+	yasdbhandler "ytc/internal/api/handler/ytcctlhandler/yasdb"
+)
+
 type YasdbCmd struct {
 	Show showCmd `cmd:"show" name:"show" help:"Show current yashandb information."`
 	Set  setCmd  `cmd:"set"  name:"set"  help:"Set yashandb information."`
@@ -9,3 +14,7 @@ type YasdbCmd struct {
 func (c YasdbCmd) Run() error {
 	return nil
 }
+
+func newProfileHandler() *yasdbhandler.ProfileHandler {
+	return yasdbhandler.NewProfileHandler(confdef.GetYTCConf().ProfilePath)
+}
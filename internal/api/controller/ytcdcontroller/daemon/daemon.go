@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ytc/commons/flags"
+	"ytc/defs/confdef"
+	daemonhandler "ytc/internal/api/handler/ytcdhandler/daemon"
+	"ytc/log"
+)
+
+type DaemonCmd struct {
+}
+
+// [Interface Func]
+// Run runs the scheduled collections until SIGINT or SIGTERM is received, and reloads the configurations on SIGHUP.
+func (c DaemonCmd) Run(globals *flags.Globals) error {
+	reload := func() (confdef.Strategy, error) {
+		if err := confdef.InitConf(globals.Config); err != nil {
+			return confdef.Strategy{}, err
+		}
+		return confdef.GetStrategyConf(), nil
+	}
+	daemon, err := daemonhandler.NewDaemon(confdef.GetStrategyConf(), reload)
+	if err != nil {
+		log.Controller.Errorf("init daemon err: %s", err.Error())
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+	defer signal.Stop(reloadCh)
+	log.Controller.Infof("ytcd started, pid: %d", os.Getpid())
+	err = daemon.Run(ctx, reloadCh)
+	if err != nil {
+		log.Controller.Errorf("run daemon err: %s", err.Error())
+	}
+	log.Controller.Infof("ytcd stopped")
+	return err
+}
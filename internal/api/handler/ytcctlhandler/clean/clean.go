@@ -0,0 +1,180 @@
+package cleanhandler
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"ytc/defs/bashdef"
+	ytcclean "ytc/internal/modules/ytc/clean"
+	"ytc/internal/modules/ytc/collect/resultgenner"
+	"ytc/log"
+
+	"git.yasdb.com/go/yasutil/size"
+)
+
+// CleanHandler removes the expired collection results and the leftovers of the interrupted collections.
+type CleanHandler struct {
+	Output    string // the output dir of the collection results
+	Retention ytcclean.Retention
+	DryRun    bool // only list what would be removed
+	Resumable bool // remove the working dirs which can be resumed by 'ytcctl collect --resume' too
+	Yes       bool // kill the stale processes without confirmation
+}
+
+func NewCleanHandler(output string, retention ytcclean.Retention, dryRun bool) *CleanHandler {
+	return &CleanHandler{
+		Output:    output,
+		Retention: retention,
+		DryRun:    dryRun,
+	}
+}
+
+func (h *CleanHandler) Clean() error {
+	results, err := ytcclean.ListResults(h.Output)
+	if err != nil {
+		return err
+	}
+	expired, reasons := h.Retention.Expired(results, time.Now())
+	dirs, resumable, err := ytcclean.ListOrphanDirs(h.Output)
+	if err != nil {
+		return err
+	}
+	if h.Resumable {
+		dirs = append(dirs, resumable...)
+		resumable = nil
+	}
+	stales, err := ytcclean.ListStaleProcesses()
+	if err != nil {
+		return err
+	}
+	if len(resumable) != 0 {
+		fmt.Printf("Working dirs kept for 'ytcctl collect --resume', use --resumable to remove them:\n")
+		for _, dir := range resumable {
+			fmt.Printf("  %s  %s\n", path.Base(dir), formatSize(getDirSize(dir)))
+		}
+	}
+	if len(expired) == 0 && len(dirs) == 0 && len(stales) == 0 {
+		fmt.Printf("Nothing to clean in %s\n", h.Output)
+		return nil
+	}
+	var (
+		failed int
+		freed  int64
+	)
+	if len(expired) != 0 {
+		fmt.Printf("Expired results in %s:\n", h.Output)
+		for i, result := range expired {
+			fmt.Printf("  %s  %s  %s\n", path.Base(result.Path), formatSize(result.Size), reasons[i])
+			if h.DryRun {
+				continue
+			}
+			if err := os.Remove(result.Path); err != nil {
+				log.Handler.Errorf("remove result %s err: %s", result.Path, err.Error())
+				failed++
+				continue
+			}
+			log.Handler.Infof("result %s removed, %s", result.Path, reasons[i])
+			freed += result.Size
+			// the signature and the redaction mapping are useless without the result
+			if err := os.Remove(resultgenner.GenSignaturePath(result.Path)); err != nil && !os.IsNotExist(err) {
+				log.Handler.Warnf("remove signature of %s err: %s", result.Path, err.Error())
+			}
+			if err := os.Remove(resultgenner.GenRedactionMappingPath(result.Path)); err != nil && !os.IsNotExist(err) {
+				log.Handler.Warnf("remove redaction mapping of %s err: %s", result.Path, err.Error())
+			}
+		}
+	}
+	if len(dirs) != 0 {
+		fmt.Printf("Working dirs left by the interrupted collections:\n")
+		for _, dir := range dirs {
+			dirSize := getDirSize(dir)
+			fmt.Printf("  %s  %s\n", path.Base(dir), formatSize(dirSize))
+			if h.DryRun {
+				continue
+			}
+			if err := os.RemoveAll(dir); err != nil {
+				log.Handler.Errorf("remove working dir %s err: %s", dir, err.Error())
+				failed++
+				continue
+			}
+			log.Handler.Infof("working dir %s removed", dir)
+			freed += dirSize
+		}
+	}
+	if len(stales) != 0 {
+		fmt.Printf("Processes left by the exited ytcctl or ytcd:\n")
+		for _, p := range stales {
+			fmt.Printf("  pid: %d, parent pid: %d, command: %s\n", p.Pid, p.ParentPid, p.Cmdline)
+		}
+		if !h.DryRun && h.confirmKill(len(stales)) {
+			failed += h.killStales(stales)
+		}
+	}
+	if h.DryRun {
+		fmt.Printf("Dry run, %s is removed or killed\n", bashdef.WithYellow("nothing"))
+		return nil
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of them can not be cleaned, please check the log for details", failed)
+	}
+	fmt.Printf("The clean has been %s, %s freed\n", bashdef.WithGreen("completed"), formatSize(freed))
+	return nil
+}
+
+// confirmKill asks whether to kill the stale processes unless Yes is set.
+func (h *CleanHandler) confirmKill(n int) bool {
+	if h.Yes {
+		return true
+	}
+	var isConfirm string
+	fmt.Printf("Are you sure to kill the %d processes [y/n] ?\n", n)
+	fmt.Scanln(&isConfirm)
+	if strings.ToLower(isConfirm) != "y" {
+		fmt.Printf("The processes are %s, use --yes to kill them without confirmation\n", bashdef.WithYellow("not killed"))
+		return false
+	}
+	return true
+}
+
+// killStales kills the stale processes and returns the number of the failed ones, the processes which have exited or
+// whose pids have been reused are skipped.
+func (h *CleanHandler) killStales(stales []ytcclean.StaleProcess) (failed int) {
+	for _, p := range stales {
+		err := p.Kill()
+		if err == syscall.ESRCH {
+			log.Handler.Infof("process %d is not the stale one any more, skip it, command: %s", p.Pid, p.Cmdline)
+			continue
+		}
+		if err != nil {
+			log.Handler.Errorf("kill process %d err: %s", p.Pid, err.Error())
+			failed++
+			continue
+		}
+		log.Handler.Infof("process %d killed, command: %s", p.Pid, p.Cmdline)
+	}
+	return
+}
+
+// getDirSize returns the total size of the regular files in dir, the inaccessible ones are ignored.
+func getDirSize(dir string) (total int64) {
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return
+}
+
+func formatSize(n int64) string {
+	return size.GenHumanReadableSize(float64(n), 2)
+}
@@ -0,0 +1,154 @@
+package clusterhandler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"sync"
+	"syscall"
+	"time"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/errdef"
+	"ytc/defs/timedef"
+	ytccluster "ytc/internal/modules/ytc/cluster"
+	"ytc/log"
+	"ytc/utils/cryptoutil"
+	"ytc/utils/fileutil"
+
+	"git.yasdb.com/go/yasutil/fs"
+)
+
+// ClusterHandler collects the nodes of the inventory over ssh, at most Options.Parallel of them at the same time, and
+// combines their result packages into one cluster package with a per-node index.
+type ClusterHandler struct {
+	Inventory      *ytccluster.Inventory
+	InventoryPath  string
+	Options        *ytccluster.Options
+	Output         string
+	EncryptKey     *cryptoutil.EncryptKey // encrypt the cluster package with it while packing if it is set
+	CancelOnSignal bool                   // cancel the collection on SIGINT and SIGTERM
+	ResultPath     string                 // the path of the cluster package after collecting
+
+	mtx sync.Mutex // the lines of the nodes are printed one by one
+}
+
+func NewClusterHandler(inventoryPath string, inventory *ytccluster.Inventory, opts *ytccluster.Options, output string) *ClusterHandler {
+	return &ClusterHandler{
+		Inventory:     inventory,
+		InventoryPath: inventoryPath,
+		Options:       opts,
+		Output:        output,
+	}
+}
+
+// Collect runs the collection on all the nodes, the cluster package is produced even if some nodes failed.
+func (h *ClusterHandler) Collect() error {
+	ctx, stop := h.collectContext()
+	defer stop()
+	dir := path.Join(h.Output, h.Options.Name)
+	if err := fs.Mkdir(dir); err != nil {
+		return err
+	}
+	h.Options.OnStep = func(node *ytccluster.Node, step string) {
+		log.Handler.Infof("node %s: %s", node.Name, step)
+		h.printf("[%s] %s\n", node.Name, step)
+	}
+	fmt.Printf("Collecting %d nodes from %s to %s...\n\n", len(h.Inventory.Nodes),
+		h.Options.Start.Format(timedef.TIME_FORMAT_UNTIL_MINITE), h.Options.End.Format(timedef.TIME_FORMAT_UNTIL_MINITE))
+	index := &ytccluster.Index{
+		Name:      h.Options.Name,
+		Inventory: h.InventoryPath,
+		Start:     h.Options.Start,
+		End:       h.Options.End,
+		CreatedAt: time.Now(),
+		Nodes:     make([]*ytccluster.NodeResult, len(h.Inventory.Nodes)),
+	}
+	parallel := h.Options.Parallel
+	if parallel <= 0 {
+		parallel = len(h.Inventory.Nodes)
+	}
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, node := range h.Inventory.Nodes {
+		wg.Add(1)
+		go func(i int, node *ytccluster.Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			res := ytccluster.CollectNode(ctx, node, h.Options, dir)
+			index.Nodes[i] = res
+			switch res.Status {
+			case ytccluster.NODE_STATUS_FAILED:
+				log.Handler.Errorf("collect on node %s err: %s", node.Name, res.Error)
+				h.printf("[%s] %s: %s\n", node.Name, bashdef.WithRed(res.Status), res.Error)
+				return
+			case ytccluster.NODE_STATUS_CANCELLED:
+				log.Handler.Warnf("collect on node %s cancelled, %d files pulled", node.Name, len(res.Files))
+				h.printf("[%s] %s\n", node.Name, bashdef.WithYellow(res.Status))
+				return
+			}
+			h.printf("[%s] %s\n", node.Name, bashdef.WithGreen(res.Status))
+		}(i, node)
+	}
+	wg.Wait()
+	if err := index.Write(dir); err != nil {
+		return err
+	}
+	fmt.Printf("\n%s\n\nPacking the results of the nodes, please wait for a moment...\n\n", index.Table())
+	packagePath, err := ytccluster.Pack(dir, h.EncryptKey)
+	if skipped, ok := err.(fileutil.FileErrors); ok {
+		log.Handler.Warnf("files not packed: %s", skipped.Error())
+		fmt.Println(bashdef.WithYellow(fmt.Sprintf("%d files could not be read and were not packed, they are kept in %s, see the log for details.", len(skipped), dir)))
+	} else if err != nil {
+		log.Handler.Errorf("pack cluster package err: %s", err.Error())
+		return err
+	}
+	h.ResultPath = packagePath
+	mapping, err := ytccluster.WriteRedactionMapping(packagePath, index.Nodes)
+	if err != nil {
+		log.Handler.Errorf("write redaction mapping err: %s", err.Error())
+		fmt.Println(bashdef.WithYellow(fmt.Sprintf("Failed to write the redaction mapping: %s", err.Error())))
+	}
+	failed := index.Failed()
+	status := bashdef.WithGreen("completed")
+	switch {
+	case ctx.Err() != nil:
+		status = bashdef.WithYellow("cancelled")
+	case len(failed) != 0:
+		status = bashdef.WithYellow(fmt.Sprintf("failed on %d of %d nodes", len(failed), len(index.Nodes)))
+	}
+	fmt.Printf("The cluster collection has been %s and the result was saved to %s, thanks for your use.\n", status, bashdef.WithBlue(packagePath))
+	if len(mapping) != 0 {
+		fmt.Printf("The sensitive data were redacted and the mapping of the tokens was saved to %s, keep it private.\n", bashdef.WithBlue(mapping))
+	}
+	if ctx.Err() != nil {
+		return errdef.ErrCollectCancelled
+	}
+	if len(failed) != 0 {
+		return errdef.ErrClusterNodeFailed
+	}
+	return nil
+}
+
+func (h *ClusterHandler) printf(format string, a ...interface{}) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	fmt.Printf(format, a...)
+}
+
+// collectContext returns the context of the collection, which is cancelled on SIGINT and SIGTERM if CancelOnSignal is set.
+func (h *ClusterHandler) collectContext() (context.Context, context.CancelFunc) {
+	if !h.CancelOnSignal {
+		return context.WithCancel(context.Background())
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// restore the default behavior, so that the second signal terminates the process at once
+		stop()
+	}()
+	return ctx, stop
+}
@@ -1,25 +1,47 @@
 package ytcctlhandler
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"ytc/commons/std"
 	"ytc/defs/bashdef"
 	"ytc/defs/collecttypedef"
+	"ytc/defs/confdef"
 	"ytc/defs/errdef"
 	ytccollect "ytc/internal/modules/ytc/collect"
 	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/data"
+	"ytc/internal/modules/ytc/collect/redact"
+	"ytc/internal/modules/ytc/collect/resultgenner"
 	"ytc/log"
+	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/terminalutil/barutil"
 
+	"git.yasdb.com/go/yasutil/fs"
 	"git.yasdb.com/go/yasutil/tabler"
 )
 
+const (
+	// the stopped item is waited for at most the duration, since the item which does not stop with its context,
+	// such as the one calculating the workload by gopsutil, should not block the collection
+	_item_stop_timeout = 30 * time.Second
+	// the stopped items which are still running are waited for at most the duration before packing, so that they do
+	// not write into the package dir while it is archived
+	_stopped_items_wait_timeout  = time.Minute
+	_stopped_items_poll_interval = 100 * time.Millisecond
+)
+
 var (
 	_module_order = []string{
 		collecttypedef.TYPE_BASE,
@@ -30,6 +52,11 @@ var (
 )
 
 func (c *CollecterHandler) Collect(yasdbValidate error) error {
+	if err := c.loadSigningKey(); err != nil {
+		log.Handler.Errorf(err.Error())
+		fmt.Printf("%s\n", bashdef.WithRed(err.Error()))
+		return err
+	}
 	noAccessMap, err := c.checkAccess(yasdbValidate)
 	if err != nil {
 		log.Handler.Errorf(err.Error())
@@ -40,28 +67,46 @@ func (c *CollecterHandler) Collect(yasdbValidate error) error {
 		log.Handler.Errorf(err.Error())
 		return err
 	}
-	if err := c.printCollectItem(moduleItems); err != nil {
+	if c.isResumed() && maxCol(mapValues(moduleItems)) <= 0 {
+		fmt.Printf("%s\n\n", bashdef.WithBlue("All the items have been collected"))
+		return c.collect(moduleItems)
+	}
+	sizes := c.estimate(moduleItems)
+	c.skipNotApplicable(moduleItems, sizes)
+	c.checkPackageSize(moduleItems, sizes)
+	if err := c.checkDiskSpace(moduleItems, sizes); err != nil {
 		log.Handler.Errorf(err.Error())
 		return err
 	}
+	if err := c.printCollectItem(moduleItems, sizes); err != nil {
+		log.Handler.Errorf(err.Error())
+		return err
+	}
+	fmt.Printf("\nEstimated disk usage: %s, free space of %s: %s\n", formatSize(c.requiredSpace(sizes)), c.CollectResult.CollectParam.Output, c.freeSpaceStr())
 	fmt.Printf("\nStarting collect...\n\n")
 	return c.collect(moduleItems)
 }
 
 func (c *CollecterHandler) checkAccess(yasdbValidateErr error) (map[string][]ytccollectcommons.NoAccessRes, error) {
 	m := make(map[string][]ytccollectcommons.NoAccessRes)
-	for _, c := range c.Collecters {
-		noAccessList := c.CheckAccess(yasdbValidateErr)
+	for _, collecter := range c.Collecters {
+		noAccessList := c.filterCompleted(collecter.Type(), collecter.CheckAccess(yasdbValidateErr))
 		if len(noAccessList) != 0 {
-			m[c.Type()] = noAccessList
+			m[collecter.Type()] = noAccessList
 		}
 	}
 	if len(m) == 0 {
 		return m, nil
 	}
+	if c.AccessPolicy == ACCESS_POLICY_SKIP {
+		skipForceCollect(m)
+	}
 	if err := c.printNoAccessItem(m); err != nil {
 		return m, err
 	}
+	if err := c.confirmContinue(); err != nil {
+		return m, err
+	}
 	return m, nil
 }
 
@@ -97,6 +142,18 @@ func (c *CollecterHandler) printNoAccessItem(m map[string][]ytccollectcommons.No
 		}
 	}
 	table.Print()
+	return nil
+}
+
+func (c *CollecterHandler) confirmContinue() error {
+	switch c.AccessPolicy {
+	case ACCESS_POLICY_YES, ACCESS_POLICY_SKIP:
+		fmt.Printf("\nContinue collect with access policy: %s\n", c.AccessPolicy)
+		return nil
+	case ACCESS_POLICY_FAIL:
+		fmt.Printf("\n%s\n", bashdef.WithRed(errdef.ErrInaccessibleItems.Error()))
+		return errdef.ErrInaccessibleItems
+	}
 	var isConfirm string
 	fmt.Printf("\nAre you want continue collect [y/n] ?\n")
 	fmt.Scanln(&isConfirm)
@@ -106,12 +163,12 @@ func (c *CollecterHandler) printNoAccessItem(m map[string][]ytccollectcommons.No
 
 	isConfirm = strings.ToLower(isConfirm)
 	if isConfirm != "y" {
-		return fmt.Errorf("some validations failed, not continue collect")
+		return errdef.ErrNotContinueCollect
 	}
 	return nil
 }
 
-func (c *CollecterHandler) printCollectItem(typeItem map[string][]string) error {
+func (c *CollecterHandler) printCollectItem(typeItem map[string][]string, sizes map[string]int64) error {
 	var (
 		itemTitle   []*tabler.RowTitle
 		moduleItems = make([][]string, 0)
@@ -138,6 +195,9 @@ func (c *CollecterHandler) printCollectItem(typeItem map[string][]string) error
 		for j, item := range moduleItems {
 			if i < len(item) {
 				row[j] = item[i]
+				if size := sizes[itemKey(moduleNames[j], item[i])]; size > 0 {
+					row[j] = fmt.Sprintf("%s (%s)", item[i], formatSize(size))
+				}
 				continue
 			}
 			row[j] = " "
@@ -159,16 +219,57 @@ func (c *CollecterHandler) getCollectItem(noAccessMap map[string][]ytccollectcom
 		if !ok {
 			noAccess = make([]ytccollectcommons.NoAccessRes, 0)
 		}
-		typeItem[t] = collect.ItemsToCollect(noAccess)
+		typeItem[t] = make([]string, 0)
+		for _, item := range collect.ItemsToCollect(noAccess) {
+			if c.isResumed() && c.state.IsCompleted(t, item) {
+				continue
+			}
+			typeItem[t] = append(typeItem[t], item)
+		}
 	}
 	return
 }
 
+// filterCompleted drops the completed items when resuming, there is no need to tip them.
+func (c *CollecterHandler) filterCompleted(module string, noAccess []ytccollectcommons.NoAccessRes) []ytccollectcommons.NoAccessRes {
+	if !c.isResumed() {
+		return noAccess
+	}
+	res := make([]ytccollectcommons.NoAccessRes, 0, len(noAccess))
+	for _, r := range noAccess {
+		if !c.state.IsCompleted(module, r.ModuleItem) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
 func (c *CollecterHandler) collect(moduleItems map[string][]string) error {
-	progress := barutil.NewProgress(barutil.WithWidth(100))
+	strategy := confdef.GetStrategyConf().Collect
+	concurrency := strategy.GetProcessNumberLimit()
+	if c.Streaming {
+		// the files in the package dir belong to the only running item when it is done
+		concurrency = 1
+	}
+	opts := []barutil.ProgressOpt{barutil.WithWidth(100), barutil.WithConcurrency(concurrency)}
+	if c.NoProgress {
+		opts = append(opts, barutil.WithOutput(io.Discard))
+	}
+	progress := barutil.NewProgress(opts...)
 	if e := c.PreCollect(); e != nil {
 		return e
 	}
+	if err := c.prepareStore(); err != nil {
+		return err
+	}
+	// the files which exist before collecting, such as the state and the files of the resumed collection, are
+	// attributed by the details of the items
+	if _, err := c.manifest.NewFiles(); err != nil {
+		log.Handler.Warnf("list files of %s err: %s", c.CollectResult.GetPackageDir(), err.Error())
+	}
+	ctx, stop := c.collectContext()
+	defer stop()
+	c.CollectResult.CollectParam.SetContext(ctx)
 	collMap := c.collecterMap()
 	moduleFuncs := make(map[string]map[string]func() error)
 	for module, items := range moduleItems {
@@ -177,15 +278,84 @@ func (c *CollecterHandler) collect(moduleItems map[string][]string) error {
 			log.Handler.Errorf("collect type: %s not exist", module)
 			continue
 		}
-		moduleFuncs[module] = collMap[module].CollectFunc(items)
+		funcs := collMap[module].CollectFunc(items)
+		res := collMap[module].CollectOK()
+		for item, fn := range funcs {
+			// the item is recorded into the state after the scheduler, so that the stopped one is not completed
+			funcs[item] = c.flushItem(item, c.attributeItem(item, c.recordItem(module, res, item, c.scheduleItem(ctx, res, module, item, strategy.GetItemTimeout(item), fn))))
+		}
+		moduleFuncs[module] = funcs
 	}
-	for _, module := range _module_order {
+	for _, module := range c.moduleOrder() {
 		if funcs, ok := moduleFuncs[module]; ok {
 			progress.AddBar(module, funcs)
 		}
 	}
 	progress.Start()
-	return c.CollectOK()
+	if ctx.Err() != nil {
+		fmt.Printf("%s\n\n", bashdef.WithYellow("The collection has been cancelled, packing the finished items..."))
+	}
+	if err := c.CollectOK(); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return errdef.ErrCollectCancelled
+	}
+	return nil
+}
+
+// collectContext returns the context of the collection, which is cancelled on SIGINT and SIGTERM if CancelOnSignal is set.
+func (c *CollecterHandler) collectContext() (context.Context, context.CancelFunc) {
+	if !c.CancelOnSignal {
+		return context.WithCancel(context.Background())
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// restore the default behavior, so that the second signal terminates the process at once
+		stop()
+	}()
+	return ctx, stop
+}
+
+// prepareStore creates the redactor if Redact is set, and opens the stream of the result package in streaming mode,
+// otherwise saves the state which is used to resume the collection. The streaming and multi-instance collections can
+// not be resumed.
+func (c *CollecterHandler) prepareStore() error {
+	if c.Redact {
+		redactor, err := redact.NewRedactor(c.CollectResult.GetPackageDir(), confdef.GetStrategyConf().Redact)
+		if err != nil {
+			log.Handler.Errorf("create redactor err: %s", err.Error())
+			return err
+		}
+		redactor.SetTokenPrefix(c.RedactTokenPrefix)
+		c.redactor = redactor
+		c.CollectResult.SetRedactor(redactor)
+	}
+	c.manifest = resultgenner.NewManifest(c.CollectResult.GetPackageDir())
+	c.CollectResult.SetManifest(c.manifest)
+	c.CollectResult.SetEncryptKey(c.EncryptKey)
+	if c.Streaming {
+		stream, err := fileutil.NewTarGzStream(c.CollectResult.GetPackageDir(), c.CollectResult.GetResultPath(), resultgenner.EncryptTarOpts(c.EncryptKey)...)
+		if err != nil {
+			log.Handler.Errorf("open result package err: %s", err.Error())
+			return err
+		}
+		c.stream = stream
+		c.CollectResult.SetStream(stream)
+		return nil
+	}
+	if c.CollectResult.CollectParam.IsMultiInstance() {
+		return nil
+	}
+	if !c.isResumed() {
+		c.state = data.NewCollectState(c.CollectResult.GetPackageDir(), c.Types, c.CollectResult.CollectParam)
+	}
+	if err := c.state.Save(); err != nil {
+		// the collection goes on, but it can not be resumed
+		log.Handler.Warnf("save collect state err: %s", err.Error())
+	}
+	return nil
 }
 
 func (c *CollecterHandler) PreCollect() error {
@@ -210,20 +380,242 @@ func (c *CollecterHandler) collecterMap() (res map[string]ytccollect.TypedCollec
 func (c *CollecterHandler) CollectOK() error {
 	c.CollectResult.CollectEndTime = time.Now()
 	for _, collecter := range c.Collecters {
-		c.CollectResult.Modules[collecter.Type()] = collecter.CollectOK()
+		c.CollectResult.SetModule(collecter.Type(), collecter.CollectOK())
+	}
+	if c.state != nil {
+		if err := c.state.Remove(); err != nil {
+			log.Handler.Warnf("remove collect state err: %s", err.Error())
+		}
 	}
+	c.waitStopped()
 	fmt.Printf("Packing collected results, please wait for a moment...\n\n")
+	c.CollectResult.SetSigningKey(c.SigningKey)
 	path, err := c.CollectResult.GenResult(c.CollectResult.CollectParam.Output, c.Types)
 	if err != nil {
+		if c.stream != nil {
+			// keep the files which have been archived
+			c.stream.Close()
+		}
 		err = fmt.Errorf("failed to gen result, err: %v", err)
 		log.Handler.Error(err)
 		fmt.Println(err.Error())
 		return err
 	}
-	fmt.Printf("The collection has been %s and the result was saved to %s, thanks for your use.\n", bashdef.WithGreen("completed"), bashdef.WithBlue(path))
+	c.ResultPath = path
+	status := bashdef.WithGreen("completed")
+	if c.CollectResult.CollectParam.Context().Err() != nil {
+		status = bashdef.WithYellow("cancelled")
+	}
+	fmt.Printf("The collection has been %s and the result was saved to %s, thanks for your use.\n", status, bashdef.WithBlue(path))
+	warnPackageSize(path)
+	if unpacked := c.CollectResult.UnpackedFiles; len(unpacked) != 0 {
+		fmt.Println(bashdef.WithYellow(fmt.Sprintf("%d files could not be read and were not packed, see the report and the log for details.", len(unpacked))))
+	}
+	if mapping := resultgenner.GenRedactionMappingPath(path); c.redactor != nil && fs.IsFileExist(mapping) {
+		fmt.Printf("The sensitive data were redacted and the mapping of the tokens was saved to %s, keep it private.\n", bashdef.WithBlue(mapping))
+	}
+	if signature := resultgenner.GenSignaturePath(path); c.SigningKey != nil {
+		if fs.IsFileExist(signature) {
+			fmt.Printf("The result was signed and the signature was saved to %s.\n", bashdef.WithBlue(signature))
+		} else {
+			fmt.Println(bashdef.WithYellow("The result could not be signed and was kept unsigned, see the log for details."))
+		}
+	}
 	return nil
 }
 
+// waitStopped waits for the stopped items which are still running to return within _stopped_items_wait_timeout,
+// the package is archived anyway after that, and the files of the items still running may be incomplete.
+func (c *CollecterHandler) waitStopped() {
+	n := c.stopped.Load()
+	if n == 0 {
+		return
+	}
+	log.Handler.Warnf("wait for %d stopped items to return before packing", n)
+	fmt.Printf("%s\n\n", bashdef.WithYellow(fmt.Sprintf("Waiting for %d stopped items to return before packing...", n)))
+	ticker := time.NewTicker(_stopped_items_poll_interval)
+	defer ticker.Stop()
+	timer := time.NewTimer(_stopped_items_wait_timeout)
+	defer timer.Stop()
+	for c.stopped.Load() != 0 {
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			n = c.stopped.Load()
+			log.Handler.Errorf("%d stopped items are still running %s before packing", n, _stopped_items_wait_timeout.String())
+			fmt.Printf("%s\n\n", bashdef.WithYellow(fmt.Sprintf("%d stopped items are still running, their files in the result may be incomplete.", n)))
+			return
+		}
+	}
+	log.Handler.Infof("all the stopped items returned before packing")
+}
+
+// scheduleItem returns the collect func of the item which is stopped if it does not return within timeout or ctx is done,
+// then the item is recorded as timed out or cancelled, and the result of fn is dropped. The item runs with its own
+// context in the param, so that its child processes and copies are stopped with it, and the slot of the item is not
+// released until fn returns or _item_stop_timeout passes.
+func (c *CollecterHandler) scheduleItem(ctx context.Context, res *datadef.YTCModule, module, item string, timeout time.Duration, fn func() error) func() error {
+	key := itemKey(module, item)
+	param := c.CollectResult.CollectParam
+	return func() error {
+		if ctx.Err() != nil {
+			// the item waiting for running is not started any more
+			return cancelItem(res, item, true)
+		}
+		itemCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		param.SetItemContext(key, itemCtx)
+		defer param.SetItemContext(key, nil)
+		done := make(chan error, 1)
+		go func() {
+			done <- fn()
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-itemCtx.Done():
+		}
+		returned := waitItem(done)
+		// the stopped item is recorded before fn may return late, the record with a status is kept by res.Set
+		var err error
+		if ctx.Err() != nil {
+			err = cancelItem(res, item, returned)
+		} else {
+			err = timeoutItem(res, item, timeout, returned)
+		}
+		if !returned {
+			log.Handler.Errorf("collect %s is still running %s after it was stopped", key, _item_stop_timeout.String())
+			c.stopped.Add(1)
+			go func() {
+				<-done
+				c.generation.Add(1)
+				c.stopped.Add(-1)
+				log.Handler.Infof("collect %s returned after it was stopped", key)
+			}()
+		}
+		return err
+	}
+}
+
+func timeoutItem(module *datadef.YTCModule, item string, timeout time.Duration, returned bool) error {
+	err := fmt.Errorf("collect %s timeout", item)
+	log.Handler.Errorf("%s after %s", err.Error(), timeout.String())
+	module.Set(&datadef.YTCItem{
+		Name:        item,
+		Error:       err.Error(),
+		Description: fmt.Sprintf(ytccollectcommons.ITEM_TIMEOUT_DESC, timeout.String()) + runningDesc(returned),
+		Status:      datadef.ITEM_STATUS_TIMEOUT,
+	})
+	return err
+}
+
+// waitItem waits for the stopped item to return within _item_stop_timeout, it reports whether the item has returned.
+func waitItem(done <-chan error) bool {
+	timer := time.NewTimer(_item_stop_timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func runningDesc(returned bool) string {
+	if returned {
+		return ""
+	}
+	return fmt.Sprintf(ytccollectcommons.ITEM_RUNNING_DESC, _item_stop_timeout.String())
+}
+
+// flushItem archives the files of the item in streaming mode once it is done, they are removed from the package dir.
+// Nothing is archived while any stopped item is still running, since it may be writing its files, they are archived
+// by the flush after it returns or when packing.
+func (c *CollecterHandler) flushItem(item string, fn func() error) func() error {
+	if c.stream == nil {
+		return fn
+	}
+	return func() error {
+		err := fn()
+		if n := c.stopped.Load(); n != 0 {
+			log.Handler.Warnf("defer archiving files of %s, %d stopped items are still running", item, n)
+			return err
+		}
+		if c.redactor != nil {
+			if e := c.redactor.Redact(); e != nil {
+				log.Handler.Warnf("redact files of %s err: %s", item, e.Error())
+			}
+		}
+		if e := c.manifest.Record(); e != nil {
+			log.Handler.Warnf("record files of %s into manifest err: %s", item, e.Error())
+		}
+		if e := c.stream.Flush(); e != nil {
+			log.Handler.Errorf("archive files of %s err: %s", item, e.Error())
+		}
+		return err
+	}
+}
+
+// attributeItem attributes the new files in the package dir to the item in the manifest once it is done. The files
+// are attributed only if no other item ran at the same time, which is always true in streaming mode, otherwise they
+// may be written by the others and are attributed by the details of the items when packing.
+func (c *CollecterHandler) attributeItem(item string, fn func() error) func() error {
+	return func() error {
+		generation := c.generation.Add(1)
+		c.running.Add(1)
+		err := fn()
+		c.attributeMtx.Lock()
+		defer c.attributeMtx.Unlock()
+		defer c.running.Add(-1)
+		files, e := c.manifest.NewFiles()
+		if e != nil {
+			log.Handler.Warnf("list files of %s err: %s", item, e.Error())
+		}
+		// the items which finished while this one was running have listed their files, the stopped items are checked
+		// before the generation, which is changed before a stopped item returns
+		if c.running.Load() == 1 && c.stopped.Load() == 0 && c.generation.Load() == generation {
+			c.manifest.AttributeFiles(files, item)
+		}
+		return err
+	}
+}
+
+// recordItem saves the item into the state after it is completed, so that it is skipped when resuming.
+// The item stopped by the scheduler is recorded as timed out or cancelled, it is not completed.
+func (c *CollecterHandler) recordItem(module string, res *datadef.YTCModule, item string, fn func() error) func() error {
+	if c.state == nil {
+		return fn
+	}
+	return func() error {
+		err := fn()
+		if completed, ok := res.Items()[item]; ok && len(completed.Status) == 0 {
+			if e := c.state.Complete(module, completed); e != nil {
+				log.Handler.Warnf("save collect state of %s err: %s", item, e.Error())
+			}
+		}
+		return err
+	}
+}
+
+func cancelItem(module *datadef.YTCModule, item string, returned bool) error {
+	err := fmt.Errorf("collect %s cancelled", item)
+	module.Set(&datadef.YTCItem{
+		Name:        item,
+		Error:       err.Error(),
+		Description: ytccollectcommons.ITEM_CANCELLED_DESC + runningDesc(returned),
+		Status:      datadef.ITEM_STATUS_CANCELLED,
+	})
+	return err
+}
+
+func skipForceCollect(m map[string][]ytccollectcommons.NoAccessRes) {
+	for _, noAccessList := range m {
+		for i := range noAccessList {
+			noAccessList[i].ForceCollect = false
+		}
+	}
+}
+
 func isCollectedStr(f bool) string {
 	flag := strconv.FormatBool(f)
 	if f {
@@ -232,6 +624,13 @@ func isCollectedStr(f bool) string {
 	return bashdef.WithRed(flag)
 }
 
+func mapValues(m map[string][]string) (values [][]string) {
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return
+}
+
 func maxCol(rows [][]string) int {
 	max := -1
 	for _, row := range rows {
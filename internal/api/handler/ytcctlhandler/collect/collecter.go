@@ -1,33 +1,128 @@
 package ytcctlhandler
 
 import (
+	"crypto/ed25519"
+	"sync"
+	"sync/atomic"
+
 	"ytc/defs/collecttypedef"
+	"ytc/defs/confdef"
 	ytccollect "ytc/internal/modules/ytc/collect"
 	"ytc/internal/modules/ytc/collect/data"
 	"ytc/internal/modules/ytc/collect/extra"
+	"ytc/internal/modules/ytc/collect/redact"
+	"ytc/internal/modules/ytc/collect/resultgenner"
+	"ytc/utils/cryptoutil"
+	"ytc/utils/fileutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+// AccessPolicy decides how to deal with the inaccessible collection items.
+type AccessPolicy string
+
+const (
+	ACCESS_POLICY_PROMPT AccessPolicy = "prompt" // ask the user whether to continue
+	ACCESS_POLICY_YES    AccessPolicy = "yes"    // continue, the items which can be force collected are still collected
+	ACCESS_POLICY_SKIP   AccessPolicy = "skip"   // continue, all the inaccessible items are skipped
+	ACCESS_POLICY_FAIL   AccessPolicy = "fail"   // stop the collection
 )
 
 type CollecterHandler struct {
-	Collecters    []ytccollect.TypedCollecter
-	CollectResult *data.YTCReport
-	Types         map[string]struct{}
+	Collecters        []ytccollect.TypedCollecter
+	CollectResult     *data.YTCReport
+	Types             map[string]struct{}
+	AccessPolicy      AccessPolicy
+	NoProgress        bool                   // do not draw the progress bars, such as running in ytcd
+	CancelOnSignal    bool                   // cancel the collection on SIGINT and SIGTERM, the finished items are still packed
+	Streaming         bool                   // collect the items one by one and archive the files of each item once it is done
+	SpacePolicy       string                 // what to do when the free space of the output is not enough, see confdef.DISK_SPACE_POLICY_*
+	EncryptKey        *cryptoutil.EncryptKey // encrypt the result package with it while packing if it is set
+	SigningKey        ed25519.PrivateKey     // sign the result package with it if it is set, it is loaded from SigningKeyFile by Collect if it is nil
+	SigningKeyFile    string                 // the file of the signing key, it is signing_key of ytc.toml by default
+	Redact            bool                   // redact the text files of the result package with the rules of the strategy
+	RedactTokenPrefix string                 // namespace the redaction tokens with it if it is not empty, such as the node of the cluster collection
+	ResultPath        string                 // the path of the result package, it is set after the collection completed
+
+	state    *data.CollectState // it is saved as each item completes
+	resumed  bool
+	stream   *fileutil.TarGzStream
+	manifest *resultgenner.Manifest // the files are recorded in it before they are archived by the stream
+	redactor *redact.Redactor       // the files are redacted by it before they are recorded in the manifest
+	stopped  atomic.Int32           // the number of the stopped items which are still running, their files are not archived
+
+	// the new files are attributed to the item which is done only if it ran alone, see attributeItem
+	attributeMtx sync.Mutex
+	running      atomic.Int32
+	generation   atomic.Int64 // changed when an item starts or a stopped item returns
 }
 
 func NewCollecterHandler(types map[string]struct{}, collectParam *collecttypedef.CollectParam) (*CollecterHandler, error) {
-	typedCollecter := make([]ytccollect.TypedCollecter, 0)
-	for t := range types {
-		c, err := ytccollect.NewTypedCollecter(t, collectParam)
+	typedCollecter, err := newTypedCollecters(types, collectParam)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range collectParam.Instances {
+		collecters, err := newInstanceCollecters(types, collectParam, instance)
 		if err != nil {
 			return nil, err
 		}
-		typedCollecter = append(typedCollecter, c)
+		typedCollecter = append(typedCollecter, collecters...)
 	}
 	if len(collectParam.Include) != 0 {
 		typedCollecter = append(typedCollecter, extra.NewExtraCollecter(collectParam))
 	}
 	return &CollecterHandler{
-		Collecters:    typedCollecter,
-		CollectResult: data.NewYTCReport(collectParam),
-		Types:         types,
+		Collecters:     typedCollecter,
+		CollectResult:  data.NewYTCReport(collectParam),
+		Types:          types,
+		AccessPolicy:   ACCESS_POLICY_PROMPT,
+		SpacePolicy:    confdef.GetStrategyConf().Collect.GetDiskSpacePolicy(),
+		SigningKeyFile: confdef.GetYTCConf().SigningKey,
 	}, nil
 }
+
+// loadSigningKey loads the signing key from SigningKeyFile if it is not given, so that the key is only read when
+// a package is going to be signed.
+func (c *CollecterHandler) loadSigningKey() error {
+	if c.SigningKey != nil || stringutil.IsEmpty(c.SigningKeyFile) {
+		return nil
+	}
+	key, err := cryptoutil.LoadSigningKey(c.SigningKeyFile)
+	if err != nil {
+		return yaserr.Wrapf(err, "load signing key %s", c.SigningKeyFile)
+	}
+	c.SigningKey = key
+	return nil
+}
+
+// NewEncryptKey returns the key to encrypt the result package, the public key file takes precedence over the passphrase.
+func NewEncryptKey(publicKeyFile, passphrase string) (*cryptoutil.EncryptKey, error) {
+	if !stringutil.IsEmpty(publicKeyFile) {
+		publicKey, err := cryptoutil.LoadPublicKey(publicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &cryptoutil.EncryptKey{PublicKey: publicKey}, nil
+	}
+	if stringutil.IsEmpty(passphrase) {
+		return nil, cryptoutil.ErrKeyNotGiven
+	}
+	return &cryptoutil.EncryptKey{Passphrase: passphrase}, nil
+}
+
+// Resume makes the handler finish the interrupted collection of the state, the completed items are not collected again.
+func (c *CollecterHandler) Resume(state *data.CollectState) {
+	c.state, c.resumed = state, true
+	for _, collecter := range c.Collecters {
+		res := collecter.CollectOK()
+		for _, item := range state.CompletedItems(collecter.Type()) {
+			res.Set(item)
+		}
+	}
+}
+
+func (c *CollecterHandler) isResumed() bool {
+	return c.resumed
+}
@@ -0,0 +1,117 @@
+package ytcctlhandler
+
+import (
+	"path"
+	"sync"
+
+	"ytc/defs/collecttypedef"
+	ytccollect "ytc/internal/modules/ytc/collect"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/yasdb"
+)
+
+// instanceCollecter collects the DB-level items of one of the instances in the multi-instance collection, its type is
+// keyed by the instance such as 'diag@db1', and its files are collected into the dir of the instance.
+type instanceCollecter struct {
+	ytccollect.TypedCollecter
+	param     *collecttypedef.CollectParam
+	validator *instanceValidator
+}
+
+// instanceValidator checks the yasdb user and password on the instance once, it is shared by the collecters of the instance.
+type instanceValidator struct {
+	env  *yasdb.YasdbEnv
+	once sync.Once
+	err  error
+}
+
+func newInstanceCollecters(types map[string]struct{}, collectParam *collecttypedef.CollectParam, instance collecttypedef.Instance) ([]ytccollect.TypedCollecter, error) {
+	param := collectParam.ForInstance(instance)
+	validator := &instanceValidator{
+		env: &yasdb.YasdbEnv{
+			YasdbHome:     param.YasdbHome,
+			YasdbData:     param.YasdbData,
+			YasdbUser:     param.YasdbUser,
+			YasdbPassword: param.YasdbPassword,
+		},
+	}
+	collecters, err := newTypedCollecters(types, param)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ytccollect.TypedCollecter, 0, len(collecters))
+	for _, c := range collecters {
+		res = append(res, &instanceCollecter{TypedCollecter: c, param: param, validator: validator})
+	}
+	return res, nil
+}
+
+// newTypedCollecters returns the collecters of the types. In the multi-instance collection, the collecters without any
+// item are dropped, such as perf of the host.
+func newTypedCollecters(types map[string]struct{}, collectParam *collecttypedef.CollectParam) ([]ytccollect.TypedCollecter, error) {
+	res := make([]ytccollect.TypedCollecter, 0)
+	for t := range types {
+		c, err := ytccollect.NewTypedCollecter(t, collectParam)
+		if err != nil {
+			return nil, err
+		}
+		if (collectParam.IsMultiInstance() || !collectParam.HasHostItems()) && len(c.ItemsToCollect(nil)) == 0 {
+			continue
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// [Interface Func]
+func (i *instanceCollecter) Type() string {
+	return collecttypedef.GenInstanceKey(i.TypedCollecter.Type(), i.param.Instance)
+}
+
+// [Interface Func]
+func (i *instanceCollecter) CheckAccess(yasdbValidate error) []ytccollectcommons.NoAccessRes {
+	// the yasdb user and password are checked on the instance instead
+	return i.TypedCollecter.CheckAccess(i.validator.validate())
+}
+
+// [Interface Func]
+func (i *instanceCollecter) PreCollect(packageDir string) error {
+	return i.TypedCollecter.PreCollect(path.Join(packageDir, i.param.GetInstanceDir()))
+}
+
+// [Interface Func]
+func (i *instanceCollecter) Plan(items []string) []ytccollectcommons.PlanItem {
+	plan := i.TypedCollecter.Plan(items)
+	for j := range plan {
+		plan[j].Module = i.Type()
+	}
+	return plan
+}
+
+func (v *instanceValidator) validate() error {
+	v.once.Do(func() {
+		v.err = v.env.ValidYasdbUserAndPwd()
+	})
+	return v.err
+}
+
+// moduleOrder returns the modules in the order of the progress bars and the plan, the host-level modules come first and
+// then the modules of each instance.
+func (c *CollecterHandler) moduleOrder() []string {
+	order := append([]string{}, _module_order...)
+	for _, instance := range c.CollectResult.CollectParam.Instances {
+		for _, module := range _module_order {
+			order = append(order, collecttypedef.GenInstanceKey(module, instance.Name))
+		}
+	}
+	return order
+}
+
+// itemKey returns the key of the item in the estimated sizes, the item of an instance is keyed by the instance such as
+// 'YashanDB-AlertLog@db1', since it is collected once per instance.
+func itemKey(module, item string) string {
+	if _, instance := collecttypedef.ParseInstanceKey(module); len(instance) != 0 {
+		return collecttypedef.GenInstanceKey(item, instance)
+	}
+	return item
+}
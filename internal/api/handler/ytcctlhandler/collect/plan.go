@@ -0,0 +1,157 @@
+package ytcctlhandler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/collecttypedef"
+	"ytc/defs/timedef"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/utils/jsonutil"
+
+	"git.yasdb.com/go/yasutil/tabler"
+)
+
+const (
+	PLAN_FORMAT_TABLE = "table"
+	PLAN_FORMAT_JSON  = "json"
+)
+
+// CollectPlan is what a collection would read, copy, query and execute, nothing is written to resolve it.
+type CollectPlan struct {
+	Start        string                       `json:"start"`
+	End          string                       `json:"end"`
+	YasdbHome    string                       `json:"yasdbHome"`
+	YasdbData    string                       `json:"yasdbData"`
+	YasdbUser    string                       `json:"yasdbUser"`
+	YasdbAddress string                       `json:"yasdbAddress,omitempty"` // the DB-level items are collected over TCP from it
+	Instances    []collecttypedef.Instance    `json:"instances,omitempty"`    // the instances of the multi-instance collection
+	Package      string                       `json:"package"`                // the result package which would be generated
+	AccessPolicy AccessPolicy                 `json:"accessPolicy"`
+	Items        []ytccollectcommons.PlanItem `json:"items"`
+}
+
+// Plan checks the access of the collection items and resolves their sources without collecting.
+func (c *CollecterHandler) Plan(yasdbValidate error) *CollectPlan {
+	param := c.CollectResult.CollectParam
+	plan := &CollectPlan{
+		Start:        param.StartTime.Format(timedef.TIME_FORMAT),
+		End:          param.EndTime.Format(timedef.TIME_FORMAT),
+		YasdbHome:    param.YasdbHome,
+		YasdbData:    param.YasdbData,
+		YasdbUser:    param.YasdbUser,
+		YasdbAddress: param.YasdbAddress,
+		Instances:    param.Instances,
+		Package:      c.CollectResult.GetPackageTarPath(),
+		AccessPolicy: c.AccessPolicy,
+		Items:        make([]ytccollectcommons.PlanItem, 0),
+	}
+	collMap := c.collecterMap()
+	for _, module := range c.moduleOrder() {
+		collecter, ok := collMap[module]
+		if !ok {
+			continue
+		}
+		noAccess := collecter.CheckAccess(yasdbValidate)
+		if c.AccessPolicy == ACCESS_POLICY_SKIP {
+			skipForceCollect(map[string][]ytccollectcommons.NoAccessRes{module: noAccess})
+		}
+		items := append(collecter.Plan(collecter.ItemsToCollect(noAccess)), planNotApplicable(param, collecter.Type())...)
+		plan.Items = append(plan.Items, genModulePlan(collecter.Type(), items, noAccess)...)
+	}
+	return plan
+}
+
+// genModulePlan fills the inaccessible description and tips of the items, and adds the items which are not collected.
+func genModulePlan(module string, items []ytccollectcommons.PlanItem, noAccess []ytccollectcommons.NoAccessRes) []ytccollectcommons.PlanItem {
+	noAccessMap := make(map[string]ytccollectcommons.NoAccessRes)
+	for _, res := range noAccess {
+		noAccessMap[res.ModuleItem] = res
+	}
+	collected := make(map[string]struct{})
+	for i := range items {
+		collected[items[i].Item] = struct{}{}
+		if res, ok := noAccessMap[items[i].Item]; ok {
+			items[i].Description, items[i].Tips = res.Description, res.Tips
+		}
+	}
+	for _, res := range noAccess {
+		if _, ok := collected[res.ModuleItem]; ok {
+			continue
+		}
+		items = append(items, ytccollectcommons.PlanItem{
+			Module:      module,
+			Item:        res.ModuleItem,
+			Description: res.Description,
+			Tips:        res.Tips,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Item < items[j].Item })
+	return items
+}
+
+func (p *CollectPlan) Print(format string) error {
+	if format == PLAN_FORMAT_JSON {
+		fmt.Println(jsonutil.ToJSONString(p))
+		return nil
+	}
+	fmt.Printf("%s\n\n", bashdef.WithBlue("The collection plan, nothing is collected"))
+	fmt.Printf("Time range:    %s ~ %s\n", p.Start, p.End)
+	if len(p.Instances) == 0 {
+		fmt.Printf("YASDB_HOME:    %s\n", p.YasdbHome)
+	}
+	if len(p.YasdbAddress) != 0 {
+		fmt.Printf("YASDB_ADDRESS: %s\n", p.YasdbAddress)
+	} else if len(p.Instances) == 0 {
+		fmt.Printf("YASDB_DATA:    %s\n", p.YasdbData)
+	}
+	for _, instance := range p.Instances {
+		fmt.Printf("Instance:      %s (YASDB_HOME: %s, YASDB_DATA: %s)\n", instance.Name, instance.YasdbHome, instance.YasdbData)
+	}
+	fmt.Printf("YASDB_USER:    %s\n", p.YasdbUser)
+	fmt.Printf("Package:       %s\n", p.Package)
+	fmt.Printf("Access policy: %s\n\n", p.AccessPolicy)
+	table := tabler.NewTable(
+		"",
+		tabler.NewRowTitle("TYPE", 15),
+		tabler.NewRowTitle("COLLECT_ITEM", 25),
+		tabler.NewRowTitle("COLLECTED?", 15),
+		tabler.NewRowTitle("SOURCE", 100),
+	)
+	var lastModule string
+	for _, item := range p.Items {
+		module := ""
+		if item.Module != lastModule {
+			module, lastModule = strings.ToUpper(collecttypedef.GetTypeFullName(item.Module)), item.Module
+		}
+		for i, source := range planSourceLines(item) {
+			name, collected := item.Item, isCollectedStr(item.Collected)
+			if i != 0 {
+				module, name, collected = "", "", ""
+			}
+			if err := table.AddColumn(module, name, collected, source); err != nil {
+				return err
+			}
+		}
+	}
+	table.Print()
+	return nil
+}
+
+func planSourceLines(item ytccollectcommons.PlanItem) (lines []string) {
+	if !item.Collected {
+		return []string{bashdef.WithRed(item.Description)}
+	}
+	for _, source := range item.Sources {
+		lines = append(lines, source.String())
+	}
+	if len(item.Error) != 0 {
+		lines = append(lines, bashdef.WithRed(fmt.Sprintf("error: %s", item.Error)))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "-")
+	}
+	return
+}
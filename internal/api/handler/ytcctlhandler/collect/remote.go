@@ -0,0 +1,59 @@
+package ytcctlhandler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/collecttypedef"
+	ytccollect "ytc/internal/modules/ytc/collect"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/utils/stringutil"
+)
+
+// notApplicableItems returns the items of the module which are selected but not applicable when collecting over TCP.
+func notApplicableItems(param *collecttypedef.CollectParam, module string) []string {
+	if !param.IsRemote() {
+		return nil
+	}
+	var res []string
+	for item, t := range ytccollect.ItemTypes() {
+		if t == module && ytccollectcommons.IsItemNotApplicable(param, item) {
+			res = append(res, item)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// skipNotApplicable records the items which are not applicable when collecting over TCP in the result as skipped,
+// so that the report tells why they are missing.
+func (c *CollecterHandler) skipNotApplicable(moduleItems map[string][]string, sizes map[string]int64) {
+	param := c.CollectResult.CollectParam
+	var skipped []string
+	for _, collecter := range c.Collecters {
+		skipped = append(skipped, notApplicableItems(param, collecter.Type())...)
+	}
+	if len(skipped) == 0 {
+		return
+	}
+	c.skipItems(moduleItems, sizes, skipped, func(item string) string {
+		return datadef.GenNotApplicableRemoteDesc(param.YasdbAddress)
+	})
+	fmt.Printf("%s\n\n", bashdef.WithYellow(fmt.Sprintf("Collecting over TCP from %s, skip the items which are not applicable: %s", param.YasdbAddress, strings.Join(skipped, stringutil.STR_COMMA))))
+}
+
+// planNotApplicable returns the plan of the items of the module which are not applicable when collecting over TCP.
+func planNotApplicable(param *collecttypedef.CollectParam, module string) []ytccollectcommons.PlanItem {
+	var res []ytccollectcommons.PlanItem
+	for _, item := range notApplicableItems(param, module) {
+		res = append(res, ytccollectcommons.PlanItem{
+			Module:      module,
+			Item:        item,
+			Description: fmt.Sprintf(ytccollectcommons.ITEM_NOT_APPLICABLE_DESC, param.YasdbAddress),
+		})
+	}
+	return res
+}
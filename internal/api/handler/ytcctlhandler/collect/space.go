@@ -0,0 +1,234 @@
+package ytcctlhandler
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/collecttypedef"
+	"ytc/defs/confdef"
+	"ytc/defs/errdef"
+	ytccollect "ytc/internal/modules/ytc/collect"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/log"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yasutil/size"
+)
+
+// the core dumps are skipped first when downgrading, they are usually the largest and the least necessary
+var _downgrade_first = map[string]struct{}{
+	datadef.DIAG_YASDB_COREDUMP: {},
+}
+
+// estimate returns the estimated size of each item to collect.
+func (c *CollecterHandler) estimate(moduleItems map[string][]string) map[string]int64 {
+	sizes := make(map[string]int64)
+	collMap := c.collecterMap()
+	for module, items := range moduleItems {
+		collecter, ok := collMap[module]
+		if !ok {
+			continue
+		}
+		for item, size := range collecter.Estimate(items) {
+			sizes[itemKey(module, item)] = size
+		}
+	}
+	return sizes
+}
+
+// requiredSpace returns the disk space needed by the items. The files are copied into the package dir and then packed,
+// in streaming mode only the largest item is staged besides the package. The package is encrypted while archiving,
+// so the encryption needs no more space.
+func (c *CollecterHandler) requiredSpace(sizes map[string]int64) int64 {
+	var max int64
+	total := totalSize(sizes)
+	for _, size := range sizes {
+		if size > max {
+			max = size
+		}
+	}
+	if c.Streaming {
+		return total + max
+	}
+	return total * 2
+}
+
+// checkDiskSpace compares the estimated size with the free space of the output, and deals with the shortage by the disk space policy.
+// The items skipped by downgrading are removed from moduleItems and recorded in the result.
+func (c *CollecterHandler) checkDiskSpace(moduleItems map[string][]string, sizes map[string]int64) error {
+	output := c.CollectResult.CollectParam.Output
+	free, err := ytccollectcommons.FreeSpace(output)
+	if err != nil {
+		// the collection goes on, the same as before the check
+		log.Handler.Warnf("get free space of %s err: %s", output, err.Error())
+		return nil
+	}
+	required := c.requiredSpace(sizes)
+	log.Handler.Infof("estimated disk usage: %d, free space of %s: %d", required, output, free)
+	if required <= free {
+		return nil
+	}
+	shortage := errdef.NewErrInsufficientDiskSpace(output, free, required)
+	switch c.SpacePolicy {
+	case confdef.DISK_SPACE_POLICY_IGNORE:
+		fmt.Printf("%s\n\n", bashdef.WithYellow(shortage.Error()))
+		return nil
+	case confdef.DISK_SPACE_POLICY_DOWNGRADE:
+		skipped, ok := c.downgrade(sizes, free)
+		if !ok {
+			break
+		}
+		c.skipItems(moduleItems, sizes, skipped, func(item string) string {
+			return fmt.Sprintf(ytccollectcommons.ITEM_NO_SPACE_DESC, formatSize(sizes[item]))
+		})
+		fmt.Printf("%s\n\n", bashdef.WithYellow(fmt.Sprintf("The free space of %s is not enough, skip: %s", output, strings.Join(skipped, stringutil.STR_COMMA))))
+		return nil
+	}
+	fmt.Printf("%s\n", bashdef.WithRed(shortage.Error()))
+	return shortage
+}
+
+// downgrade chooses the items to skip until the rest fit in the free space.
+func (c *CollecterHandler) downgrade(sizes map[string]int64, free int64) ([]string, bool) {
+	return chooseSkippedItems(sizes, func(rest map[string]int64) bool {
+		return c.requiredSpace(rest) <= free
+	})
+}
+
+// checkPackageSize skips the items in the same order as downgrading until the estimated size is within max_package_size of the strategy.
+// It is best effort, the estimate is uncompressed and the items without an estimate, such as AWR, are not counted.
+func (c *CollecterHandler) checkPackageSize(moduleItems map[string][]string, sizes map[string]int64) {
+	collectConf := confdef.GetStrategyConf().Collect
+	limit, err := collectConf.GetMaxPackageSize()
+	if err != nil {
+		log.Handler.Warnf("parse max_package_size %s err: %s", collectConf.MaxPackageSize, err.Error())
+		return
+	}
+	if limit <= 0 {
+		return
+	}
+	skipped, _ := chooseSkippedItems(sizes, func(rest map[string]int64) bool {
+		return totalSize(rest) <= limit
+	})
+	if len(skipped) == 0 {
+		return
+	}
+	c.skipItems(moduleItems, sizes, skipped, func(item string) string {
+		return datadef.GenExceedPackageSizeDesc(formatSize(sizes[item]), collectConf.MaxPackageSize)
+	})
+	fmt.Printf("%s\n\n", bashdef.WithYellow(fmt.Sprintf("The estimated size exceeds max_package_size: %s, skip: %s", collectConf.MaxPackageSize, strings.Join(skipped, stringutil.STR_COMMA))))
+}
+
+// chooseSkippedItems returns the items to skip, the core dumps first and then the largest ones, until the rest fit.
+func chooseSkippedItems(sizes map[string]int64, fits func(rest map[string]int64) bool) ([]string, bool) {
+	var candidates []string
+	for item, size := range sizes {
+		if size > 0 {
+			candidates = append(candidates, item)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		itemI, _ := collecttypedef.ParseInstanceKey(candidates[i])
+		itemJ, _ := collecttypedef.ParseInstanceKey(candidates[j])
+		_, firstI := _downgrade_first[itemI]
+		_, firstJ := _downgrade_first[itemJ]
+		if firstI != firstJ {
+			return firstI
+		}
+		if sizes[candidates[i]] != sizes[candidates[j]] {
+			return sizes[candidates[i]] > sizes[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	rest := make(map[string]int64)
+	for item, size := range sizes {
+		rest[item] = size
+	}
+	var skipped []string
+	for _, item := range candidates {
+		if fits(rest) {
+			break
+		}
+		delete(rest, item)
+		skipped = append(skipped, item)
+	}
+	return skipped, fits(rest)
+}
+
+// skipItems removes the skipped items from moduleItems and sizes, and records them in the result with the description.
+func (c *CollecterHandler) skipItems(moduleItems map[string][]string, sizes map[string]int64, skipped []string, desc func(item string) string) {
+	skippedMap := make(map[string]struct{})
+	for _, item := range skipped {
+		skippedMap[item] = struct{}{}
+	}
+	for module, items := range moduleItems {
+		rest := make([]string, 0, len(items))
+		for _, item := range items {
+			if _, ok := skippedMap[itemKey(module, item)]; !ok {
+				rest = append(rest, item)
+			}
+		}
+		moduleItems[module] = rest
+	}
+	collMap := c.collecterMap()
+	itemTypes := ytccollect.ItemTypes()
+	for _, key := range skipped {
+		item, instance := collecttypedef.ParseInstanceKey(key)
+		module := itemTypes[item]
+		if len(instance) != 0 {
+			module = collecttypedef.GenInstanceKey(module, instance)
+		}
+		collecter, ok := collMap[module]
+		if !ok {
+			continue
+		}
+		description := desc(key)
+		log.Handler.Warnf("skip %s, estimated size: %d, %s", key, sizes[key], description)
+		collecter.CollectOK().Set(&datadef.YTCItem{
+			Name:        item,
+			Error:       fmt.Sprintf("collect %s skipped", item),
+			Description: description,
+			Status:      datadef.ITEM_STATUS_SKIPPED,
+		})
+		delete(sizes, key)
+	}
+}
+
+func totalSize(sizes map[string]int64) (total int64) {
+	for _, size := range sizes {
+		total += size
+	}
+	return
+}
+
+func (c *CollecterHandler) freeSpaceStr() string {
+	free, err := ytccollectcommons.FreeSpace(c.CollectResult.CollectParam.Output)
+	if err != nil {
+		return "unknown"
+	}
+	return formatSize(free)
+}
+
+func formatSize(n int64) string {
+	return size.GenHumanReadableSize(float64(n), 2)
+}
+
+// warnPackageSize warns if the package exceeds max_package_size of the strategy, which is only checked against the
+// estimated size before collecting.
+func warnPackageSize(packagePath string) {
+	collectConf := confdef.GetStrategyConf().Collect
+	limit, err := collectConf.GetMaxPackageSize()
+	if err != nil || limit <= 0 {
+		return
+	}
+	info, err := os.Stat(packagePath)
+	if err != nil || info.Size() <= limit {
+		return
+	}
+	log.Handler.Warnf("the size %d of %s exceeds max_package_size: %s", info.Size(), packagePath, collectConf.MaxPackageSize)
+	fmt.Println(bashdef.WithYellow(fmt.Sprintf("The size of the result %s exceeds max_package_size: %s, which is only checked against the estimated size before collecting.", formatSize(info.Size()), collectConf.MaxPackageSize)))
+}
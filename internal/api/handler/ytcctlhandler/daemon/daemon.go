@@ -0,0 +1,226 @@
+package daemonhandler
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/runtimedef"
+	"ytc/defs/timedef"
+	"ytc/internal/modules/ytcd"
+	"ytc/log"
+	"ytc/utils/fileutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+const (
+	_start_timeout  = 5 * time.Second
+	_stop_timeout   = 30 * time.Second
+	_kill_timeout   = 5 * time.Second
+	_reload_timeout = 3 * time.Second
+	_check_interval = 100 * time.Millisecond
+
+	_out_tail_lines = 10
+)
+
+// DaemonHandler manages the lifecycle of ytcd by the pid file.
+type DaemonHandler struct {
+	Config string // the configuration file passed to ytcd
+}
+
+func NewDaemonHandler(config string) *DaemonHandler {
+	return &DaemonHandler{Config: config}
+}
+
+func (h *DaemonHandler) Start() error {
+	pid, err := ytcd.GetRunningPid()
+	if err != nil {
+		return yaserr.Wrapf(err, "read pid file %s", ytcd.GetPidFile())
+	}
+	if pid != 0 {
+		fmt.Printf("ytcd is already running, pid: %d\n", pid)
+		return nil
+	}
+	out, err := os.OpenFile(ytcd.GetOutFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, fileutil.DEFAULT_FILE_MODE)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	cmd := exec.Command(ytcd.GetBin(), "--config", h.Config)
+	cmd.Dir = runtimedef.GetYTCHome()
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// run in a new session, so ytcd will not be stopped when the terminal is closed
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		log.Handler.Errorf("start ytcd err: %s", err.Error())
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	ticker := time.NewTicker(_check_interval)
+	defer ticker.Stop()
+	timeout := time.After(_start_timeout)
+	for {
+		select {
+		case err := <-exited:
+			log.Handler.Errorf("ytcd exited unexpectedly: %v", err)
+			return fmt.Errorf("ytcd exited unexpectedly: %v, the last output in %s:\n%s", err, ytcd.GetOutFile(), tailOutFile())
+		case <-ticker.C:
+			if pid, _ := ytcd.GetRunningPid(); pid == cmd.Process.Pid {
+				fmt.Printf("ytcd has been %s, pid: %d\n", bashdef.WithGreen("started"), pid)
+				return nil
+			}
+		case <-timeout:
+			return fmt.Errorf("ytcd(pid: %d) has not written the pid file in %s, please check %s", cmd.Process.Pid, _start_timeout, ytcd.GetOutFile())
+		}
+	}
+}
+
+// Stop sends SIGTERM to ytcd and waits for it to exit, SIGKILL is sent if ytcd is still running and force is true.
+func (h *DaemonHandler) Stop(force bool) error {
+	pid, err := ytcd.GetRunningPid()
+	if err != nil {
+		return yaserr.Wrapf(err, "read pid file %s", ytcd.GetPidFile())
+	}
+	if pid == 0 {
+		fmt.Println("ytcd is not running")
+		// remove the pid file left by a killed ytcd
+		return ytcd.RemovePidFile()
+	}
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		return yaserr.Wrapf(err, "send SIGTERM to ytcd(pid: %d)", pid)
+	}
+	fmt.Printf("Stopping ytcd(pid: %d), a running collection will be finished first...\n", pid)
+	if waitExit(pid, _stop_timeout) {
+		fmt.Printf("ytcd has been %s\n", bashdef.WithGreen("stopped"))
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("ytcd(pid: %d) is still running after %s, try again later or use --force to kill it", pid, _stop_timeout)
+	}
+	log.Handler.Warnf("ytcd(pid: %d) is still running after %s, kill it", pid, _stop_timeout)
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+		return yaserr.Wrapf(err, "send SIGKILL to ytcd(pid: %d)", pid)
+	}
+	if !waitExit(pid, _kill_timeout) {
+		return fmt.Errorf("ytcd(pid: %d) is still running after SIGKILL", pid)
+	}
+	fmt.Printf("ytcd has been %s\n", bashdef.WithYellow("killed"))
+	return ytcd.RemovePidFile()
+}
+
+func (h *DaemonHandler) Restart(force bool) error {
+	if err := h.Stop(force); err != nil {
+		return err
+	}
+	return h.Start()
+}
+
+// Reload sends SIGHUP to ytcd, so that ytcd re-reads ytc.toml and strategy.toml.
+func (h *DaemonHandler) Reload() error {
+	pid, err := ytcd.GetRunningPid()
+	if err != nil {
+		return yaserr.Wrapf(err, "read pid file %s", ytcd.GetPidFile())
+	}
+	if pid == 0 {
+		return fmt.Errorf("ytcd is not running")
+	}
+	sent := time.Now()
+	if err := syscall.Kill(pid, syscall.SIGHUP); err != nil {
+		return yaserr.Wrapf(err, "send SIGHUP to ytcd(pid: %d)", pid)
+	}
+	deadline := sent.Add(_reload_timeout)
+	for time.Now().Before(deadline) {
+		if status, err := ytcd.LoadStatus(); err == nil && status.ReloadTime.After(sent) {
+			fmt.Printf("ytcd has been %s\n", bashdef.WithGreen("reloaded"))
+			return nil
+		}
+		time.Sleep(_check_interval)
+	}
+	fmt.Printf("The reload signal has been sent to ytcd(pid: %d), it will be reloaded after the running collection, please check %s\n",
+		pid, logFile())
+	return nil
+}
+
+func (h *DaemonHandler) Status() error {
+	pid, err := ytcd.GetRunningPid()
+	if err != nil {
+		return yaserr.Wrapf(err, "read pid file %s", ytcd.GetPidFile())
+	}
+	if pid == 0 {
+		fmt.Printf("ytcd is %s\n", bashdef.WithYellow("not running"))
+		return nil
+	}
+	status, err := ytcd.LoadStatus()
+	if err != nil || status.Pid != pid {
+		fmt.Printf("ytcd is %s, pid: %d, status is not available\n", bashdef.WithGreen("running"), pid)
+		return nil
+	}
+	now := time.Now()
+	printField("Status", bashdef.WithGreen("running"))
+	printField("Pid", fmt.Sprint(pid))
+	printField("Start Time", formatTime(status.StartTime))
+	printField("Uptime", now.Sub(status.StartTime).Round(time.Second).String())
+	if !status.ReloadTime.IsZero() {
+		printField("Reload Time", formatTime(status.ReloadTime))
+	}
+	if !stringutil.IsEmpty(status.Running) {
+		printField("Collecting", status.Running)
+	}
+	if next := status.NextJob(); next != nil {
+		printField("Next Job", fmt.Sprintf("%s(%s) at %s, in %s", next.Schedule, next.Type, formatTime(next.NextRun),
+			next.NextRun.Sub(now).Round(time.Second)))
+	} else {
+		printField("Next Job", "none")
+	}
+	if last := status.LastResult; last != nil {
+		if last.Succeeded() {
+			printField("Last Job", fmt.Sprintf("%s %s at %s, saved to %s", last.Schedule, bashdef.WithGreen("succeeded"), formatTime(last.EndTime), last.Package))
+		} else {
+			printField("Last Job", fmt.Sprintf("%s %s at %s: %s", last.Schedule, bashdef.WithRed("failed"), formatTime(last.EndTime), last.Error))
+		}
+	} else {
+		printField("Last Job", "none")
+	}
+	return nil
+}
+
+// waitExit reports whether ytcd exited in timeout.
+func waitExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if !ytcd.IsYtcdProcess(pid) {
+			return true
+		}
+		time.Sleep(_check_interval)
+	}
+	return !ytcd.IsYtcdProcess(pid)
+}
+
+func tailOutFile() string {
+	lines, err := fileutil.Tail(ytcd.GetOutFile(), _out_tail_lines)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(lines, stringutil.STR_NEWLINE)
+}
+
+func logFile() string {
+	return path.Join(runtimedef.GetLogPath(), ytcd.APP_NAME+".log")
+}
+
+func printField(key, value string) {
+	fmt.Printf("%-12s: %s\n", key, value)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(timedef.TIME_FORMAT)
+}
@@ -0,0 +1,61 @@
+package keygenhandler
+
+import (
+	"fmt"
+	"os"
+
+	"ytc/defs/bashdef"
+	"ytc/log"
+	"ytc/utils/cryptoutil"
+)
+
+const (
+	KEY_TYPE_ENCRYPT = "encrypt"
+	KEY_TYPE_SIGN    = "sign"
+)
+
+// KeygenHandler generates the key pair to encrypt or sign the collection results.
+type KeygenHandler struct {
+	Output string
+}
+
+func NewKeygenHandler(output string) *KeygenHandler {
+	return &KeygenHandler{Output: output}
+}
+
+func (h *KeygenHandler) Keygen() error {
+	publicKey, privateKey, err := cryptoutil.GenerateKey()
+	if err != nil {
+		log.Handler.Errorf("generate key err: %s", err.Error())
+		return err
+	}
+	publicKeyFile, err := cryptoutil.WriteKeyFiles(h.Output, publicKey, privateKey)
+	if err != nil {
+		log.Handler.Errorf("write key files err: %s", err.Error())
+		return err
+	}
+	fmt.Printf("The private key has been written to %s, keep it secret and use it to decrypt the collection results.\n", bashdef.WithBlue(h.Output))
+	fmt.Printf("The public key has been written to %s, set it as collect.encrypt_public_key or --encrypt-public-key on the hosts to collect.\n", bashdef.WithBlue(publicKeyFile))
+	return nil
+}
+
+// KeygenSign generates the ed25519 key pair to sign the collection results, the public key carries the host name.
+func (h *KeygenHandler) KeygenSign() error {
+	publicKey, privateKey, err := cryptoutil.GenerateSigningKey()
+	if err != nil {
+		log.Handler.Errorf("generate signing key err: %s", err.Error())
+		return err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	publicKeyFile, err := cryptoutil.WriteSigningKeyFiles(h.Output, publicKey, privateKey, host)
+	if err != nil {
+		log.Handler.Errorf("write signing key files err: %s", err.Error())
+		return err
+	}
+	fmt.Printf("The private key has been written to %s, keep it secret and set it as signing_key of ytc.toml.\n", bashdef.WithBlue(h.Output))
+	fmt.Printf("The public key %s has been written to %s, append it to the trusted keys of the verifiers.\n", cryptoutil.Fingerprint(publicKey), bashdef.WithBlue(publicKeyFile))
+	return nil
+}
@@ -0,0 +1,46 @@
+package reporthandler
+
+import (
+	"fmt"
+
+	"ytc/defs/bashdef"
+	"ytc/internal/modules/ytc/collect/data"
+	"ytc/internal/modules/ytc/collect/resultgenner/reporter"
+	"ytc/log"
+	"ytc/utils/cryptoutil"
+)
+
+type ReportHandler struct {
+	Input  string
+	Output string
+	Types  []reporter.ReportType
+	Key    *cryptoutil.DecryptKey // decrypt the encrypted input with it
+}
+
+func NewReportHandler(input, output string, types []reporter.ReportType) *ReportHandler {
+	return &ReportHandler{
+		Input:  input,
+		Output: output,
+		Types:  types,
+	}
+}
+
+// Report regenerates reports from an existing collection result with the current reporters.
+func (h *ReportHandler) Report() error {
+	fmt.Printf("Loading collection result from %s...\n", h.Input)
+	ytcReport, err := data.LoadYTCReport(h.Input, h.Key)
+	if err != nil {
+		log.Handler.Errorf("load collection result %s err: %s", h.Input, err.Error())
+		return err
+	}
+	paths, err := ytcReport.RegenReport(h.Output, h.Types...)
+	if err != nil {
+		log.Handler.Errorf("regenerate report err: %s", err.Error())
+		return err
+	}
+	fmt.Printf("The reports have been %s:\n", bashdef.WithGreen("generated"))
+	for _, p := range paths {
+		fmt.Printf("\t%s\n", bashdef.WithBlue(p))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package strategyhandler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/confdef"
+	"ytc/defs/errdef"
+	ytccollect "ytc/internal/modules/ytc/collect"
+	"ytc/internal/modules/ytcd"
+	"ytc/log"
+	"ytc/utils/fileutil"
+
+	"git.yasdb.com/go/yaserr"
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	_backup_suffix = ".bak"
+)
+
+type StrategyHandler struct {
+	Path string // the strategy file in use
+}
+
+func NewStrategyHandler(path string) *StrategyHandler {
+	return &StrategyHandler{Path: path}
+}
+
+// Show prints the effective strategy, in which the empty items are filled with the default values.
+func (h *StrategyHandler) Show() error {
+	fmt.Printf("# effective strategy of %s\n", h.Path)
+	return encodeStrategy(os.Stdout, confdef.GetStrategyConf().WithDefaults())
+}
+
+// Replace validates fname and replaces the strategy file with it, the current one is backed up.
+func (h *StrategyHandler) Replace(fname string) error {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	if err := validateStrategy(data); err != nil {
+		log.Handler.Errorf("validate strategy %s err: %s", fname, err.Error())
+		return errdef.NewErrInvalidStrategy(fname, err)
+	}
+	if err := h.write(data); err != nil {
+		return err
+	}
+	fmt.Printf("The strategy has been %s with %s\n", bashdef.WithGreen("replaced"), fname)
+	return nil
+}
+
+// validateStrategy validates the content of a strategy file, the collection items are checked too.
+func validateStrategy(data []byte) error {
+	conf, err := confdef.ParseStrategy(data)
+	if err != nil {
+		return err
+	}
+	if item, ok := ytccollect.UnknownItem(conf.Collect.GetItems()); ok {
+		return errdef.NewErrYtcFlag("collect.items", item, nil, ytccollect.ItemsHelp())
+	}
+	if item, ok := ytccollect.UnknownItem(conf.Collect.GetSkipItems()); ok {
+		return errdef.NewErrYtcFlag("collect.skip_items", item, nil, ytccollect.ItemsHelp())
+	}
+	timeouts, _ := conf.Collect.GetItemTimeouts()
+	for item := range timeouts {
+		if _, ok := ytccollect.UnknownItem([]string{item}); ok {
+			return errdef.NewErrYtcFlag("collect.item_timeouts", item, nil, ytccollect.ItemsHelp())
+		}
+	}
+	return nil
+}
+
+// Export writes the current strategy to output, the effective one is written if effective is true.
+func (h *StrategyHandler) Export(output string, effective, force bool) error {
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		output = path.Join(output, path.Base(h.Path))
+	}
+	if fileutil.IsExist(output) && !force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", output)
+	}
+	var (
+		data []byte
+		err  error
+	)
+	if effective {
+		var buf bytes.Buffer
+		err = encodeStrategy(&buf, confdef.GetStrategyConf().WithDefaults())
+		data = buf.Bytes()
+	} else {
+		data, err = os.ReadFile(h.Path)
+	}
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFile(output, data); err != nil {
+		return err
+	}
+	fmt.Printf("The strategy has been %s to %s\n", bashdef.WithGreen("exported"), output)
+	return nil
+}
+
+func encodeStrategy(w io.Writer, strategy confdef.Strategy) error {
+	encoder := toml.NewEncoder(w)
+	encoder.Indent = ""
+	return encoder.Encode(strategy)
+}
+
+// write backs up the current strategy file and replaces it with data atomically.
+func (h *StrategyHandler) write(data []byte) error {
+	info, err := os.Stat(h.Path)
+	if err != nil {
+		return err
+	}
+	current, err := os.ReadFile(h.Path)
+	if err != nil {
+		return err
+	}
+	backup := h.Path + _backup_suffix
+	if err := fileutil.WriteFileAtomic(backup, current, info.Mode().Perm()); err != nil {
+		return yaserr.Wrapf(err, "back up strategy to %s", backup)
+	}
+	if err := fileutil.WriteFileAtomic(h.Path, data, info.Mode().Perm()); err != nil {
+		return yaserr.Wrapf(err, "write strategy %s", h.Path)
+	}
+	log.Handler.Infof("strategy %s updated, the previous one was backed up to %s", h.Path, backup)
+	fmt.Printf("The previous strategy was backed up to %s\n", backup)
+	if pid, _ := ytcd.GetRunningPid(); pid != 0 {
+		fmt.Printf("ytcd is running, use 'ytcctl daemon reload' to apply the new strategy\n")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package strategyhandler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/confdef"
+	"ytc/defs/errdef"
+	"ytc/log"
+	"ytc/utils/stringutil"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	_toml_tag = "toml"
+)
+
+var (
+	_tomlKeyRegex = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*=`)
+)
+
+// KeyValue is an item to update, the key is in the format of '<table>.<key>', such as 'collect.range'.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Update sets the items of the strategy file, the comments and the other items are kept as they are.
+func (h *StrategyHandler) Update(kvs []KeyValue) error {
+	data, err := os.ReadFile(h.Path)
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		table, key, line, err := genTomlLine(kv)
+		if err != nil {
+			return errdef.NewErrInvalidStrategy(h.Path, err)
+		}
+		data = setTomlKey(data, table, key, line)
+	}
+	if err := validateStrategy(data); err != nil {
+		log.Handler.Errorf("validate updated strategy err: %s", err.Error())
+		return errdef.NewErrInvalidStrategy(h.Path, err)
+	}
+	if err := h.write(data); err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		fmt.Printf("%s = %s\n", kv.Key, kv.Value)
+	}
+	fmt.Printf("The strategy has been %s\n", bashdef.WithGreen("updated"))
+	return nil
+}
+
+// genTomlLine checks the key by the fields of confdef.Strategy and returns the toml line of the item.
+func genTomlLine(kv KeyValue) (table, key, line string, err error) {
+	fields := strings.Split(kv.Key, stringutil.STR_DOT)
+	if len(fields) != 2 {
+		err = fmt.Errorf("key %s should be in the format of '<table>.<key>', such as 'collect.range'", kv.Key)
+		return
+	}
+	table, key = fields[0], fields[1]
+	kind, ok := fieldKind(reflect.TypeOf(confdef.Strategy{}), table, key)
+	if !ok {
+		err = fmt.Errorf("unknown key: %s", kv.Key)
+		return
+	}
+	var value interface{}
+	switch kind {
+	case reflect.String:
+		value = kv.Value
+	case reflect.Int:
+		if value, err = strconv.Atoi(kv.Value); err != nil {
+			err = fmt.Errorf("the value of %s should be an integer", kv.Key)
+			return
+		}
+	case reflect.Bool:
+		if value, err = strconv.ParseBool(kv.Value); err != nil {
+			err = fmt.Errorf("the value of %s should be true or false", kv.Key)
+			return
+		}
+	default:
+		err = fmt.Errorf("key %s can not be updated, please use 'ytcctl strategy replace'", kv.Key)
+		return
+	}
+	var buf bytes.Buffer
+	if err = toml.NewEncoder(&buf).Encode(map[string]interface{}{key: value}); err != nil {
+		return
+	}
+	line = strings.TrimSpace(buf.String())
+	return
+}
+
+// fieldKind returns the kind of the field whose toml key is '<table>.<key>'.
+func fieldKind(t reflect.Type, table, key string) (reflect.Kind, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		tableField := t.Field(i)
+		if tableField.Tag.Get(_toml_tag) != table || tableField.Type.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < tableField.Type.NumField(); j++ {
+			field := tableField.Type.Field(j)
+			if field.Tag.Get(_toml_tag) == key {
+				return field.Type.Kind(), true
+			}
+		}
+	}
+	return reflect.Invalid, false
+}
+
+// setTomlKey replaces the line of the key in the table, or adds the line to the end of the table.
+func setTomlKey(data []byte, table, key, line string) []byte {
+	lines := strings.Split(string(data), stringutil.STR_NEWLINE)
+	var (
+		current  string
+		found    bool
+		insertAt = -1
+	)
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if strings.HasPrefix(trimmed, "[") {
+			current = tableName(trimmed)
+			if current == table {
+				found = true
+				insertAt = i + 1
+			}
+			continue
+		}
+		if current != table {
+			continue
+		}
+		if matches := _tomlKeyRegex.FindStringSubmatch(trimmed); len(matches) > 1 && matches[1] == key {
+			lines[i] = line
+			return []byte(strings.Join(lines, stringutil.STR_NEWLINE))
+		}
+		if !stringutil.IsEmpty(trimmed) && !strings.HasPrefix(trimmed, stringutil.STR_HASH) {
+			insertAt = i + 1
+		}
+	}
+	if !found {
+		content := strings.TrimRight(string(data), stringutil.STR_NEWLINE)
+		return []byte(fmt.Sprintf("%s\n\n[%s]\n%s\n", content, table, line))
+	}
+	lines = append(lines[:insertAt], append([]string{line}, lines[insertAt:]...)...)
+	return []byte(strings.Join(lines, stringutil.STR_NEWLINE))
+}
+
+// tableName returns the name of a table header such as '[collect] # comment'.
+func tableName(header string) string {
+	if i := strings.Index(header, stringutil.STR_HASH); i >= 0 {
+		header = header[:i]
+	}
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(header), "[]"))
+}
@@ -0,0 +1,56 @@
+package strategyhandler_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	strategyhandler "ytc/internal/api/handler/ytcctlhandler/strategy"
+	"ytc/log"
+)
+
+const _strategy_file = "../../../../../config/strategy.toml"
+
+func TestUpdate(t *testing.T) {
+	if err := log.InitLogger("ytcctl", log.NewLogOption(log.SetLogPath(t.TempDir()))); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(_strategy_file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fname := path.Join(t.TempDir(), "strategy.toml")
+	if err := os.WriteFile(fname, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	h := strategyhandler.NewStrategyHandler(fname)
+	if err := h.Update([]strategyhandler.KeyValue{
+		{Key: "collect.streaming", Value: "true"},
+		{Key: "collect.slow_sql_strip_literals", Value: "1"},
+		{Key: "collect.process_number_limit", Value: "2"},
+		{Key: "redact.enable", Value: "true"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"streaming = true", "slow_sql_strip_literals = true", "process_number_limit = 2", "[redact]\nenable = true"} {
+		if !strings.Contains(string(updated), line) {
+			t.Errorf("%q should be in the updated strategy", line)
+		}
+	}
+	if len(strings.Split(string(updated), "\n")) != len(strings.Split(string(data), "\n")) {
+		t.Errorf("the lines of the items should be replaced in place:\n%s", updated)
+	}
+	for _, kv := range []strategyhandler.KeyValue{
+		{Key: "collect.streaming", Value: "yes"},
+		{Key: "collect.process_number_limit", Value: "two"},
+	} {
+		if err := h.Update([]strategyhandler.KeyValue{kv}); err == nil || !strings.Contains(err.Error(), kv.Key) {
+			t.Errorf("%s = %s should be rejected, got %v", kv.Key, kv.Value, err)
+		}
+	}
+}
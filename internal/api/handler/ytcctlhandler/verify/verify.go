@@ -0,0 +1,132 @@
+package verifyhandler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/timedef"
+	"ytc/internal/modules/ytc/collect/resultgenner"
+	"ytc/log"
+	"ytc/utils/cryptoutil"
+	"ytc/utils/stringutil"
+)
+
+var (
+	ErrPackageNotMatch   = errors.New("the package does not match its manifest")
+	ErrSignatureNotValid = errors.New("the signature of the package is not valid")
+	ErrNoTrustedKeys     = errors.New("the package is signed but no trusted keys are given, give --trusted-keys or trusted_keys of ytc.toml")
+)
+
+// VerifyHandler checks a collection result package against the signature next to it and the manifest in it.
+type VerifyHandler struct {
+	Package          string
+	Key              *cryptoutil.DecryptKey // decrypt the encrypted package with it
+	TrustedKeys      string                 // the file of the public keys trusted to sign the package
+	RequireSignature bool                   // fail if the package is not signed
+}
+
+func NewVerifyHandler(pkg string, key *cryptoutil.DecryptKey) *VerifyHandler {
+	return &VerifyHandler{Package: pkg, Key: key}
+}
+
+func (h *VerifyHandler) Verify() error {
+	sig, err := h.verifySignature()
+	if err != nil {
+		return err
+	}
+	res, err := resultgenner.VerifyPackage(h.Package, h.Key)
+	if err == cryptoutil.ErrKeyRequired && sig != nil {
+		// the signed checksum of the package is enough to prove it is not altered
+		fmt.Printf("The files are not checked against the manifest since the package is encrypted, give --key or the passphrase to check them.\n")
+		return nil
+	}
+	if err != nil {
+		log.Handler.Errorf("verify %s err: %s", h.Package, err.Error())
+		return err
+	}
+	if sig != nil && sig.ManifestSHA256 != res.ManifestSHA256 {
+		log.Handler.Warnf("verify %s: the manifest %s does not match the signature %s", h.Package, res.ManifestSHA256, sig.ManifestSHA256)
+		fmt.Println(bashdef.WithRed("the manifest does not match the signature"))
+		return ErrSignatureNotValid
+	}
+	if len(res.Missing) != 0 {
+		fmt.Printf("Missing files:\n")
+		for _, p := range res.Missing {
+			fmt.Printf("  %s%s\n", p, h.itemSuffix(res, p))
+		}
+	}
+	if len(res.Extra) != 0 {
+		fmt.Printf("Extra files:\n")
+		for _, p := range res.Extra {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+	if len(res.Altered) != 0 {
+		fmt.Printf("Altered files:\n")
+		var altered []string
+		for p := range res.Altered {
+			altered = append(altered, p)
+		}
+		sort.Strings(altered)
+		for _, p := range altered {
+			fmt.Printf("  %s%s: %s\n", p, h.itemSuffix(res, p), strings.Join(res.Altered[p], ", "))
+		}
+	}
+	if !res.OK() {
+		log.Handler.Warnf("verify %s: %d missing, %d extra, %d altered", h.Package, len(res.Missing), len(res.Extra), len(res.Altered))
+		fmt.Printf("%s: %d verified, %d missing, %d extra, %d altered\n", bashdef.WithRed(ErrPackageNotMatch.Error()), res.Verified, len(res.Missing), len(res.Extra), len(res.Altered))
+		return ErrPackageNotMatch
+	}
+	fmt.Printf("%s has been %s, %d files match the manifest\n", res.Package, bashdef.WithGreen("verified"), res.Verified)
+	return nil
+}
+
+// verifySignature checks the signature next to the package with the trusted keys, nil is returned if the package
+// is not signed and the signature is not required.
+func (h *VerifyHandler) verifySignature() (*resultgenner.Signature, error) {
+	if _, err := os.Stat(resultgenner.GenSignaturePath(h.Package)); os.IsNotExist(err) {
+		if h.RequireSignature {
+			fmt.Printf("%s: %s\n", bashdef.WithRed(ErrSignatureNotValid.Error()), resultgenner.ErrSignatureNotFound)
+			return nil, ErrSignatureNotValid
+		}
+		fmt.Printf("%s, only the manifest is checked\n", bashdef.WithYellow("the package is not signed"))
+		return nil, nil
+	}
+	if stringutil.IsEmpty(h.TrustedKeys) {
+		return nil, ErrNoTrustedKeys
+	}
+	trusted, err := cryptoutil.LoadTrustedKeys(h.TrustedKeys)
+	if err != nil {
+		log.Handler.Errorf("load trusted keys err: %s", err.Error())
+		return nil, err
+	}
+	sig, key, err := resultgenner.VerifySignature(h.Package, trusted)
+	switch {
+	case err == nil:
+	case errors.Is(err, resultgenner.ErrUntrustedKey), errors.Is(err, resultgenner.ErrHostNotMatch),
+		err == resultgenner.ErrInvalidSignature, err == resultgenner.ErrSignatureNotMatch:
+		log.Handler.Warnf("verify signature of %s: %s", h.Package, err.Error())
+		fmt.Printf("%s: %s\n", bashdef.WithRed(ErrSignatureNotValid.Error()), err.Error())
+		return nil, ErrSignatureNotValid
+	default:
+		log.Handler.Errorf("verify signature of %s err: %s", h.Package, err.Error())
+		return nil, err
+	}
+	signer := key.Fingerprint
+	if !stringutil.IsEmpty(key.Host) {
+		signer = fmt.Sprintf("%s (%s)", key.Fingerprint, key.Host)
+	}
+	fmt.Printf("The signature is %s, signed by %s on host %s at %s\n", bashdef.WithGreen("valid"), signer, sig.Host, sig.SignedAt.Local().Format(timedef.TIME_FORMAT))
+	return sig, nil
+}
+
+func (h *VerifyHandler) itemSuffix(res *resultgenner.VerifyResult, p string) string {
+	if item := res.Items[p]; len(item) != 0 {
+		return fmt.Sprintf(" (%s)", item)
+	}
+	return ""
+}
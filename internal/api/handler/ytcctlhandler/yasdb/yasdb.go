@@ -0,0 +1,80 @@
+package yasdbhandler
+
+import (
+	"fmt"
+
+	"ytc/defs/bashdef"
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+)
+
+// ProfileHandler manages the yasdb profiles stored in Path.
+type ProfileHandler struct {
+	Path string
+}
+
+func NewProfileHandler(path string) *ProfileHandler {
+	return &ProfileHandler{Path: path}
+}
+
+// Show prints the profile of name, all profiles are printed if name is empty, the passwords are masked.
+func (h *ProfileHandler) Show(name string) error {
+	envs, err := yasdb.LoadProfiles(h.Path)
+	if err != nil {
+		return err
+	}
+	if len(name) != 0 {
+		env, ok := envs[name]
+		if !ok {
+			return errdef.NewErrProfileNotFound(name, h.Path)
+		}
+		printProfile(name, env)
+		return nil
+	}
+	if len(envs) == 0 {
+		fmt.Printf("No yasdb profile found, please set one by 'ytcctl yasdb set'\n")
+		return nil
+	}
+	for i, name := range yasdb.SortedProfileNames(envs) {
+		if i != 0 {
+			fmt.Println()
+		}
+		printProfile(name, envs[name])
+	}
+	return nil
+}
+
+// Load returns the profile of name, nil is returned if it has not been set.
+func (h *ProfileHandler) Load(name string) (*yasdb.YasdbEnv, error) {
+	envs, err := yasdb.LoadProfiles(h.Path)
+	if err != nil {
+		return nil, err
+	}
+	return envs[name], nil
+}
+
+// Set saves env as the profile of name, env should be validated by the caller.
+func (h *ProfileHandler) Set(name string, env *yasdb.YasdbEnv) error {
+	if err := yasdb.SaveProfile(h.Path, name, env); err != nil {
+		log.Handler.Errorf("save profile %s err: %s", name, err.Error())
+		return err
+	}
+	log.Handler.Infof("profile %s saved to %s, YASDB_HOME: %s, YASDB_DATA: %s, YASDB_USER: %s", name, h.Path, env.YasdbHome, env.YasdbData, env.YasdbUser)
+	printProfile(name, env)
+	fmt.Printf("The profile has been %s to %s\n", bashdef.WithGreen("saved"), h.Path)
+	return nil
+}
+
+func printProfile(name string, env *yasdb.YasdbEnv) {
+	printField("Profile", name)
+	printField(constdef.YASDB_HOME, env.YasdbHome)
+	printField(constdef.YASDB_DATA, env.YasdbData)
+	printField(constdef.YASDB_USER, env.YasdbUser)
+	printField(constdef.YASDB_PASSWORD, env.YasdbPassword.String())
+}
+
+func printField(key, value string) {
+	fmt.Printf("%-14s: %s\n", key, value)
+}
@@ -0,0 +1,173 @@
+package daemonhandler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"ytc/defs/confdef"
+	"ytc/defs/timedef"
+	"ytc/internal/modules/ytcd"
+	"ytc/log"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+// ReloadFunc re-reads the configurations and returns the new strategy.
+type ReloadFunc func() (confdef.Strategy, error)
+
+// Daemon runs the scheduled collections of strategy one by one.
+type Daemon struct {
+	output string
+	jobs   []*job
+	status *ytcd.Status
+	reload ReloadFunc
+}
+
+func NewDaemon(strategy confdef.Strategy, reload ReloadFunc) (*Daemon, error) {
+	d := &Daemon{
+		reload: reload,
+		status: &ytcd.Status{Pid: os.Getpid(), StartTime: time.Now()},
+	}
+	if err := d.setStrategy(strategy); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Run waits and runs the jobs until ctx is done, the running collection will not be interrupted.
+// The configurations are reloaded when receiving from reloadCh.
+func (d *Daemon) Run(ctx context.Context, reloadCh <-chan os.Signal) error {
+	pid, err := ytcd.GetRunningPid()
+	if err != nil {
+		return yaserr.Wrapf(err, "read pid file %s", ytcd.GetPidFile())
+	}
+	if pid != 0 && pid != os.Getpid() {
+		return fmt.Errorf("ytcd is already running, pid: %d", pid)
+	}
+	if err := ytcd.WritePidFile(os.Getpid()); err != nil {
+		return yaserr.Wrapf(err, "write pid file %s", ytcd.GetPidFile())
+	}
+	defer func() {
+		if err := ytcd.RemovePidFile(); err != nil {
+			log.Handler.Errorf("remove pid file err: %s", err.Error())
+		}
+	}()
+	d.writeStatus()
+	for {
+		// no job to wait for if timer is nil, and receiving from a nil channel blocks forever
+		var (
+			timer   *time.Timer
+			timerCh <-chan time.Time
+		)
+		j := d.nextJob()
+		if j != nil {
+			timer = time.NewTimer(time.Until(j.next))
+			timerCh = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-reloadCh:
+			d.reloadStrategy()
+		case <-timerCh:
+			d.runJob(j)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+func (d *Daemon) setStrategy(strategy confdef.Strategy) error {
+	if err := strategy.ValidateSchedules(); err != nil {
+		return yaserr.Wrapf(err, "validate schedules")
+	}
+	// keep the next run time of the unchanged schedules
+	nexts := make(map[confdef.Schedule]time.Time)
+	for _, j := range d.jobs {
+		nexts[j.schedule] = j.next
+	}
+	var jobs []*job
+	now := time.Now()
+	for _, schedule := range strategy.Schedules {
+		j, err := newJob(schedule, now)
+		if err != nil {
+			return yaserr.Wrapf(err, "schedule %s", schedule.Name)
+		}
+		if next, ok := nexts[schedule]; ok {
+			j.next = next
+		}
+		jobs = append(jobs, j)
+		log.Handler.Infof("schedule %s type: %s, next run at %s", schedule.Name, schedule.Type, j.next.Format(timedef.TIME_FORMAT))
+	}
+	if len(jobs) == 0 {
+		log.Handler.Warnf("no schedule found in strategy, nothing to do")
+	}
+	d.output = strategy.Collect.Output
+	d.jobs = jobs
+	return nil
+}
+
+func (d *Daemon) reloadStrategy() {
+	log.Handler.Infof("reloading configurations")
+	strategy, err := d.reload()
+	if err == nil {
+		err = d.setStrategy(strategy)
+	}
+	if err != nil {
+		log.Handler.Errorf("reload err, keep the current configurations: %s", err.Error())
+		return
+	}
+	d.status.ReloadTime = time.Now()
+	d.writeStatus()
+	log.Handler.Infof("configurations reloaded")
+}
+
+func (d *Daemon) runJob(j *job) {
+	log.Handler.Infof("schedule %s started", j.schedule.Name)
+	d.status.Running = j.schedule.Name
+	d.writeStatus()
+	res := j.run(d.output)
+	cost := res.EndTime.Sub(res.BeginTime).Round(time.Second)
+	if res.Succeeded() {
+		log.Handler.Infof("schedule %s succeeded in %s, the result was saved to %s", res.Schedule, cost, res.Package)
+	} else {
+		log.Handler.Errorf("schedule %s failed in %s: %s", res.Schedule, cost, res.Error)
+	}
+	next, err := j.schedule.NextRun(time.Now())
+	if err != nil {
+		// the schedule has been validated, it should never happen
+		log.Handler.Errorf("schedule %s get next run err: %s", j.schedule.Name, err.Error())
+		next = time.Now().Add(time.Hour)
+	}
+	j.next = next
+	log.Handler.Infof("schedule %s next run at %s", j.schedule.Name, j.next.Format(timedef.TIME_FORMAT))
+	d.status.Running = ""
+	d.status.LastResult = &res
+	d.writeStatus()
+}
+
+func (d *Daemon) nextJob() (next *job) {
+	for _, j := range d.jobs {
+		if next == nil || j.next.Before(next.next) {
+			next = j
+		}
+	}
+	return
+}
+
+func (d *Daemon) writeStatus() {
+	d.status.Jobs = make([]ytcd.JobStatus, 0, len(d.jobs))
+	for _, j := range d.jobs {
+		d.status.Jobs = append(d.status.Jobs, ytcd.JobStatus{
+			Schedule: j.schedule.Name,
+			Type:     j.schedule.Type,
+			NextRun:  j.next,
+		})
+	}
+	if err := ytcd.WriteStatus(d.status); err != nil {
+		log.Handler.Errorf("write status err: %s", err.Error())
+	}
+}
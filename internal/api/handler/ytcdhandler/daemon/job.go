@@ -0,0 +1,161 @@
+package daemonhandler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ytc/defs/collecttypedef"
+	"ytc/defs/confdef"
+	constdef "ytc/defs/constants"
+	ytcctlhandler "ytc/internal/api/handler/ytcctlhandler/collect"
+	ytccollect "ytc/internal/modules/ytc/collect"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/internal/modules/ytcd"
+	"ytc/log"
+	"ytc/utils/cryptoutil"
+	"ytc/utils/fileutil"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+type job struct {
+	schedule confdef.Schedule
+	next     time.Time
+}
+
+func newJob(schedule confdef.Schedule, now time.Time) (*job, error) {
+	next, err := schedule.NextRun(now)
+	if err != nil {
+		return nil, err
+	}
+	return &job{schedule: schedule, next: next}, nil
+}
+
+// run runs the collection of the schedule with the same pipeline as 'ytcctl collect', and never panics.
+func (j *job) run(output string) (res ytcd.JobResult) {
+	res = ytcd.JobResult{Schedule: j.schedule.Name, BeginTime: time.Now()}
+	defer func() {
+		if r := recover(); r != nil {
+			res.Error = fmt.Sprintf("panic: %v", r)
+		}
+		res.EndTime = time.Now()
+	}()
+	path, err := j.collect(output, res.BeginTime)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	res.Package = path
+	return
+}
+
+func (j *job) collect(output string, begin time.Time) (string, error) {
+	env, yasdbValidate, err := j.getYasdbEnv()
+	if err != nil {
+		return "", yaserr.Wrapf(err, "get yasdb env")
+	}
+	param, err := j.genCollectParam(env, output, begin)
+	if err != nil {
+		return "", err
+	}
+	handler, err := ytcctlhandler.NewCollecterHandler(j.schedule.GetTypes(), param)
+	if err != nil {
+		return "", err
+	}
+	handler.AccessPolicy = ytcctlhandler.AccessPolicy(j.schedule.GetAccessPolicy())
+	handler.NoProgress = true
+	handler.Streaming = confdef.GetStrategyConf().Collect.Streaming
+	handler.Redact = confdef.GetStrategyConf().Redact.Enable
+	if handler.EncryptKey, err = j.encryptKey(); err != nil {
+		return "", yaserr.Wrapf(err, "get encrypt key")
+	}
+	if err := handler.Collect(yasdbValidate); err != nil {
+		return "", err
+	}
+	return handler.ResultPath, nil
+}
+
+// encryptKey returns the key of collect.encrypt in the strategy, the passphrase is read from the environment variables of ytcd.
+func (j *job) encryptKey() (*cryptoutil.EncryptKey, error) {
+	strategy := confdef.GetStrategyConf().Collect
+	if !strategy.Encrypt {
+		return nil, nil
+	}
+	return ytcctlhandler.NewEncryptKey(strategy.GetEncryptPublicKey(), os.Getenv(constdef.YTC_ENCRYPT_PASSPHRASE))
+}
+
+// getYasdbEnv gets yasdb env from the environment variables of ytcd, the credentials file and the yasdb process in turn.
+func (j *job) getYasdbEnv() (env *yasdb.YasdbEnv, yasdbValidate error, err error) {
+	env = &yasdb.YasdbEnv{
+		YasdbHome:     strings.TrimSpace(os.Getenv(constdef.YASDB_HOME)),
+		YasdbData:     strings.TrimSpace(os.Getenv(constdef.YASDB_DATA)),
+		YasdbUser:     strings.TrimSpace(os.Getenv(constdef.YASDB_USER)),
+		YasdbPassword: pwdutil.NewSecret(strings.TrimSpace(os.Getenv(constdef.YASDB_PASSWORD))),
+	}
+	if !stringutil.IsEmpty(j.schedule.Credentials) {
+		if err = env.FillFromCredentials(j.schedule.Credentials); err != nil {
+			return
+		}
+	}
+	env.FillFromProcess()
+	if err = env.ValidYasdbHome(); err != nil {
+		return
+	}
+	if err = env.ValidYasdbData(); err != nil {
+		return
+	}
+	// yasdb internal data will be checked by the access policy
+	if yasdbValidate = env.ValidYasdbUserAndPwd(); yasdbValidate != nil {
+		log.Handler.Warnf("schedule %s validate yasdb err: %s", j.schedule.Name, yasdbValidate.Error())
+	}
+	return
+}
+
+func (j *job) genCollectParam(env *yasdb.YasdbEnv, output string, begin time.Time) (*collecttypedef.CollectParam, error) {
+	r, err := j.schedule.GetRange()
+	if err != nil {
+		return nil, err
+	}
+	owner, err := fileutil.GetOwner(env.YasdbHome)
+	if err != nil {
+		return nil, yaserr.Wrapf(err, "get os owner of yasdb home %s", env.YasdbHome)
+	}
+	strategy := confdef.GetStrategyConf()
+	items, skipItems := strategy.Collect.GetItems(), strategy.Collect.GetSkipItems()
+	if item, ok := ytccollect.UnknownItem(append(items, skipItems...)); ok {
+		return nil, fmt.Errorf("unknown collection item %s in the strategy", item)
+	}
+	// the same as 'ytcctl collect', one more minute to include the data of current minute
+	end := begin.Add(time.Minute)
+	return &collecttypedef.CollectParam{
+		StartTime:       begin.Add(-r),
+		EndTime:         end,
+		Output:          output,
+		YasdbHome:       env.YasdbHome,
+		YasdbData:       env.YasdbData,
+		YasdbUser:       env.YasdbUser,
+		YasdbPassword:   env.YasdbPassword,
+		Include:         splitPaths(j.schedule.Include),
+		Exclude:         splitPaths(j.schedule.Exclude),
+		Items:           items,
+		SkipItems:       skipItems,
+		BeginTime:       begin,
+		YasdbHomeOSUser: owner.Username,
+	}, nil
+}
+
+func splitPaths(value string) (res []string) {
+	value = strings.Trim(strings.TrimSpace(value), stringutil.STR_COMMA)
+	if stringutil.IsEmpty(value) {
+		return
+	}
+	for _, field := range strings.Split(value, stringutil.STR_COMMA) {
+		res = append(res, filepath.Clean(strings.TrimSpace(field)))
+	}
+	return
+}
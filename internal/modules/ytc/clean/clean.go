@@ -0,0 +1,240 @@
+package clean
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"ytc/defs/collecttypedef"
+	"ytc/defs/runtimedef"
+	"ytc/defs/timedef"
+	"ytc/internal/modules/ytc/collect/data"
+	"ytc/internal/modules/ytcd"
+	"ytc/utils/cryptoutil"
+	"ytc/utils/processutil"
+
+	"git.yasdb.com/go/yasutil/size"
+)
+
+const (
+	_ytcctl_name = "ytcctl"
+	_collect_cmd = "collect"
+	_tar_suffix  = ".tar.gz"
+)
+
+var (
+	// ytc-20060102150405.tar.gz or the encrypted ytc-20060102150405.tar.gz.enc, and its working dir ytc-20060102150405,
+	// the same for the cluster package ytc-cluster-20060102150405.tar.gz
+	_resultRegex     = regexp.MustCompile(fmt.Sprintf(`^(?:%s|%s)-(\d{14})%s(?:%s)?$`, collecttypedef.PACKAGE_NAME_PREFIX, collecttypedef.CLUSTER_PACKAGE_NAME_PREFIX, regexp.QuoteMeta(_tar_suffix), regexp.QuoteMeta(cryptoutil.ENCRYPTED_FILE_SUFFIX)))
+	_workingDirRegex = regexp.MustCompile(fmt.Sprintf(`^(?:%s|%s)-(\d{14})$`, collecttypedef.PACKAGE_NAME_PREFIX, collecttypedef.CLUSTER_PACKAGE_NAME_PREFIX))
+)
+
+// Result is a packed collection result in collect.output.
+type Result struct {
+	Path string
+	Time time.Time // the begin time of the collection, parsed from the file name
+	Size int64
+}
+
+// Retention limits the collection results, the zero values are not limited.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxCount int
+	MaxSize  int64
+	KeepNone bool // all the results are expired, such as 'ytcctl clean --all'
+}
+
+// StaleProcess is a child process of ytcctl or ytcd, such as yasql and sar, whose parent has exited.
+type StaleProcess struct {
+	Pid       int
+	Cmdline   string
+	ParentPid int
+}
+
+// ListResults returns the collection results in output, the newest one comes first.
+func ListResults(output string) ([]Result, error) {
+	entries, err := os.ReadDir(output)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var results []Result
+	for _, entry := range entries {
+		matches := _resultRegex.FindStringSubmatch(entry.Name())
+		if len(matches) != 2 || !entry.Type().IsRegular() {
+			continue
+		}
+		t, err := time.ParseInLocation(timedef.TIME_FORMAT_IN_FILE, matches[1], time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		results = append(results, Result{Path: path.Join(output, entry.Name()), Time: t, Size: info.Size()})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Time.After(results[j].Time) })
+	return results, nil
+}
+
+// Expired returns the results beyond the retention with the reasons, results should be sorted by ListResults.
+func (r Retention) Expired(results []Result, now time.Time) (expired []Result, reasons []string) {
+	var total int64
+	for i, result := range results {
+		total += result.Size
+		var reason string
+		switch {
+		case r.MaxAge > 0 && now.Sub(result.Time) > r.MaxAge:
+			reason = fmt.Sprintf("older than %s", formatAge(r.MaxAge))
+		case r.KeepNone:
+			reason = "all results are removed"
+		case r.MaxCount > 0 && i >= r.MaxCount:
+			reason = fmt.Sprintf("more than %d results", r.MaxCount)
+		case r.MaxSize > 0 && total > r.MaxSize:
+			reason = fmt.Sprintf("total size exceeds %s", size.GenHumanReadableSize(float64(r.MaxSize), 2))
+		default:
+			continue
+		}
+		expired = append(expired, result)
+		reasons = append(reasons, reason)
+	}
+	return
+}
+
+func formatAge(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return d.String()
+	}
+}
+
+// ListOrphanDirs returns the working dirs ytc-<timestamp> in output, which are left by the interrupted collections.
+// The temporary files of a collection, such as the AWR sql files, are in its working dir. The working dirs with the
+// state of the collection can be finished by 'ytcctl collect --resume', they are returned as resumable instead.
+// Nothing is returned if a collection is running, because its working dir can not be told apart.
+func ListOrphanDirs(output string) (orphans, resumable []string, err error) {
+	entries, err := os.ReadDir(output)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !_workingDirRegex.MatchString(entry.Name()) {
+			continue
+		}
+		dir := path.Join(output, entry.Name())
+		if _, e := os.Stat(path.Join(dir, data.STATE_FILE_NAME)); e == nil {
+			resumable = append(resumable, dir)
+			continue
+		}
+		orphans = append(orphans, dir)
+	}
+	if len(orphans) == 0 && len(resumable) == 0 {
+		return nil, nil, nil
+	}
+	running, err := IsCollecting()
+	if err != nil || running {
+		return nil, nil, err
+	}
+	sort.Strings(orphans)
+	sort.Strings(resumable)
+	return orphans, resumable, nil
+}
+
+// IsCollecting reports whether a collection of another ytcctl or ytcd is running.
+func IsCollecting() (bool, error) {
+	if status, err := ytcd.LoadStatus(); err == nil && len(status.Running) != 0 {
+		if pid, _ := ytcd.GetRunningPid(); pid == status.Pid {
+			return true, nil
+		}
+	}
+	pids, err := processutil.ListPids()
+	if err != nil {
+		return false, err
+	}
+	for _, pid := range pids {
+		if pid == os.Getpid() {
+			continue
+		}
+		items, err := processutil.NewProcess(pid).GetCmdlineItems()
+		if err != nil || len(items) < 2 || path.Base(items[0]) != _ytcctl_name {
+			continue
+		}
+		for _, item := range items[1:] {
+			if item == _collect_cmd {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ListStaleProcesses returns the processes marked by runtimedef.GetChildEnv, whose parent is no longer running.
+func ListStaleProcesses() ([]StaleProcess, error) {
+	pids, err := processutil.ListPids()
+	if err != nil {
+		return nil, err
+	}
+	var stales []StaleProcess
+	for _, pid := range pids {
+		p := processutil.NewProcess(pid)
+		value, err := p.GetEnv(runtimedef.ENV_YTC_PARENT_PID)
+		if err != nil || len(value) == 0 {
+			continue
+		}
+		parent, err := strconv.Atoi(value)
+		if err != nil || parent == os.Getpid() || isYtcProcess(parent) {
+			continue
+		}
+		cmdline, err := p.GetCmdlineItems()
+		if err != nil {
+			continue
+		}
+		stales = append(stales, StaleProcess{Pid: pid, Cmdline: strings.Join(cmdline, " "), ParentPid: parent})
+	}
+	return stales, nil
+}
+
+// Kill kills the stale process with SIGKILL after checking that the pid still belongs to it, since the process may
+// have exited and the pid may be reused after it was listed. ESRCH is returned if the pid does not belong to it.
+func (s StaleProcess) Kill() error {
+	p := processutil.NewProcess(s.Pid)
+	value, err := p.GetEnv(runtimedef.ENV_YTC_PARENT_PID)
+	if err != nil || value != strconv.Itoa(s.ParentPid) {
+		return syscall.ESRCH
+	}
+	cmdline, err := p.GetCmdlineItems()
+	if err != nil || strings.Join(cmdline, " ") != s.Cmdline {
+		return syscall.ESRCH
+	}
+	if isYtcProcess(s.ParentPid) {
+		return syscall.ESRCH
+	}
+	return syscall.Kill(s.Pid, syscall.SIGKILL)
+}
+
+// isYtcProcess reports whether pid is a running ytcctl or ytcd, the pid may be reused by another process.
+func isYtcProcess(pid int) bool {
+	items, err := processutil.NewProcess(pid).GetCmdlineItems()
+	if err != nil || len(items) == 0 {
+		return false
+	}
+	name := path.Base(items[0])
+	return name == _ytcctl_name || name == ytcd.APP_NAME
+}
@@ -0,0 +1,113 @@
+package clean_test
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"syscall"
+	"testing"
+	"time"
+
+	"ytc/defs/runtimedef"
+	"ytc/internal/modules/ytc/clean"
+)
+
+func TestRetentionExpired(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.Local)
+	files := map[string]int{
+		"ytc-20230110110000.tar.gz":     100,
+		"ytc-20230109110000.tar.gz":     100,
+		"ytc-20230108110000.tar.gz.enc": 100,
+		"ytc-20230101110000.tar.gz":     100,
+		"ytc-report-x.tar.gz":           100,
+	}
+	for name, size := range files {
+		if err := os.WriteFile(path.Join(dir, name), make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := clean.ListResults(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || path.Base(results[0].Path) != "ytc-20230110110000.tar.gz" {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	cases := []struct {
+		Name      string
+		Retention clean.Retention
+		Expect    int
+	}{
+		{Name: "unlimited", Retention: clean.Retention{}, Expect: 0},
+		{Name: "age", Retention: clean.Retention{MaxAge: 7 * 24 * time.Hour}, Expect: 1},
+		{Name: "count", Retention: clean.Retention{MaxCount: 2}, Expect: 2},
+		{Name: "none", Retention: clean.Retention{KeepNone: true}, Expect: 4},
+		{Name: "size", Retention: clean.Retention{MaxSize: 250}, Expect: 2},
+		{Name: "all", Retention: clean.Retention{MaxAge: time.Hour, MaxCount: 3, MaxSize: 1000}, Expect: 3},
+	}
+	for _, c := range cases {
+		expired, reasons := c.Retention.Expired(results, now)
+		if len(expired) != c.Expect || len(reasons) != c.Expect {
+			t.Errorf("%s: expect %d expired, got: %v, %v", c.Name, c.Expect, expired, reasons)
+		}
+	}
+}
+
+func TestListOrphanDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"ytc-20230110110000", "ytc-20230109110000", "ytc-cluster-20230108110000", "ytc-report"} {
+		if err := os.Mkdir(path.Join(dir, name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path.Join(dir, "ytc-20230109110000", "ytc-state.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	orphans, resumable, err := clean.ListOrphanDirs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 2 || path.Base(orphans[0]) != "ytc-20230110110000" || path.Base(orphans[1]) != "ytc-cluster-20230108110000" {
+		t.Errorf("unexpected orphan dirs: %v", orphans)
+	}
+	if len(resumable) != 1 || path.Base(resumable[0]) != "ytc-20230109110000" {
+		t.Errorf("the dir with the state should be resumable: %v", resumable)
+	}
+}
+
+func TestStaleProcessKill(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	cmd.Env = append(os.Environ(), runtimedef.ENV_YTC_PARENT_PID+"=1")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	defer cmd.Process.Kill()
+	// the pid listed before may be reused by another process
+	for _, p := range []clean.StaleProcess{
+		{Pid: cmd.Process.Pid, Cmdline: "yasql sys", ParentPid: 1},
+		{Pid: cmd.Process.Pid, Cmdline: "sleep 30", ParentPid: 2},
+	} {
+		if err := p.Kill(); err != syscall.ESRCH {
+			t.Errorf("the process not matching %+v should not be killed, got %v", p, err)
+		}
+	}
+	select {
+	case <-exited:
+		t.Fatal("the process should not be killed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := (clean.StaleProcess{Pid: cmd.Process.Pid, Cmdline: "sleep 30", ParentPid: 1}).Kill(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Error("the stale process should be killed")
+	}
+}
@@ -0,0 +1,247 @@
+package cluster_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	ytccluster "ytc/internal/modules/ytc/cluster"
+	"ytc/utils/pwdutil"
+	"ytc/utils/sshutil/sshtest"
+)
+
+// the fake ytcctl writes a result package into --output, and fails if the password is not given by stdin
+const _fake_ytcctl = `#!/bin/sh
+echo "$@"
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--help) exit 0 ;;
+	--output) output="$2"; shift ;;
+	esac
+	shift
+done
+[ "$YASDB_PASSWORD" = "yasdb_123" ] || { echo "wrong password" >&2; exit 3; }
+echo "package of $(hostname)" > "$output/ytc-20230101120000.tar.gz"
+echo '{"HOST_1": "db1"}' > "$output/ytc-20230101120000.redaction.json"
+mkdir "$output/ytc-20230101120000"
+`
+
+// the slow fake ytcctl runs until it is interrupted, the ssh server of the test does not forward the signals
+const _slow_fake_ytcctl = `#!/bin/sh
+trap 'echo interrupted; kill $! 2>/dev/null; echo partial > "$output/ytc-20230101120000.tar.gz"; exit 5' INT
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--help) exit 0 ;;
+	--output) output="$2"; shift ;;
+	esac
+	shift
+done
+touch "$output/started"
+sleep 30 &
+wait
+`
+
+// the old fake ytcctl rejects --redact-token-prefix as kong does for an unknown flag
+const _old_fake_ytcctl = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+	--version) echo "1.0.0"; exit 0 ;;
+	--redact-token-prefix) echo "ytcctl: error: unknown flag --redact-token-prefix" >&2; exit 2 ;;
+	esac
+done
+echo "$@"
+`
+
+func TestLoadInventory(t *testing.T) {
+	dir := t.TempDir()
+	fname := writeFile(t, dir, "hosts.toml", `
+user = "yashan"
+port = 2222
+yasdb_home = "/home/yashan/yasdb_home"
+
+[[nodes]]
+name = "primary"
+host = "192.168.1.2"
+yasdb_data = "/data/yasdb/db1"
+
+[[nodes]]
+host = "192.168.1.3"
+port = 22
+user = "root"
+`)
+	inventory, err := ytccluster.LoadInventory(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, standby := inventory.Nodes[0], inventory.Nodes[1]
+	if primary.Port != 2222 || primary.User != "yashan" || primary.YasdbHome != "/home/yashan/yasdb_home" || primary.WorkDir != ytccluster.DEFAULT_WORK_DIR {
+		t.Errorf("the node should be filled with the defaults: %+v", primary)
+	}
+	if standby.Name != "192.168.1.3" || standby.Port != 22 || standby.User != "root" || standby.YasdbData != "" {
+		t.Errorf("the defaults should be overridden by the node: %+v", standby)
+	}
+	for _, content := range []string{
+		``,
+		"[[nodes]]\nname = \"n1\"\n",
+		"[[nodes]]\nhost = \"h1\"\n[[nodes]]\nhost = \"h1\"\n",
+		"[[nodes]]\nhost = \"h1\"\nname = \"a/b\"\n",
+		"[[nodes]]\nhost = \"h1\"\nyasdb_data = \"data\"\n",
+		"work_dir = \"/\"\n[[nodes]]\nhost = \"h1\"\n",
+	} {
+		if _, err := ytccluster.LoadInventory(writeFile(t, dir, "invalid.toml", content)); err == nil {
+			t.Errorf("the inventory should be invalid:\n%s", content)
+		}
+	}
+}
+
+func TestCollectNode(t *testing.T) {
+	dir := t.TempDir()
+	server, err := sshtest.NewServer("ssh_123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	ytcHome := path.Join(dir, "ytc")
+	if err := os.MkdirAll(path.Join(ytcHome, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(ytcHome, "bin", "ytcctl"), []byte(_fake_ytcctl), 0755); err != nil {
+		t.Fatal(err)
+	}
+	slowYTCHome := path.Join(dir, "ytc-slow")
+	if err := os.MkdirAll(path.Join(slowYTCHome, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(slowYTCHome, "bin", "ytcctl"), []byte(_slow_fake_ytcctl), 0755); err != nil {
+		t.Fatal(err)
+	}
+	oldYTCHome := path.Join(dir, "ytc-old")
+	if err := os.MkdirAll(path.Join(oldYTCHome, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(oldYTCHome, "bin", "ytcctl"), []byte(_old_fake_ytcctl), 0755); err != nil {
+		t.Fatal(err)
+	}
+	knownHosts := writeFile(t, dir, "known_hosts", server.KnownHostsLine()+"\n")
+	inventory, err := ytccluster.LoadInventory(writeFile(t, dir, "hosts.toml", strings.Join([]string{
+		`port = ` + strconv.Itoa(server.Port()),
+		`known_hosts = "` + knownHosts + `"`,
+		`work_dir = "` + path.Join(dir, "work") + `"`,
+		`yasdb_user = "sys"`,
+		`[[nodes]]`,
+		`name = "primary"`,
+		`host = "` + server.Host() + `"`,
+		`ytc_home = "` + ytcHome + `"`,
+		`[[nodes]]`,
+		`name = "standby"`,
+		`host = "` + server.Host() + `"`,
+		`ytc_home = "` + path.Join(dir, "missing") + `"`,
+		`[[nodes]]`,
+		`name = "cancelled"`,
+		`host = "` + server.Host() + `"`,
+		`ytc_home = "` + slowYTCHome + `"`,
+		`[[nodes]]`,
+		`name = "old"`,
+		`host = "` + server.Host() + `"`,
+		`ytc_home = "` + oldYTCHome + `"`,
+	}, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("HOME", dir)
+	end := time.Date(2023, 1, 1, 12, 1, 0, 0, time.Local)
+	opts := &ytccluster.Options{
+		Name:          "ytc-cluster-20230101120100",
+		Start:         end.Add(-time.Hour),
+		End:           end,
+		Args:          []string{"--type", "base", "--yes"},
+		Redact:        true,
+		YasdbPassword: pwdutil.NewSecret("yasdb_123"),
+		SSHPassword:   pwdutil.NewSecret("ssh_123"),
+	}
+	clusterDir := path.Join(dir, "results", opts.Name)
+	primary := ytccluster.CollectNode(context.Background(), inventory.Nodes[0], opts, clusterDir)
+	if primary.Status != ytccluster.NODE_STATUS_SUCCESS || primary.ExitCode != 0 || len(primary.Files) != 1 {
+		t.Fatalf("unexpected result of primary: %+v", primary)
+	}
+	if f := primary.Files[0]; f.Path != "nodes/primary/ytc-20230101120000.tar.gz" || f.Size == 0 || len(f.SHA256) != 64 {
+		t.Errorf("unexpected file of primary: %+v", f)
+	}
+	if string(primary.RedactionMapping) != "{\"HOST_1\": \"db1\"}\n" {
+		t.Errorf("the redaction mapping should be kept out of the package, got %q", primary.RedactionMapping)
+	}
+	output, err := os.ReadFile(path.Join(clusterDir, primary.Log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "collect --start 2023-01-01-11-01 --end 2023-01-01-12-00") || !strings.Contains(string(output), "--yasdb-user sys --type base --yes") {
+		t.Errorf("unexpected args of ytcctl collect: %s", output)
+	}
+	if !strings.Contains(string(output), "--yes --redact --redact-token-prefix primary") {
+		t.Errorf("the redaction tokens should be namespaced by the node: %s", output)
+	}
+	if _, err := os.Stat(path.Join(dir, "work", opts.Name+"-primary")); !os.IsNotExist(err) {
+		t.Errorf("the work dir on the node should be removed, got %v", err)
+	}
+	standby := ytccluster.CollectNode(context.Background(), inventory.Nodes[1], opts, clusterDir)
+	if standby.Status != ytccluster.NODE_STATUS_FAILED || !strings.Contains(standby.Error, "missing/bin/ytcctl") {
+		t.Errorf("unexpected result of standby: %+v", standby)
+	}
+	old := ytccluster.CollectNode(context.Background(), inventory.Nodes[3], opts, clusterDir)
+	if old.Status != ytccluster.NODE_STATUS_FAILED || !strings.Contains(old.Error, "too old") || !strings.Contains(old.Error, "version 1.0.0") {
+		t.Errorf("the old ytcctl should be rejected before ytcctl collect: %+v", old)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelledWorkDir := path.Join(dir, "work", opts.Name+"-cancelled")
+	go func() {
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(path.Join(cancelledWorkDir, "results", "started")); err == nil {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		cancel()
+	}()
+	cancelled := ytccluster.CollectNode(ctx, inventory.Nodes[2], opts, clusterDir)
+	if cancelled.Status != ytccluster.NODE_STATUS_CANCELLED || cancelled.ExitCode != 5 || len(cancelled.Files) != 1 {
+		t.Errorf("the partial result of cancelled should be pulled: %+v", cancelled)
+	}
+	if output, err := os.ReadFile(path.Join(clusterDir, cancelled.Log)); err != nil || !strings.Contains(string(output), "interrupted") {
+		t.Errorf("ytcctl collect should be interrupted before the cleanup, got %q, err: %v", output, err)
+	}
+	if _, err := os.Stat(cancelledWorkDir); !os.IsNotExist(err) {
+		t.Errorf("the work dir on the node should be removed after ytcctl collect exits, got %v", err)
+	}
+
+	index := &ytccluster.Index{Name: opts.Name, Start: opts.Start, End: opts.End, Nodes: []*ytccluster.NodeResult{primary, standby, old, cancelled}}
+	if failed := index.Failed(); len(failed) != 2 || failed[0].Name != "standby" || failed[1].Name != "old" {
+		t.Errorf("unexpected failed nodes: %+v", failed)
+	}
+	if err := index.Write(clusterDir); err != nil {
+		t.Fatal(err)
+	}
+	packagePath, err := ytccluster.Pack(clusterDir, nil)
+	if err != nil || packagePath != clusterDir+".tar.gz" {
+		t.Fatalf("unexpected package %s, err: %v", packagePath, err)
+	}
+	if _, err := os.Stat(clusterDir); !os.IsNotExist(err) {
+		t.Errorf("the dir should be removed after packing, got %v", err)
+	}
+	mapping, err := ytccluster.WriteRedactionMapping(packagePath, index.Nodes)
+	if err != nil || mapping != path.Join(dir, "results", opts.Name+".redaction.json") {
+		t.Errorf("unexpected redaction mapping %s, err: %v", mapping, err)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	fname := path.Join(dir, name)
+	if err := os.WriteFile(fname, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return fname
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"ytc/defs/timedef"
+	"ytc/utils/fileutil"
+	"ytc/utils/jsonutil"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	INDEX_JSON_FILENAME = "ytc-cluster-index.json"
+	INDEX_TEXT_FILENAME = "ytc-cluster-index.txt"
+)
+
+// Index is the per-node index of the cluster package.
+type Index struct {
+	Name      string        `json:"name"`
+	Inventory string        `json:"inventory"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Nodes     []*NodeResult `json:"nodes"`
+}
+
+// Failed returns the nodes which failed.
+func (i *Index) Failed() []*NodeResult {
+	var res []*NodeResult
+	for _, n := range i.Nodes {
+		if n.Status == NODE_STATUS_FAILED {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// Write writes the index into dir as json for the tools and as a table for the readers.
+func (i *Index) Write(dir string) error {
+	if err := os.WriteFile(path.Join(dir, INDEX_JSON_FILENAME), []byte(jsonutil.ToJSONString(i)), fileutil.DEFAULT_FILE_MODE); err != nil {
+		return err
+	}
+	text := fmt.Sprintf("Cluster collection %s\nInventory: %s\nTime window: %s ~ %s\n\n%s\n",
+		i.Name, i.Inventory, i.Start.Format(timedef.TIME_FORMAT_UNTIL_MINITE), i.End.Format(timedef.TIME_FORMAT_UNTIL_MINITE), i.Table())
+	return os.WriteFile(path.Join(dir, INDEX_TEXT_FILENAME), []byte(text), fileutil.DEFAULT_FILE_MODE)
+}
+
+// Table renders the nodes as a table.
+func (i *Index) Table() string {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Node", "Host", "Status", "Exit Code", "Duration", "Files", "Error"})
+	for _, n := range i.Nodes {
+		var files []string
+		for _, f := range n.Files {
+			files = append(files, path.Base(f.Path))
+		}
+		t.AppendRow(table.Row{n.Name, n.Host, n.Status, n.ExitCode, n.FinishedAt.Sub(n.StartedAt).Round(time.Second), strings.Join(files, "\n"), n.Error})
+	}
+	return t.Render()
+}
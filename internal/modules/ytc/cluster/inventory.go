@@ -0,0 +1,170 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"ytc/defs/regexdef"
+	"ytc/utils/sshutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	DEFAULT_WORK_DIR = "/tmp/ytc-cluster"
+)
+
+var (
+	ErrNoNode = errors.New("no node is given in the inventory")
+)
+
+// NodeConf is how to reach a node and collect on it. The ones at the top of the inventory are the defaults of all
+// the nodes, and each node can override them.
+type NodeConf struct {
+	Port                  int    `toml:"port"`
+	User                  string `toml:"user"`
+	IdentityFile          string `toml:"identity_file"`
+	KnownHosts            string `toml:"known_hosts"`
+	InsecureIgnoreHostKey bool   `toml:"insecure_ignore_host_key"` // ignored if it is true in either place
+	WorkDir               string `toml:"work_dir"`                 // the dir to push ytc and write the result to on the node
+	YTCHome               string `toml:"ytc_home"`                 // the installed ytc on the node, the local one is pushed if it is empty
+	YasdbHome             string `toml:"yasdb_home"`
+	YasdbData             string `toml:"yasdb_data"`
+	YasdbUser             string `toml:"yasdb_user"`
+}
+
+type Node struct {
+	NodeConf
+	Name string `toml:"name"` // the dir of the node in the cluster package, it is the host if it is empty
+	Host string `toml:"host"`
+}
+
+// Inventory is the nodes to collect in the cluster collection, loaded from the toml file given by --hosts.
+type Inventory struct {
+	NodeConf
+	Nodes []*Node `toml:"nodes"`
+}
+
+// LoadInventory loads the inventory, the nodes are filled with the defaults and validated.
+func LoadInventory(fname string) (*Inventory, error) {
+	inventory := &Inventory{}
+	if _, err := toml.DecodeFile(fname, inventory); err != nil {
+		return nil, yaserr.Wrapf(err, "decode inventory %s", fname)
+	}
+	inventory.fill()
+	if err := inventory.validate(); err != nil {
+		return nil, yaserr.Wrapf(err, "validate inventory %s", fname)
+	}
+	return inventory, nil
+}
+
+func (i *Inventory) fill() {
+	for _, n := range i.Nodes {
+		n.fill(i.NodeConf)
+	}
+}
+
+func (i *Inventory) validate() error {
+	if len(i.Nodes) == 0 {
+		return ErrNoNode
+	}
+	names := make(map[string]struct{})
+	for j, n := range i.Nodes {
+		if stringutil.IsEmpty(n.Host) {
+			return fmt.Errorf("host of node %d is empty", j+1)
+		}
+		if !regexdef.NodeNameRegex.MatchString(n.Name) {
+			return fmt.Errorf("name of node %s is invalid, only letters, digits, '_', '.' and '-' are allowed", n.Name)
+		}
+		if _, ok := names[n.Name]; ok {
+			return fmt.Errorf("name of node %s is duplicated", n.Name)
+		}
+		names[n.Name] = struct{}{}
+		if err := n.validate(); err != nil {
+			return yaserr.Wrapf(err, "node %s", n.Name)
+		}
+	}
+	return nil
+}
+
+// fill replaces the zero values by the defaults.
+func (n *Node) fill(defaults NodeConf) {
+	if stringutil.IsEmpty(n.Name) {
+		n.Name = n.Host
+	}
+	if n.Port == 0 {
+		n.Port = defaults.Port
+	}
+	if n.Port == 0 {
+		n.Port = sshutil.DEFAULT_PORT
+	}
+	n.InsecureIgnoreHostKey = n.InsecureIgnoreHostKey || defaults.InsecureIgnoreHostKey
+	for _, f := range []struct {
+		value *string
+		def   string
+	}{
+		{&n.User, defaults.User},
+		{&n.IdentityFile, defaults.IdentityFile},
+		{&n.KnownHosts, defaults.KnownHosts},
+		{&n.WorkDir, defaults.WorkDir},
+		{&n.YTCHome, defaults.YTCHome},
+		{&n.YasdbHome, defaults.YasdbHome},
+		{&n.YasdbData, defaults.YasdbData},
+		{&n.YasdbUser, defaults.YasdbUser},
+	} {
+		if stringutil.IsEmpty(*f.value) {
+			*f.value = f.def
+		}
+	}
+	if stringutil.IsEmpty(n.WorkDir) {
+		n.WorkDir = DEFAULT_WORK_DIR
+	}
+}
+
+func (n *Node) validate() error {
+	if n.Port < 1 || n.Port > 65535 {
+		return fmt.Errorf("port %d is out of range", n.Port)
+	}
+	for _, f := range []string{n.IdentityFile, n.KnownHosts} {
+		if stringutil.IsEmpty(f) {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			return err
+		}
+	}
+	// the paths on the node
+	for _, p := range []struct {
+		key, value string
+	}{
+		{"work_dir", n.WorkDir},
+		{"ytc_home", n.YTCHome},
+		{"yasdb_home", n.YasdbHome},
+		{"yasdb_data", n.YasdbData},
+	} {
+		if !stringutil.IsEmpty(p.value) && !path.IsAbs(p.value) {
+			return fmt.Errorf("%s %s should be an absolute path", p.key, p.value)
+		}
+	}
+	if path.Clean(n.WorkDir) == "/" {
+		return fmt.Errorf("work_dir should not be /")
+	}
+	return nil
+}
+
+// SSHConfig returns the config to connect to the node.
+func (n *Node) SSHConfig(password string) sshutil.Config {
+	return sshutil.Config{
+		Host:                  n.Host,
+		Port:                  n.Port,
+		User:                  n.User,
+		IdentityFile:          n.IdentityFile,
+		Password:              password,
+		KnownHosts:            n.KnownHosts,
+		InsecureIgnoreHostKey: n.InsecureIgnoreHostKey,
+	}
+}
@@ -0,0 +1,473 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"ytc/defs/collecttypedef"
+	constdef "ytc/defs/constants"
+	"ytc/internal/modules/ytc/collect/redact"
+	"ytc/utils/fileutil"
+	"ytc/utils/pwdutil"
+	"ytc/utils/sshutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+	"git.yasdb.com/go/yasutil/fs"
+)
+
+const (
+	NODE_STATUS_SUCCESS   = "success"
+	NODE_STATUS_EMPTY     = "nothing_to_collect"
+	NODE_STATUS_FAILED    = "failed"
+	NODE_STATUS_CANCELLED = "cancelled"
+
+	NODES_DIR_NAME    = "nodes"
+	NODE_LOG_FILENAME = "ytcctl.log"
+
+	_remote_ytc_dir_name     = "ytc"
+	_remote_results_dir_name = "results"
+	_remote_pid_file_name    = "ytcctl.pid"       // the pid of ytcctl collect on the node, to stop it when cancelled
+	_remote_stop_file_name   = "ytcctl.stop"      // ytcctl collect is not started any more once it exists
+	_time_flag_format        = "2006-01-02-15-04" // yyyy-MM-dd-hh-mm of --start and --end
+
+	// ytcctl collect still packs the finished items after SIGINT, it is killed if it does not exit in time
+	_remote_stop_timeout = 2 * time.Minute
+	// the partial result of the stopped ytcctl collect is pulled within the duration after the collection is cancelled
+	_cancelled_pull_timeout = 10 * time.Minute
+)
+
+var (
+	// the parts of the local ytc home pushed to the nodes
+	_ytc_home_parts = []string{"bin/ytcctl", "config", "scripts", "static"}
+
+	// the machine names of uname -m of GOARCH, the others are compared with GOARCH as they are
+	_uname_machines = map[string][]string{
+		"amd64": {"x86_64", "amd64"},
+		"arm64": {"aarch64", "arm64"},
+		"386":   {"i386", "i686"},
+	}
+)
+
+// Options is how to collect on each node of the cluster.
+type Options struct {
+	Name          string    // the name of the cluster package, the work dirs on the nodes are named by it
+	Start         time.Time // the time window shared by the nodes
+	End           time.Time
+	Args          []string // the other flags of ytcctl collect given to all the nodes, such as --type and --yes
+	Redact        bool     // redact the result of each node, the tokens are namespaced by the node
+	Parallel      int      // the max number of the nodes collected at the same time, 0 means no limit
+	YasdbPassword pwdutil.Secret
+	SSHPassword   pwdutil.Secret
+	LocalYTCHome  string // pushed to the nodes without ytc_home
+	OnStep        func(node *Node, step string)
+}
+
+// PackageFile is a file pulled from the node, such as the result package and its signature.
+type PackageFile struct {
+	Path   string `json:"path"` // relative to the cluster package
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// NodeResult is the collection on a node, which is recorded in the index of the cluster package.
+type NodeResult struct {
+	Name       string        `json:"name"`
+	Host       string        `json:"host"`
+	Status     string        `json:"status"`
+	ExitCode   int           `json:"exitCode"` // the exit code of ytcctl collect on the node, -1 if it did not exit
+	Error      string        `json:"error,omitempty"`
+	Pushed     bool          `json:"pushed"` // whether ytc is pushed to the node
+	Files      []PackageFile `json:"files"`
+	Log        string        `json:"log,omitempty"` // the output of ytcctl collect, relative to the cluster package
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+
+	RedactionMapping json.RawMessage `json:"-"` // the mapping of the tokens if the result is redacted, it is not packed
+}
+
+// nodeCollecter collects on a node over ssh, the files of the node are pulled into dir.
+type nodeCollecter struct {
+	node   *Node
+	opts   *Options
+	dir    string
+	client *sshutil.Client
+	result *NodeResult
+}
+
+// CollectNode runs ytcctl collect on the node and pulls the result package into <clusterDir>/nodes/<name>. The
+// node is recorded as failed if any step fails, and the files pulled before are kept. It is recorded as cancelled if
+// ctx is done, the partial result packed by the stopped ytcctl collect is still pulled.
+func CollectNode(ctx context.Context, node *Node, opts *Options, clusterDir string) *NodeResult {
+	c := &nodeCollecter{
+		node: node,
+		opts: opts,
+		dir:  path.Join(clusterDir, NODES_DIR_NAME, node.Name),
+		result: &NodeResult{
+			Name:      node.Name,
+			Host:      node.Host,
+			ExitCode:  -1,
+			Files:     make([]PackageFile, 0),
+			StartedAt: time.Now(),
+		},
+	}
+	err := c.collect(ctx)
+	c.result.FinishedAt = time.Now()
+	switch {
+	case ctx.Err() != nil:
+		// the files pulled from the stopped ytcctl collect are kept
+		c.result.Status = NODE_STATUS_CANCELLED
+		if err != nil && err != ctx.Err() {
+			c.result.Error = err.Error()
+		}
+	case err != nil:
+		c.result.Status = NODE_STATUS_FAILED
+		c.result.Error = err.Error()
+	case c.result.ExitCode == constdef.EXIT_CODE_NOTHING_TO_COLLECT:
+		c.result.Status = NODE_STATUS_EMPTY
+	default:
+		c.result.Status = NODE_STATUS_SUCCESS
+	}
+	return c.result
+}
+
+func (c *nodeCollecter) collect(ctx context.Context) error {
+	if ctx.Err() != nil {
+		// the node waiting for its turn is not started any more
+		return ctx.Err()
+	}
+	if err := fs.Mkdir(c.dir); err != nil {
+		return err
+	}
+	c.step("connecting to %s", c.node.SSHConfig("").Addr())
+	client, err := sshutil.Dial(c.node.SSHConfig(c.opts.SSHPassword.Reveal()))
+	if err != nil {
+		return yaserr.Wrapf(err, "connect to %s", c.node.Host)
+	}
+	c.client = client
+	defer client.Close()
+	defer c.cleanup()
+	ytcctl, err := c.prepareYTC(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.runCollect(ctx, ytcctl); err != nil {
+		return err
+	}
+	pullCtx := ctx
+	if ctx.Err() != nil {
+		// the stopped ytcctl collect has packed the finished items, they are still pulled before the cleanup
+		var cancel context.CancelFunc
+		pullCtx, cancel = context.WithTimeout(context.Background(), _cancelled_pull_timeout)
+		defer cancel()
+	}
+	if err := c.pull(pullCtx); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	switch c.result.ExitCode {
+	case constdef.EXIT_CODE_SUCCESS, constdef.EXIT_CODE_NOTHING_TO_COLLECT:
+		return nil
+	}
+	return fmt.Errorf("ytcctl collect exited with %d, see %s for details", c.result.ExitCode, c.result.Log)
+}
+
+// workDir returns the dir on the node to push ytc and write the result to, it is removed after the collection. It is
+// named by the node as well, so that the nodes on the same host do not share it.
+func (c *nodeCollecter) workDir() string {
+	return path.Join(c.node.WorkDir, c.opts.Name+stringutil.STR_HYPHEN+c.node.Name)
+}
+
+func (c *nodeCollecter) resultsDir() string {
+	return path.Join(c.workDir(), _remote_results_dir_name)
+}
+
+// prepareYTC returns the path of ytcctl on the node, the local ytc is pushed to the work dir if ytc_home is not given.
+func (c *nodeCollecter) prepareYTC(ctx context.Context) (string, error) {
+	if !stringutil.IsEmpty(c.node.YTCHome) {
+		ytcctl := path.Join(c.node.YTCHome, "bin", "ytcctl")
+		_, err := c.client.Output(ctx, "test -x "+sshutil.Quote(ytcctl))
+		var exitErr *sshutil.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s is not found or not executable on the node", ytcctl)
+		}
+		if err != nil {
+			return "", yaserr.Wrapf(err, "check %s on the node", ytcctl)
+		}
+		if c.opts.Redact {
+			if err := c.checkRedact(ctx, ytcctl); err != nil {
+				return "", err
+			}
+		}
+		return ytcctl, nil
+	}
+	if err := c.checkPlatform(ctx); err != nil {
+		return "", err
+	}
+	home := path.Join(c.workDir(), _remote_ytc_dir_name)
+	c.step("pushing ytc to %s", home)
+	var parts []string
+	for _, p := range _ytc_home_parts {
+		if _, err := os.Stat(path.Join(c.opts.LocalYTCHome, p)); err == nil {
+			parts = append(parts, p)
+		}
+	}
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(fileutil.TarGzTo(w, c.opts.LocalYTCHome, parts))
+	}()
+	var stderr bytes.Buffer
+	err := c.client.Run(ctx, fmt.Sprintf("mkdir -p %[1]s && tar xzf - -C %[1]s", sshutil.Quote(home)), r, io.Discard, &stderr)
+	r.CloseWithError(err)
+	if err != nil {
+		return "", yaserr.Wrapf(err, "push ytc to %s: %s", home, strings.TrimSpace(stderr.String()))
+	}
+	c.result.Pushed = true
+	return path.Join(home, "bin", "ytcctl"), nil
+}
+
+// checkRedact checks that the installed ytcctl on the node accepts --redact-token-prefix, which is given by collectCmd
+// with --redact, the older ytcctl rejects it as an unknown flag before --help is handled.
+func (c *nodeCollecter) checkRedact(ctx context.Context, ytcctl string) error {
+	_, err := c.client.Output(ctx, fmt.Sprintf("%s collect --redact-token-prefix %s --help", sshutil.Quote(ytcctl), sshutil.Quote(c.node.Name)))
+	var exitErr *sshutil.ExitError
+	if !errors.As(err, &exitErr) {
+		if err != nil {
+			return yaserr.Wrapf(err, "check %s on the node", ytcctl)
+		}
+		return nil
+	}
+	version := "unknown"
+	if out, err := c.client.Output(ctx, sshutil.Quote(ytcctl)+" --version"); err == nil && len(strings.TrimSpace(string(out))) != 0 {
+		version = strings.TrimSpace(string(out))
+	}
+	return fmt.Errorf("ytc on the node is too old for --redact of the cluster collection, %s is of version %s and %s, update it or remove ytc_home from the inventory to push the local ytc",
+		ytcctl, version, exitErr.Error())
+}
+
+// checkPlatform checks that the local ytcctl, which is pushed to the node, is built for the OS and the machine of the node.
+func (c *nodeCollecter) checkPlatform(ctx context.Context) error {
+	out, err := c.client.Output(ctx, "uname -sm")
+	if err != nil {
+		return yaserr.Wrapf(err, "get the platform of the node")
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return fmt.Errorf("unexpected output of uname -sm on the node: %s", strings.TrimSpace(string(out)))
+	}
+	if !strings.EqualFold(fields[0], runtime.GOOS) || !isMachineOf(fields[1], runtime.GOARCH) {
+		return fmt.Errorf("the node is %s %s but the local ytcctl is built for %s/%s, install ytc of the platform on the node and give ytc_home in the inventory",
+			fields[0], fields[1], runtime.GOOS, runtime.GOARCH)
+	}
+	return nil
+}
+
+func isMachineOf(machine, arch string) bool {
+	names, ok := _uname_machines[arch]
+	if !ok {
+		return strings.EqualFold(machine, arch)
+	}
+	for _, name := range names {
+		if machine == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runCollect runs ytcctl collect on the node, its output is written to the log of the node. The yasdb password is
+// given by stdin, so that it is not visible in the process list of the node. When ctx is done, ytcctl collect is
+// interrupted by SIGINT and waited for, so that it packs the finished items and the work dir is not removed under it.
+func (c *nodeCollecter) runCollect(ctx context.Context, ytcctl string) error {
+	c.step("collecting from %s to %s", c.opts.Start.Format(_time_flag_format), c.opts.End.Format(_time_flag_format))
+	logFile, err := os.OpenFile(path.Join(c.dir, NODE_LOG_FILENAME), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fileutil.DEFAULT_FILE_MODE)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	c.result.Log = path.Join(NODES_DIR_NAME, c.node.Name, NODE_LOG_FILENAME)
+	stdin := strings.NewReader(c.opts.YasdbPassword.Reveal() + stringutil.STR_NEWLINE)
+	// the session is only closed after ytcctl collect is stopped
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			c.stopCollect()
+			cancelRun()
+		case <-done:
+		}
+	}()
+	err = c.client.Run(runCtx, c.collectCmd(ytcctl), stdin, logFile, logFile)
+	close(done)
+	<-stopped
+	var exitErr *sshutil.ExitError
+	if errors.As(err, &exitErr) {
+		c.result.ExitCode = exitErr.Status
+		return nil
+	}
+	if ctx.Err() != nil {
+		// the session is closed after ytcctl collect is stopped, whatever it has packed is pulled
+		return nil
+	}
+	if err != nil {
+		return yaserr.Wrapf(err, "run ytcctl collect")
+	}
+	c.result.ExitCode = constdef.EXIT_CODE_SUCCESS
+	return nil
+}
+
+func (c *nodeCollecter) collectCmd(ytcctl string) string {
+	args := []string{
+		"collect",
+		"--start", c.opts.Start.Format(_time_flag_format),
+		// the end is inclusive to the minute in ytcctl collect
+		"--end", c.opts.End.Add(-time.Minute).Format(_time_flag_format),
+		"--output", c.resultsDir(),
+	}
+	for _, f := range []struct{ flag, value string }{
+		{"--yasdb-home", c.node.YasdbHome},
+		{"--yasdb-data", c.node.YasdbData},
+		{"--yasdb-user", c.node.YasdbUser},
+	} {
+		if !stringutil.IsEmpty(f.value) {
+			args = append(args, f.flag, f.value)
+		}
+	}
+	args = append(args, c.opts.Args...)
+	if c.opts.Redact {
+		args = append(args, "--redact", "--redact-token-prefix", c.node.Name)
+	}
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, sshutil.Quote(arg))
+	}
+	// the pid is written before the stop file is checked, so that stopCollect either finds the pid or keeps
+	// ytcctl collect from starting, and ytcctl collect is exec'ed to have the pid
+	return fmt.Sprintf("IFS= read -r YASDB_PASSWORD; export YASDB_PASSWORD; mkdir -p %[1]s && echo $$ > %[2]s && [ ! -e %[3]s ] && exec %[4]s %[5]s",
+		sshutil.Quote(c.resultsDir()), sshutil.Quote(c.pidFile()), sshutil.Quote(c.stopFile()), sshutil.Quote(ytcctl), strings.Join(quoted, stringutil.STR_BLANK_SPACE))
+}
+
+func (c *nodeCollecter) pidFile() string {
+	return path.Join(c.workDir(), _remote_pid_file_name)
+}
+
+func (c *nodeCollecter) stopFile() string {
+	return path.Join(c.workDir(), _remote_stop_file_name)
+}
+
+// stopCollect interrupts ytcctl collect on the node by SIGINT and waits for it to exit within _remote_stop_timeout,
+// it is killed after that.
+func (c *nodeCollecter) stopCollect() {
+	c.step("stopping ytcctl collect")
+	timeout := int(_remote_stop_timeout / time.Second)
+	script := fmt.Sprintf(`mkdir -p %[1]s && touch %[2]s; pid=$(cat %[3]s 2>/dev/null) || exit 0
+kill -INT "$pid" 2>/dev/null || exit 0
+i=0
+while kill -0 "$pid" 2>/dev/null; do
+	if [ $i -ge %[4]d ]; then kill -KILL "$pid"; echo "killed after %[4]d seconds" >&2; exit 1; fi
+	sleep 1; i=$((i+1))
+done`, sshutil.Quote(c.workDir()), sshutil.Quote(c.stopFile()), sshutil.Quote(c.pidFile()), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), _remote_stop_timeout+sshutil.DEFAULT_TIMEOUT)
+	defer cancel()
+	if _, err := c.client.Output(ctx, script); err != nil {
+		c.step("failed to stop ytcctl collect: %s", err.Error())
+	}
+}
+
+// pull copies the result files of the node, the working dirs of the collection are not pulled.
+func (c *nodeCollecter) pull(ctx context.Context) error {
+	out, err := c.client.Output(ctx, "ls -1 "+sshutil.Quote(c.resultsDir()))
+	if err != nil {
+		return yaserr.Wrapf(err, "list results on the node")
+	}
+	var names []string
+	for _, name := range strings.Split(string(out), stringutil.STR_NEWLINE) {
+		if name = strings.TrimSpace(name); strings.HasPrefix(name, collecttypedef.PACKAGE_NAME_PREFIX+stringutil.STR_HYPHEN) && path.Ext(name) != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) != 0 {
+		c.step("pulling %d files", len(names))
+	}
+	for _, name := range names {
+		if err := c.pullFile(ctx, name); err != nil {
+			return yaserr.Wrapf(err, "pull %s", name)
+		}
+	}
+	return nil
+}
+
+func (c *nodeCollecter) pullFile(ctx context.Context, name string) error {
+	src := "cat " + sshutil.Quote(path.Join(c.resultsDir(), name))
+	if strings.HasSuffix(name, redact.MAPPING_FILE_SUFFIX) {
+		// the mapping is kept out of the package
+		out, err := c.client.Output(ctx, src)
+		if err != nil {
+			return err
+		}
+		c.result.RedactionMapping = out
+		return nil
+	}
+	f, err := os.OpenFile(path.Join(c.dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fileutil.DEFAULT_FILE_MODE)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	w := &countWriter{w: io.MultiWriter(f, h)}
+	if err := c.client.Run(ctx, src, nil, w, io.Discard); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	c.result.Files = append(c.result.Files, PackageFile{
+		Path:   path.Join(NODES_DIR_NAME, c.node.Name, name),
+		Size:   w.n,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	})
+	return nil
+}
+
+// cleanup removes the work dir on the node, including the pushed ytc and the result files.
+func (c *nodeCollecter) cleanup() {
+	// the commands of the cancelled context can not run
+	ctx, cancel := context.WithTimeout(context.Background(), sshutil.DEFAULT_TIMEOUT)
+	defer cancel()
+	if _, err := c.client.Output(ctx, "rm -rf "+sshutil.Quote(c.workDir())); err != nil {
+		c.step("failed to remove %s: %s", c.workDir(), err.Error())
+	}
+}
+
+func (c *nodeCollecter) step(format string, a ...interface{}) {
+	if c.opts.OnStep != nil {
+		c.opts.OnStep(c.node, fmt.Sprintf(format, a...))
+	}
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
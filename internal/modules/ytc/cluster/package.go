@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"encoding/json"
+	"os"
+
+	"ytc/internal/modules/ytc/collect/resultgenner"
+	"ytc/utils/cryptoutil"
+	"ytc/utils/fileutil"
+	"ytc/utils/jsonutil"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+const (
+	_package_suffix = ".tar.gz"
+)
+
+// Pack archives the dir of the cluster collection into <dir>.tar.gz and removes the dir, the package is encrypted
+// into <dir>.tar.gz.enc while archiving if key is given. It returns the path of the package. The files which can not
+// be read are not packed, they are returned as fileutil.FileErrors with the path of the package and the dir is kept
+// for them.
+func Pack(dir string, key *cryptoutil.EncryptKey) (string, error) {
+	packagePath := dir + _package_suffix
+	if key != nil {
+		packagePath += cryptoutil.ENCRYPTED_FILE_SUFFIX
+	}
+	err := fileutil.TarGz(dir, packagePath, resultgenner.EncryptTarOpts(key)...)
+	if _, ok := err.(fileutil.FileErrors); ok {
+		return packagePath, err
+	}
+	if err != nil {
+		return "", yaserr.Wrapf(err, "archive %s", dir)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	return packagePath, nil
+}
+
+// WriteRedactionMapping merges the mappings of the nodes into one keyed by the node, and writes it next to the
+// package with mode 0600. It returns the path of the mapping, which is empty if no node is redacted.
+func WriteRedactionMapping(packagePath string, results []*NodeResult) (string, error) {
+	mappings := make(map[string]json.RawMessage)
+	for _, r := range results {
+		if len(r.RedactionMapping) != 0 {
+			mappings[r.Name] = r.RedactionMapping
+		}
+	}
+	if len(mappings) == 0 {
+		return "", nil
+	}
+	fname := resultgenner.GenRedactionMappingPath(packagePath)
+	return fname, fileutil.WriteFileAtomic(fname, []byte(jsonutil.ToJSONString(mappings)), 0600)
+}
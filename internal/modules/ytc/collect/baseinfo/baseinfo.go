@@ -110,6 +110,9 @@ func (b *BaseCollecter) CheckAccess(yasdbValidate error) (noAccess []ytccollectc
 	noAccess = make([]ytccollectcommons.NoAccessRes, 0)
 	funcMap := b.CheckFunc()
 	for item, fn := range funcMap {
+		if !ytccollectcommons.IsItemSelected(b.CollectParam, item) {
+			continue
+		}
 		noAccessRes := fn()
 		if noAccessRes != nil {
 			log.Module.Debugf("item [%s] check asscess desc: %s tips %s", item, noAccessRes.Description, noAccessRes.Tips)
@@ -143,6 +146,9 @@ func (b *BaseCollecter) Type() string {
 func (b *BaseCollecter) ItemsToCollect(noAccess []ytccollectcommons.NoAccessRes) (res []string) {
 	noMap := b.getNotAccessItem(noAccess)
 	for item := range BaseInfoChineseName {
+		if !ytccollectcommons.IsItemSelected(b.CollectParam, item) {
+			continue
+		}
 		if _, ok := noMap[item]; !ok {
 			res = append(res, item)
 		}
@@ -166,6 +172,16 @@ func (b *BaseCollecter) PreCollect(packageDir string) (err error) {
 	return
 }
 
+// [Interface Func]
+func (b *BaseCollecter) Plan(items []string) []ytccollectcommons.PlanItem {
+	return ytccollectcommons.GenPlan(b.Type(), items, b.planFunc())
+}
+
+// [Interface Func]
+func (b *BaseCollecter) Estimate(items []string) map[string]int64 {
+	return ytccollectcommons.GenEstimate(items, b.estimateFunc())
+}
+
 // [Interface Func]
 func (b *BaseCollecter) CollectOK() *datadef.YTCModule {
 	return b.ModuleCollectRes
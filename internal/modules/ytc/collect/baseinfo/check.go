@@ -50,6 +50,9 @@ func (b *BaseCollecter) CheckFireWallAccess() error {
 }
 
 func (b *BaseCollecter) checkYasdbVersion() *ytccollectcommons.NoAccessRes {
+	if b.IsRemote() {
+		return b.checkRemoteYasdbVersion()
+	}
 	yasdb := path.Join(b.YasdbHome, ytccollectcommons.BIN, ytccollectcommons.YASDB)
 	err := fileutil.CheckAccess(yasdb)
 	if err == nil {
@@ -63,16 +66,34 @@ func (b *BaseCollecter) checkYasdbVersion() *ytccollectcommons.NoAccessRes {
 	}
 }
 
+// checkRemoteYasdbVersion checks the version which is queried from v$version over TCP.
+func (b *BaseCollecter) checkRemoteYasdbVersion() *ytccollectcommons.NoAccessRes {
+	noAccess := &ytccollectcommons.NoAccessRes{ModuleItem: datadef.BASE_YASDB_VERION}
+	yasql := path.Join(b.YasdbHome, ytccollectcommons.BIN, ytccollectcommons.YASQL)
+	if err := fileutil.CheckAccess(yasql); err != nil {
+		desc, tips := ytccollectcommons.PathErrDescAndTips(yasql, err)
+		ytccollectcommons.FillDescTips(noAccess, desc, tips)
+		return noAccess
+	}
+	if b.yasdbValidateErr != nil {
+		desc, tips := ytccollectcommons.YasErrDescAndTips(b.yasdbValidateErr)
+		ytccollectcommons.FillDescTips(noAccess, desc, tips)
+		return noAccess
+	}
+	return nil
+}
+
 func (b *BaseCollecter) checkYasdbParameter() (noAccess *ytccollectcommons.NoAccessRes) {
 	noAccess = new(ytccollectcommons.NoAccessRes)
 	noAccess.ModuleItem = datadef.BASE_YASDB_PARAMETER
 	yasql := path.Join(b.YasdbHome, ytccollectcommons.BIN, ytccollectcommons.YASQL)
 	ini := path.Join(b.YasdbData, ytccollectcommons.CONFIG, ytccollectcommons.YASDB_INI)
-	iniErr := fileutil.CheckAccess(ini)
+	// yasdb.ini of the remote instance is not readable, the parameters can only be queried
+	iniOK := !b.IsRemote() && fileutil.CheckAccess(ini) == nil
 	yasqlErr := fileutil.CheckAccess(yasql)
 	if yasqlErr != nil {
 		desc, tips := ytccollectcommons.PathErrDescAndTips(yasql, yasqlErr)
-		if iniErr == nil {
+		if iniOK {
 			noAccess.ForceCollect = true
 			ytccollectcommons.FillDescTips(noAccess, desc, fmt.Sprintf(ytccollectcommons.DEFAULT_PARAMETER_TIPS, ini))
 			return
@@ -83,7 +104,7 @@ func (b *BaseCollecter) checkYasdbParameter() (noAccess *ytccollectcommons.NoAcc
 	if b.yasdbValidateErr != nil {
 		b.notConnectDB = true
 		desc, tips := ytccollectcommons.YasErrDescAndTips(b.yasdbValidateErr)
-		if iniErr == nil {
+		if iniOK {
 			noAccess.ForceCollect = true
 			ytccollectcommons.FillDescTips(noAccess, desc, fmt.Sprintf(ytccollectcommons.DEFAULT_PARAMETER_TIPS, ini))
 			return
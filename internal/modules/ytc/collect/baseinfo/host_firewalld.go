@@ -20,6 +20,7 @@ func (b *BaseCollecter) getHostFirewalldStatus() (err error) {
 	log := log.Module.M(datadef.BASE_HOST_FIREWALLD)
 	osRelease := runtimedef.GetOSRelease()
 	execer := execerutil.NewExecer(log)
+	ctx := b.ItemContext(datadef.BASE_HOST_FIREWALLD)
 	// ubuntu
 	if osRelease.Id == osutil.UBUNTU_ID {
 		if !userutil.IsCurrentUserRoot() {
@@ -27,12 +28,12 @@ func (b *BaseCollecter) getHostFirewalldStatus() (err error) {
 			hostFirewallStatus.Description = datadef.GenUbuntuFirewalldDesc()
 			return
 		}
-		_, stdout, _ := execer.Exec(bashdef.CMD_BASH, "-c", fmt.Sprintf("%s status", bashdef.CMD_UFW))
+		_, stdout, _ := execer.ExecContext(ctx, bashdef.CMD_BASH, "-c", fmt.Sprintf("%s status", bashdef.CMD_UFW))
 		hostFirewallStatus.Details = strings.Contains(stdout, _ubuntu_firewalld_active)
 		return
 	}
 	// other os
-	_, stdout, _ := execer.Exec(bashdef.CMD_BASH, "-c", fmt.Sprintf("%s is-active firewalld", bashdef.CMD_SYSTEMCTL))
+	_, stdout, _ := execer.ExecContext(ctx, bashdef.CMD_BASH, "-c", fmt.Sprintf("%s is-active firewalld", bashdef.CMD_SYSTEMCTL))
 	hostFirewallStatus.Details = strings.Contains(stdout, _firewalld_active) && !strings.Contains(stdout, _firewalld_inactive)
 	return
 }
@@ -73,14 +73,9 @@ func (b *BaseCollecter) hostHistoryWorkload(log yaslog.YasLog, itemName string,
 		return
 	}
 	// collect
-	sar := sar.NewSar(log)
-	strategyConf := confdef.GetStrategyConf()
-	sarDir := strategyConf.Collect.SarDir
-	if stringutil.IsEmpty(sarDir) {
-		sarDir = sar.GetSarDir()
-	}
+	sar := sar.NewSar(log).WithContext(b.ItemContext(itemName))
 	sarOutput := make(collecttypedef.WorkloadOutput)
-	args := b.genHistoryWorkloadArgs(start, end, sarDir)
+	args := b.genHistoryWorkloadArgs(start, end, b.getSarDir(sar))
 	for _, arg := range args {
 		output, e := sar.Collect(workloadType, sarArg, arg)
 		if e != nil {
@@ -95,6 +90,15 @@ func (b *BaseCollecter) hostHistoryWorkload(log yaslog.YasLog, itemName string,
 	return
 }
 
+// getSarDir returns collect.sar_dir in strategy, or the sar dir of the os by default.
+func (b *BaseCollecter) getSarDir(sar *sar.Sar) string {
+	sarDir := confdef.GetStrategyConf().Collect.SarDir
+	if stringutil.IsEmpty(sarDir) {
+		sarDir = sar.GetSarDir()
+	}
+	return sarDir
+}
+
 func (b *BaseCollecter) genHistoryWorkloadArgs(start, end time.Time, sarDir string) (args []string) {
 	// get data between start and end
 	var dates []time.Time
@@ -141,7 +145,7 @@ func (b *BaseCollecter) hostCurrentWorkload(log yaslog.YasLog, itemName string,
 			log.Error(err)
 			return
 		}
-		sar := sar.NewSar(log)
+		sar := sar.NewSar(log).WithContext(b.ItemContext(itemName))
 		return sar.Collect(workloadType, sarArg, strconv.Itoa(scrapeInterval), strconv.Itoa(scrapeTimes))
 	}
 	// use gopsutil to calculate by ourself
@@ -0,0 +1,104 @@
+package baseinfo
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/confdef"
+	"ytc/defs/runtimedef"
+	"ytc/internal/modules/ytc/collect/baseinfo/sar"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+	"ytc/utils/osutil"
+	"ytc/utils/userutil"
+)
+
+// the base items only generate small data files
+func (b *BaseCollecter) estimateFunc() map[string]ytccollectcommons.EstimateFunc {
+	return map[string]ytccollectcommons.EstimateFunc{}
+}
+
+func (b *BaseCollecter) planFunc() map[string]ytccollectcommons.PlanFunc {
+	return map[string]ytccollectcommons.PlanFunc{
+		datadef.BASE_YASDB_VERION:      b.planYasdbVersion,
+		datadef.BASE_YASDB_PARAMETER:   b.planYasdbParameter,
+		datadef.BASE_HOST_OS_INFO:      b.planSystem("host information and os release"),
+		datadef.BASE_HOST_FIREWALLD:    b.planHostFirewalld,
+		datadef.BASE_HOST_CPU:          b.planSystem("cpu information"),
+		datadef.BASE_HOST_DISK:         b.planSystem("disk partitions and usage"),
+		datadef.BASE_HOST_NETWORK:      b.planSystem("network interfaces"),
+		datadef.BASE_HOST_MEMORY:       b.planSystem("virtual memory"),
+		datadef.BASE_HOST_NETWORK_IO:   b.planWorkload(datadef.BASE_HOST_NETWORK_IO),
+		datadef.BASE_HOST_CPU_USAGE:    b.planWorkload(datadef.BASE_HOST_CPU_USAGE),
+		datadef.BASE_HOST_DISK_IO:      b.planWorkload(datadef.BASE_HOST_DISK_IO),
+		datadef.BASE_HOST_MEMORY_USAGE: b.planWorkload(datadef.BASE_HOST_MEMORY_USAGE),
+	}
+}
+
+func (b *BaseCollecter) planYasdbVersion() ([]ytccollectcommons.PlanSource, error) {
+	if b.IsRemote() {
+		return []ytccollectcommons.PlanSource{ytccollectcommons.SQLSource(yasdb.VersionSQL())}, nil
+	}
+	yasdbBinPath := path.Join(b.YasdbHome, "bin", bashdef.CMD_YASDB)
+	return []ytccollectcommons.PlanSource{ytccollectcommons.CommandSource(yasdbBinPath + " -V")}, nil
+}
+
+func (b *BaseCollecter) planYasdbParameter() ([]ytccollectcommons.PlanSource, error) {
+	var sources []ytccollectcommons.PlanSource
+	if !b.IsRemote() {
+		sources = append(sources, ytccollectcommons.FileSource(path.Join(b.YasdbData, CONFIG_DIR_NAME, KEY_YASDB_INI)))
+	}
+	if !b.notConnectDB {
+		sources = append(sources, ytccollectcommons.SQLSource(yasdb.AllParameterSQL()))
+	}
+	return sources, nil
+}
+
+func (b *BaseCollecter) planHostFirewalld() ([]ytccollectcommons.PlanSource, error) {
+	if runtimedef.GetOSRelease().Id == osutil.UBUNTU_ID {
+		if !userutil.IsCurrentUserRoot() {
+			return nil, errors.New("checking ubuntu firewall status requires sudo or root")
+		}
+		return []ytccollectcommons.PlanSource{ytccollectcommons.CommandSource(fmt.Sprintf("%s status", bashdef.CMD_UFW))}, nil
+	}
+	return []ytccollectcommons.PlanSource{ytccollectcommons.CommandSource(fmt.Sprintf("%s is-active firewalld", bashdef.CMD_SYSTEMCTL))}, nil
+}
+
+func (b *BaseCollecter) planSystem(desc string) ytccollectcommons.PlanFunc {
+	return func() ([]ytccollectcommons.PlanSource, error) {
+		return []ytccollectcommons.PlanSource{ytccollectcommons.SystemSource(desc)}, nil
+	}
+}
+
+// planWorkload returns the sar commands of the history and current workload, gopsutil is used instead if sar is not found.
+func (b *BaseCollecter) planWorkload(itemName string) ytccollectcommons.PlanFunc {
+	return func() ([]ytccollectcommons.PlanSource, error) {
+		strategyConf := confdef.GetStrategyConf()
+		scrapeInterval, scrapeTimes := strategyConf.Collect.ScrapeInterval, strategyConf.Collect.ScrapeTimes
+		workloadType, ok := ItemNameToWorkloadTypeMap[itemName]
+		if !ok {
+			return nil, fmt.Errorf("failed to get workload type from item name: %s", itemName)
+		}
+		if b.CheckSarAccess() != nil {
+			current := fmt.Sprintf("current %s workload", workloadType)
+			return []ytccollectcommons.PlanSource{
+				ytccollectcommons.SystemSource(current, fmt.Sprintf("scrape %d times every %d seconds", scrapeTimes, scrapeInterval)),
+			}, nil
+		}
+		sarArg := WorkloadTypeToSarArgMap[workloadType]
+		var sources []ytccollectcommons.PlanSource
+		sarDir := b.getSarDir(sar.NewSar(log.Module.M(itemName)))
+		for _, arg := range b.genHistoryWorkloadArgs(b.StartTime, b.EndTime, sarDir) {
+			cmd := strings.Join(strings.Fields(fmt.Sprintf("%s %s %s", bashdef.CMD_SAR, sarArg, arg)), " ")
+			sources = append(sources, ytccollectcommons.CommandSource(cmd, KEY_HISTORY))
+		}
+		cmd := fmt.Sprintf("%s %s %d %d", bashdef.CMD_SAR, sarArg, scrapeInterval, scrapeTimes)
+		sources = append(sources, ytccollectcommons.CommandSource(cmd, KEY_CURRENT))
+		return sources, nil
+	}
+}
@@ -1,6 +1,7 @@
 package sar
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -26,6 +27,7 @@ const (
 type Sar struct {
 	log    yaslog.YasLog
 	parser SarParser
+	ctx    context.Context
 }
 
 func NewSar(yaslog yaslog.YasLog) *Sar {
@@ -35,6 +37,12 @@ func NewSar(yaslog yaslog.YasLog) *Sar {
 	}
 }
 
+// WithContext sets the context of sar, the running sar is killed when it is done.
+func (s *Sar) WithContext(ctx context.Context) *Sar {
+	s.ctx = ctx
+	return s
+}
+
 func getParser(yaslog yaslog.YasLog) SarParser {
 	os := runtimedef.GetOSRelease()
 	switch os.Id {
@@ -59,7 +67,11 @@ func (s *Sar) Collect(t collecttypedef.WorkloadType, args ...string) (collecttyp
 	realArgs := []string{bashdef.CMD_SAR}
 	realArgs = append(realArgs, args...)
 	cmd := fmt.Sprintf(" %s", strings.Join(realArgs, stringutil.STR_BLANK_SPACE))
-	ret, stdout, stderr := execer.EnvExec(_envs, bashdef.CMD_BASH, "-c", cmd)
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ret, stdout, stderr := execer.EnvExecContext(ctx, append([]string{runtimedef.GetChildEnv()}, _envs...), bashdef.CMD_BASH, "-c", cmd)
 	if ret != 0 {
 		err := errors.New(stderr)
 		return res, err
@@ -4,10 +4,10 @@ import (
 	"path"
 
 	"ytc/defs/errdef"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
-	"ytc/utils/yasqlutil"
 
 	"git.yasdb.com/go/yasutil/fs"
 	ini "gopkg.in/ini.v1"
@@ -22,7 +22,9 @@ func (b *BaseCollecter) getYasdbParameter() (err error) {
 	log := log.Module.M(datadef.BASE_YASDB_PARAMETER)
 
 	// collect yasdb ini config
-	if yasdbIni, err := b.getYasdbIni(); err != nil {
+	if b.IsRemote() {
+		yasdbParameterItem.Children[KEY_YASDB_INI] = datadef.YTCItem{Error: "yasdb.ini is not readable over TCP", Description: datadef.GenNotApplicableRemoteDesc(b.YasdbAddress)}
+	} else if yasdbIni, err := b.getYasdbIni(); err != nil {
 		yasdbParameterItem.Children[KEY_YASDB_INI] = datadef.YTCItem{Error: err.Error(), Description: datadef.GenDefaultDesc()}
 		log.Errorf("failed to get yasdb.ini, err: %s", err.Error())
 	} else {
@@ -64,6 +66,6 @@ func (b *BaseCollecter) getYasdbIni() (res map[string]string, err error) {
 
 func (b *BaseCollecter) getParameter() (pv []*yasdb.VParameter, err error) {
 	// collect parameter from v$parameter
-	tx := yasqlutil.GetLocalInstance(b.YasdbUser, b.YasdbPassword, b.YasdbHome, b.YasdbData)
+	tx := ytccollectcommons.NewItemYasql(b.CollectParam, datadef.BASE_YASDB_PARAMETER)
 	return yasdb.QueryAllParameter(tx)
 }
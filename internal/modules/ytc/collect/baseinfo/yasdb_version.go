@@ -7,7 +7,9 @@ import (
 
 	"ytc/defs/bashdef"
 	"ytc/defs/errdef"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
 	"ytc/utils/execerutil"
 	"ytc/utils/yasqlutil"
@@ -20,6 +22,19 @@ func (b *BaseCollecter) getYasdbVersion() (err error) {
 	defer b.fillResult(&yasdbVersionItem)
 
 	log := log.Module.M(datadef.BASE_YASDB_VERION)
+	if b.IsRemote() {
+		// yasdb of the remote instance can not be executed, query v$version instead
+		version, qErr := yasdb.QueryVersion(ytccollectcommons.NewItemYasql(b.CollectParam, datadef.BASE_YASDB_VERION))
+		if qErr != nil {
+			err = qErr
+			log.Errorf("failed to query yashandb version, err: %s", err.Error())
+			yasdbVersionItem.Error = err.Error()
+			yasdbVersionItem.Description = datadef.GenGetDatabaseViewDesc("v$version")
+			return
+		}
+		yasdbVersionItem.Details = version
+		return
+	}
 	yasdbBinPath := path.Join(b.YasdbHome, "bin", bashdef.CMD_YASDB)
 	if !fs.IsFileExist(yasdbBinPath) {
 		err = &errdef.ErrFileNotFound{Fname: yasdbBinPath}
@@ -30,7 +45,7 @@ func (b *BaseCollecter) getYasdbVersion() (err error) {
 	}
 	execer := execerutil.NewExecer(log)
 	env := []string{fmt.Sprintf("%s=%s", yasqlutil.LIB_KEY, path.Join(b.YasdbHome, yasqlutil.LIB_PATH))}
-	ret, stdout, stderr := execer.EnvExec(env, yasdbBinPath, "-V")
+	ret, stdout, stderr := execer.EnvExecContext(b.ItemContext(datadef.BASE_YASDB_VERION), env, yasdbBinPath, "-V")
 	if ret != 0 {
 		err = fmt.Errorf("failed to get yasdb version, err: %s", stderr)
 		log.Error(err)
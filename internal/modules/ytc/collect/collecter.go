@@ -1,12 +1,18 @@
 package collect
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"ytc/defs/collecttypedef"
 	"ytc/internal/modules/ytc/collect/baseinfo"
 	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/internal/modules/ytc/collect/diagnosis"
+	"ytc/internal/modules/ytc/collect/extra"
 	"ytc/internal/modules/ytc/collect/performance"
+	"ytc/utils/stringutil"
 )
 
 type TypedCollecter interface {
@@ -16,6 +22,8 @@ type TypedCollecter interface {
 	Type() string
 	PreCollect(packageDir string) error
 	CollectOK() *datadef.YTCModule
+	Plan(items []string) []ytccollectcommons.PlanItem
+	Estimate(items []string) map[string]int64
 }
 
 func NewTypedCollecter(t string, collectParam *collecttypedef.CollectParam) (TypedCollecter, error) {
@@ -29,3 +37,40 @@ func NewTypedCollecter(t string, collectParam *collecttypedef.CollectParam) (Typ
 	}
 	return nil, collecttypedef.ErrKnownType
 }
+
+// ItemTypes returns the collection items and the types they belong to.
+func ItemTypes() map[string]string {
+	res := make(map[string]string)
+	for t, items := range map[string]map[string]string{
+		collecttypedef.TYPE_BASE:  baseinfo.BaseInfoChineseName,
+		collecttypedef.TYPE_DIAG:  diagnosis.DiagChineseName,
+		collecttypedef.TYPE_PERF:  performance.PerformanceChineseName,
+		collecttypedef.TYPE_EXTRA: extra.ExtraChineseName,
+	} {
+		for item := range items {
+			res[item] = t
+		}
+	}
+	return res
+}
+
+// UnknownItem returns the first one of items which is not a collection item.
+func UnknownItem(items []string) (string, bool) {
+	itemTypes := ItemTypes()
+	for _, item := range items {
+		if _, ok := itemTypes[item]; !ok {
+			return item, true
+		}
+	}
+	return "", false
+}
+
+// ItemsHelp lists all the collection items, it is used as the help of the invalid items.
+func ItemsHelp() string {
+	var items []string
+	for item := range ItemTypes() {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return fmt.Sprintf("you can choose one or more of (%s), split with ','", strings.Join(items, stringutil.STR_BAR))
+}
@@ -1 +1,62 @@
 package collect_test
+
+import (
+	"testing"
+
+	"ytc/defs/collecttypedef"
+	ytccollect "ytc/internal/modules/ytc/collect"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+)
+
+func TestItemSelection(t *testing.T) {
+	itemTypes := ytccollect.ItemTypes()
+	if itemTypes[datadef.PERF_YASDB_AWR] != collecttypedef.TYPE_PERF || itemTypes[datadef.DIAG_HOST_BASH_HISTORY] != collecttypedef.TYPE_DIAG {
+		t.Fatalf("unexpected item types: %v", itemTypes)
+	}
+	if item, ok := ytccollect.UnknownItem([]string{datadef.PERF_YASDB_AWR, "YashanDB-Unknown"}); !ok || item != "YashanDB-Unknown" {
+		t.Errorf("YashanDB-Unknown should be unknown, got %s %v", item, ok)
+	}
+	param := &collecttypedef.CollectParam{
+		Items:     []string{datadef.PERF_YASDB_AWR, datadef.DIAG_HOST_BASH_HISTORY},
+		SkipItems: []string{datadef.DIAG_HOST_BASH_HISTORY},
+	}
+	cases := map[string]bool{
+		datadef.PERF_YASDB_AWR:         true,
+		datadef.DIAG_HOST_BASH_HISTORY: false,
+		datadef.PERF_YASDB_SLOW_SQL:    false,
+	}
+	for item, selected := range cases {
+		if param.IsItemSelected(item) != selected {
+			t.Errorf("item %s selected should be %v", item, selected)
+		}
+	}
+	if !(&collecttypedef.CollectParam{}).IsItemSelected(datadef.PERF_YASDB_SLOW_SQL) {
+		t.Error("all the items should be selected by default")
+	}
+}
+
+func TestRemoteItemSelection(t *testing.T) {
+	param := &collecttypedef.CollectParam{
+		YasdbAddress: "192.168.1.2:1688",
+		SkipItems:    []string{datadef.PERF_YASDB_AWR},
+	}
+	cases := map[string]bool{
+		datadef.BASE_YASDB_PARAMETER: true,
+		datadef.PERF_YASDB_SLOW_SQL:  true,
+		datadef.PERF_YASDB_AWR:       false,
+		datadef.DIAG_YASDB_ALERTLOG:  false,
+		datadef.BASE_HOST_CPU:        false,
+	}
+	for item, selected := range cases {
+		if ytccollectcommons.IsItemSelected(param, item) != selected {
+			t.Errorf("item %s selected over TCP should be %v", item, selected)
+		}
+	}
+	if !ytccollectcommons.IsItemNotApplicable(param, datadef.DIAG_YASDB_ALERTLOG) || ytccollectcommons.IsItemNotApplicable(param, datadef.PERF_YASDB_AWR) {
+		t.Error("only the selected items which need the files or processes of the host are not applicable")
+	}
+	if !ytccollectcommons.IsItemSelected(&collecttypedef.CollectParam{}, datadef.BASE_HOST_CPU) {
+		t.Error("the host-level items should be selected when collecting locally")
+	}
+}
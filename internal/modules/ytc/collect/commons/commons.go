@@ -1,6 +1,7 @@
 package ytccollectcommons
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,12 +10,12 @@ import (
 
 	"ytc/defs/errdef"
 	"ytc/log"
+	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/userutil"
 	"ytc/utils/yasqlutil"
 
 	"git.yasdb.com/go/yaslog"
-	"git.yasdb.com/go/yasutil/fs"
 )
 
 const (
@@ -76,6 +77,16 @@ const (
 	NO_SATISFIED_TIPS      = "you can increase the collection interval appropriately"
 )
 
+// scheduler
+const (
+	ITEM_TIMEOUT_DESC   = "the item has not been completed within the timeout period: %s, you can modify strategy.toml 'item_timeout' or 'item_timeouts' to customize the timeout period"
+	ITEM_CANCELLED_DESC = "the collection was interrupted before the item was completed"
+	ITEM_RUNNING_DESC   = ", and it was still running %s after it was stopped, its files may be incomplete"
+	ITEM_NO_SPACE_DESC  = "the item is skipped because of insufficient disk space, its estimated size is %s, you can modify strategy.toml 'disk_space_policy' or use '--disk-space-policy' to change the policy"
+
+	ITEM_NOT_APPLICABLE_DESC = "the item needs the files or processes of the database host, it is not applicable when collecting over TCP from %s"
+)
+
 // yasdb home
 const (
 	BIN   = "bin"
@@ -216,12 +227,16 @@ func FilesErrDescAndTips(res map[string]error) (desc string, tips string) {
 	return
 }
 
-func CopyDir(log yaslog.YasLog, src, dest string, excludeMap map[string]struct{}) (err error) {
+// CopyDir copies the files of src to dest, the failed files are skipped. It stops once ctx is done.
+func CopyDir(ctx context.Context, log yaslog.YasLog, src, dest string, excludeMap map[string]struct{}) (err error) {
 	if strings.TrimSpace(src) == strings.TrimSpace(dest) {
 		log.Infof("src path: %s is equal to dest path: %s, skip", src, dest)
 		return
 	}
 	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if _, ok := excludeMap[path]; ok {
 			log.Infof("skip exclude path: %s", path)
 			return nil
@@ -237,7 +252,10 @@ func CopyDir(log yaslog.YasLog, src, dest string, excludeMap map[string]struct{}
 				return nil
 			}
 		} else {
-			if err = fs.CopyFile(path, destNewPath); err != nil {
+			if err = fileutil.CopyFileContext(ctx, path, destNewPath); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
 				log.Infof("skip path: %s, because of err: %s", path, err.Error())
 				return nil
 			}
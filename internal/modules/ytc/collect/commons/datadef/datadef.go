@@ -34,3 +34,13 @@ const (
 	// extra file collect
 	EXTRA_FILE_COLLECT = "Extra-FileCollect"
 )
+
+// RemoteItems are the DB-level items which only query the database, they are the only ones collected over TCP.
+var RemoteItems = map[string]struct{}{
+	BASE_YASDB_VERION:          {},
+	BASE_YASDB_PARAMETER:       {},
+	DIAG_YASDB_INSTANCE_STATUS: {},
+	DIAG_YASDB_DATABASE_STATUS: {},
+	PERF_YASDB_AWR:             {},
+	PERF_YASDB_SLOW_SQL:        {},
+}
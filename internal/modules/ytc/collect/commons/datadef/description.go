@@ -18,6 +18,10 @@ const (
 	DESC_YASDB_PROCESS_STATUS       = "获取数据库进程信息失败，请检查数据库进程是否存在"
 	DESC_GET_DATABASE_VIEW          = "查询数据库%s视图失败，请检查数据库状态或查看YTC日志定位原因"
 	DESC_NO_PERMISSION_SYSLOG       = "没有权限收集系统日志"
+	DESC_TRUNCATED_LOG              = "%s超过max_log_bytes_per_item：%s，已丢弃开头的%s，仅保留末尾部分"
+	DESC_SKIPPED_CORE_FILES         = "coredump文件超过max_core_files：%d或max_core_size：%s，已优先保留最新的文件，跳过：%s"
+	DESC_EXCEED_PACKAGE_SIZE        = "预估大小%s超过max_package_size：%s，已跳过本收集项"
+	DESC_NOT_APPLICABLE_REMOTE      = "通过TCP连接%s收集时无法读取数据库主机上的文件和进程，不适用本收集项，请在数据库主机上执行ytcctl collect收集"
 )
 
 const (
@@ -28,6 +32,22 @@ func GenDefaultDesc() string {
 	return DESC_DEFAULT
 }
 
+func GenTruncatedLogDesc(fname, limit, removed string) string {
+	return fmt.Sprintf(DESC_TRUNCATED_LOG, fname, limit, removed)
+}
+
+func GenSkippedCoreFilesDesc(maxFiles int, maxSize string, skipped []string) string {
+	return fmt.Sprintf(DESC_SKIPPED_CORE_FILES, maxFiles, maxSize, strings.Join(skipped, ", "))
+}
+
+func GenExceedPackageSizeDesc(size, limit string) string {
+	return fmt.Sprintf(DESC_EXCEED_PACKAGE_SIZE, size, limit)
+}
+
+func GenNotApplicableRemoteDesc(address string) string {
+	return fmt.Sprintf(DESC_NOT_APPLICABLE_REMOTE, address)
+}
+
 func GenNoPermissionDesc(str string) string {
 	return fmt.Sprintf(DESC_NO_PERMISSION, str)
 }
@@ -7,8 +7,17 @@ const (
 	DATATYPE_GOPSUTIL DataType = "gopstuil"
 )
 
+// the items which are stopped by the scheduler have a status, the others are decided by the error
+const (
+	ITEM_STATUS_TIMEOUT   ItemStatus = "timeout"
+	ITEM_STATUS_CANCELLED ItemStatus = "cancelled"
+	ITEM_STATUS_SKIPPED   ItemStatus = "skipped"
+)
+
 type DataType string
 
+type ItemStatus string
+
 type YTCItem struct {
 	Name        string             `json:"-"`                     // 收集项名称
 	Error       string             `json:"error,omitempty"`       // 原始报错信息
@@ -16,6 +25,22 @@ type YTCItem struct {
 	Details     interface{}        `json:"details,omitempty"`     // 每个收集项包含的数据
 	DataType    DataType           `json:"datatype,omitempty"`    // 数据类型，在Details可能使用多种数据时使用
 	Children    map[string]YTCItem `json:"children,omitempty"`
+	Status      ItemStatus         `json:"status,omitempty"` // 被中止的收集项的状态，如超时
+}
+
+// TruncatedDetails is the details of the item whose files are truncated by the limits of the strategy,
+// the details of the others are the path only.
+type TruncatedDetails struct {
+	Path        string   `json:"path"`
+	Truncations []string `json:"truncations"`
+}
+
+// GenPathDetails returns the path, or TruncatedDetails if any file of the path is truncated.
+func GenPathDetails(path string, truncations []string) interface{} {
+	if len(truncations) == 0 {
+		return path
+	}
+	return TruncatedDetails{Path: path, Truncations: truncations}
 }
 
 type YTCModule struct {
@@ -31,6 +56,10 @@ func (c *YTCModule) Set(item *YTCItem) {
 	if c.items == nil {
 		c.items = make(map[string]*YTCItem)
 	}
+	// the stopped item is kept, the collect func of it may still return later
+	if old, ok := c.items[item.Name]; ok && len(old.Status) != 0 {
+		return
+	}
 	c.items[item.Name] = item
 }
 
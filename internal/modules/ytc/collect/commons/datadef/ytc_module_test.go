@@ -0,0 +1,22 @@
+package datadef_test
+
+import (
+	"testing"
+
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+)
+
+func TestYTCModuleSetKeepsStopped(t *testing.T) {
+	module := &datadef.YTCModule{Module: "base"}
+	module.Set(&datadef.YTCItem{Name: "item", Error: "collect item timeout", Status: datadef.ITEM_STATUS_TIMEOUT})
+	// the collect func of the stopped item returns late
+	module.Set(&datadef.YTCItem{Name: "item", Details: "path"})
+	module.Set(&datadef.YTCItem{Name: "other", Details: "path"})
+	items := module.Items()
+	if item := items["item"]; item.Status != datadef.ITEM_STATUS_TIMEOUT || item.Details != nil {
+		t.Errorf("the stopped item should be kept: %+v", item)
+	}
+	if item := items["other"]; item.Details != "path" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+}
@@ -0,0 +1,110 @@
+package ytccollectcommons
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"ytc/log"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// EstimateFunc returns the bytes which would be copied into the package dir by a collection item.
+type EstimateFunc func() (int64, error)
+
+// GenEstimate estimates the sizes of the items by the estimate funcs, the items without estimate func
+// only generate small data files and are estimated as 0.
+func GenEstimate(items []string, funcs map[string]EstimateFunc) map[string]int64 {
+	res := make(map[string]int64)
+	for _, item := range items {
+		fn, ok := funcs[item]
+		if !ok {
+			res[item] = 0
+			continue
+		}
+		size, err := fn()
+		if err != nil {
+			// the access of the item has been checked, the error is reported when collecting
+			log.Module.Warnf("estimate size of %s err: %s", item, err.Error())
+		}
+		res[item] = size
+	}
+	return res
+}
+
+// EstimateBySources sums the sizes of the sources of the given kinds, the sources which can not be accessed are ignored.
+func EstimateBySources(fn PlanFunc, kinds ...string) EstimateFunc {
+	return func() (total int64, err error) {
+		sources, err := fn()
+		for _, source := range sources {
+			if !containsKind(kinds, source.Kind) {
+				continue
+			}
+			switch source.Kind {
+			case PLAN_SOURCE_FILE:
+				total += FileSize(source.Value)
+			case PLAN_SOURCE_DIR:
+				total += DirSize(source.Value, nil)
+			}
+		}
+		return
+	}
+}
+
+// CapEstimate limits the size estimated by fn, the limit which is not greater than 0 means no limit.
+func CapEstimate(fn EstimateFunc, limit int64) EstimateFunc {
+	return func() (int64, error) {
+		size, err := fn()
+		if limit > 0 && size > limit {
+			size = limit
+		}
+		return size, err
+	}
+}
+
+func FileSize(fname string) int64 {
+	info, err := os.Stat(fname)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
+
+// DirSize sums the sizes of the regular files in dir, the paths in excludeMap are skipped.
+func DirSize(dir string, excludeMap map[string]struct{}) (total int64) {
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if _, ok := excludeMap[p]; ok {
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return
+}
+
+// FreeSpace returns the bytes available to the current user on the filesystem of dir.
+func FreeSpace(dir string) (int64, error) {
+	usage, err := disk.Usage(dir)
+	if err != nil {
+		return 0, err
+	}
+	return int64(usage.Free), nil
+}
+
+func containsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package ytccollectcommons
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// plan source kind
+const (
+	PLAN_SOURCE_FILE    = "file"    // a file to be read or copied
+	PLAN_SOURCE_DIR     = "dir"     // a directory to be copied or scanned
+	PLAN_SOURCE_SQL     = "sql"     // a sql statement to be queried
+	PLAN_SOURCE_COMMAND = "command" // a command to be executed
+	PLAN_SOURCE_SYSTEM  = "system"  // the system information read from /proc or /sys
+)
+
+// PlanSource is a concrete source which is read, copied, queried or executed by a collection item.
+type PlanSource struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// PlanItem is the plan of a collection item.
+type PlanItem struct {
+	Module      string       `json:"module"`
+	Item        string       `json:"item"`
+	Collected   bool         `json:"collected"`
+	Description string       `json:"description,omitempty"` // why the item is inaccessible
+	Tips        string       `json:"tips,omitempty"`
+	Sources     []PlanSource `json:"sources,omitempty"`
+	Error       string       `json:"error,omitempty"` // the sources can not be resolved
+}
+
+type PlanFunc func() ([]PlanSource, error)
+
+func (s PlanSource) String() string {
+	if len(s.Detail) == 0 {
+		return fmt.Sprintf("%s: %s", s.Kind, s.Value)
+	}
+	return fmt.Sprintf("%s: %s (%s)", s.Kind, s.Value, s.Detail)
+}
+
+// GenPlan resolves the sources of the items by the plan funcs, the items are sorted by name.
+func GenPlan(module string, items []string, funcs map[string]PlanFunc) []PlanItem {
+	sorted := append([]string{}, items...)
+	sort.Strings(sorted)
+	res := make([]PlanItem, 0, len(sorted))
+	for _, item := range sorted {
+		planItem := PlanItem{Module: module, Item: item, Collected: true}
+		fn, ok := funcs[item]
+		if !ok {
+			planItem.Error = fmt.Sprintf("no plan of %s", item)
+			res = append(res, planItem)
+			continue
+		}
+		sources, err := fn()
+		if err != nil {
+			planItem.Error = err.Error()
+		}
+		planItem.Sources = sources
+		res = append(res, planItem)
+	}
+	return res
+}
+
+func FileSource(value string, detail ...string) PlanSource {
+	return PlanSource{Kind: PLAN_SOURCE_FILE, Value: value, Detail: strings.Join(detail, ", ")}
+}
+
+func DirSource(value string, detail ...string) PlanSource {
+	return PlanSource{Kind: PLAN_SOURCE_DIR, Value: value, Detail: strings.Join(detail, ", ")}
+}
+
+func SQLSource(value string, detail ...string) PlanSource {
+	return PlanSource{Kind: PLAN_SOURCE_SQL, Value: value, Detail: strings.Join(detail, ", ")}
+}
+
+func CommandSource(value string, detail ...string) PlanSource {
+	return PlanSource{Kind: PLAN_SOURCE_COMMAND, Value: value, Detail: strings.Join(detail, ", ")}
+}
+
+func SystemSource(value string, detail ...string) PlanSource {
+	return PlanSource{Kind: PLAN_SOURCE_SYSTEM, Value: value, Detail: strings.Join(detail, ", ")}
+}
@@ -0,0 +1,40 @@
+package ytccollectcommons
+
+import (
+	"ytc/defs/collecttypedef"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/utils/yasqlutil"
+)
+
+// NewYasql returns the yasql to query the database of the param, it connects over TCP if the yasdb address is given,
+// otherwise it connects locally with YASDB_DATA.
+func NewYasql(param *collecttypedef.CollectParam) *yasqlutil.Yasql {
+	if param.IsRemote() {
+		return yasqlutil.GetRemoteInstance(param.YasdbUser, param.YasdbPassword.Reveal(), param.YasdbAddress, param.YasdbHome).WithContext(param.Context())
+	}
+	return yasqlutil.GetLocalInstance(param.YasdbUser, param.YasdbPassword.Reveal(), param.YasdbHome, param.YasdbData).WithContext(param.Context())
+}
+
+// NewItemYasql returns the yasql of NewYasql for the item, it is killed when the item times out or the collection is cancelled.
+func NewItemYasql(param *collecttypedef.CollectParam, item string) *yasqlutil.Yasql {
+	return NewYasql(param).WithContext(param.ItemContext(item))
+}
+
+// IsItemSelected reports whether the item is selected by the param, only datadef.RemoteItems are selected when
+// collecting over TCP.
+func IsItemSelected(param *collecttypedef.CollectParam, item string) bool {
+	if !param.IsItemSelected(item) {
+		return false
+	}
+	if !param.IsRemote() {
+		return true
+	}
+	_, ok := datadef.RemoteItems[item]
+	return ok
+}
+
+// IsItemNotApplicable reports whether the item is selected by the items and skip items but not applicable when
+// collecting over TCP.
+func IsItemNotApplicable(param *collecttypedef.CollectParam, item string) bool {
+	return param.IsItemSelected(item) && !IsItemSelected(param, item)
+}
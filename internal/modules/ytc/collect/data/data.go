@@ -1,23 +1,24 @@
 package data
 
 import (
+	"crypto/ed25519"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"ytc/defs/collecttypedef"
 	"ytc/defs/timedef"
-	"ytc/internal/modules/ytc/collect/baseinfo"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	report "ytc/internal/modules/ytc/collect/data/reporter"
 	"ytc/internal/modules/ytc/collect/data/reporter/commons"
-	"ytc/internal/modules/ytc/collect/diagnosis"
-	"ytc/internal/modules/ytc/collect/extra"
-	"ytc/internal/modules/ytc/collect/performance"
+	"ytc/internal/modules/ytc/collect/redact"
 	"ytc/internal/modules/ytc/collect/resultgenner"
 	"ytc/internal/modules/ytc/collect/resultgenner/reporter"
 	"ytc/internal/modules/ytc/collect/resultgenner/reporter/htmldef"
 	"ytc/log"
+	"ytc/utils/cryptoutil"
+	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
 
 	"git.yasdb.com/go/yaserr"
@@ -25,14 +26,24 @@ import (
 )
 
 // validate interface
-var _ resultgenner.Genner = (*YTCReport)(nil)
+var (
+	_ resultgenner.Genner                = (*YTCReport)(nil)
+	_ resultgenner.UnpackedFilesRecorder = (*YTCReport)(nil)
+)
 
 type YTCReport struct {
-	CollectBeginTime time.Time                     `json:"collectBeginTime"`
-	CollectEndTime   time.Time                     `json:"collectEndTime"`
-	CollectParam     *collecttypedef.CollectParam  `json:"collectParam"`
-	Modules          map[string]*datadef.YTCModule `json:"modules"`
+	CollectBeginTime time.Time                                `json:"collectBeginTime"`
+	CollectEndTime   time.Time                                `json:"collectEndTime"`
+	CollectParam     *collecttypedef.CollectParam             `json:"collectParam"`
+	Modules          map[string]*datadef.YTCModule            `json:"modules"`
+	Instances        map[string]map[string]*datadef.YTCModule `json:"instances,omitempty"`     // the DB-level modules of each instance
+	UnpackedFiles    map[string]string                        `json:"unpackedFiles,omitempty"` // the files which could not be read into the package
 	genner           resultgenner.BaseGenner
+	stream           *fileutil.TarGzStream
+	manifest         *resultgenner.Manifest
+	encryptKey       *cryptoutil.EncryptKey
+	signingKey       ed25519.PrivateKey
+	redactor         *redact.Redactor
 }
 
 func NewYTCReport(param *collecttypedef.CollectParam) *YTCReport {
@@ -52,24 +63,16 @@ func (r *YTCReport) GenData(data interface{}, fname string) error {
 func (r *YTCReport) GenReport() (content reporter.ReportContent, err error) {
 	var graphs []string
 	logger := log.Module.M("generate report")
-	moduleNum := 0
-	for _, moduleName := range _moduleOrder {
-		module, ok := r.Modules[moduleName]
-		if !ok {
-			logger.Infof("module: %s unfound, pass", moduleName)
-			continue
-		}
-		moduleNum++
-
-		moduleTitlePrefix := fmt.Sprintf("%d", moduleNum)
-		moduleContent := reporter.GenReportContentByTitle(fmt.Sprintf("%s %s", moduleTitlePrefix, collecttypedef.CollectTypeChineseName[moduleName]), reporter.FONT_SIZE_H1)
+	for i, module := range r.orderedModules() {
+		moduleTitlePrefix := fmt.Sprintf("%d", i+1)
+		moduleContent := reporter.GenReportContentByTitle(fmt.Sprintf("%s %s", moduleTitlePrefix, module.title), reporter.FONT_SIZE_H1)
 		content.Txt += moduleContent.Txt
 		content.Markdown += moduleContent.Markdown
 		content.HTML += moduleContent.HTML
 
 		itemNum := 0
 		items := module.Items()
-		for _, itemName := range _itemOrder[moduleName] {
+		for _, itemName := range _itemOrder[module.name] {
 			item, ok := items[itemName]
 			if !ok {
 				logger.Infof("item: %s unfound, pass", itemName)
@@ -84,6 +87,14 @@ func (r *YTCReport) GenReport() (content reporter.ReportContent, err error) {
 			itemNum++
 
 			itemTitlePrefix := moduleTitlePrefix + stringutil.STR_DOT + fmt.Sprintf("%d", itemNum)
+			if len(item.Status) != 0 {
+				// the stopped item has no data, only the error is reported
+				itemContent := r.genStoppedItemContent(module.name, *item, itemTitlePrefix)
+				content.Txt += itemContent.Txt + stringutil.STR_NEWLINE
+				content.Markdown += itemContent.Markdown + stringutil.STR_NEWLINE
+				content.HTML += itemContent.HTML + stringutil.STR_NEWLINE
+				continue
+			}
 			itemContent, e := reporter.Report(*item, itemTitlePrefix)
 			if e != nil {
 				err = yaserr.Wrapf(e, "generete report of %s", itemName)
@@ -102,21 +113,185 @@ func (r *YTCReport) GenReport() (content reporter.ReportContent, err error) {
 	return
 }
 
-func (r *YTCReport) GenResult(outputDir string, types map[string]struct{}) (string, error) {
+// [Interface Func]
+func (r *YTCReport) RecordUnpackedFiles(files map[string]string) {
+	if r.UnpackedFiles == nil {
+		r.UnpackedFiles = make(map[string]string)
+	}
+	for f, err := range files {
+		r.UnpackedFiles[f] = err
+	}
+}
+
+// reportModule is a module in the report, the modules of the instances are titled with the instance names.
+type reportModule struct {
+	*datadef.YTCModule
+	name  string
+	title string
+}
+
+// orderedModules returns the modules in the order of the report, the host-level modules come first and then the
+// modules of each instance by the instance name.
+func (r *YTCReport) orderedModules() (res []reportModule) {
+	for _, name := range _moduleOrder {
+		if module, ok := r.Modules[name]; ok {
+			res = append(res, reportModule{YTCModule: module, name: name, title: collecttypedef.CollectTypeChineseName[name]})
+		} else {
+			log.Module.Infof("module: %s unfound, pass", name)
+		}
+	}
+	for _, instance := range r.instanceNames() {
+		for _, name := range _moduleOrder {
+			if module, ok := r.Instances[instance][name]; ok {
+				title := fmt.Sprintf("%s（实例：%s）", collecttypedef.CollectTypeChineseName[name], instance)
+				res = append(res, reportModule{YTCModule: module, name: name, title: title})
+			}
+		}
+	}
+	return
+}
+
+func (r *YTCReport) instanceNames() []string {
+	names := make([]string, 0, len(r.Instances))
+	for name := range r.Instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetModule sets the result of the module, the module of an instance is keyed by collecttypedef.GenInstanceKey.
+func (r *YTCReport) SetModule(key string, module *datadef.YTCModule) {
+	name, instance := collecttypedef.ParseInstanceKey(key)
+	if stringutil.IsEmpty(instance) {
+		r.Modules[name] = module
+		return
+	}
+	if r.Instances == nil {
+		r.Instances = make(map[string]map[string]*datadef.YTCModule)
+	}
+	if _, ok := r.Instances[instance]; !ok {
+		r.Instances[instance] = make(map[string]*datadef.YTCModule)
+	}
+	r.Instances[instance][name] = module
+}
+
+// allModules returns the host-level modules and the modules of all the instances.
+func (r *YTCReport) allModules() []*datadef.YTCModule {
+	modules := make([]*datadef.YTCModule, 0, len(r.Modules))
 	for _, m := range r.Modules {
+		modules = append(modules, m)
+	}
+	for _, instance := range r.Instances {
+		for _, m := range instance {
+			modules = append(modules, m)
+		}
+	}
+	return modules
+}
+
+func (r *YTCReport) genStoppedItemContent(module string, item datadef.YTCItem, titlePrefix string) reporter.ReportContent {
+	name, ok := _itemChineseName[module][item.Name]
+	if !ok {
+		name = item.Name
+	}
+	ew := commons.ReporterWriter.NewErrorWriter(item.Error, item.Description)
+	return reporter.GenReportContentByWriterAndTitle(ew, fmt.Sprintf("%s %s", titlePrefix, name), reporter.FONT_SIZE_H2)
+}
+
+func (r *YTCReport) GenResult(outputDir string, types map[string]struct{}) (string, error) {
+	for _, m := range r.allModules() {
 		m.FillJSONItems()
 	}
 	genner := resultgenner.BaseResultGenner{
-		Datas:        r.Modules,
+		Datas:        r,
 		CollectTypes: types,
 		OutputDir:    outputDir,
 		Timestamp:    r.CollectBeginTime.Format(timedef.TIME_FORMAT_IN_FILE),
 		PackageName:  r.CollectParam.GetPackageName(),
 		Genner:       r,
+		Stream:       r.stream,
+		Manifest:     r.manifest,
+		ItemPaths:    r.genItemPaths(),
+		EncryptKey:   r.encryptKey,
+		SigningKey:   r.signingKey,
+		Redactor:     r.redactor,
 	}
 	return genner.GenResult()
 }
 
+// SetStream makes the result be packed by the stream, which archives the files of the items while collecting,
+// the files should be recorded in the manifest before they are archived.
+func (r *YTCReport) SetStream(stream *fileutil.TarGzStream) {
+	r.stream = stream
+}
+
+// SetManifest makes the files of the result be listed in the manifest, which has attributed the files to the items
+// while collecting.
+func (r *YTCReport) SetManifest(manifest *resultgenner.Manifest) {
+	r.manifest = manifest
+}
+
+// SetEncryptKey makes the result package be encrypted with the key while packing, it should be set before the stream
+// is created for GetResultPath.
+func (r *YTCReport) SetEncryptKey(key *cryptoutil.EncryptKey) {
+	r.encryptKey = key
+}
+
+// SetSigningKey makes the final result package be signed with the key.
+func (r *YTCReport) SetSigningKey(key ed25519.PrivateKey) {
+	r.signingKey = key
+}
+
+// SetRedactor makes the text files in the package be redacted by the redactor before packing.
+func (r *YTCReport) SetRedactor(redactor *redact.Redactor) {
+	r.redactor = redactor
+}
+
+// genItemPaths returns the paths relative to the package dir in the details of the items, they are the paths in the
+// details such as 'ytc-20230101120000/yasdb/log/alert.log' or the keys of the details of Extra-FileCollect.
+// They attribute the files which are not attributed by the manifest while collecting.
+func (r *YTCReport) genItemPaths() map[string]string {
+	res := make(map[string]string)
+	prefix := r.CollectParam.GetPackageName() + "/"
+	var addPaths func(name string, details interface{})
+	addPaths = func(name string, details interface{}) {
+		var candidates []string
+		switch d := details.(type) {
+		case string:
+			candidates = append(candidates, d)
+		case datadef.TruncatedDetails:
+			candidates = append(candidates, d.Path)
+		case map[string]string:
+			for k := range d {
+				candidates = append(candidates, k)
+			}
+		case map[string]interface{}:
+			// the details loaded from the state of the resumed collection
+			for k, v := range d {
+				candidates = append(candidates, k)
+				if s, ok := v.(string); ok {
+					candidates = append(candidates, s)
+				}
+			}
+		}
+		for _, c := range candidates {
+			if strings.HasPrefix(c, prefix) {
+				res[strings.TrimPrefix(c, prefix)] = name
+			}
+		}
+	}
+	for _, module := range r.allModules() {
+		for name, item := range module.Items() {
+			addPaths(name, item.Details)
+			for _, child := range item.Children {
+				addPaths(name, child.Details)
+			}
+		}
+	}
+	return res
+}
+
 func (r *YTCReport) GetPackageDir() string {
 	genner := resultgenner.BaseResultGenner{
 		OutputDir:   r.CollectParam.Output,
@@ -126,6 +301,27 @@ func (r *YTCReport) GetPackageDir() string {
 	return genner.GetPackageDir()
 }
 
+// GetPackageTarPath returns the path of the result package which will be generated.
+func (r *YTCReport) GetPackageTarPath() string {
+	genner := resultgenner.BaseResultGenner{
+		OutputDir:   r.CollectParam.Output,
+		Timestamp:   r.CollectBeginTime.Format(timedef.TIME_FORMAT_IN_FILE),
+		PackageName: r.CollectParam.GetPackageName(),
+	}
+	return genner.GetPackageTarPath()
+}
+
+// GetResultPath returns the path of the final result, which is the encrypted one if the encrypt key is set.
+func (r *YTCReport) GetResultPath() string {
+	genner := resultgenner.BaseResultGenner{
+		OutputDir:   r.CollectParam.Output,
+		Timestamp:   r.CollectBeginTime.Format(timedef.TIME_FORMAT_IN_FILE),
+		PackageName: r.CollectParam.GetPackageName(),
+		EncryptKey:  r.encryptKey,
+	}
+	return genner.GetResultPath()
+}
+
 func (r *YTCReport) genReportOverview() (content reporter.ReportContent) {
 	titleContent := reporter.GenReportContentByTitle("报告概览", reporter.FONT_SIZE_H1)
 	genTableRows := func(sep string) []table.Row {
@@ -135,7 +331,7 @@ func (r *YTCReport) genReportOverview() (content reporter.ReportContent) {
 		}
 		var modules []string
 		for _, m := range _moduleOrder {
-			if _, ok := r.Modules[m]; ok {
+			if r.hasModule(m) {
 				modules = append(modules, collecttypedef.CollectTypeChineseName[m])
 			}
 		}
@@ -143,16 +339,39 @@ func (r *YTCReport) genReportOverview() (content reporter.ReportContent) {
 			{"收集类型", strings.Join(modules, "，")},
 			{"收集范围--起始时间", r.CollectParam.StartTime.Format(timedef.TIME_FORMAT_UNTIL_MINITE)},
 			{"收集范围--截止时间", r.CollectParam.EndTime.Format(timedef.TIME_FORMAT_UNTIL_MINITE)},
-			{"YashanDB信息--YASDB_HOME", r.CollectParam.YasdbHome},
-			{"YashanDB信息--YASDB_DATA", r.CollectParam.YasdbData},
-			{"数据库用户(用于收集YashanDB信息)", user},
 		}
+		if r.CollectParam.IsMultiInstance() {
+			var instances []string
+			for _, instance := range r.CollectParam.Instances {
+				instances = append(instances, fmt.Sprintf("%s：%s，%s", instance.Name, instance.YasdbHome, instance.YasdbData))
+			}
+			rows = append(rows, table.Row{"YashanDB信息--实例(YASDB_HOME，YASDB_DATA)", strings.Join(instances, sep)})
+		} else if r.CollectParam.IsRemote() {
+			rows = append(rows,
+				table.Row{"YashanDB信息--YASDB_HOME", r.CollectParam.YasdbHome},
+				table.Row{"YashanDB信息--数据库地址(通过TCP连接收集)", r.CollectParam.YasdbAddress},
+			)
+		} else {
+			rows = append(rows,
+				table.Row{"YashanDB信息--YASDB_HOME", r.CollectParam.YasdbHome},
+				table.Row{"YashanDB信息--YASDB_DATA", r.CollectParam.YasdbData},
+			)
+		}
+		rows = append(rows, table.Row{"数据库用户(用于收集YashanDB信息)", user})
 		if len(r.CollectParam.Include) > 0 {
 			rows = append(rows, table.Row{"额外的收集文件", strings.Join(r.CollectParam.Include, sep)})
 		}
 		if len(r.CollectParam.Exclude) > 0 {
 			rows = append(rows, table.Row{"过滤的收集文件", strings.Join(r.CollectParam.Exclude, sep)})
 		}
+		if len(r.UnpackedFiles) > 0 {
+			var files []string
+			for f := range r.UnpackedFiles {
+				files = append(files, fmt.Sprintf("%s：%s", f, r.UnpackedFiles[f]))
+			}
+			sort.Strings(files)
+			rows = append(rows, table.Row{"无法读取而未打包的文件", strings.Join(files, sep)})
+		}
 		rows = append(rows, table.Row{"收集结果存放目录", r.CollectParam.Output})
 		rows = append(rows, table.Row{"任务开始时间", r.CollectBeginTime.Format(timedef.TIME_FORMAT)})
 		rows = append(rows, table.Row{"任务结束时间", r.CollectEndTime.Format(timedef.TIME_FORMAT)})
@@ -179,49 +398,31 @@ func (r *YTCReport) genReportOverview() (content reporter.ReportContent) {
 }
 
 func (r *YTCReport) genModulesAndItems() (modules []string, items [][]string) {
-	for _, m := range _moduleOrder {
-		if module, ok := r.Modules[m]; ok {
-			modules = append(modules, collecttypedef.CollectTypeChineseName[m])
-			tmpItems := module.Items()
-			switch module.Module {
-			case collecttypedef.TYPE_BASE:
-				var names []string
-				for _, item := range _baseItemOrder {
-					if _, ok := tmpItems[item]; ok {
-						names = append(names, baseinfo.BaseInfoChineseName[item])
-					}
-				}
-				items = append(items, names)
-			case collecttypedef.TYPE_DIAG:
-				var names []string
-				for _, item := range _diagItemOrder {
-					if _, ok := tmpItems[item]; ok {
-						names = append(names, diagnosis.DiagChineseName[item])
-					}
-				}
-				items = append(items, names)
-			case collecttypedef.TYPE_PERF:
-				var names []string
-				for _, item := range _perfItemOrder {
-					if _, ok := tmpItems[item]; ok {
-						names = append(names, performance.PerformanceChineseName[item])
-					}
-				}
-				items = append(items, names)
-			case collecttypedef.TYPE_EXTRA:
-				var names []string
-				for _, item := range _extraItemOrder {
-					if _, ok := tmpItems[item]; ok {
-						names = append(names, extra.ExtraChineseName[item])
-					}
-				}
-				items = append(items, names)
-			default:
+	for _, m := range r.orderedModules() {
+		modules = append(modules, m.title)
+		tmpItems := m.Items()
+		var names []string
+		for _, item := range _itemOrder[m.name] {
+			if _, ok := tmpItems[item]; ok {
+				names = append(names, _itemChineseName[m.name][item])
 			}
 		}
+		items = append(items, names)
 	}
 	return
+}
 
+// hasModule reports whether the module is collected on the host or any of the instances.
+func (r *YTCReport) hasModule(name string) bool {
+	if _, ok := r.Modules[name]; ok {
+		return true
+	}
+	for _, instance := range r.Instances {
+		if _, ok := instance[name]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *YTCReport) genReportItems() (content reporter.ReportContent) {
@@ -0,0 +1,172 @@
+package data
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"ytc/defs/collecttypedef"
+	"ytc/defs/errdef"
+	"ytc/defs/regexdef"
+	"ytc/defs/timedef"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/resultgenner"
+	"ytc/internal/modules/ytc/collect/resultgenner/reporter"
+	"ytc/utils/cryptoutil"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+var (
+	ErrDataFileNotFound = errors.New("ytc-<timestamp>.json not found in the collection result")
+)
+
+// LoadYTCReport loads the data file of a collection result, which can be a ytc-*.tar.gz or an extracted directory.
+// The encrypted ytc-*.tar.gz.enc is decrypted with key.
+func LoadYTCReport(input string, key *cryptoutil.DecryptKey) (*YTCReport, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &errdef.ErrFileNotFound{Fname: input}
+		}
+		return nil, err
+	}
+	var (
+		fname string
+		data  []byte
+	)
+	if info.IsDir() {
+		fname, data, err = readDataFromDir(input)
+	} else {
+		fname, data, err = readDataFromTarGz(input, key)
+	}
+	if err != nil {
+		return nil, yaserr.Wrapf(err, "read data file from %s", input)
+	}
+	report, err := parseYTCReport(fname, data)
+	if err != nil {
+		return nil, yaserr.Wrapf(&errdef.ErrFileParseFailed{Fname: fname, Err: err}, "parse data file")
+	}
+	return report, nil
+}
+
+// RegenReport generates the reports of the given types into <outputDir>/<package name>, and returns the report paths.
+func (r *YTCReport) RegenReport(outputDir string, types ...reporter.ReportType) ([]string, error) {
+	genner := resultgenner.BaseResultGenner{
+		OutputDir:   outputDir,
+		Timestamp:   r.CollectParam.GetPackageTimestamp(),
+		PackageName: r.CollectParam.GetPackageName(),
+		Genner:      r,
+	}
+	return genner.GenReport(types...)
+}
+
+func readDataFromDir(dir string) (string, []byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !regexdef.YtcDataFileRegex.MatchString(entry.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(path.Join(dir, entry.Name()))
+		return entry.Name(), data, err
+	}
+	return "", nil, ErrDataFileNotFound
+}
+
+func readDataFromTarGz(fname string, key *cryptoutil.DecryptKey) (string, []byte, error) {
+	f, err := cryptoutil.OpenFile(fname, key)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", nil, err
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return "", nil, ErrDataFileNotFound
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		name := path.Base(header.Name)
+		// the data file is placed in the root of the package dir: ytc-<timestamp>/ytc-<timestamp>.json
+		if header.Typeflag != tar.TypeReg || !regexdef.YtcDataFileRegex.MatchString(name) || strings.Contains(path.Dir(path.Clean(header.Name)), "/") {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		return name, data, err
+	}
+}
+
+func parseYTCReport(fname string, data []byte) (*YTCReport, error) {
+	report := NewYTCReport(&collecttypedef.CollectParam{})
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+	if len(report.Modules) == 0 && len(report.Instances) == 0 {
+		// the data file of early versions only contains modules
+		modules := make(map[string]*datadef.YTCModule)
+		if err := json.Unmarshal(data, &modules); err != nil {
+			return nil, err
+		}
+		report.Modules = modules
+	}
+	if report.CollectParam == nil {
+		report.CollectParam = &collecttypedef.CollectParam{}
+	}
+	// use the timestamp in file name, so the new reports have the same names as the original ones
+	timestamp := regexdef.YtcDataFileRegex.FindStringSubmatch(fname)[1]
+	beginTime, err := time.ParseInLocation(timedef.TIME_FORMAT_IN_FILE, timestamp, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	report.CollectParam.BeginTime = beginTime
+	if report.CollectBeginTime.IsZero() {
+		report.CollectBeginTime = beginTime
+	}
+	fillModules(report.Modules)
+	for _, modules := range report.Instances {
+		fillModules(modules)
+	}
+	return report, nil
+}
+
+func fillModules(modules map[string]*datadef.YTCModule) {
+	for name, module := range modules {
+		if module == nil {
+			delete(modules, name)
+			continue
+		}
+		module.Module = name
+		for itemName, item := range module.JSONItems {
+			if item == nil {
+				continue
+			}
+			item.Name = itemName
+			fillChildrenName(item)
+			module.Set(item)
+		}
+	}
+}
+
+func fillChildrenName(item *datadef.YTCItem) {
+	for name, child := range item.Children {
+		child.Name = name
+		fillChildrenName(&child)
+		item.Children[name] = child
+	}
+}
@@ -2,7 +2,11 @@ package data
 
 import (
 	"ytc/defs/collecttypedef"
+	"ytc/internal/modules/ytc/collect/baseinfo"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/diagnosis"
+	"ytc/internal/modules/ytc/collect/extra"
+	"ytc/internal/modules/ytc/collect/performance"
 )
 
 // module ordera
@@ -62,3 +66,12 @@ var (
 		collecttypedef.TYPE_EXTRA: _extraItemOrder,
 	}
 )
+
+var (
+	_itemChineseName = map[string]map[string]string{
+		collecttypedef.TYPE_BASE:  baseinfo.BaseInfoChineseName,
+		collecttypedef.TYPE_DIAG:  diagnosis.DiagChineseName,
+		collecttypedef.TYPE_PERF:  performance.PerformanceChineseName,
+		collecttypedef.TYPE_EXTRA: extra.ExtraChineseName,
+	}
+)
@@ -49,13 +49,13 @@ func (r HostCPUReporter) Report(item datadef.YTCItem, titlePrefix string) (conte
 func (r HostCPUReporter) parseCPUInfos(cpuInfoItem datadef.YTCItem) (cpuInfos []cpu.InfoStat, err error) {
 	cpuInfos, ok := cpuInfoItem.Details.([]cpu.InfoStat)
 	if !ok {
-		tmp, ok := cpuInfoItem.Details.([]map[string]interface{})
+		tmp, ok := cpuInfoItem.Details.([]interface{})
 		if !ok {
 			err = &commons.ErrInterfaceTypeNotMatch{
 				Key: cpuInfoItem.Name,
 				Targets: []interface{}{
 					[]cpu.InfoStat{},
-					[]map[string]interface{}{},
+					[]interface{}{},
 				},
 				Current: cpuInfoItem.Details,
 			}
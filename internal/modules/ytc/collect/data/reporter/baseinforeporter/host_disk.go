@@ -50,13 +50,13 @@ func (r HostDiskReporter) Report(item datadef.YTCItem, titlePrefix string) (cont
 func (r HostDiskReporter) parseDiskUsage(item datadef.YTCItem) (usages []baseinfo.DiskUsage, err error) {
 	usages, ok := item.Details.([]baseinfo.DiskUsage)
 	if !ok {
-		tmp, ok := item.Details.([]map[string]interface{})
+		tmp, ok := item.Details.([]interface{})
 		if !ok {
 			err = &commons.ErrInterfaceTypeNotMatch{
 				Key: item.Name,
 				Targets: []interface{}{
 					[]disk.UsageStat{},
-					[]map[string]interface{}{},
+					[]interface{}{},
 				},
 				Current: item.Details,
 			}
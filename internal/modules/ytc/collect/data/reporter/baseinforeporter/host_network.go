@@ -51,13 +51,13 @@ func (r HostNetworkReporter) Report(item datadef.YTCItem, titlePrefix string) (c
 func (r HostNetworkReporter) parseNetworkInfo(item datadef.YTCItem) (networks []net.InterfaceStat, err error) {
 	networks, ok := item.Details.([]net.InterfaceStat)
 	if !ok {
-		tmp, ok := item.Details.([]map[string]interface{})
+		tmp, ok := item.Details.([]interface{})
 		if !ok {
 			err = &commons.ErrInterfaceTypeNotMatch{
 				Key: item.Name,
 				Targets: []interface{}{
 					[]net.InterfaceStat{},
-					[]map[string]interface{}{},
+					[]interface{}{},
 				},
 				Current: item.Details,
 			}
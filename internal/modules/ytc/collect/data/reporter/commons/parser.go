@@ -1,6 +1,12 @@
 package commons
 
-import "git.yasdb.com/go/yaserr"
+import (
+	"encoding/json"
+
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+
+	"git.yasdb.com/go/yaserr"
+)
 
 // ParseString converts the data to a string value, and returns an error with name and context if failed.
 func ParseString(name string, data interface{}, context string) (value string, err error) {
@@ -31,3 +37,28 @@ func ParseBool(name string, data interface{}, context string) (value bool, err e
 	}
 	return
 }
+
+// ParsePathDetails converts the data which is a path or datadef.TruncatedDetails, and returns an error with name and context if failed.
+func ParsePathDetails(name string, data interface{}, context string) (details datadef.TruncatedDetails, err error) {
+	switch v := data.(type) {
+	case string:
+		details.Path = v
+		return
+	case datadef.TruncatedDetails:
+		details = v
+		return
+	case map[string]interface{}:
+		bytes, _ := json.Marshal(v)
+		if err = json.Unmarshal(bytes, &details); err != nil {
+			err = yaserr.Wrapf(err, context)
+		}
+		return
+	}
+	err = &ErrInterfaceTypeNotMatch{
+		Key:     name,
+		Targets: []interface{}{"", datadef.TruncatedDetails{}, map[string]interface{}{}},
+		Current: data,
+	}
+	err = yaserr.Wrapf(err, context)
+	return
+}
@@ -0,0 +1,35 @@
+package commons_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/data/reporter/commons"
+)
+
+func TestParsePathDetails(t *testing.T) {
+	truncated := datadef.GenPathDetails("yasdb/log/run.log", []string{"run.log truncated"})
+	// the details are unmarshaled as a map when the report is generated from the json result
+	var unmarshaled interface{}
+	data, _ := json.Marshal(truncated)
+	if err := json.Unmarshal(data, &unmarshaled); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []interface{}{truncated, unmarshaled} {
+		details, err := commons.ParsePathDetails(datadef.DIAG_YASDB_RUNLOG, d, "parse run log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Path != "yasdb/log/run.log" || len(details.Truncations) != 1 {
+			t.Fatalf("unexpected details: %+v", details)
+		}
+	}
+	details, err := commons.ParsePathDetails(datadef.DIAG_YASDB_RUNLOG, datadef.GenPathDetails("yasdb/log/run.log", nil), "parse run log")
+	if err != nil || details.Path != "yasdb/log/run.log" || len(details.Truncations) != 0 {
+		t.Fatalf("unexpected details: %+v, err: %v", details, err)
+	}
+	if _, err := commons.ParsePathDetails(datadef.DIAG_YASDB_RUNLOG, 1, "parse run log"); err == nil {
+		t.Fatal("the int details should be invalid")
+	}
+}
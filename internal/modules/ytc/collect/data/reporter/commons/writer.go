@@ -1,7 +1,11 @@
 package commons
 
 import (
+	"strings"
+
+	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/internal/modules/ytc/collect/resultgenner/reporter"
+	"ytc/utils/stringutil"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 )
@@ -18,3 +22,14 @@ func GenStringWriter(title string, rows ...string) reporter.Writer {
 func GenPathWriter(path string) reporter.Writer {
 	return GenStringWriter("存放路径", path)
 }
+
+// GenPathDetailsWriter writes the path, and the truncations beside it if there are.
+func GenPathDetailsWriter(details datadef.TruncatedDetails) reporter.Writer {
+	if len(details.Truncations) == 0 {
+		return GenPathWriter(details.Path)
+	}
+	tw := ReporterWriter.NewTableWriter()
+	tw.AppendHeader(table.Row{"存放路径", "截断说明"})
+	tw.AppendRow(table.Row{details.Path, strings.Join(details.Truncations, stringutil.STR_NEWLINE)})
+	return tw
+}
@@ -31,7 +31,7 @@ func (r HostKernelLogReporter) Report(item datadef.YTCItem, titlePrefix string)
 	}
 
 	// report host dmesg log
-	demsgLog, err := commons.ParseString(item.Name, item.Details, "parse host dmesg log")
+	demsgLog, err := commons.ParsePathDetails(item.Name, item.Details, "parse host dmesg log")
 	if err != nil {
 		return
 	}
@@ -40,6 +40,6 @@ func (r HostKernelLogReporter) Report(item datadef.YTCItem, titlePrefix string)
 	return
 }
 
-func (r HostKernelLogReporter) genReportContentWriter(demsgLog string) reporter.Writer {
-	return commons.GenPathWriter(demsgLog)
+func (r HostKernelLogReporter) genReportContentWriter(demsgLog datadef.TruncatedDetails) reporter.Writer {
+	return commons.GenPathDetailsWriter(demsgLog)
 }
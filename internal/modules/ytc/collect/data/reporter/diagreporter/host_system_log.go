@@ -72,12 +72,12 @@ func (r HostSystemLogReporter) validateSystemLogItem(item datadef.YTCItem) (mess
 	return
 }
 
-func (r HostSystemLogReporter) parseMessageLogItem(messageLogItem datadef.YTCItem) (messageLog string, err error) {
-	return commons.ParseString(diagnosis.SYSTEM_MESSAGES_LOG, messageLogItem.Details, "parse host message log")
+func (r HostSystemLogReporter) parseMessageLogItem(messageLogItem datadef.YTCItem) (messageLog datadef.TruncatedDetails, err error) {
+	return commons.ParsePathDetails(diagnosis.SYSTEM_MESSAGES_LOG, messageLogItem.Details, "parse host message log")
 }
 
-func (r HostSystemLogReporter) parseSysLogItem(sysLogItem datadef.YTCItem) (sysLog string, err error) {
-	return commons.ParseString(diagnosis.SYSTEM_SYS_LOG, sysLogItem.Details, "parse host sys log")
+func (r HostSystemLogReporter) parseSysLogItem(sysLogItem datadef.YTCItem) (sysLog datadef.TruncatedDetails, err error) {
+	return commons.ParsePathDetails(diagnosis.SYSTEM_SYS_LOG, sysLogItem.Details, "parse host sys log")
 }
 
 func (r HostSystemLogReporter) genMessageLogContent(messageLogItem datadef.YTCItem, titlePrefix string) (messageLogItemContent reporter.ReportContent, err error) {
@@ -92,7 +92,7 @@ func (r HostSystemLogReporter) genMessageLogContent(messageLogItem datadef.YTCIt
 			err = yaserr.Wrapf(e, "parse host message log")
 			return
 		}
-		tw := commons.GenPathWriter(messageLog)
+		tw := commons.GenPathDetailsWriter(messageLog)
 		messageLogItemContent = reporter.GenReportContentByWriterAndTitle(tw, title, fontSize)
 	}
 	return
@@ -110,7 +110,7 @@ func (r HostSystemLogReporter) genSysLogContent(sysLogItem datadef.YTCItem, titl
 			err = yaserr.Wrapf(e, "parse host sys log")
 			return
 		}
-		tw := commons.GenPathWriter(sysLog)
+		tw := commons.GenPathDetailsWriter(sysLog)
 		sysLogContent = reporter.GenReportContentByWriterAndTitle(tw, title, fontSize)
 	}
 	return
@@ -31,7 +31,7 @@ func (r YashanDBAlertLogReporter) Report(item datadef.YTCItem, titlePrefix strin
 	}
 
 	// report yasdb alert log
-	alertLog, err := commons.ParseString(item.Name, item.Details, "parse yasdb alert log")
+	alertLog, err := commons.ParsePathDetails(item.Name, item.Details, "parse yasdb alert log")
 	if err != nil {
 		return
 	}
@@ -40,6 +40,6 @@ func (r YashanDBAlertLogReporter) Report(item datadef.YTCItem, titlePrefix strin
 	return
 }
 
-func (r YashanDBAlertLogReporter) genReportContentWriter(alertLog string) reporter.Writer {
-	return commons.GenPathWriter(alertLog)
+func (r YashanDBAlertLogReporter) genReportContentWriter(alertLog datadef.TruncatedDetails) reporter.Writer {
+	return commons.GenPathDetailsWriter(alertLog)
 }
@@ -31,7 +31,7 @@ func (r YashanDBCoreDumpReporter) Report(item datadef.YTCItem, titlePrefix strin
 	}
 
 	// report yasdb coredump
-	coreDump, err := commons.ParseString(item.Name, item.Details, "parse yasdb coredump")
+	coreDump, err := commons.ParsePathDetails(item.Name, item.Details, "parse yasdb coredump")
 	if err != nil {
 		return
 	}
@@ -40,6 +40,6 @@ func (r YashanDBCoreDumpReporter) Report(item datadef.YTCItem, titlePrefix strin
 	return
 }
 
-func (r YashanDBCoreDumpReporter) genReportContentWriter(coreDump string) reporter.Writer {
-	return commons.GenPathWriter(coreDump)
+func (r YashanDBCoreDumpReporter) genReportContentWriter(coreDump datadef.TruncatedDetails) reporter.Writer {
+	return commons.GenPathDetailsWriter(coreDump)
 }
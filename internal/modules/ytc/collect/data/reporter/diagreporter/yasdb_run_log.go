@@ -31,7 +31,7 @@ func (r YashanDBRunLogReporter) Report(item datadef.YTCItem, titlePrefix string)
 	}
 
 	// report yasdb run log
-	runLog, err := commons.ParseString(item.Name, item.Details, "parse yasdb run log")
+	runLog, err := commons.ParsePathDetails(item.Name, item.Details, "parse yasdb run log")
 	if err != nil {
 		return
 	}
@@ -40,6 +40,6 @@ func (r YashanDBRunLogReporter) Report(item datadef.YTCItem, titlePrefix string)
 	return
 }
 
-func (r YashanDBRunLogReporter) genReportContentWriter(runLog string) reporter.Writer {
-	return commons.GenPathWriter(runLog)
+func (r YashanDBRunLogReporter) genReportContentWriter(runLog datadef.TruncatedDetails) reporter.Writer {
+	return commons.GenPathDetailsWriter(runLog)
 }
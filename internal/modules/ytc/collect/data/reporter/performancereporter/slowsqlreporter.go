@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 
+	"ytc/defs/confdef"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/internal/modules/ytc/collect/data/reporter/commons"
 	"ytc/internal/modules/ytc/collect/performance"
@@ -68,6 +71,7 @@ func (r SlowSqlReporter) genExistChildren(slowSQL datadef.YTCItem) (res []string
 		performance.KEY_SLOW_SQL_PARAMETER,
 		performance.KEY_SLOW_SQL_LOGS_IN_TABLE,
 		performance.KEY_SLOW_SQL_LOGS_IN_FILE,
+		performance.KEY_SLOW_SQL_SUMMARY,
 	}
 	for _, c := range children {
 		if _, ok := slowSQL.Children[c]; ok {
@@ -93,6 +97,7 @@ func (r SlowSqlReporter) allChildrenReportFunc() (funMap map[string]genReportFun
 		performance.KEY_SLOW_SQL_PARAMETER:     r.genSlowParamContent,
 		performance.KEY_SLOW_SQL_LOGS_IN_TABLE: r.genSlowLogsInTableContent,
 		performance.KEY_SLOW_SQL_LOGS_IN_FILE:  r.genSlowLogsInFileContent,
+		performance.KEY_SLOW_SQL_SUMMARY:       r.genSlowSummaryContent,
 	}
 }
 
@@ -163,11 +168,79 @@ func (r SlowSqlReporter) genSlowLogsInTableContent(titlePrefix string, index int
 	if err != nil {
 		return
 	}
-	writer := r.genSlowLogWriter(slowLogs)
+	var writer reporter.Writer
+	if summary, ok := slowSQL.Children[performance.KEY_SLOW_SQL_SUMMARY]; ok && summary.Description != ytccollectcommons.SLOW_LOG {
+		// the slow logs are aggregated in the summary, the raw ones are only kept in the data file
+		writer = commons.GenStringWriter("说明", fmt.Sprintf("共 %d 条慢SQL，已按SQL指纹汇总至%s，原始记录保存在数据文件中", len(slowLogs), performance.PerformanceChildChineseName[performance.KEY_SLOW_SQL_SUMMARY]))
+	} else {
+		writer = r.genSlowLogWriter(slowLogs)
+	}
+	content = reporter.GenReportContentByWriterAndTitle(writer, title, fontSize)
+	return
+}
+
+func (r SlowSqlReporter) genSlowSummaryContent(titlePrefix string, index int, slowSQL datadef.YTCItem) (content reporter.ReportContent, err error) {
+	title := fmt.Sprintf("%s.%d %s", titlePrefix, index, performance.PerformanceChildChineseName[performance.KEY_SLOW_SQL_SUMMARY])
+	fontSize := reporter.FONT_SIZE_H3
+	summaryItem, ok := slowSQL.Children[performance.KEY_SLOW_SQL_SUMMARY]
+	if !ok {
+		return
+	}
+	summaries, err := r.parseSlowSummaries(summaryItem)
+	if err != nil {
+		return
+	}
+	writer := r.genSlowSummaryWriter(summaries, summaryItem.Description, confdef.GetStrategyConf().Report.GetSlowSQLTopN())
 	content = reporter.GenReportContentByWriterAndTitle(writer, title, fontSize)
 	return
 }
 
+// genSlowSummaryWriter writes the top n summaries, which are sorted by the total time, the sql ids of each fingerprint
+// are listed with their own counts and total times.
+func (r SlowSqlReporter) genSlowSummaryWriter(summaries []*performance.SlowSQLSummary, source string, topN int) reporter.Writer {
+	tw := commons.ReporterWriter.NewTableWriter()
+	var captions []string
+	if !stringutil.IsEmpty(source) {
+		captions = append(captions, fmt.Sprintf("数据来源：%s", source))
+	}
+	tw.AppendHeader(table.Row{"排名", "SQL指纹", "SQL_ID(执行次数/总耗时ms)", "执行次数", "总耗时(ms)", "平均耗时(ms)", "P95耗时(ms)", "最大耗时(ms)", "返回行数", "用户", "连接IP"})
+	for i, s := range summaries {
+		if i >= topN {
+			captions = append(captions, fmt.Sprintf("共 %d 个SQL指纹，仅展示总耗时最长的前 %d 个", len(summaries), topN))
+			break
+		}
+		tw.AppendRow(table.Row{
+			i + 1,
+			s.Fingerprint,
+			r.genSQLIDStats(s),
+			s.Count,
+			s.TotalTime,
+			s.AvgTime,
+			s.P95Time,
+			s.MaxTime,
+			s.RowsSent,
+			strings.Join(s.Users, stringutil.STR_NEWLINE),
+			strings.Join(s.Hosts, stringutil.STR_NEWLINE),
+		})
+	}
+	if len(captions) != 0 {
+		tw.SetCaption(strings.Join(captions, "；"))
+	}
+	return tw
+}
+
+// genSQLIDStats falls back to the sql ids for the data files without the stats of them.
+func (r SlowSqlReporter) genSQLIDStats(s *performance.SlowSQLSummary) string {
+	if len(s.SQLIDStats) == 0 {
+		return strings.Join(s.SQLIDs, stringutil.STR_NEWLINE)
+	}
+	lines := make([]string, 0, len(s.SQLIDStats))
+	for _, stats := range s.SQLIDStats {
+		lines = append(lines, fmt.Sprintf("%s (%d/%v)", stats.SQLID, stats.Count, stats.TotalTime))
+	}
+	return strings.Join(lines, stringutil.STR_NEWLINE)
+}
+
 func (r SlowSqlReporter) genSlowLogWriter(slowLogs []*yasdb.SlowLog) reporter.Writer {
 	tw := commons.ReporterWriter.NewTableWriter()
 	tw.AppendHeader(table.Row{"DATABASE_NAME", "USER_NAME", "START_TIME", "USER_HOST", "QUERY_TIME", "ROWS_SENT", "SQL_ID", "SQL_TEXT"})
@@ -235,3 +308,28 @@ func (r SlowSqlReporter) parseSlowLogs(slowLogsItem datadef.YTCItem) (logs []*ya
 	}
 	return
 }
+
+func (r SlowSqlReporter) parseSlowSummaries(summaryItem datadef.YTCItem) (summaries []*performance.SlowSQLSummary, err error) {
+	summaries, ok := summaryItem.Details.([]*performance.SlowSQLSummary)
+	if !ok {
+		tmp, ok := summaryItem.Details.([]interface{})
+		if !ok {
+			err = &commons.ErrInterfaceTypeNotMatch{
+				Key: performance.KEY_SLOW_SQL_SUMMARY,
+				Targets: []interface{}{
+					[]interface{}{},
+					[]*performance.SlowSQLSummary{},
+				},
+				Current: summaryItem.Details,
+			}
+			err = yaserr.Wrapf(err, "parse slow sql summaries")
+			return
+		}
+		data, _ := json.Marshal(tmp)
+		if err = json.Unmarshal(data, &summaries); err != nil {
+			err = yaserr.Wrapf(err, "unmarshal slow sql summaries")
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package data
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"ytc/defs/collecttypedef"
+	"ytc/defs/errdef"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/utils/fileutil"
+
+	"git.yasdb.com/go/yaserr"
+)
+
+// STATE_FILE_NAME is the state file in the package dir, it is removed before packing.
+const STATE_FILE_NAME = "ytc-state.json"
+
+// CollectState records the collect param and the completed items of a collection, so that it can be resumed
+// after being interrupted. The password is never saved.
+type CollectState struct {
+	Types        []string                      `json:"types"`
+	BeginTime    time.Time                     `json:"beginTime"`
+	CollectParam *collecttypedef.CollectParam  `json:"collectParam"`
+	Modules      map[string]*datadef.YTCModule `json:"modules"` // the completed items
+	packageDir   string
+	mtx          sync.Mutex
+}
+
+func NewCollectState(packageDir string, types map[string]struct{}, param *collecttypedef.CollectParam) *CollectState {
+	state := &CollectState{
+		BeginTime:    param.BeginTime,
+		CollectParam: param,
+		Modules:      make(map[string]*datadef.YTCModule),
+		packageDir:   packageDir,
+	}
+	for t := range types {
+		state.Types = append(state.Types, t)
+	}
+	sort.Strings(state.Types)
+	return state
+}
+
+// LoadCollectState loads the state file in the package dir of an interrupted collection.
+func LoadCollectState(packageDir string) (*CollectState, error) {
+	fname := path.Join(packageDir, STATE_FILE_NAME)
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &errdef.ErrFileNotFound{Fname: fname}
+		}
+		return nil, err
+	}
+	state := &CollectState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, yaserr.Wrapf(&errdef.ErrFileParseFailed{Fname: fname, Err: err}, "parse state file")
+	}
+	if state.CollectParam == nil {
+		state.CollectParam = &collecttypedef.CollectParam{}
+	}
+	if state.Modules == nil {
+		state.Modules = make(map[string]*datadef.YTCModule)
+	}
+	state.CollectParam.BeginTime = state.BeginTime
+	// the package dir may be moved, the result is packed beside it
+	state.CollectParam.Output = path.Dir(path.Clean(packageDir))
+	state.packageDir = packageDir
+	for name, module := range state.Modules {
+		if module == nil {
+			delete(state.Modules, name)
+			continue
+		}
+		module.Module = name
+		for itemName, item := range module.JSONItems {
+			if item == nil {
+				continue
+			}
+			item.Name = itemName
+			fillChildrenName(item)
+			module.Set(item)
+		}
+	}
+	return state, nil
+}
+
+// TypeMap returns the collect types in the form used by the collecter handler.
+func (s *CollectState) TypeMap() map[string]struct{} {
+	types := make(map[string]struct{})
+	for _, t := range s.Types {
+		types[t] = struct{}{}
+	}
+	return types
+}
+
+// CompletedItems returns the completed items of the module.
+func (s *CollectState) CompletedItems(module string) map[string]*datadef.YTCItem {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	m, ok := s.Modules[module]
+	if !ok {
+		return make(map[string]*datadef.YTCItem)
+	}
+	return m.Items()
+}
+
+func (s *CollectState) IsCompleted(module, item string) bool {
+	_, ok := s.CompletedItems(module)[item]
+	return ok
+}
+
+// Complete records the completed item and saves the state file.
+func (s *CollectState) Complete(module string, item *datadef.YTCItem) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	m, ok := s.Modules[module]
+	if !ok {
+		m = &datadef.YTCModule{Module: module}
+		s.Modules[module] = m
+	}
+	m.Set(item)
+	return s.save()
+}
+
+func (s *CollectState) Save() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.save()
+}
+
+// Remove removes the state file, the collection can not be resumed any more.
+func (s *CollectState) Remove() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if err := os.Remove(s.fname()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *CollectState) save() error {
+	for _, m := range s.Modules {
+		m.FillJSONItems()
+	}
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(s.fname(), data, fileutil.DEFAULT_FILE_MODE)
+}
+
+func (s *CollectState) fname() string {
+	return path.Join(s.packageDir, STATE_FILE_NAME)
+}
@@ -0,0 +1,67 @@
+package data_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"ytc/defs/collecttypedef"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/data"
+	"ytc/utils/pwdutil"
+)
+
+func TestCollectState(t *testing.T) {
+	packageDir := path.Join(t.TempDir(), "ytc-20230101120000")
+	if err := os.Mkdir(packageDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	param := &collecttypedef.CollectParam{
+		YasdbHome:     "/home/yashan/yasdb_home",
+		YasdbUser:     "sys",
+		YasdbPassword: pwdutil.NewSecret("yasdb_123"),
+		BeginTime:     time.Date(2023, 1, 1, 12, 0, 0, 0, time.Local),
+		Items:         []string{datadef.BASE_HOST_CPU, datadef.DIAG_YASDB_ADR},
+	}
+	types := map[string]struct{}{collecttypedef.TYPE_BASE: {}, collecttypedef.TYPE_DIAG: {}}
+	state := data.NewCollectState(packageDir, types, param)
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Complete(collecttypedef.TYPE_BASE, &datadef.YTCItem{Name: datadef.BASE_HOST_CPU, Details: "cpu"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path.Join(packageDir, data.STATE_FILE_NAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "yasdb_123") {
+		t.Fatalf("the password is saved: %s", content)
+	}
+
+	loaded, err := data.LoadCollectState(packageDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.CollectParam.BeginTime.Equal(param.BeginTime) || loaded.CollectParam.GetPackageName() != path.Base(packageDir) {
+		t.Fatalf("unexpected package name: %s", loaded.CollectParam.GetPackageName())
+	}
+	if loaded.CollectParam.Output != path.Dir(packageDir) || len(loaded.CollectParam.Items) != 2 || len(loaded.TypeMap()) != 2 {
+		t.Fatalf("unexpected collect param: %+v", loaded.CollectParam)
+	}
+	if !loaded.IsCompleted(collecttypedef.TYPE_BASE, datadef.BASE_HOST_CPU) || loaded.IsCompleted(collecttypedef.TYPE_DIAG, datadef.DIAG_YASDB_ADR) {
+		t.Fatalf("unexpected completed items: %+v", loaded.Modules)
+	}
+	if item := loaded.CompletedItems(collecttypedef.TYPE_BASE)[datadef.BASE_HOST_CPU]; item.Name != datadef.BASE_HOST_CPU || item.Details != "cpu" {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+
+	if err := loaded.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := data.LoadCollectState(packageDir); err == nil {
+		t.Fatal("the state file is not removed")
+	}
+}
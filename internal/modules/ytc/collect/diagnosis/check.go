@@ -17,11 +17,10 @@ import (
 	"ytc/utils/processutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/userutil"
-	"ytc/utils/yasqlutil"
 )
 
 func GetAdrPath(collectParam *collecttypedef.CollectParam) (string, error) {
-	tx := yasqlutil.GetLocalInstance(collectParam.YasdbUser, collectParam.YasdbPassword, collectParam.YasdbHome, collectParam.YasdbData)
+	tx := ytccollectcommons.NewYasql(collectParam)
 	dest, err := yasdb.QueryParameter(tx, yasdb.PM_DIAGNOSTIC_DEST)
 	return strings.ReplaceAll(dest, stringutil.STR_QUESTION_MARK, collectParam.YasdbData), err
 }
@@ -66,7 +65,7 @@ func GetCoreDumpPath() (string, string, error) {
 }
 
 func GetYasdbRunLogPath(collectParam *collecttypedef.CollectParam) (string, error) {
-	tx := yasqlutil.GetLocalInstance(collectParam.YasdbUser, collectParam.YasdbPassword, collectParam.YasdbHome, collectParam.YasdbData)
+	tx := ytccollectcommons.NewYasql(collectParam)
 	dest, err := yasdb.QueryParameter(tx, yasdb.PM_RUN_LOG_FILE_PATH)
 	return strings.ReplaceAll(dest, stringutil.STR_QUESTION_MARK, collectParam.YasdbData), err
 }
@@ -109,6 +108,10 @@ func (d *DiagCollecter) checkYasdbInstanceStatus() *ytccollectcommons.NoAccessRe
 	err := fileutil.CheckAccess(yasql)
 	if err != nil {
 		desc, tips := ytccollectcommons.PathErrDescAndTips(yasql, err)
+		if d.IsRemote() {
+			ytccollectcommons.FillDescTips(noAccess, desc, tips)
+			return noAccess
+		}
 		procs, processErr := processutil.GetYasdbProcess(d.YasdbData)
 		if processErr != nil || len(procs) == 0 {
 			ytccollectcommons.FillDescTips(noAccess, desc, tips)
@@ -2,8 +2,10 @@ package diagnosis
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path"
 	"sort"
@@ -11,23 +13,26 @@ import (
 	"strings"
 	"time"
 
+	"ytc/defs/confdef"
 	"ytc/defs/timedef"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/timeutil"
 
 	"git.yasdb.com/go/yaslog"
+	"git.yasdb.com/go/yasutil/size"
 )
 
-func (b *DiagCollecter) collectHostLog(log yaslog.YasLog, src, dest string, prefix string) (err error) {
+func (b *DiagCollecter) collectHostLog(ctx context.Context, log yaslog.YasLog, w *logWriter, src, dest string, prefix string) (err error) {
 	hasSetDateext, err := b.hasSetDateext()
 	if err != nil {
 		return
 	}
 	if hasSetDateext {
-		return b.collectHostLogWithSetDateext(log, src, dest, prefix)
+		return b.collectHostLogWithSetDateext(ctx, log, w, src, dest, prefix)
 	}
-	return b.collectHostLogWithoutSetDateext(log, src, dest)
+	return b.collectHostLogWithoutSetDateext(ctx, log, w, src, dest)
 }
 
 func (b *DiagCollecter) hostLogTimeParse(date time.Time, line string) (t time.Time, err error) {
@@ -54,7 +59,7 @@ func (b *DiagCollecter) hostLogTimeParse(date time.Time, line string) (t time.Ti
 	return
 }
 
-func (b *DiagCollecter) collectHostLogWithSetDateext(log yaslog.YasLog, src, dest string, prefix string) (err error) {
+func (b *DiagCollecter) collectHostLogWithSetDateext(ctx context.Context, log yaslog.YasLog, w *logWriter, src, dest string, prefix string) (err error) {
 	var srcs []string
 	srcs, err = b.getLogFiles(log, path.Dir(src), prefix)
 	if err != nil {
@@ -110,8 +115,11 @@ func (b *DiagCollecter) collectHostLogWithSetDateext(log yaslog.YasLog, src, des
 				continue
 			}
 		}
-		if err = b.collectLog(log, logFile, dest, date, b.hostLogTimeParse); err != nil {
+		if err = b.collectLog(ctx, log, w, logFile, dest, date, b.hostLogTimeParse); err != nil {
 			log.Errorf("failed to collect from: %s, err: %s", logFile, err.Error())
+			if ctx.Err() != nil {
+				return
+			}
 			continue
 		}
 		log.Debugf("succeed to collect %s", logFile)
@@ -119,7 +127,7 @@ func (b *DiagCollecter) collectHostLogWithSetDateext(log yaslog.YasLog, src, des
 	return
 }
 
-func (b *DiagCollecter) collectHostLogWithoutSetDateext(log yaslog.YasLog, src, dest string) (err error) {
+func (b *DiagCollecter) collectHostLogWithoutSetDateext(ctx context.Context, log yaslog.YasLog, w *logWriter, src, dest string) (err error) {
 	// get log file last modify time
 	srcInfo, err := os.Stat(src)
 	if err != nil {
@@ -130,7 +138,7 @@ func (b *DiagCollecter) collectHostLogWithoutSetDateext(log yaslog.YasLog, src,
 		log.Infof("log %s last modify time is %s, skip", src, srcModTime)
 		return
 	}
-	return b.reverseCollectLog(log, src, dest, srcModTime, b.hostLogTimeParse)
+	return b.reverseCollectLog(ctx, log, w, src, dest, srcModTime, b.hostLogTimeParse)
 }
 
 func (b *DiagCollecter) hasSetDateext() (res bool, err error) {
@@ -168,13 +176,10 @@ func (b *DiagCollecter) getLogFiles(log yaslog.YasLog, logPath string, prefix st
 	return
 }
 
-// some log may not contain date info in the log file content, but in the log name
-func (b *DiagCollecter) collectLog(log yaslog.YasLog, src, dest string, date time.Time, timeParseFunc logTimeParseFunc) (err error) {
-	destFile, err := os.OpenFile(dest, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileutil.DEFAULT_FILE_MODE)
-	if err != nil {
-		return
-	}
-	defer destFile.Close()
+// some log may not contain date info in the log file content, but in the log name.
+// The collection stops once ctx is done, the lines written into dest are kept.
+func (b *DiagCollecter) collectLog(ctx context.Context, log yaslog.YasLog, w *logWriter, src, dest string, date time.Time, timeParseFunc logTimeParseFunc) (err error) {
+	w.addDest(dest)
 	srcFile, err := os.Open(src)
 	if err != nil {
 		return
@@ -184,6 +189,9 @@ func (b *DiagCollecter) collectLog(log yaslog.YasLog, src, dest string, date tim
 	var t time.Time
 	scanner := bufio.NewScanner(srcFile)
 	for scanner.Scan() {
+		if err = ctx.Err(); err != nil {
+			return
+		}
 		txt := scanner.Text()
 		line := stringutil.RemoveExtraSpaces(strings.TrimSpace(txt))
 		if stringutil.IsEmpty(line) {
@@ -199,8 +207,7 @@ func (b *DiagCollecter) collectLog(log yaslog.YasLog, src, dest string, date tim
 		if t.After(b.EndTime) {
 			break
 		}
-		_, err = destFile.WriteString(txt + stringutil.STR_NEWLINE)
-		if err != nil {
+		if err = w.writeLine(dest, txt); err != nil {
 			return
 		}
 	}
@@ -208,73 +215,242 @@ func (b *DiagCollecter) collectLog(log yaslog.YasLog, src, dest string, date tim
 	return
 }
 
-func (b *DiagCollecter) reverseCollectLog(log yaslog.YasLog, src, dest string, date time.Time, timeParseFunc logTimeParseFunc) (err error) {
-	// open tmp file
-	tmp := fmt.Sprintf("%s.temp", dest)
-	tmpFile, err := os.OpenFile(tmp, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileutil.DEFAULT_FILE_MODE)
-	if err != nil {
+// reverseCollectLog reads the lines of src from the last one until the first line before the start time, and writes
+// them into dest in the original order. The lines which fit are counted before writing, so that they are written
+// from the end of dest backwards without a temporary file.
+func (b *DiagCollecter) reverseCollectLog(ctx context.Context, log yaslog.YasLog, w *logWriter, src, dest string, date time.Time, timeParseFunc logTimeParseFunc) (err error) {
+	w.addDest(dest)
+	scan := func(fn func(line string) (bool, error)) error {
+		// open src file in reverse order
+		reverseSrcFile, err := fileutil.NewReverseFile(src)
+		if err != nil {
+			return err
+		}
+		defer reverseSrcFile.Close()
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			line, err := reverseSrcFile.ReadLine()
+			if err != nil {
+				if err == io.EOF {
+					// read to end
+					return nil
+				}
+				return err
+			}
+			t, err := timeParseFunc(date, stringutil.RemoveExtraSpaces(strings.TrimSpace(line)))
+			if err != nil {
+				return err
+			}
+			if t.After(b.EndTime) {
+				continue
+			}
+			if t.Before(b.StartTime) {
+				return nil
+			}
+			if ok, err := fn(line); !ok || err != nil {
+				return err
+			}
+		}
+	}
+	if w.counting {
+		return scan(func(line string) (bool, error) {
+			return true, w.writeLine(dest, line)
+		})
+	}
+	var kept int64
+	keep := w.keepBytes(dest)
+	if err = scan(func(line string) (bool, error) {
+		n := int64(len(line) + 1)
+		if kept+n > keep {
+			return false, nil
+		}
+		kept += n
+		return true, nil
+	}); err != nil {
 		return
 	}
-	defer os.Remove(tmp)
-	// open src file in reverse order
-	reverseSrcFile, err := fileutil.NewReverseFile(src)
+	var removed int64
+	if w.limit > 0 {
+		removed = w.sizes[dest] - kept
+	}
+	f, offset, err := w.open(dest, removed)
 	if err != nil {
 		return
 	}
-	defer reverseSrcFile.Close()
-	for {
-		line, e := reverseSrcFile.ReadLine()
-		if e != nil {
-			if e == io.EOF {
-				// read to end
-				break
-			}
-			err = e
-			return
-		}
-		var t time.Time
-		t, err = timeParseFunc(date, stringutil.RemoveExtraSpaces(strings.TrimSpace(line)))
-		if err != nil {
-			return
-		}
-		if t.After(b.EndTime) {
-			continue
+	pos := offset + kept
+	if err = scan(func(line string) (bool, error) {
+		n := int64(len(line) + 1)
+		if pos-n < offset {
+			return false, nil
 		}
-		if t.Before(b.StartTime) {
-			break
+		pos -= n
+		_, err := f.WriteAt([]byte(line+stringutil.STR_NEWLINE), pos)
+		return true, err
+	}); err != nil {
+		return
+	}
+	if _, err = f.Seek(offset+kept, io.SeekStart); err != nil {
+		return
+	}
+	log.Debugf("succeed to write log file %s to %s", src, dest)
+	return
+}
+
+// logWriter writes the lines of the logs collected by an item into the dest files, all the dest files of the item
+// are kept within max_log_bytes_per_item of the strategy. The item collects its logs twice with it if the limit is
+// set, the lines are only counted by the first pass, and the second pass drops the head of the dest files which do
+// not fit, so that no more than the limit is written to disk.
+type logWriter struct {
+	limit    int64            // 0 means no limit, the lines are written by the only pass then
+	counting bool             // the lines are counted but not written
+	sizes    map[string]int64 // the bytes of the lines of each dest file counted by the first pass
+	keeps    map[string]int64 // the bytes kept in each dest file, which are shared out of the limit
+	removed  map[string]int64 // the bytes dropped at the head of each dest file
+	written  map[string]int64
+	dests    map[string]struct{} // the dest files are created even if they have no lines
+	files    map[string]*os.File
+}
+
+// collectLogs runs collect to collect the logs of the item with the logWriter, collect runs twice if
+// max_log_bytes_per_item is set, see logWriter. The logWriter is returned to get the truncations of the dest files.
+func (b *DiagCollecter) collectLogs(log yaslog.YasLog, collect func(w *logWriter) error) (*logWriter, error) {
+	collectConf := confdef.GetStrategyConf().Collect
+	limit, err := collectConf.GetMaxLogBytesPerItem()
+	if err != nil {
+		log.Warnf("failed to parse max_log_bytes_per_item: %s, err: %v, it is not limited", collectConf.MaxLogBytesPerItem, err)
+		limit = 0
+	}
+	w := &logWriter{
+		limit:   limit,
+		sizes:   make(map[string]int64),
+		keeps:   make(map[string]int64),
+		removed: make(map[string]int64),
+		written: make(map[string]int64),
+		dests:   make(map[string]struct{}),
+		files:   make(map[string]*os.File),
+	}
+	if limit > 0 {
+		w.counting = true
+		if err := collect(w); err != nil {
+			return w, err
 		}
-		// write to tmp file
-		if _, err = tmpFile.WriteString(line + stringutil.STR_NEWLINE); err != nil {
-			return
+		w.counting = false
+		w.shareLimit()
+	}
+	err = collect(w)
+	if e := w.close(); e != nil && err == nil {
+		err = e
+	}
+	return w, err
+}
+
+// shareLimit shares the limit among the dest files, the dest files smaller than their shares keep all their lines,
+// and the rest of their shares are shared by the others.
+func (w *logWriter) shareLimit() {
+	dests := make([]string, 0, len(w.sizes))
+	for dest := range w.sizes {
+		dests = append(dests, dest)
+	}
+	sort.Slice(dests, func(i, j int) bool {
+		return w.sizes[dests[i]] < w.sizes[dests[j]]
+	})
+	left := w.limit
+	for i, dest := range dests {
+		keep := left / int64(len(dests)-i)
+		if w.sizes[dest] < keep {
+			keep = w.sizes[dest]
 		}
+		w.keeps[dest] = keep
+		left -= keep
+	}
+}
+
+func (w *logWriter) keepBytes(dest string) int64 {
+	if w.limit <= 0 {
+		return math.MaxInt64
 	}
-	// reverse open tmp file
-	reverseTmpFile, err := fileutil.NewReverseFile(tmp)
+	return w.keeps[dest]
+}
+
+// writeLine writes the line into dest, the lines at the head are dropped until the rest of dest fit,
+// and the lines appended to the log after counting are dropped if they do not fit.
+func (w *logWriter) writeLine(dest, line string) error {
+	n := int64(len(line) + 1)
+	if w.counting {
+		w.sizes[dest] += n
+		return nil
+	}
+	keep := w.keepBytes(dest)
+	if _, ok := w.files[dest]; !ok && w.limit > 0 && w.sizes[dest]-w.removed[dest] > keep {
+		w.removed[dest] += n
+		return nil
+	}
+	if w.written[dest]+n > keep {
+		return nil
+	}
+	f, _, err := w.open(dest, w.removed[dest])
 	if err != nil {
-		return
+		return err
+	}
+	if _, err := f.WriteString(line + stringutil.STR_NEWLINE); err != nil {
+		return err
 	}
-	defer reverseTmpFile.Close()
-	// open dest file
-	destFile, err := os.OpenFile(dest, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileutil.DEFAULT_FILE_MODE)
+	w.written[dest] += n
+	return nil
+}
+
+// open opens dest for writing at the first time, the truncation marker is written at its first line if the head of
+// the lines is removed. It returns the offset after the marker.
+func (w *logWriter) open(dest string, removed int64) (*os.File, int64, error) {
+	if f, ok := w.files[dest]; ok {
+		offset, err := f.Seek(0, io.SeekCurrent)
+		return f, offset, err
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fileutil.DEFAULT_FILE_MODE)
 	if err != nil {
-		return
+		return nil, 0, err
 	}
-	defer destFile.Close()
-	for {
-		line, e := reverseTmpFile.ReadLine()
-		if e != nil {
-			if e == io.EOF {
-				// read to end
-				break
-			}
+	w.files[dest] = f
+	w.removed[dest] = removed
+	if removed <= 0 {
+		return f, 0, nil
+	}
+	marker := fmt.Sprintf(LOG_TRUNCATED_MARKER, removed, confdef.GetStrategyConf().Collect.MaxLogBytesPerItem) + stringutil.STR_NEWLINE
+	if _, err := f.WriteString(marker); err != nil {
+		return nil, 0, err
+	}
+	return f, int64(len(marker)), nil
+}
+
+func (w *logWriter) addDest(dest string) {
+	w.dests[dest] = struct{}{}
+}
+
+// close creates the dest files which have no lines written and closes all the dest files.
+func (w *logWriter) close() (err error) {
+	for dest := range w.dests {
+		if _, ok := w.files[dest]; ok {
+			continue
+		}
+		if _, _, e := w.open(dest, w.removed[dest]); e != nil && err == nil {
 			err = e
-			return
 		}
-		// write to dest file
-		if _, err = destFile.WriteString(line + stringutil.STR_NEWLINE); err != nil {
-			return
+	}
+	for _, f := range w.files {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
 		}
 	}
-	log.Debugf("succeed to write log file %s to %s", src, dest)
+	return
+}
+
+// truncations returns the truncation of dest to be recorded in the details of the item.
+func (w *logWriter) truncations(dest string) (truncations []string) {
+	if removed := w.removed[dest]; removed > 0 {
+		limit := confdef.GetStrategyConf().Collect.MaxLogBytesPerItem
+		truncations = append(truncations, datadef.GenTruncatedLogDesc(path.Base(dest), limit, size.GenHumanReadableSize(float64(removed), 2)))
+	}
 	return
 }
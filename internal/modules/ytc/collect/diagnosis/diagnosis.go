@@ -49,6 +49,9 @@ const (
 	LOG_FILE_SUFFIX = "%s.log"
 	TAR_FILE_SUFFIX = "%s.tar.gz"
 
+	// the first line of the log file whose head is truncated by max_log_bytes_per_item
+	LOG_TRUNCATED_MARKER = "[ytc] %d bytes at the head of the log are truncated by max_log_bytes_per_item: %s"
+
 	CORE_FILE_KEY = "core"
 )
 
@@ -67,8 +70,6 @@ var (
 	}
 )
 
-var _packageDir = ""
-
 type logTimeParseFunc func(date time.Time, line string) (time.Time, error)
 
 type DiagCollecter struct {
@@ -76,6 +77,7 @@ type DiagCollecter struct {
 	ModuleCollectRes *datadef.YTCModule
 	yasdbValidateErr error
 	notConnectDB     bool
+	packageDir       string
 }
 
 func NewDiagCollecter(collectParam *collecttypedef.CollectParam) *DiagCollecter {
@@ -108,6 +110,9 @@ func (d *DiagCollecter) CheckAccess(yasdbValidate error) (noAccess []ytccollectc
 	noAccess = make([]ytccollectcommons.NoAccessRes, 0)
 	funcMap := d.CheckFunc()
 	for item, fn := range funcMap {
+		if !ytccollectcommons.IsItemSelected(d.CollectParam, item) {
+			continue
+		}
 		noAccessRes := fn()
 		if noAccessRes != nil {
 			log.Module.Debugf("item [%s] check asscess desc: %s tips %s", item, noAccessRes.Description, noAccessRes.Tips)
@@ -141,6 +146,9 @@ func (b *DiagCollecter) Type() string {
 func (b *DiagCollecter) ItemsToCollect(noAccess []ytccollectcommons.NoAccessRes) (res []string) {
 	noMap := b.getNotAccessItem(noAccess)
 	for item := range DiagChineseName {
+		if !ytccollectcommons.IsItemSelected(b.CollectParam, item) {
+			continue
+		}
 		if _, ok := noMap[item]; !ok {
 			res = append(res, item)
 		}
@@ -162,27 +170,45 @@ func (b *DiagCollecter) getNotAccessItem(noAccess []ytccollectcommons.NoAccessRe
 // [Interface Func]
 func (b *DiagCollecter) PreCollect(packageDir string) (err error) {
 	b.setPackageDir(packageDir)
-	if err = fs.Mkdir(path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, CORE_DUMP_DIR_NAME)); err != nil {
-		return
-	}
-	if err = fs.Mkdir(path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, DIAG_DIR_NAME)); err != nil {
+	if b.IsRemote() {
+		// only the status are queried over TCP, no file is collected
 		return
 	}
-	if err = fs.Mkdir(path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME)); err != nil {
-		return
+	var dirs []string
+	if b.HasYasdbItems() {
+		dirs = append(dirs,
+			path.Join(ytccollectcommons.YASDB_DIR_NAME, CORE_DUMP_DIR_NAME),
+			path.Join(ytccollectcommons.YASDB_DIR_NAME, DIAG_DIR_NAME),
+			path.Join(ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME),
+		)
 	}
-	if err = fs.Mkdir(path.Join(_packageDir, ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME)); err != nil {
-		return
+	if b.HasHostItems() {
+		dirs = append(dirs,
+			path.Join(ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME),
+			path.Join(ytccollectcommons.HOST_DIR_NAME, BASH_HISTORY_DIR_NAME),
+		)
 	}
-	if err = fs.Mkdir(path.Join(_packageDir, ytccollectcommons.HOST_DIR_NAME, BASH_HISTORY_DIR_NAME)); err != nil {
-		return
+	for _, dir := range dirs {
+		if err = fs.Mkdir(path.Join(b.packageDir, dir)); err != nil {
+			return
+		}
 	}
 	return
 }
 
 func (b *DiagCollecter) setPackageDir(packageDir string) {
-	_packageDir = packageDir
-	log.Module.Infof("package dir is %s", _packageDir)
+	b.packageDir = packageDir
+	log.Module.Infof("package dir is %s", b.packageDir)
+}
+
+// [Interface Func]
+func (b *DiagCollecter) Plan(items []string) []ytccollectcommons.PlanItem {
+	return ytccollectcommons.GenPlan(b.Type(), items, b.planFunc())
+}
+
+// [Interface Func]
+func (b *DiagCollecter) Estimate(items []string) map[string]int64 {
+	return ytccollectcommons.GenEstimate(items, b.estimateFunc())
 }
 
 // [Interface Func]
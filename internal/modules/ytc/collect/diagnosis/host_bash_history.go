@@ -15,12 +15,12 @@ import (
 	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/log"
+	"ytc/utils/execerutil"
 	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/userutil"
 
 	"git.yasdb.com/go/yaslog"
-	"git.yasdb.com/go/yasutil/execer"
 )
 
 const (
@@ -126,7 +126,7 @@ func (d *DiagCollecter) genDumpBashHistoryTmpFileCommand(script, user string) st
 
 func (d *DiagCollecter) collectHostBashHistoryByPermission(logger yaslog.YasLog, users map[string]struct{}, destPath, script string) map[string]string {
 	resp := make(map[string]string)
-	executor := execer.NewExecer(logger)
+	executor := execerutil.NewExecer(logger)
 	for user := range users {
 		var bin string
 		var args []string
@@ -150,7 +150,7 @@ func (d *DiagCollecter) collectHostBashHistoryByPermission(logger yaslog.YasLog,
 			user != d.YasdbHomeOSUser {
 			continue
 		}
-		ret, _, stderr := executor.Exec(bin, args...)
+		ret, _, stderr := executor.ExecContext(d.ItemContext(datadef.DIAG_HOST_BASH_HISTORY), bin, args...)
 		if ret != 0 {
 			resp[user] = stderr
 			continue
@@ -170,7 +170,7 @@ func (d *DiagCollecter) collectHostBashHistoryByPermission(logger yaslog.YasLog,
 }
 
 func (d *DiagCollecter) collectHostBashHistory() (err error) {
-	destPath := path.Join(_packageDir, ytccollectcommons.HOST_DIR_NAME, BASH_HISTORY_DIR_NAME)
+	destPath := path.Join(d.packageDir, ytccollectcommons.HOST_DIR_NAME, BASH_HISTORY_DIR_NAME)
 	script := path.Join(runtimedef.GetScriptsPath(), bash_history_ctl)
 	resp := datadef.YTCItem{
 		Name:    datadef.DIAG_HOST_BASH_HISTORY,
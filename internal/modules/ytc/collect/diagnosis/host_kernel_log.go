@@ -3,13 +3,14 @@ package diagnosis
 import (
 	"fmt"
 	"path"
+	"strings"
 
 	"ytc/defs/bashdef"
 	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/log"
 	"ytc/utils/execerutil"
-	"ytc/utils/fileutil"
+	"ytc/utils/stringutil"
 )
 
 func (b *DiagCollecter) collectHostKernelLog() (err error) {
@@ -17,12 +18,12 @@ func (b *DiagCollecter) collectHostKernelLog() (err error) {
 	defer b.fillResult(&hostKernelLogItem)
 
 	log := log.Module.M(datadef.DIAG_HOST_KERNELLOG)
-	destPath := path.Join(_packageDir, ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME)
+	destPath := path.Join(b.packageDir, ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME)
 	// dmesg.log
 	execer := execerutil.NewExecer(log)
 	dmesgFile := fmt.Sprintf(LOG_FILE_SUFFIX, SYSTEM_DMESG_LOG)
 	dest := path.Join(destPath, fmt.Sprintf(LOG_FILE_SUFFIX, SYSTEM_DMESG_LOG))
-	ret, stdout, stderr := execer.Exec(bashdef.CMD_BASH, "-c", bashdef.CMD_DMESG)
+	ret, stdout, stderr := execer.ExecContext(b.ItemContext(datadef.DIAG_HOST_KERNELLOG), bashdef.CMD_BASH, "-c", bashdef.CMD_DMESG)
 	if ret != 0 {
 		err = fmt.Errorf("failed to get host dmesg log, err: %s", stderr)
 		log.Error(err)
@@ -31,12 +32,24 @@ func (b *DiagCollecter) collectHostKernelLog() (err error) {
 		return
 	}
 	// write to dest
-	if err = fileutil.WriteFile(dest, []byte(stdout)); err != nil {
+	w, err := b.collectLogs(log, func(w *logWriter) error {
+		w.addDest(dest)
+		for _, line := range strings.SplitAfter(stdout, stringutil.STR_NEWLINE) {
+			if len(line) == 0 {
+				continue
+			}
+			if err := w.writeLine(dest, strings.TrimSuffix(line, stringutil.STR_NEWLINE)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		log.Error(err)
 		hostKernelLogItem.Error = err.Error()
 		hostKernelLogItem.Description = datadef.GenDefaultDesc()
 		return
 	}
-	hostKernelLogItem.Details = b.GenPackageRelativePath(path.Join(ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME, dmesgFile))
+	hostKernelLogItem.Details = datadef.GenPathDetails(b.GenPackageRelativePath(path.Join(ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME, dmesgFile)), w.truncations(dest))
 	return
 }
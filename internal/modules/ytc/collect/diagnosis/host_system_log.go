@@ -18,25 +18,37 @@ func (b *DiagCollecter) collectHostSystemLog() (err error) {
 	defer b.fillResult(&hostSystemLogItem)
 
 	log := log.Module.M(datadef.DIAG_HOST_SYSTEMLOG)
-	destPath := path.Join(_packageDir, ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME)
+	destPath := path.Join(b.packageDir, ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME)
+	ctx := b.ItemContext(datadef.DIAG_HOST_SYSTEMLOG)
 	if userutil.IsCurrentUserRoot() {
-		// message.log
+		// message.log and syslog.log share max_log_bytes_per_item
 		destMessageLogFile := path.Join(destPath, fmt.Sprintf(LOG_FILE_SUFFIX, SYSTEM_MESSAGES_LOG))
-		if err = b.collectHostLog(log, SYSTEM_LOG_MESSAGES, destMessageLogFile, SYSTEM_MESSAGES_LOG); err != nil {
+		destSysLogFile := path.Join(destPath, fmt.Sprintf(LOG_FILE_SUFFIX, SYSTEM_SYS_LOG))
+		var messageErr, sysLogErr error
+		w, e := b.collectLogs(log, func(w *logWriter) error {
+			messageErr = b.collectHostLog(ctx, log, w, SYSTEM_LOG_MESSAGES, destMessageLogFile, SYSTEM_MESSAGES_LOG)
+			sysLogErr = b.collectHostLog(ctx, log, w, SYSTEM_LOG_SYSLOG, destSysLogFile, SYSTEM_SYS_LOG)
+			return ctx.Err()
+		})
+		if e != nil && messageErr == nil {
+			messageErr = e
+		}
+		if e != nil && sysLogErr == nil {
+			sysLogErr = e
+		}
+		if err = messageErr; err != nil {
 			log.Error(err)
 			hostSystemLogItem.Children[SYSTEM_MESSAGES_LOG] = datadef.YTCItem{Error: err.Error(), Description: datadef.GenDefaultDesc()}
 		} else {
 			logPath := b.GenPackageRelativePath(path.Join(ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME, fmt.Sprintf(LOG_FILE_SUFFIX, SYSTEM_MESSAGES_LOG)))
-			hostSystemLogItem.Children[SYSTEM_MESSAGES_LOG] = datadef.YTCItem{Details: logPath}
+			hostSystemLogItem.Children[SYSTEM_MESSAGES_LOG] = datadef.YTCItem{Details: datadef.GenPathDetails(logPath, w.truncations(destMessageLogFile))}
 		}
-		// syslog.log
-		destSysLogFile := path.Join(destPath, fmt.Sprintf(LOG_FILE_SUFFIX, SYSTEM_SYS_LOG))
-		if err = b.collectHostLog(log, SYSTEM_LOG_SYSLOG, destSysLogFile, SYSTEM_SYS_LOG); err != nil {
+		if err = sysLogErr; err != nil {
 			log.Error(err)
 			hostSystemLogItem.Children[SYSTEM_SYS_LOG] = datadef.YTCItem{Error: err.Error(), Description: datadef.GenDefaultDesc()}
 		} else {
 			logPath := b.GenPackageRelativePath(path.Join(ytccollectcommons.HOST_DIR_NAME, LOG_DIR_NAME, fmt.Sprintf(LOG_FILE_SUFFIX, SYSTEM_SYS_LOG)))
-			hostSystemLogItem.Children[SYSTEM_SYS_LOG] = datadef.YTCItem{Details: logPath}
+			hostSystemLogItem.Children[SYSTEM_SYS_LOG] = datadef.YTCItem{Details: datadef.GenPathDetails(logPath, w.truncations(destSysLogFile))}
 		}
 	} else {
 		message := "has no permission to collect system log"
@@ -0,0 +1,182 @@
+package diagnosis
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"ytc/defs/bashdef"
+	"ytc/defs/confdef"
+	"ytc/defs/errdef"
+	"ytc/defs/runtimedef"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/log"
+	"ytc/utils/userutil"
+
+	"git.yasdb.com/go/yasutil/fs"
+)
+
+var errSkipNotConnectDB = errors.New("connect failed, skip")
+
+func (d *DiagCollecter) planFunc() map[string]ytccollectcommons.PlanFunc {
+	return map[string]ytccollectcommons.PlanFunc{
+		datadef.DIAG_YASDB_PROCESS_STATUS:  d.planYasdbProcessStatus,
+		datadef.DIAG_YASDB_INSTANCE_STATUS: d.planYasdbQuery(yasdb.InstanceSQL()),
+		datadef.DIAG_YASDB_DATABASE_STATUS: d.planYasdbQuery(yasdb.DatabaseSQL()),
+		datadef.DIAG_YASDB_ADR:             d.planYasdbADR,
+		datadef.DIAG_YASDB_ALERTLOG:        d.planYasdbAlertLog,
+		datadef.DIAG_YASDB_RUNLOG:          d.planYasdbRunLog,
+		datadef.DIAG_YASDB_COREDUMP:        d.planYasdbCoreDump,
+		datadef.DIAG_HOST_SYSTEMLOG:        d.planHostSystemLog,
+		datadef.DIAG_HOST_KERNELLOG:        d.planHostKernelLog,
+		datadef.DIAG_HOST_BASH_HISTORY:     d.planHostBashHistory,
+	}
+}
+
+// the whole ADR dir is copied, while only the matched files of the core dump dir are copied,
+// the logs of each item are limited by max_log_bytes_per_item
+func (d *DiagCollecter) estimateFunc() map[string]ytccollectcommons.EstimateFunc {
+	maxLogBytes, _ := confdef.GetStrategyConf().Collect.GetMaxLogBytesPerItem()
+	return map[string]ytccollectcommons.EstimateFunc{
+		datadef.DIAG_YASDB_ADR:      ytccollectcommons.EstimateBySources(d.planYasdbADR, ytccollectcommons.PLAN_SOURCE_DIR),
+		datadef.DIAG_YASDB_ALERTLOG: ytccollectcommons.CapEstimate(ytccollectcommons.EstimateBySources(d.planYasdbAlertLog, ytccollectcommons.PLAN_SOURCE_FILE), maxLogBytes),
+		datadef.DIAG_YASDB_RUNLOG:   ytccollectcommons.CapEstimate(ytccollectcommons.EstimateBySources(d.planYasdbRunLog, ytccollectcommons.PLAN_SOURCE_FILE), maxLogBytes),
+		datadef.DIAG_YASDB_COREDUMP: ytccollectcommons.EstimateBySources(d.planYasdbCoreDump, ytccollectcommons.PLAN_SOURCE_FILE),
+		datadef.DIAG_HOST_SYSTEMLOG: ytccollectcommons.CapEstimate(ytccollectcommons.EstimateBySources(d.planHostSystemLog, ytccollectcommons.PLAN_SOURCE_FILE), maxLogBytes),
+	}
+}
+
+func (d *DiagCollecter) planYasdbProcessStatus() ([]ytccollectcommons.PlanSource, error) {
+	desc := fmt.Sprintf("yasdb process matched with %s", d.YasdbData)
+	return []ytccollectcommons.PlanSource{ytccollectcommons.SystemSource(desc)}, nil
+}
+
+func (d *DiagCollecter) planYasdbQuery(sql string) ytccollectcommons.PlanFunc {
+	return func() ([]ytccollectcommons.PlanSource, error) {
+		if d.notConnectDB {
+			return nil, errSkipNotConnectDB
+		}
+		return []ytccollectcommons.PlanSource{ytccollectcommons.SQLSource(sql)}, nil
+	}
+}
+
+func (d *DiagCollecter) planYasdbADR() (sources []ytccollectcommons.PlanSource, err error) {
+	adrPath := path.Join(d.YasdbData, DIAG_DIR_NAME)
+	if !d.notConnectDB {
+		sources = append(sources, ytccollectcommons.SQLSource(yasdb.ParameterSQL(yasdb.PM_DIAGNOSTIC_DEST)))
+		if adrPath, err = GetAdrPath(d.CollectParam); err != nil {
+			return
+		}
+	}
+	if !fs.IsDirExist(adrPath) {
+		err = &errdef.ErrFileNotFound{Fname: adrPath}
+		return
+	}
+	sources = append(sources, ytccollectcommons.DirSource(adrPath))
+	return
+}
+
+func (d *DiagCollecter) planYasdbAlertLog() ([]ytccollectcommons.PlanSource, error) {
+	alertLog := path.Join(GetYasdbAlertLogPath(d.YasdbData), fmt.Sprintf(LOG_FILE_SUFFIX, YASDB_ALERT_LOG))
+	return []ytccollectcommons.PlanSource{ytccollectcommons.FileSource(alertLog)}, nil
+}
+
+func (d *DiagCollecter) planYasdbRunLog() (sources []ytccollectcommons.PlanSource, err error) {
+	log := log.Module.M(datadef.DIAG_YASDB_RUNLOG)
+	runLogPath := path.Join(d.YasdbData, LOG_DIR_NAME, YASDB_RUN_LOG)
+	if !d.notConnectDB {
+		sources = append(sources, ytccollectcommons.SQLSource(yasdb.ParameterSQL(yasdb.PM_RUN_LOG_FILE_PATH)))
+		if runLogPath, err = GetYasdbRunLogPath(d.CollectParam); err != nil {
+			return
+		}
+	}
+	runLogFiles, err := d.getLogFiles(log, runLogPath, YASDB_RUN_LOG)
+	if err != nil {
+		return
+	}
+	for _, f := range d.filterRunLogFiles(log, runLogFiles) {
+		sources = append(sources, ytccollectcommons.FileSource(f))
+	}
+	return
+}
+
+func (d *DiagCollecter) planYasdbCoreDump() (sources []ytccollectcommons.PlanSource, err error) {
+	log := log.Module.M(datadef.DIAG_YASDB_COREDUMP)
+	originCoreDumpPath, coreDumpType, err := GetCoreDumpPath()
+	if err != nil {
+		return
+	}
+	coreDumpPath := d.getCoreDumpDir(originCoreDumpPath, coreDumpType)
+	re, err := d.getCoreDumpRegexp(originCoreDumpPath, coreDumpType)
+	if err != nil {
+		return
+	}
+	sources = append(sources, ytccollectcommons.DirSource(coreDumpPath, coreDumpType, fmt.Sprintf("regexp: %s", re.String())))
+	files, err := os.ReadDir(coreDumpPath)
+	if err != nil {
+		return
+	}
+	coreFiles, err := d.filterCoreDumpFiles(log, coreDumpPath, files, re)
+	coreFiles, _ = d.limitCoreDumpFiles(log, coreDumpPath, coreFiles)
+	for _, f := range coreFiles {
+		sources = append(sources, ytccollectcommons.FileSource(path.Join(coreDumpPath, f)))
+	}
+	return
+}
+
+func (d *DiagCollecter) planHostSystemLog() (sources []ytccollectcommons.PlanSource, err error) {
+	if !userutil.IsCurrentUserRoot() {
+		err = errors.New("has no permission to collect system log")
+		return
+	}
+	log := log.Module.M(datadef.DIAG_HOST_SYSTEMLOG)
+	hasSetDateext, err := d.hasSetDateext()
+	if err != nil {
+		return
+	}
+	for _, src := range []string{SYSTEM_LOG_MESSAGES, SYSTEM_LOG_SYSLOG} {
+		if !hasSetDateext {
+			if fs.IsFileExist(src) {
+				sources = append(sources, ytccollectcommons.FileSource(src))
+			}
+			continue
+		}
+		logFiles, e := d.getLogFiles(log, path.Dir(src), path.Base(src))
+		if e != nil {
+			continue
+		}
+		for _, f := range logFiles {
+			sources = append(sources, ytccollectcommons.FileSource(f))
+		}
+	}
+	return
+}
+
+func (d *DiagCollecter) planHostKernelLog() ([]ytccollectcommons.PlanSource, error) {
+	return []ytccollectcommons.PlanSource{ytccollectcommons.CommandSource(bashdef.CMD_DMESG)}, nil
+}
+
+func (d *DiagCollecter) planHostBashHistory() (sources []ytccollectcommons.PlanSource, err error) {
+	if _currentBashHistoryPermission == bhp_has_no_permission {
+		err = errors.New(has_no_permission_message)
+		return
+	}
+	script := path.Join(runtimedef.GetScriptsPath(), bash_history_ctl)
+	var users []string
+	for user := range d.genTargetUsers() {
+		if _currentBashHistoryPermission == bhp_can_su_to_yasdb_user_without_password_permission && user != d.YasdbHomeOSUser {
+			continue
+		}
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	for _, user := range users {
+		detail := fmt.Sprintf("user: %s, %s", user, _bashHistoryPermissionNames[_currentBashHistoryPermission])
+		sources = append(sources, ytccollectcommons.CommandSource(d.genDumpBashHistoryTmpFileCommand(script, user), detail))
+	}
+	return
+}
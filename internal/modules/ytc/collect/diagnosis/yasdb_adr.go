@@ -35,8 +35,8 @@ func (b *DiagCollecter) collectYasdbADR() (err error) {
 		return
 	}
 	// package adr to dest
-	destPath := path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, DIAG_DIR_NAME)
-	if err = ytccollectcommons.CopyDir(log, adrPath, destPath, nil); err != nil {
+	destPath := path.Join(b.packageDir, ytccollectcommons.YASDB_DIR_NAME, DIAG_DIR_NAME)
+	if err = ytccollectcommons.CopyDir(b.ItemContext(datadef.DIAG_YASDB_ADR), log, adrPath, destPath, nil); err != nil {
 		log.Error(err)
 		yasdbADRItem.Error = err.Error()
 		yasdbADRItem.Description = datadef.GenDefaultDesc()
@@ -20,19 +20,23 @@ func (b *DiagCollecter) collectYasdbAlertLog() (err error) {
 	log := log.Module.M(datadef.DIAG_YASDB_ALERTLOG)
 	logPath := path.Join(b.YasdbData, LOG_DIR_NAME)
 	alertLogPath, alertLogFile := path.Join(logPath, YASDB_ALERT_LOG), fmt.Sprintf(LOG_FILE_SUFFIX, YASDB_ALERT_LOG)
-	destPath := path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME)
+	destPath := path.Join(b.packageDir, ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME)
 	// get alert log
 	timeParseFunc := func(date time.Time, line string) (t time.Time, e error) {
 		fields := strings.Split(line, stringutil.STR_BAR)
 		return time.ParseInLocation(timedef.TIME_FORMAT_WITH_MICROSECOND, fields[0], time.Local)
 	}
 	srcFile, destFile := path.Join(alertLogPath, alertLogFile), path.Join(destPath, alertLogFile)
-	if err = b.collectLog(log, srcFile, destFile, time.Now(), timeParseFunc); err != nil {
+	ctx, now := b.ItemContext(datadef.DIAG_YASDB_ALERTLOG), time.Now()
+	w, err := b.collectLogs(log, func(w *logWriter) error {
+		return b.collectLog(ctx, log, w, srcFile, destFile, now, timeParseFunc)
+	})
+	if err != nil {
 		log.Error(err)
 		yasdbAlertLogItem.Error = err.Error()
 		yasdbAlertLogItem.Description = datadef.GenDefaultDesc()
 		return
 	}
-	yasdbAlertLogItem.Details = b.GenPackageRelativePath(path.Join(ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME, alertLogFile))
+	yasdbAlertLogItem.Details = datadef.GenPathDetails(b.GenPackageRelativePath(path.Join(ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME, alertLogFile)), w.truncations(destFile))
 	return
 }
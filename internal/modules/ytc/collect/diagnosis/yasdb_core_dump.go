@@ -4,6 +4,7 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 
 	"ytc/defs/confdef"
@@ -13,7 +14,7 @@ import (
 	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
 
-	"git.yasdb.com/go/yasutil/fs"
+	"git.yasdb.com/go/yaslog"
 )
 
 const (
@@ -46,19 +47,13 @@ func (b *DiagCollecter) yasdbCoreDumpFile() (err error) {
 
 	log := log.Module.M(datadef.DIAG_YASDB_COREDUMP)
 	originCoreDumpPath, coreDumpType, err := GetCoreDumpPath()
-	coreDumpPath := originCoreDumpPath
 	if err != nil {
 		log.Errorf("failed to get coredump file path, err: %v", err)
 		yasdbCoreDumpItem.Error = err.Error()
 		yasdbCoreDumpItem.Description = datadef.GenGetCoreDumpPathDesc()
 		return
 	}
-	if coreDumpType == CORE_DIRECT {
-		if !path.IsAbs(originCoreDumpPath) {
-			coreDumpPath = path.Join(b.YasdbHome, ytccollectcommons.BIN, originCoreDumpPath)
-		}
-		coreDumpPath = path.Dir(coreDumpPath)
-	}
+	coreDumpPath := b.getCoreDumpDir(originCoreDumpPath, coreDumpType)
 	log.Infof("coredump file path is: %s", coreDumpPath)
 	re, err := b.getCoreDumpRegexp(originCoreDumpPath, coreDumpType)
 	if err != nil {
@@ -75,6 +70,28 @@ func (b *DiagCollecter) yasdbCoreDumpFile() (err error) {
 		yasdbCoreDumpItem.Description = datadef.GenReadCoreDumpPathDesc(coreDumpPath)
 		return
 	}
+	coreFiles, err := b.filterCoreDumpFiles(log, coreDumpPath, files, re)
+	if err != nil {
+		yasdbCoreDumpItem.Error = err.Error()
+		return
+	}
+	coreFiles, truncations := b.limitCoreDumpFiles(log, coreDumpPath, coreFiles)
+	ctx := b.ItemContext(datadef.DIAG_YASDB_COREDUMP)
+	for _, file := range coreFiles {
+		src, dest := path.Join(coreDumpPath, file), path.Join(b.packageDir, ytccollectcommons.YASDB_DIR_NAME, CORE_DUMP_DIR_NAME, file)
+		if err = fileutil.CopyFileContext(ctx, src, dest); err != nil {
+			log.Errorf("failed to copy file %s to %s", src, dest, err)
+			yasdbCoreDumpItem.Error = err.Error()
+			yasdbCoreDumpItem.Description = datadef.GenDefaultDesc()
+			return
+		}
+	}
+	yasdbCoreDumpItem.Details = datadef.GenPathDetails(b.GenPackageRelativePath(path.Join(ytccollectcommons.YASDB_DIR_NAME, CORE_DUMP_DIR_NAME)), truncations)
+	return
+}
+
+// filterCoreDumpFiles returns the accessible core files matched by re, which are modified between the start and end time.
+func (b *DiagCollecter) filterCoreDumpFiles(log yaslog.YasLog, coreDumpPath string, files []os.DirEntry, re *regexp.Regexp) (res []string, err error) {
 	for _, file := range files {
 		if !file.Type().IsRegular() || !re.MatchString(file.Name()) {
 			continue
@@ -87,7 +104,6 @@ func (b *DiagCollecter) yasdbCoreDumpFile() (err error) {
 		if e != nil {
 			err = e
 			log.Error(err)
-			yasdbCoreDumpItem.Error = err.Error()
 			return
 		}
 		createAt := info.ModTime()
@@ -95,18 +111,69 @@ func (b *DiagCollecter) yasdbCoreDumpFile() (err error) {
 			log.Infof("the modify time of %s is %s, skip", file.Name(), createAt)
 			continue
 		}
-		src, dest := path.Join(coreDumpPath, file.Name()), path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, CORE_DUMP_DIR_NAME, file.Name())
-		if err = fs.CopyFile(src, dest); err != nil {
-			log.Errorf("failed to copy file %s to %s", src, dest, err)
-			yasdbCoreDumpItem.Error = err.Error()
-			yasdbCoreDumpItem.Description = datadef.GenDefaultDesc()
-			return
-		}
+		res = append(res, file.Name())
 	}
-	yasdbCoreDumpItem.Details = b.GenPackageRelativePath(path.Join(ytccollectcommons.YASDB_DIR_NAME, CORE_DUMP_DIR_NAME))
 	return
 }
 
+// limitCoreDumpFiles keeps the newest core files within max_core_files and max_core_size of the strategy,
+// the core file which is larger than the rest of max_core_size is skipped and the older ones are still tried.
+func (b *DiagCollecter) limitCoreDumpFiles(log yaslog.YasLog, coreDumpPath string, coreFiles []string) (res []string, truncations []string) {
+	collectConf := confdef.GetStrategyConf().Collect
+	maxSize, err := collectConf.GetMaxCoreSize()
+	if err != nil {
+		log.Warnf("failed to parse max_core_size: %s, err: %v, it is not limited", collectConf.MaxCoreSize, err)
+	}
+	if collectConf.MaxCoreFiles <= 0 && maxSize <= 0 {
+		return coreFiles, nil
+	}
+	infos := make(map[string]os.FileInfo)
+	for _, file := range coreFiles {
+		info, err := os.Stat(path.Join(coreDumpPath, file))
+		if err != nil {
+			log.Errorf("failed to stat core file %s, err: %v, skip", file, err)
+			continue
+		}
+		infos[file] = info
+		res = append(res, file)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		ti, tj := infos[res[i]].ModTime(), infos[res[j]].ModTime()
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return res[i] < res[j]
+	})
+	var kept, skipped []string
+	var total int64
+	for _, file := range res {
+		size := infos[file].Size()
+		if (collectConf.MaxCoreFiles > 0 && len(kept) >= collectConf.MaxCoreFiles) || (maxSize > 0 && total+size > maxSize) {
+			log.Infof("skip core file %s, size: %d, the limit of the strategy is reached", file, size)
+			skipped = append(skipped, file)
+			continue
+		}
+		kept = append(kept, file)
+		total += size
+	}
+	if len(skipped) != 0 {
+		truncations = append(truncations, datadef.GenSkippedCoreFilesDesc(collectConf.MaxCoreFiles, collectConf.MaxCoreSize, skipped))
+	}
+	return kept, truncations
+}
+
+// getCoreDumpDir returns the dir of the core files, the relative core pattern is relative to the bin of yasdb home.
+func (d *DiagCollecter) getCoreDumpDir(originCoreDumpPath string, coreDumpType string) string {
+	coreDumpPath := originCoreDumpPath
+	if coreDumpType == CORE_DIRECT {
+		if !path.IsAbs(originCoreDumpPath) {
+			coreDumpPath = path.Join(d.YasdbHome, ytccollectcommons.BIN, originCoreDumpPath)
+		}
+		coreDumpPath = path.Dir(coreDumpPath)
+	}
+	return coreDumpPath
+}
+
 func (d *DiagCollecter) getCoreDumpRegexp(coreDumpPath string, coreDumpType string) (*regexp.Regexp, error) {
 	coreFileKey := confdef.GetStrategyConf().Collect.CoreFileKey
 	if !stringutil.IsEmpty(coreFileKey) {
@@ -3,10 +3,10 @@ package diagnosis
 import (
 	"fmt"
 
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
-	"ytc/utils/yasqlutil"
 )
 
 func (b *DiagCollecter) getYasdbDatabaseStatus() (err error) {
@@ -21,7 +21,7 @@ func (b *DiagCollecter) getYasdbDatabaseStatus() (err error) {
 		log.Error(err)
 		return
 	}
-	tx := yasqlutil.GetLocalInstance(b.YasdbUser, b.YasdbPassword, b.YasdbHome, b.YasdbData)
+	tx := ytccollectcommons.NewItemYasql(b.CollectParam, datadef.DIAG_YASDB_DATABASE_STATUS)
 	data, err := yasdb.QueryDatabase(tx)
 	if err != nil {
 		log.Error(err)
@@ -3,10 +3,10 @@ package diagnosis
 import (
 	"fmt"
 
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
 	"ytc/internal/modules/ytc/collect/commons/datadef"
 	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
-	"ytc/utils/yasqlutil"
 )
 
 func (b *DiagCollecter) getYasdbInstanceStatus() (err error) {
@@ -21,7 +21,7 @@ func (b *DiagCollecter) getYasdbInstanceStatus() (err error) {
 		log.Error(err)
 		return
 	}
-	tx := yasqlutil.GetLocalInstance(b.YasdbUser, b.YasdbPassword, b.YasdbHome, b.YasdbData)
+	tx := ytccollectcommons.NewItemYasql(b.CollectParam, datadef.DIAG_YASDB_INSTANCE_STATUS)
 	data, err := yasdb.QueryInstance(tx)
 	if err != nil {
 		log.Error(err)
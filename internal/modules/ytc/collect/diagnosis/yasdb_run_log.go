@@ -1,6 +1,7 @@
 package diagnosis
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"strings"
@@ -31,7 +32,7 @@ func (b *DiagCollecter) collectYasdbRunLog() (err error) {
 			return
 		}
 	}
-	destPath := path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME)
+	destPath := path.Join(b.packageDir, ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME)
 	// get run log files
 	runLogFiles, err := b.getLogFiles(log, runLogPath, YASDB_RUN_LOG)
 	if err != nil {
@@ -41,17 +42,22 @@ func (b *DiagCollecter) collectYasdbRunLog() (err error) {
 		return
 	}
 	// write run log to dest
-	if err = b.collectRunLog(log, runLogFiles, path.Join(destPath, runLogFile), b.StartTime, b.EndTime); err != nil {
+	destFile := path.Join(destPath, runLogFile)
+	ctx := b.ItemContext(datadef.DIAG_YASDB_RUNLOG)
+	w, err := b.collectLogs(log, func(w *logWriter) error {
+		return b.collectRunLog(ctx, log, w, runLogFiles, destFile, b.StartTime, b.EndTime)
+	})
+	if err != nil {
 		log.Error(err)
 		yasdbRunLogItem.Error = err.Error()
 		yasdbRunLogItem.Description = datadef.GenDefaultDesc()
 		return
 	}
-	yasdbRunLogItem.Details = b.GenPackageRelativePath(path.Join(ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME, runLogFile))
+	yasdbRunLogItem.Details = datadef.GenPathDetails(b.GenPackageRelativePath(path.Join(ytccollectcommons.YASDB_DIR_NAME, LOG_DIR_NAME, runLogFile)), w.truncations(destFile))
 	return
 }
 
-func (b *DiagCollecter) collectRunLog(log yaslog.YasLog, srcs []string, dest string, start, end time.Time) (err error) {
+func (b *DiagCollecter) collectRunLog(ctx context.Context, log yaslog.YasLog, w *logWriter, srcs []string, dest string, start, end time.Time) (err error) {
 	timeParseFunc := func(date time.Time, line string) (t time.Time, err error) {
 		fields := strings.Split(line, stringutil.STR_BLANK_SPACE)
 		if len(fields) < 2 {
@@ -61,6 +67,16 @@ func (b *DiagCollecter) collectRunLog(log yaslog.YasLog, srcs []string, dest str
 		timeStr := fmt.Sprintf("%s %s", fields[0], fields[1])
 		return time.ParseInLocation(timedef.TIME_FORMAT_WITH_MICROSECOND, timeStr, time.Local)
 	}
+	for _, f := range b.filterRunLogFiles(log, srcs) {
+		if err = b.collectLog(ctx, log, w, f, dest, time.Now(), timeParseFunc); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// filterRunLogFiles skips the run log files which end before the start time, the end time is parsed from the file name.
+func (b *DiagCollecter) filterRunLogFiles(log yaslog.YasLog, srcs []string) (res []string) {
 	for _, f := range srcs {
 		logEndTime := time.Now()
 		if path.Base(f) != fmt.Sprintf(LOG_FILE_SUFFIX, YASDB_RUN_LOG) {
@@ -69,6 +85,7 @@ func (b *DiagCollecter) collectRunLog(log yaslog.YasLog, srcs []string, dest str
 				log.Errorf("failed to get log end time from %s, skip", f)
 				continue
 			}
+			var err error
 			if logEndTime, err = time.ParseInLocation(timedef.TIME_FORMAT_IN_FILE, fileds[1], time.Local); err != nil {
 				log.Errorf("failed to parse log end time from %s", fileds[1])
 				continue
@@ -79,9 +96,7 @@ func (b *DiagCollecter) collectRunLog(log yaslog.YasLog, srcs []string, dest str
 			log.Debugf("skip run log file: %s", f)
 			continue
 		}
-		if err = b.collectLog(log, f, dest, time.Now(), timeParseFunc); err != nil {
-			return
-		}
+		res = append(res, f)
 	}
 	return
 }
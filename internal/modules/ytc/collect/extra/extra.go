@@ -19,8 +19,6 @@ const (
 	KEY_EXTRA_FILE = "extra file"
 )
 
-var _packageDir = ""
-
 var (
 	ExtraChineseName = map[string]string{
 		datadef.EXTRA_FILE_COLLECT: "额外文件收集",
@@ -30,6 +28,7 @@ var (
 type ExtraCollecter struct {
 	*collecttypedef.CollectParam
 	ModuleCollectRes *datadef.YTCModule
+	packageDir       string
 }
 
 func NewExtraCollecter(collectParam *collecttypedef.CollectParam) *ExtraCollecter {
@@ -51,6 +50,9 @@ func (b *ExtraCollecter) CheckAccess(yasdbValidate error) (noAccess []ytccollect
 	noAccess = make([]ytccollectcommons.NoAccessRes, 0)
 	funcMap := b.CheckFunc()
 	for item, fn := range funcMap {
+		if !ytccollectcommons.IsItemSelected(b.CollectParam, item) {
+			continue
+		}
 		noAccessRes := fn()
 		if noAccessRes != nil {
 			log.Module.Debugf("item [%s] check asscess desc: %s tips %s", item, noAccessRes.Description, noAccessRes.Tips)
@@ -79,6 +81,9 @@ func (b *ExtraCollecter) CollectFunc(items []string) (res map[string]func() erro
 func (b *ExtraCollecter) ItemsToCollect(noAccess []ytccollectcommons.NoAccessRes) (res []string) {
 	noMap := b.getNotAccessItem(noAccess)
 	for item := range ExtraChineseName {
+		if !ytccollectcommons.IsItemSelected(b.CollectParam, item) {
+			continue
+		}
 		if _, ok := noMap[item]; !ok {
 			res = append(res, item)
 		}
@@ -104,6 +109,16 @@ func (b *ExtraCollecter) PreCollect(packageDir string) (err error) {
 	return
 }
 
+// [Interface Func]
+func (b *ExtraCollecter) Plan(items []string) []ytccollectcommons.PlanItem {
+	return ytccollectcommons.GenPlan(b.Type(), items, b.planFunc())
+}
+
+// [Interface Func]
+func (b *ExtraCollecter) Estimate(items []string) map[string]int64 {
+	return ytccollectcommons.GenEstimate(items, b.estimateFunc())
+}
+
 // [Interface Func]
 func (b *ExtraCollecter) CollectOK() *datadef.YTCModule {
 	return b.ModuleCollectRes
@@ -116,7 +131,7 @@ func (b *ExtraCollecter) itemFunc() map[string]func() error {
 }
 
 func (b *ExtraCollecter) setPackageDir(packageDir string) {
-	_packageDir = packageDir
+	b.packageDir = packageDir
 }
 
 func (b *ExtraCollecter) fillResult(data *datadef.YTCItem) {
@@ -10,8 +10,6 @@ import (
 	"ytc/log"
 	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
-
-	"git.yasdb.com/go/yasutil/fs"
 )
 
 func (b *ExtraCollecter) collectExtraFile() (err error) {
@@ -26,11 +24,12 @@ func (b *ExtraCollecter) collectExtraFile() (err error) {
 		extraFile.Description = datadef.GenDefaultDesc()
 		return
 	}
-	destPartentDir := path.Join(_packageDir, EXTRA_DIR_NAME)
+	destPartentDir := path.Join(b.packageDir, EXTRA_DIR_NAME)
 	excludeMap := b.genExcludeMap()
+	ctx := b.ItemContext(datadef.EXTRA_FILE_COLLECT)
 	for dir, realPath := range dirs {
 		dest := path.Join(destPartentDir, dir)
-		if err = ytccollectcommons.CopyDir(log, realPath, dest, excludeMap); err != nil {
+		if err = ytccollectcommons.CopyDir(ctx, log, realPath, dest, excludeMap); err != nil {
 			log.Error(err)
 			log.Errorf("failed to copy dir %s to %s, err: %v", realPath, dest, err)
 			extraFile.Error = err.Error()
@@ -44,7 +43,7 @@ func (b *ExtraCollecter) collectExtraFile() (err error) {
 			continue
 		}
 		dest := path.Join(destPartentDir, file)
-		if err = fs.CopyFile(realPath, dest); err != nil {
+		if err = fileutil.CopyFileContext(ctx, realPath, dest); err != nil {
 			log.Errorf("failed to copy file %s to %s, err: %v", realPath, dest, err)
 			extraFile.Error = err.Error()
 			extraFile.Error = datadef.GenDefaultDesc()
@@ -0,0 +1,83 @@
+package extra
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/utils/fileutil"
+	"ytc/utils/stringutil"
+)
+
+func (b *ExtraCollecter) planFunc() map[string]ytccollectcommons.PlanFunc {
+	return map[string]ytccollectcommons.PlanFunc{
+		datadef.EXTRA_FILE_COLLECT: b.planExtraFile,
+	}
+}
+
+// planExtraFile returns the merged include paths, the excluded paths are skipped.
+func (b *ExtraCollecter) planExtraFile() (sources []ytccollectcommons.PlanSource, err error) {
+	dirs, files, err := b.filterInclude()
+	if err != nil {
+		return
+	}
+	excludeMap := b.genExcludeMap()
+	for _, name := range sortedKeys(dirs) {
+		realPath := dirs[name]
+		var excluded []string
+		for exclude := range excludeMap {
+			if fileutil.IsAncestorDir(realPath, exclude) {
+				excluded = append(excluded, exclude)
+			}
+		}
+		sort.Strings(excluded)
+		detail := []string{fmt.Sprintf("to %s", path.Join(EXTRA_DIR_NAME, name))}
+		if len(excluded) != 0 {
+			detail = append(detail, fmt.Sprintf("exclude %s", strings.Join(excluded, stringutil.STR_COMMA)))
+		}
+		sources = append(sources, ytccollectcommons.DirSource(realPath, detail...))
+	}
+	for _, name := range sortedKeys(files) {
+		realPath := files[name]
+		if _, ok := excludeMap[realPath]; ok {
+			continue
+		}
+		sources = append(sources, ytccollectcommons.FileSource(realPath, fmt.Sprintf("to %s", path.Join(EXTRA_DIR_NAME, name))))
+	}
+	return
+}
+
+func (b *ExtraCollecter) estimateFunc() map[string]ytccollectcommons.EstimateFunc {
+	return map[string]ytccollectcommons.EstimateFunc{
+		datadef.EXTRA_FILE_COLLECT: b.estimateExtraFile,
+	}
+}
+
+// estimateExtraFile sums the sizes of the include paths, the excluded paths are skipped.
+func (b *ExtraCollecter) estimateExtraFile() (total int64, err error) {
+	dirs, files, err := b.filterInclude()
+	if err != nil {
+		return
+	}
+	excludeMap := b.genExcludeMap()
+	for _, realPath := range dirs {
+		total += ytccollectcommons.DirSize(realPath, excludeMap)
+	}
+	for _, realPath := range files {
+		if _, ok := excludeMap[realPath]; !ok {
+			total += ytccollectcommons.FileSize(realPath)
+		}
+	}
+	return
+}
+
+func sortedKeys(m map[string]string) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
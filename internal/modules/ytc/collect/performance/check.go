@@ -11,7 +11,6 @@ import (
 	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
 	"ytc/utils/fileutil"
-	"ytc/utils/yasqlutil"
 
 	"git.yasdb.com/go/yaslog"
 )
@@ -21,7 +20,7 @@ const (
 )
 
 func (p *PerfCollecter) checkDatabaseOpenMode(logger yaslog.YasLog) (bool, error) {
-	tx := yasqlutil.GetLocalInstance(p.YasdbUser, p.YasdbPassword, p.YasdbHome, p.YasdbData)
+	tx := ytccollectcommons.NewYasql(p.CollectParam)
 	database, err := yasdb.QueryDatabase(tx)
 	if err != nil {
 		logger.Errorf("query v$database failed: %s", err)
@@ -77,6 +76,15 @@ func (p *PerfCollecter) checkAWR() *ytccollectcommons.NoAccessRes {
 
 func (p *PerfCollecter) checkSlowSql() *ytccollectcommons.NoAccessRes {
 	noAccess := &ytccollectcommons.NoAccessRes{ModuleItem: datadef.PERF_YASDB_SLOW_SQL}
+	if p.IsRemote() {
+		// slow.log of the remote instance is not readable, only SLOW_LOG$ is queried
+		if p.yasdbValidateErr != nil {
+			desc, tips := ytccollectcommons.YasErrDescAndTips(p.yasdbValidateErr)
+			ytccollectcommons.FillDescTips(noAccess, desc, tips)
+			return noAccess
+		}
+		return nil
+	}
 	defaultSlowLog := path.Join(p.YasdbData, ytccollectcommons.LOG, ytccollectcommons.SLOW, ytccollectcommons.SLOW_LOG)
 	defaultSlowLogTips := fmt.Sprintf(ytccollectcommons.DEFAULT_SLOWSQL_TIPS, defaultSlowLog)
 	if p.yasdbValidateErr != nil {
@@ -13,7 +13,6 @@ import (
 	"strings"
 	"time"
 
-	"ytc/defs/bashdef"
 	"ytc/defs/collecttypedef"
 	"ytc/defs/confdef"
 	"ytc/defs/timedef"
@@ -22,11 +21,11 @@ import (
 	"ytc/internal/modules/ytc/collect/yasdb"
 	"ytc/log"
 	"ytc/utils/fileutil"
+	"ytc/utils/sqlutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/yasqlutil"
 
 	"git.yasdb.com/go/yaslog"
-	"git.yasdb.com/go/yasutil/execer"
 	"git.yasdb.com/go/yasutil/fs"
 )
 
@@ -47,6 +46,7 @@ const (
 	KEY_SLOW_SQL_PARAMETER     = "slowParameter"
 	KEY_SLOW_SQL_LOGS_IN_TABLE = "slowLogsInFile"
 	KEY_SLOW_SQL_LOGS_IN_FILE  = "slowCutFile"
+	KEY_SLOW_SQL_SUMMARY       = "slowSummary"
 
 	SLOW_LOG_FILE_PATH = "SLOW_LOG_FILE_PATH"
 	AWR                = "awr"
@@ -58,10 +58,6 @@ const (
 	_exec_awr_report = "exec sys.dbms_awr.awr_report(%d,%d,%d,%d);"
 )
 
-var (
-	_packageDir string
-)
-
 var (
 	PerformanceChineseName = map[string]string{
 		datadef.PERF_YASDB_AWR:      "AWR报告",
@@ -72,6 +68,7 @@ var (
 		KEY_SLOW_SQL_PARAMETER:     "慢SQL参数",
 		KEY_SLOW_SQL_LOGS_IN_TABLE: "SLOW_LOG$系统表",
 		KEY_SLOW_SQL_LOGS_IN_FILE:  "慢SQL日志文件",
+		KEY_SLOW_SQL_SUMMARY:       "慢SQL汇总",
 	}
 
 	_slowParameter = []yasdb.ParameterName{
@@ -87,6 +84,7 @@ type PerfCollecter struct {
 	*collecttypedef.CollectParam
 	ModuleCollectRes *datadef.YTCModule
 	yasdbValidateErr error
+	packageDir       string
 }
 
 type execRes struct {
@@ -107,6 +105,9 @@ func NewPerfCollecter(collectParam *collecttypedef.CollectParam) *PerfCollecter
 // [Interface Func]
 func (p *PerfCollecter) PreCollect(packageDir string) error {
 	p.setPackageDir(packageDir)
+	if !p.HasYasdbItems() {
+		return nil
+	}
 	if err := fs.Mkdir(p.getAWRPath()); err != nil {
 		return err
 	}
@@ -127,6 +128,9 @@ func (p *PerfCollecter) CheckAccess(yasdbValidate error) (noAccess []ytccollectc
 	noAccess = make([]ytccollectcommons.NoAccessRes, 0)
 	funcMap := p.checkFunc()
 	for item, fn := range funcMap {
+		if !ytccollectcommons.IsItemSelected(p.CollectParam, item) {
+			continue
+		}
 		noAccessRes := fn()
 		if noAccessRes != nil {
 			log.Module.Debugf("item [%s] check asscess desc: %s tips %s", item, noAccessRes.Description, noAccessRes.Tips)
@@ -155,6 +159,9 @@ func (p *PerfCollecter) CollectFunc(items []string) (res map[string]func() error
 func (p *PerfCollecter) ItemsToCollect(noAccess []ytccollectcommons.NoAccessRes) (res []string) {
 	noAccessMap := ytccollectcommons.NotAccessItemToMap(noAccess)
 	for item := range PerformanceChineseName {
+		if !ytccollectcommons.IsItemSelected(p.CollectParam, item) {
+			continue
+		}
 		if _, ok := noAccessMap[item]; !ok {
 			res = append(res, item)
 		}
@@ -162,6 +169,16 @@ func (p *PerfCollecter) ItemsToCollect(noAccess []ytccollectcommons.NoAccessRes)
 	return
 }
 
+// [Interface Func]
+func (p *PerfCollecter) Plan(items []string) []ytccollectcommons.PlanItem {
+	return ytccollectcommons.GenPlan(p.Type(), items, p.planFunc())
+}
+
+// [Interface Func]
+func (p *PerfCollecter) Estimate(items []string) map[string]int64 {
+	return ytccollectcommons.GenEstimate(items, p.estimateFunc())
+}
+
 // [Interface Func]
 func (p *PerfCollecter) CollectOK() *datadef.YTCModule {
 	return p.ModuleCollectRes
@@ -175,8 +192,7 @@ func (p *PerfCollecter) itemFunc() map[string]func() error {
 }
 
 func (b *PerfCollecter) setPackageDir(packageDir string) {
-	_packageDir = packageDir
-
+	b.packageDir = packageDir
 }
 
 func (p *PerfCollecter) checkFunc() map[string]func() *ytccollectcommons.NoAccessRes {
@@ -191,7 +207,7 @@ func (p *PerfCollecter) fillResult(data *datadef.YTCItem) {
 }
 
 func (p *PerfCollecter) getSlowLogPath() (string, error) {
-	tx := yasqlutil.GetLocalInstance(p.YasdbUser, p.YasdbPassword, p.YasdbHome, p.YasdbData)
+	tx := ytccollectcommons.NewItemYasql(p.CollectParam, datadef.PERF_YASDB_SLOW_SQL)
 	slowPath, err := yasdb.QueryParameter(tx, SLOW_LOG_FILE_PATH)
 	if err != nil {
 		return "", err
@@ -217,7 +233,7 @@ func (p *PerfCollecter) collectAWR() error {
 		awr.Description = datadef.GenDefaultDesc()
 		return err
 	}
-	relative, err := filepath.Rel(_packageDir, htmlFile)
+	relative, err := filepath.Rel(p.packageDir, htmlFile)
 	if err != nil {
 		awr.Error = err.Error()
 		awr.Description = datadef.GenDefaultDesc()
@@ -252,7 +268,7 @@ func (p *PerfCollecter) createAWRSqlFile(log yaslog.YasLog) (string, error) {
 }
 
 func (p *PerfCollecter) genStartEndSnapId(log yaslog.YasLog) (int64, int64, error) {
-	tx := yasqlutil.GetLocalInstance(p.YasdbUser, p.YasdbPassword, p.YasdbHome, p.YasdbData)
+	tx := ytccollectcommons.NewItemYasql(p.CollectParam, datadef.PERF_YASDB_AWR)
 
 	instance, err := yasdb.QueryInstance(tx)
 	if err != nil {
@@ -290,7 +306,7 @@ func (p *PerfCollecter) genStartEndSnapId(log yaslog.YasLog) (int64, int64, erro
 }
 
 func (p *PerfCollecter) queryDatabaseInstance(log yaslog.YasLog) (*yasdb.WrmDatabaseInstance, error) {
-	tx := yasqlutil.GetLocalInstance(p.YasdbUser, p.YasdbPassword, p.YasdbHome, p.YasdbData)
+	tx := ytccollectcommons.NewItemYasql(p.CollectParam, datadef.PERF_YASDB_AWR)
 	dataInstance, err := yasdb.QueryWrmDatabaseInstance(tx)
 	if err != nil {
 		log.Errorf("query wrm$database_instance err: %s", err.Error())
@@ -301,12 +317,12 @@ func (p *PerfCollecter) queryDatabaseInstance(log yaslog.YasLog) (*yasdb.WrmData
 
 // locally saved awr path
 func (p *PerfCollecter) getAWRPath() string {
-	return path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, AWR)
+	return path.Join(p.packageDir, ytccollectcommons.YASDB_DIR_NAME, AWR)
 }
 
 // locally saved slow path
 func (p *PerfCollecter) getSlowPath() string {
-	return path.Join(_packageDir, ytccollectcommons.YASDB_DIR_NAME, SLOW)
+	return path.Join(p.packageDir, ytccollectcommons.YASDB_DIR_NAME, SLOW)
 }
 
 func (p *PerfCollecter) deleteSqlFile(sqlPath string) {
@@ -316,14 +332,18 @@ func (p *PerfCollecter) deleteSqlFile(sqlPath string) {
 }
 
 func (p *PerfCollecter) genAWRHtmlReport(log yaslog.YasLog, sqlFile string) (string, error) {
-	execResult := make(chan execRes)
+	execResult := make(chan execRes, 1)
 	timeout := confdef.GetStrategyConf().Collect.GetAWRTimeout()
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	itemCtx := p.ItemContext(datadef.PERF_YASDB_AWR)
+	ctx, cancel := context.WithTimeout(itemCtx, timeout)
 	defer cancel()
-	go p.genAWRReport(log, sqlFile, execResult)
+	go p.genAWRReport(ctx, log, sqlFile, execResult)
 	select {
 	case <-ctx.Done():
 		err := errors.New("gen awr report timeout")
+		if itemCtx.Err() != nil {
+			err = fmt.Errorf("gen awr report stopped: %s", itemCtx.Err().Error())
+		}
 		log.Error(err)
 		return "", err
 	case res := <-execResult:
@@ -345,20 +365,25 @@ func (p *PerfCollecter) genAWRHtmlReport(log yaslog.YasLog, sqlFile string) (str
 	}
 }
 
-func (p *PerfCollecter) genAWRReport(log yaslog.YasLog, sqlFile string, res chan execRes) {
-	yasqlBin := path.Join(p.YasdbHome, yasqlutil.BIN_PATH, yasqlutil.YASQL_BIN)
-	connectPath := fmt.Sprintf("%s/%s", p.YasdbUser, p.YasdbPassword)
-	env := []string{
-		fmt.Sprintf("%s=%s", yasqlutil.LIB_KEY, path.Join(p.YasdbHome, yasqlutil.LIB_PATH)),
-		fmt.Sprintf("%s=%s", yasqlutil.YASDB_DATA, p.YasdbData),
+func (p *PerfCollecter) genAWRReport(ctx context.Context, log yaslog.YasLog, sqlFile string, res chan execRes) {
+	tx := ytccollectcommons.NewItemYasql(p.CollectParam, datadef.PERF_YASDB_AWR)
+	cmd := tx.Command(ctx, "-f", sqlFile)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	log.Debugf("exec: %s", strings.Join(cmd.Args, " "))
+	ret := 0
+	if err := cmd.Run(); err != nil {
+		log.Errorf("exec %s failed, %s", cmd.Args[0], err)
+		ret = -1
+		if cmd.ProcessState != nil {
+			ret = cmd.ProcessState.ExitCode()
+		}
 	}
-	cmd := fmt.Sprintf("%s %s -f %s", yasqlBin, connectPath, sqlFile)
-	exec := execer.NewExecer(log)
-	ret, stdout, stderr := exec.EnvExec(env, bashdef.CMD_BASH, "-c", cmd)
 	res <- execRes{
 		ret:    ret,
-		stdout: stdout,
-		stderr: stderr,
+		stdout: yasqlutil.TrimPasswordPrompt(stdout.String()),
+		stderr: stderr.String(),
 	}
 }
 
@@ -379,15 +404,37 @@ func (p *PerfCollecter) collectSlowSQL() error {
 		Children: make(map[string]datadef.YTCItem),
 	}
 	defer p.fillResult(slowSQL)
-	slowSQL.Children[KEY_SLOW_SQL_LOGS_IN_FILE] = *p.collectSlowLogsInFile(log)
+	var fileSlows []*yasdb.SlowLog
+	if p.IsRemote() {
+		slowSQL.Children[KEY_SLOW_SQL_LOGS_IN_FILE] = datadef.YTCItem{Error: "slow.log is not readable over TCP", Description: datadef.GenNotApplicableRemoteDesc(p.YasdbAddress)}
+	} else {
+		var slowLogs *datadef.YTCItem
+		slowLogs, fileSlows = p.collectSlowLogsInFile(log)
+		slowSQL.Children[KEY_SLOW_SQL_LOGS_IN_FILE] = *slowLogs
+	}
+	var tableSlows []*yasdb.SlowLog
 	if p.yasdbValidateErr == nil {
-		slowSQL.Children[KEY_SLOW_SQL_LOGS_IN_TABLE] = *p.collectSlowLogsInTable(log)
+		var slowLogs *datadef.YTCItem
+		slowLogs, tableSlows = p.collectSlowLogsInTable(log)
+		slowSQL.Children[KEY_SLOW_SQL_LOGS_IN_TABLE] = *slowLogs
 		slowSQL.Children[KEY_SLOW_SQL_PARAMETER] = *p.collectSlowParameter(log)
 	}
+	// SLOW_LOG$ is preferred, the slow.log file is summarized when the database is not accessible or the slow logs
+	// are only written into the file, the source is kept in the description
+	switch {
+	case len(tableSlows) != 0:
+		slowSQL.Children[KEY_SLOW_SQL_SUMMARY] = datadef.YTCItem{Details: SummarizeSlowLogs(tableSlows), Description: VIEW_SLOW_LOG}
+	case fileSlows != nil:
+		slowSQL.Children[KEY_SLOW_SQL_SUMMARY] = datadef.YTCItem{Details: SummarizeSlowLogs(fileSlows), Description: ytccollectcommons.SLOW_LOG}
+	case tableSlows != nil:
+		slowSQL.Children[KEY_SLOW_SQL_SUMMARY] = datadef.YTCItem{Details: SummarizeSlowLogs(tableSlows), Description: VIEW_SLOW_LOG}
+	}
 	return nil
 }
 
-func (p *PerfCollecter) collectSlowLogsInTable(log yaslog.YasLog) (slowLogs *datadef.YTCItem) {
+// collectSlowLogsInTable also returns the slow logs with the original sql text to be summarized, the sql text in the
+// item is replaced with its fingerprint if collect.slow_sql_strip_literals of the strategy is set.
+func (p *PerfCollecter) collectSlowLogsInTable(log yaslog.YasLog) (slowLogs *datadef.YTCItem, slows []*yasdb.SlowLog) {
 	slowLogs = new(datadef.YTCItem)
 	slows, err := p.querySlowSql(log)
 	if err != nil {
@@ -396,15 +443,25 @@ func (p *PerfCollecter) collectSlowLogsInTable(log yaslog.YasLog) (slowLogs *dat
 		slowLogs.Description = datadef.GenGetDatabaseViewDesc(VIEW_SLOW_LOG)
 		return
 	}
-	slowLogs.Details = slows
-	return slowLogs
+	if !confdef.GetStrategyConf().Collect.SlowSQLStripLiterals {
+		slowLogs.Details = slows
+		return
+	}
+	stripped := make([]*yasdb.SlowLog, 0, len(slows))
+	for _, slow := range slows {
+		s := *slow
+		s.SQLText = sqlutil.Fingerprint(s.SQLText)
+		stripped = append(stripped, &s)
+	}
+	slowLogs.Details = stripped
+	return
 }
 
 func (p *PerfCollecter) collectSlowParameter(log yaslog.YasLog) (parameter *datadef.YTCItem) {
 	parameter = new(datadef.YTCItem)
 	res := make([]*yasdb.VParameter, 0)
 	for _, key := range _slowParameter {
-		tx := yasqlutil.GetLocalInstance(p.YasdbUser, p.YasdbPassword, p.YasdbHome, p.YasdbData)
+		tx := ytccollectcommons.NewItemYasql(p.CollectParam, datadef.PERF_YASDB_SLOW_SQL)
 		value, err := yasdb.QueryParameter(tx, key)
 		if err != nil {
 			parameter.Error = err.Error()
@@ -421,16 +478,17 @@ func (p *PerfCollecter) collectSlowParameter(log yaslog.YasLog) (parameter *data
 	return
 }
 
-func (p *PerfCollecter) collectSlowLogsInFile(log yaslog.YasLog) (cutSlowLog *datadef.YTCItem) {
+// collectSlowLogsInFile also returns the slow logs parsed from the original lines of the slow log file to be summarized.
+func (p *PerfCollecter) collectSlowLogsInFile(log yaslog.YasLog) (cutSlowLog *datadef.YTCItem, slows []*yasdb.SlowLog) {
 	cutSlowLog = new(datadef.YTCItem)
-	slowPath, err := p.saveSlowLog(log)
+	slowPath, slows, err := p.saveSlowLog(log)
 	if err != nil {
 		log.Errorf("get slow log err: %s", err.Error())
 		cutSlowLog.Error = err.Error()
 		cutSlowLog.Description = datadef.GenDefaultDesc()
 		return
 	}
-	relative, err := filepath.Rel(_packageDir, slowPath)
+	relative, err := filepath.Rel(p.packageDir, slowPath)
 	if err != nil {
 		log.Errorf("get relative path err: %s", err.Error())
 		cutSlowLog.Error = err.Error()
@@ -441,17 +499,24 @@ func (p *PerfCollecter) collectSlowLogsInFile(log yaslog.YasLog) (cutSlowLog *da
 	return
 }
 
-func (p *PerfCollecter) saveSlowLog(log yaslog.YasLog) (string, error) {
+func (p *PerfCollecter) saveSlowLog(log yaslog.YasLog) (string, []*yasdb.SlowLog, error) {
 	logLines, err := p.querySlowSqlFromFile(log)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	slows := ParseSlowLogLines(logLines)
+	if slows == nil {
+		slows = make([]*yasdb.SlowLog, 0)
+	}
+	if confdef.GetStrategyConf().Collect.SlowSQLStripLiterals {
+		logLines = stripSlowLogLiterals(logLines)
 	}
 	slowPath := p.getSlowPath()
 	slowFile := path.Join(slowPath, ytccollectcommons.SLOW_LOG)
 	if err := fileutil.WriteFile(slowFile, []byte(strings.Join(logLines, stringutil.STR_NEWLINE)+stringutil.STR_NEWLINE)); err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return slowFile, nil
+	return slowFile, slows, nil
 }
 
 func (p *PerfCollecter) querySlowSqlFromFile(log yaslog.YasLog) ([]string, error) {
@@ -499,7 +564,7 @@ func (p *PerfCollecter) filterSlowLog(slowLog string, log yaslog.YasLog) ([]stri
 }
 
 func (p *PerfCollecter) querySlowSql(log yaslog.YasLog) ([]*yasdb.SlowLog, error) {
-	tx := yasqlutil.GetLocalInstance(p.YasdbUser, p.YasdbPassword, p.YasdbHome, p.YasdbData)
+	tx := ytccollectcommons.NewItemYasql(p.CollectParam, datadef.PERF_YASDB_SLOW_SQL)
 	startStr, endStr := p.genStartEndStr(timedef.TIME_FORMAT)
 	slows, err := yasdb.QuerySlowLog(tx, startStr, endStr)
 	if err != nil {
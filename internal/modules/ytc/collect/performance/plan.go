@@ -0,0 +1,70 @@
+package performance
+
+import (
+	"path"
+
+	"ytc/defs/timedef"
+	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/commons/datadef"
+	"ytc/internal/modules/ytc/collect/yasdb"
+)
+
+// the ids are queried before generating the AWR report
+const _plan_awr_report = "exec sys.dbms_awr.awr_report(<DBID>,<INSTANCE_NUMBER>,<min SNAP_ID>,<max SNAP_ID>);"
+
+func (p *PerfCollecter) planFunc() map[string]ytccollectcommons.PlanFunc {
+	return map[string]ytccollectcommons.PlanFunc{
+		datadef.PERF_YASDB_AWR:      p.planAWR,
+		datadef.PERF_YASDB_SLOW_SQL: p.planSlowSQL,
+	}
+}
+
+func (p *PerfCollecter) estimateFunc() map[string]ytccollectcommons.EstimateFunc {
+	return map[string]ytccollectcommons.EstimateFunc{
+		datadef.PERF_YASDB_SLOW_SQL: ytccollectcommons.EstimateBySources(p.planSlowSQL, ytccollectcommons.PLAN_SOURCE_FILE),
+	}
+}
+
+func (p *PerfCollecter) planAWR() ([]ytccollectcommons.PlanSource, error) {
+	start, end := p.genStartEndStr(timedef.TIME_FORMAT)
+	return []ytccollectcommons.PlanSource{
+		ytccollectcommons.SQLSource(yasdb.WrmDatabaseInstanceSQL()),
+		ytccollectcommons.SQLSource(yasdb.InstanceSQL()),
+		ytccollectcommons.SQLSource(yasdb.WrmSnapshotSQL(start, end), "the start is the instance startup time if it is later"),
+		ytccollectcommons.SQLSource(_set_output),
+		ytccollectcommons.SQLSource(_plan_awr_report),
+	}, nil
+}
+
+func (p *PerfCollecter) planSlowSQL() (sources []ytccollectcommons.PlanSource, err error) {
+	// slow.log of the remote instance is not readable, only SLOW_LOG$ is queried
+	if !p.IsRemote() {
+		if sources, err = p.planSlowLogFile(); err != nil {
+			return
+		}
+	}
+	if p.yasdbValidateErr != nil {
+		return
+	}
+	start, end := p.genStartEndStr(timedef.TIME_FORMAT)
+	sources = append(sources,
+		ytccollectcommons.SQLSource(yasdb.SlowLogSQL(start, end)),
+		ytccollectcommons.SQLSource(yasdb.SqlTextSQL(), "for each slow log"),
+	)
+	for _, key := range _slowParameter {
+		sources = append(sources, ytccollectcommons.SQLSource(yasdb.ParameterSQL(key)))
+	}
+	return
+}
+
+func (p *PerfCollecter) planSlowLogFile() (sources []ytccollectcommons.PlanSource, err error) {
+	slowPath := path.Join(p.YasdbData, ytccollectcommons.LOG, ytccollectcommons.SLOW)
+	if p.yasdbValidateErr == nil {
+		sources = append(sources, ytccollectcommons.SQLSource(yasdb.ParameterSQL(SLOW_LOG_FILE_PATH)))
+		if slowPath, err = p.getSlowLogPath(); err != nil {
+			return
+		}
+	}
+	sources = append(sources, ytccollectcommons.FileSource(path.Join(slowPath, ytccollectcommons.SLOW_LOG)))
+	return
+}
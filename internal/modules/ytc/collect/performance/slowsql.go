@@ -0,0 +1,225 @@
+package performance
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ytc/internal/modules/ytc/collect/yasdb"
+	"ytc/utils/sqlutil"
+	"ytc/utils/stringutil"
+)
+
+const (
+	_p95 = 0.95
+)
+
+// SlowSQLSummary aggregates the slow sql with the same fingerprint, the ones without sql text are aggregated by
+// sql id. The times are in milliseconds as QUERY_TIME of SLOW_LOG$.
+type SlowSQLSummary struct {
+	Fingerprint string            `json:"fingerprint"`
+	SQLIDs      []string          `json:"sqlIDs"`
+	SQLIDStats  []*SlowSQLIDStats `json:"sqlIDStats"` // the slow sql of the fingerprint aggregated by sql id, in the order of SQLIDs
+	Count       int               `json:"count"`
+	TotalTime   float64           `json:"totalTime"`
+	AvgTime     float64           `json:"avgTime"`
+	P95Time     float64           `json:"p95Time"`
+	MaxTime     float64           `json:"maxTime"`
+	RowsSent    int64             `json:"rowsSent"`
+	Users       []string          `json:"users"`
+	Hosts       []string          `json:"hosts"`
+}
+
+// SlowSQLIDStats aggregates the slow sql with the same fingerprint and sql id.
+type SlowSQLIDStats struct {
+	SQLID     string  `json:"sqlID"`
+	Count     int     `json:"count"`
+	TotalTime float64 `json:"totalTime"`
+}
+
+// SummarizeSlowLogs aggregates the slow logs by the fingerprint of the sql text and then by the sql id, the summaries
+// are sorted by the total time in descending order.
+func SummarizeSlowLogs(logs []*yasdb.SlowLog) []*SlowSQLSummary {
+	type group struct {
+		summary *SlowSQLSummary
+		times   []float64
+		sqlIDs  map[string]*SlowSQLIDStats
+		users   map[string]struct{}
+		hosts   map[string]struct{}
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	for _, l := range logs {
+		fingerprint := sqlutil.Fingerprint(l.SQLText)
+		key := fingerprint
+		if stringutil.IsEmpty(fingerprint) {
+			key = "sql_id:" + l.SQLID
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{
+				summary: &SlowSQLSummary{Fingerprint: fingerprint},
+				sqlIDs:  make(map[string]*SlowSQLIDStats),
+				users:   make(map[string]struct{}),
+				hosts:   make(map[string]struct{}),
+			}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.summary.Count++
+		g.summary.TotalTime += l.QueryTime
+		g.summary.RowsSent += l.RowsSent
+		g.summary.MaxTime = math.Max(g.summary.MaxTime, l.QueryTime)
+		g.times = append(g.times, l.QueryTime)
+		if !stringutil.IsEmpty(l.SQLID) {
+			stats, ok := g.sqlIDs[l.SQLID]
+			if !ok {
+				stats = &SlowSQLIDStats{SQLID: l.SQLID}
+				g.sqlIDs[l.SQLID] = stats
+			}
+			stats.Count++
+			stats.TotalTime += l.QueryTime
+		}
+		addNotEmpty(g.users, l.UserName)
+		addNotEmpty(g.hosts, l.UserHost)
+	}
+	res := make([]*SlowSQLSummary, 0, len(groups))
+	for _, key := range keys {
+		g := groups[key]
+		g.summary.TotalTime = roundTime(g.summary.TotalTime)
+		g.summary.AvgTime = roundTime(g.summary.TotalTime / float64(g.summary.Count))
+		g.summary.P95Time = percentile(g.times, _p95)
+		g.summary.SQLIDs = make([]string, 0, len(g.sqlIDs))
+		for sqlID := range g.sqlIDs {
+			g.summary.SQLIDs = append(g.summary.SQLIDs, sqlID)
+		}
+		sort.Strings(g.summary.SQLIDs)
+		g.summary.SQLIDStats = make([]*SlowSQLIDStats, 0, len(g.sqlIDs))
+		for _, sqlID := range g.summary.SQLIDs {
+			stats := g.sqlIDs[sqlID]
+			stats.TotalTime = roundTime(stats.TotalTime)
+			g.summary.SQLIDStats = append(g.summary.SQLIDStats, stats)
+		}
+		g.summary.Users = sortedKeys(g.users)
+		g.summary.Hosts = sortedKeys(g.hosts)
+		res = append(res, g.summary)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].TotalTime != res[j].TotalTime {
+			return res[i].TotalTime > res[j].TotalTime
+		}
+		return res[i].Count > res[j].Count
+	})
+	return res
+}
+
+// ParseSlowLogLines parses the lines of the slow log file into the slow logs to be summarized, each of them starts
+// with the line of TimePrefix. COST_EXECUTE_TIME is taken as the query time, which is in milliseconds as QUERY_TIME of
+// SLOW_LOG$, and USER_HOST is split into the user and the host if it is like 'user @ host'.
+func ParseSlowLogLines(lines []string) []*yasdb.SlowLog {
+	var res []*yasdb.SlowLog
+	var cur *yasdb.SlowLog
+	var sql []string
+	flush := func() {
+		if cur != nil {
+			cur.SQLText = strings.Join(sql, stringutil.STR_NEWLINE)
+			res = append(res, cur)
+		}
+		cur, sql = nil, nil
+	}
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, TimePrefix):
+			flush()
+			cur = &yasdb.SlowLog{StartTime: strings.TrimPrefix(line, TimePrefix)}
+		case cur == nil:
+		case strings.HasPrefix(line, SqlPrefix):
+			sql = append(sql, strings.TrimPrefix(line, SqlPrefix))
+		case strings.HasPrefix(line, UserHostPrefix):
+			cur.UserName, cur.UserHost = splitUserHost(strings.TrimPrefix(line, UserHostPrefix))
+		case strings.HasPrefix(line, DBNamePrefix):
+			cur.DBName = strings.TrimSpace(strings.TrimPrefix(line, DBNamePrefix))
+		case strings.HasPrefix(line, SqlIDPrefix):
+			cur.SQLID = strings.TrimSpace(strings.TrimPrefix(line, SqlIDPrefix))
+		case strings.HasPrefix(line, ExecuteTimePrefix):
+			cur.QueryTime, _ = strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(line, ExecuteTimePrefix)), 64)
+		case strings.HasPrefix(line, RowsSentPrefix):
+			cur.RowsSent, _ = strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, RowsSentPrefix)), 10, 64)
+		case strings.HasPrefix(line, stringutil.STR_HASH):
+		case len(sql) != 0:
+			sql = append(sql, line)
+		}
+	}
+	flush()
+	return res
+}
+
+func splitUserHost(value string) (user, host string) {
+	user, host, found := strings.Cut(value, "@")
+	if !found {
+		return "", strings.TrimSpace(value)
+	}
+	user, _, _ = strings.Cut(user, "[")
+	host = strings.Trim(strings.TrimSpace(host), "[]")
+	return strings.TrimSpace(user), strings.TrimSpace(host)
+}
+
+// stripSlowLogLiterals replaces the sql text in the lines of the slow log file with its fingerprint, the sql which
+// spans lines is joined into one line.
+func stripSlowLogLiterals(lines []string) []string {
+	var res, sql []string
+	flush := func() {
+		if len(sql) != 0 {
+			res = append(res, SqlPrefix+sqlutil.Fingerprint(strings.Join(sql, stringutil.STR_NEWLINE)))
+			sql = nil
+		}
+	}
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, SqlPrefix):
+			flush()
+			sql = append(sql, strings.TrimPrefix(line, SqlPrefix))
+		case strings.HasPrefix(line, stringutil.STR_HASH) || len(sql) == 0:
+			flush()
+			res = append(res, line)
+		default:
+			sql = append(sql, line)
+		}
+	}
+	flush()
+	return res
+}
+
+// percentile returns the nearest-rank percentile of the values.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func roundTime(t float64) float64 {
+	return math.Round(t*1000) / 1000
+}
+
+func addNotEmpty(set map[string]struct{}, value string) {
+	if !stringutil.IsEmpty(value) {
+		set[value] = struct{}{}
+	}
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	res := make([]string, 0, len(set))
+	for k := range set {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
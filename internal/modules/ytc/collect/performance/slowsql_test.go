@@ -0,0 +1,75 @@
+package performance_test
+
+import (
+	"reflect"
+	"testing"
+
+	"ytc/internal/modules/ytc/collect/performance"
+	"ytc/internal/modules/ytc/collect/yasdb"
+)
+
+func TestSummarizeSlowLogs(t *testing.T) {
+	logs := []*yasdb.SlowLog{
+		{UserName: "U1", UserHost: "10.0.0.1", QueryTime: 100, RowsSent: 1, SQLID: "a1", SQLText: "select * from t where id = 1"},
+		{UserName: "U2", UserHost: "10.0.0.2", QueryTime: 300, RowsSent: 2, SQLID: "a2", SQLText: "SELECT * FROM t WHERE id = 2"},
+		{UserName: "U1", UserHost: "10.0.0.1", QueryTime: 200, RowsSent: 3, SQLID: "a1", SQLText: "select * from t where id = 1"},
+		{UserName: "U1", UserHost: "10.0.0.1", QueryTime: 1000, SQLID: "b1", SQLText: "delete from t where id in (1, 2, 3)"},
+		{UserName: "U1", UserHost: "10.0.0.1", QueryTime: 50, SQLID: "c1"},
+	}
+	summaries := performance.SummarizeSlowLogs(logs)
+	if len(summaries) != 3 {
+		t.Fatalf("expected 3 summaries, got %d", len(summaries))
+	}
+	expected := &performance.SlowSQLSummary{
+		Fingerprint: "select * from t where id = ?",
+		SQLIDs:      []string{"a1", "a2"},
+		SQLIDStats:  []*performance.SlowSQLIDStats{{SQLID: "a1", Count: 2, TotalTime: 300}, {SQLID: "a2", Count: 1, TotalTime: 300}},
+		Count:       3,
+		TotalTime:   600,
+		AvgTime:     200,
+		P95Time:     300,
+		MaxTime:     300,
+		RowsSent:    6,
+		Users:       []string{"U1", "U2"},
+		Hosts:       []string{"10.0.0.1", "10.0.0.2"},
+	}
+	if summaries[0].Fingerprint != "delete from t where id in (?+)" {
+		t.Errorf("the summaries should be sorted by the total time, got %s first", summaries[0].Fingerprint)
+	}
+	if !reflect.DeepEqual(summaries[1], expected) {
+		t.Errorf("expected %+v, got %+v", expected, summaries[1])
+	}
+	if summaries[2].Fingerprint != "" || !reflect.DeepEqual(summaries[2].SQLIDs, []string{"c1"}) {
+		t.Errorf("the slow log without sql text should be aggregated by sql id, got %+v", summaries[2])
+	}
+}
+
+func TestParseSlowLogLines(t *testing.T) {
+	lines := []string{
+		"# TIME: 2023-01-01 12:00:00",
+		"# USER_HOST: SALES[SALES] @ 10.0.0.1",
+		"# DB_NAME: yasdb",
+		"# SQL_ID: a1",
+		"# COST_EXECUTE_TIME: 120.5",
+		"# COST_OPTIMIZE_TIME: 1",
+		"# ROWS_SENT: 3",
+		"SQL: select *",
+		"from t where id = 1",
+		"# TIME: 2023-01-01 12:00:01",
+		"# SQL_ID: a2",
+		"# COST_EXECUTE_TIME: 80",
+		"SQL: select * from t where id = 2",
+	}
+	logs := performance.ParseSlowLogLines(lines)
+	expected := []*yasdb.SlowLog{
+		{DBName: "yasdb", UserName: "SALES", StartTime: "2023-01-01 12:00:00", UserHost: "10.0.0.1", QueryTime: 120.5, RowsSent: 3, SQLID: "a1", SQLText: "select *\nfrom t where id = 1"},
+		{StartTime: "2023-01-01 12:00:01", QueryTime: 80, SQLID: "a2", SQLText: "select * from t where id = 2"},
+	}
+	if !reflect.DeepEqual(logs, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, logs)
+	}
+	summaries := performance.SummarizeSlowLogs(logs)
+	if len(summaries) != 1 || summaries[0].Count != 2 || len(summaries[0].SQLIDStats) != 2 {
+		t.Errorf("the slow logs in the file should be summarized by fingerprint, got %+v", summaries)
+	}
+}
@@ -0,0 +1,251 @@
+// The redact package replaces the sensitive data in the text files of the collection result before packing.
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+
+	"ytc/defs/confdef"
+	"ytc/utils/fileutil"
+)
+
+const (
+	MAPPING_FILE_SUFFIX = ".redaction.json"
+
+	_token_formatter = "REDACTED_%s_%d"
+	_sniff_size      = 8000 // the files with NUL in the beginning are binary
+)
+
+var (
+	_token_prefix_invalid_char_regex = regexp.MustCompile(`[^A-Z0-9_]`)
+)
+
+// Redactor rewrites the text files in the package dir with the rules, the same value is replaced with the same
+// token in all the files.
+type Redactor struct {
+	dir       string
+	rules     []*rule
+	prefix    string                       // the tokens are namespaced by it, such as 'REDACTED_NODE1_IP_1'
+	tokens    map[string]map[string]string // the tokens of the values of each rule
+	stats     map[string]map[string]int    // the number of the replaced matches of each rule in each file
+	processed map[string]struct{}
+	mtx       sync.Mutex
+}
+
+func NewRedactor(packageDir string, conf confdef.Redact) (*Redactor, error) {
+	rules, err := newRules(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Redactor{
+		dir:       filepath.Clean(packageDir),
+		rules:     rules,
+		tokens:    make(map[string]map[string]string),
+		stats:     make(map[string]map[string]int),
+		processed: make(map[string]struct{}),
+	}, nil
+}
+
+// SetTokenPrefix namespaces the tokens with the prefix, so that the tokens of the results redacted separately, such
+// as the nodes of the cluster collection, never collide. The prefix is upper cased and the characters other than
+// letters, digits and underscores are replaced with underscores. It should be set before redacting.
+func (r *Redactor) SetTokenPrefix(prefix string) {
+	r.prefix = _token_prefix_invalid_char_regex.ReplaceAllString(strings.ToUpper(prefix), "_")
+}
+
+// Redact rewrites the text files in the package dir which are not processed yet, the dirs relative to the package
+// dir in skipDirs are not walked. It should be called before the files are archived and removed by the stream.
+// The files which failed are removed, so that their sensitive data are never packed, and returned as fileutil.FileErrors.
+func (r *Redactor) Redact(skipDirs ...string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	skip := make(map[string]struct{})
+	for _, d := range skipDirs {
+		skip[d] = struct{}{}
+	}
+	failed := make(fileutil.FileErrors)
+	err := filepath.WalkDir(r.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		rel, err := filepath.Rel(r.dir, p)
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if _, ok := skip[rel]; ok {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if _, ok := r.processed[rel]; ok || !d.Type().IsRegular() {
+			return nil
+		}
+		r.processed[rel] = struct{}{}
+		counts, err := r.redactFile(p)
+		if err != nil {
+			failed[p] = err
+			if e := os.Remove(p); e != nil {
+				failed[p] = fmt.Errorf("%v, and remove it: %v", err, e)
+			}
+			return nil
+		}
+		if len(counts) != 0 {
+			r.stats[rel] = counts
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(failed) != 0 {
+		return failed
+	}
+	return nil
+}
+
+// Stats returns the number of the replaced matches of each rule in the redacted files, the keys are the paths relative
+// to the package dir.
+func (r *Redactor) Stats() map[string]map[string]int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	res := make(map[string]map[string]int, len(r.stats))
+	for rel, counts := range r.stats {
+		res[rel] = make(map[string]int, len(counts))
+		for rule, count := range counts {
+			res[rel][rule] = count
+		}
+	}
+	return res
+}
+
+// Mapping returns the original values of the tokens of each rule.
+func (r *Redactor) Mapping() map[string]map[string]string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	res := make(map[string]map[string]string, len(r.tokens))
+	for rule, tokens := range r.tokens {
+		res[rule] = make(map[string]string, len(tokens))
+		for value, token := range tokens {
+			res[rule][token] = value
+		}
+	}
+	return res
+}
+
+// WriteMapping writes the mapping with mode 0600 since it holds the original values, nothing is written if no token
+// is generated. It returns whether the file is written.
+func (r *Redactor) WriteMapping(fname string) (bool, error) {
+	mapping := r.Mapping()
+	if len(mapping) == 0 {
+		return false, nil
+	}
+	data, err := json.MarshalIndent(mapping, "", "    ")
+	if err != nil {
+		return false, err
+	}
+	return true, fileutil.WriteFileAtomic(fname, data, 0600)
+}
+
+// token returns the token of the value, the host names and the emails are case insensitive.
+func (r *Redactor) token(rule, value string) string {
+	if rule == confdef.REDACT_RULE_HOSTNAME || rule == confdef.REDACT_RULE_EMAIL {
+		value = strings.ToLower(value)
+	}
+	tokens, ok := r.tokens[rule]
+	if !ok {
+		tokens = make(map[string]string)
+		r.tokens[rule] = tokens
+	}
+	if token, ok := tokens[value]; ok {
+		return token
+	}
+	name := strings.ToUpper(rule)
+	if len(r.prefix) != 0 {
+		name = r.prefix + "_" + name
+	}
+	token := fmt.Sprintf(_token_formatter, name, len(tokens)+1)
+	tokens[value] = token
+	return token
+}
+
+// redactFile rewrites the text file through a temporary file in the same dir if any rule matches, the mode, owner
+// and mtime are kept. The binary files are not changed.
+func (r *Redactor) redactFile(fname string) (map[string]int, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(f, _sniff_size)
+	head, err := br.Peek(_sniff_size)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+	tmp, err := os.CreateTemp(path.Dir(fname), "."+path.Base(fname)+".*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	bw := bufio.NewWriter(tmp)
+	counts := make(map[string]int)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) != 0 {
+			for _, rule := range r.rules {
+				line = rule.apply(line, r.token, counts)
+			}
+			if _, e := bw.Write(line); e != nil {
+				return nil, e
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		// the owner can only be kept by root, it is fine to fail since the package is chowned after packing
+		_ = os.Chown(tmp.Name(), int(stat.Uid), int(stat.Gid))
+	}
+	if err := os.Rename(tmp.Name(), fname); err != nil {
+		return nil, err
+	}
+	return counts, os.Chtimes(fname, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,177 @@
+package redact_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"ytc/defs/confdef"
+	"ytc/internal/modules/ytc/collect/redact"
+)
+
+func TestRedact(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"host/bash_history": "yasql sys/yasdb_123@10.1.2.3:1688\nping 10.1.2.3\nmail admin@example.com\n",
+		"yasdb/alert.log":   "connect from 10.1.2.3 and 10.1.2.4, listen on 127.0.0.1.\nversion 1.2.3.4.5\n",
+		"yasdb/sql.txt":     "create user u1 identified by Secret_1;\ncall 13812345678\n",
+		"static/lib.js":     "var ip = '10.9.9.9';\n",
+	}
+	binary := "\x00\x01 10.1.2.3"
+	mtime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.Local)
+	for name, content := range files {
+		writeFile(t, path.Join(dir, name), content, mtime)
+	}
+	writeFile(t, path.Join(dir, "core.1"), binary, mtime)
+
+	conf := confdef.Redact{
+		Enable: true,
+		Custom: []confdef.RedactRule{{Name: "phone", Pattern: `1[3-9]\d{9}`}},
+	}
+	redactor, err := redact.NewRedactor(dir, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := redactor.Redact("static"); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"host/bash_history": "yasql sys/******@REDACTED_IP_1:1688\nping REDACTED_IP_1\nmail REDACTED_EMAIL_1\n",
+		"yasdb/alert.log":   "connect from REDACTED_IP_1 and REDACTED_IP_2, listen on 127.0.0.1.\nversion 1.2.3.4.5\n",
+		"yasdb/sql.txt":     "create user u1 identified by ******;\ncall REDACTED_PHONE_1\n",
+		"static/lib.js":     files["static/lib.js"],
+		"core.1":            binary,
+	}
+	for name, content := range expected {
+		p := path.Join(dir, name)
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, string(data))
+		}
+		if info, err := os.Stat(p); err != nil || !info.ModTime().Equal(mtime) {
+			t.Errorf("%s: the mtime is not kept", name)
+		}
+	}
+
+	stats := redactor.Stats()
+	if counts := stats["host/bash_history"]; counts[confdef.REDACT_RULE_PASSWORD] != 1 || counts[confdef.REDACT_RULE_IP] != 2 || counts[confdef.REDACT_RULE_EMAIL] != 1 {
+		t.Errorf("unexpected stats of bash_history: %v", counts)
+	}
+	if _, ok := stats["core.1"]; ok {
+		t.Errorf("the binary file should not be redacted")
+	}
+	mapping := redactor.Mapping()
+	if mapping[confdef.REDACT_RULE_IP]["REDACTED_IP_2"] != "10.1.2.4" || mapping["phone"]["REDACTED_PHONE_1"] != "13812345678" {
+		t.Errorf("unexpected mapping: %v", mapping)
+	}
+	if _, ok := mapping[confdef.REDACT_RULE_PASSWORD]; ok {
+		t.Errorf("the passwords should not be in the mapping")
+	}
+
+	// the new files use the same tokens and the redacted files are not processed again
+	writeFile(t, path.Join(dir, "yasdb/slow.log"), "client 10.1.2.4\n", mtime)
+	if err := redactor.Redact("static"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path.Join(dir, "yasdb/slow.log")); string(data) != "client REDACTED_IP_2\n" {
+		t.Errorf("the token is not consistent: %q", string(data))
+	}
+	if redactor.Stats()["host/bash_history"][confdef.REDACT_RULE_IP] != 2 {
+		t.Errorf("the redacted file is processed again")
+	}
+
+	mappingFile := path.Join(dir, "ytc"+redact.MAPPING_FILE_SUFFIX)
+	if written, err := redactor.WriteMapping(mappingFile); err != nil || !written {
+		t.Fatalf("write mapping: %v", err)
+	}
+	if info, err := os.Stat(mappingFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("the mapping should be written with mode 0600")
+	}
+}
+
+func TestRedactVersion(t *testing.T) {
+	dir := t.TempDir()
+	p := path.Join(dir, "ytc-20230101120000.json")
+	content := "{\"details\": \"Release 23.2.1.100 x86_64 6db1237\"}\n" +
+		"YashanDB SQL Personal Edition Release 23.2.1.100 x86_64\n" +
+		"version: 23.2.1.100, VERSION=\"23.2.1.100\"\n" +
+		"connected to 23.2.1.100 from 10.1.2.3\n"
+	writeFile(t, p, content, time.Now())
+	redactor, err := redact.NewRedactor(dir, confdef.Redact{Rules: confdef.REDACT_RULE_IP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := redactor.Redact(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"details\": \"Release 23.2.1.100 x86_64 6db1237\"}\n" +
+		"YashanDB SQL Personal Edition Release 23.2.1.100 x86_64\n" +
+		"version: 23.2.1.100, VERSION=\"23.2.1.100\"\n" +
+		"connected to REDACTED_IP_1 from REDACTED_IP_2\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestRedactTokenPrefix(t *testing.T) {
+	dir := t.TempDir()
+	p := path.Join(dir, "alert.log")
+	writeFile(t, p, "connect from 10.1.2.3\n", time.Now())
+	redactor, err := redact.NewRedactor(dir, confdef.Redact{Rules: confdef.REDACT_RULE_IP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor.SetTokenPrefix("db-1")
+	if err := redactor.Redact(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(p); string(data) != "connect from REDACTED_DB_1_IP_1\n" {
+		t.Errorf("the token is not namespaced: %q", string(data))
+	}
+}
+
+func TestRedactHostname(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil || len(hostname) < 3 || strings.HasPrefix(hostname, "localhost") {
+		t.Skip("no host name to redact")
+	}
+	dir := t.TempDir()
+	p := path.Join(dir, "messages")
+	writeFile(t, p, "Jan 1 "+hostname+" kernel: vm."+hostname+"x\n", time.Now())
+	redactor, err := redact.NewRedactor(dir, confdef.Redact{Rules: confdef.REDACT_RULE_HOSTNAME})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := redactor.Redact(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Jan 1 REDACTED_HOSTNAME_1 kernel: vm." + hostname + "x\n"; string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+}
+
+func writeFile(t *testing.T, fname, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(path.Dir(fname), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fname, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(fname, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,240 @@
+package redact
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ytc/defs/confdef"
+)
+
+const (
+	_password_replacement = "******"
+	_min_hostname_length  = 3 // the shorter names are likely to be common words
+	_hosts_file           = "/etc/hosts"
+	_secret_group         = "secret"
+	_version_context_size = 32 // the bytes before the IP which are checked for the version context
+)
+
+var (
+	_password_patterns = []string{
+		`yasql\s+[^\s/@]+/(?P<secret>[^\s@]+)`,
+		`(?i)(?:password|passwd|pwd)["']?\s*[=:]\s*["']?(?P<secret>[^\s"',;]+)`,
+		`(?i)\bidentified\s+by\s+["']?(?P<secret>[^\s"',;]+)`,
+		`://[^\s/:@]+:(?P<secret>[^\s/@]+)@`,
+	}
+	_ip_pattern    = `(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)`
+	_email_pattern = `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`
+
+	_ip_regex = regexp.MustCompile(`^` + _ip_pattern + `$`)
+	// the 4-part versions of YashanDB, such as 'Release 23.2.1.100' of 'yasdb -V' and the yasql banner, or 'version: 23.2.1.100'
+	_version_context_regex = regexp.MustCompile(`(?i)\b(?:release|version|ver)["']?\s*[:=]?\s*["']?$`)
+)
+
+type rule struct {
+	name        string
+	regex       *regexp.Regexp
+	secrets     []int                                  // the indexes of the groups named secret, only the matched one is replaced if any
+	boundary    bool                                   // the match should not be a part of a longer name, such as 'vm' in 'vm.swappiness'
+	replacement string                                 // the matches are replaced with the tokens if it is empty
+	keep        func(line []byte, start, end int) bool // the matches of line[start:end] which are not sensitive
+}
+
+func newRule(name, pattern, replacement string) (*rule, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []int
+	for i, subexp := range regex.SubexpNames() {
+		if subexp == _secret_group {
+			secrets = append(secrets, i)
+		}
+	}
+	return &rule{
+		name:        name,
+		regex:       regex,
+		secrets:     secrets,
+		replacement: replacement,
+	}, nil
+}
+
+// newRules returns the rules in the order of applying, the passwords and the custom rules come first, so that they
+// are matched against the original text, and the emails come before the IPs and the host names they contain.
+func newRules(conf confdef.Redact) ([]*rule, error) {
+	enabled := make(map[string]bool)
+	for _, name := range conf.GetRules() {
+		enabled[name] = true
+	}
+	var rules []*rule
+	if enabled[confdef.REDACT_RULE_PASSWORD] {
+		rule, err := newRule(confdef.REDACT_RULE_PASSWORD, strings.Join(_password_patterns, "|"), _password_replacement)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	for _, custom := range conf.Custom {
+		rule, err := newRule(custom.Name, custom.Pattern, custom.Replacement)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if enabled[confdef.REDACT_RULE_EMAIL] {
+		rule, err := newRule(confdef.REDACT_RULE_EMAIL, _email_pattern, "")
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if enabled[confdef.REDACT_RULE_IP] {
+		rule, err := newRule(confdef.REDACT_RULE_IP, _ip_pattern, "")
+		if err != nil {
+			return nil, err
+		}
+		rule.boundary = true
+		rule.keep = keepIP
+		rules = append(rules, rule)
+	}
+	if enabled[confdef.REDACT_RULE_HOSTNAME] {
+		if names := localHostnames(); len(names) != 0 {
+			rule, err := newRule(confdef.REDACT_RULE_HOSTNAME, hostnamesPattern(names), "")
+			if err != nil {
+				return nil, err
+			}
+			rule.boundary = true
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// apply replaces the matches in line, the number of the replaced matches is added into counts.
+func (r *rule) apply(line []byte, token func(rule, value string) string, counts map[string]int) []byte {
+	matches := r.regex.FindAllSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return line
+	}
+	res := make([]byte, 0, len(line))
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(r.secrets) != 0 {
+			start, end = -1, -1
+			for _, i := range r.secrets {
+				if m[2*i] >= 0 {
+					start, end = m[2*i], m[2*i+1]
+					break
+				}
+			}
+		}
+		if start < 0 || start == end || start < last {
+			continue
+		}
+		value := string(line[start:end])
+		if r.boundary && !isBoundary(line, start, end) || r.keep != nil && r.keep(line, start, end) {
+			continue
+		}
+		replacement := r.replacement
+		if len(replacement) == 0 {
+			replacement = token(r.name, value)
+		}
+		res = append(append(res, line[last:start]...), replacement...)
+		last = end
+		counts[r.name]++
+	}
+	return append(res, line[last:]...)
+}
+
+// isBoundary reports whether line[start:end] is not adjacent to the characters of the names, a dot after it is
+// allowed if it ends a sentence.
+func isBoundary(line []byte, start, end int) bool {
+	if start > 0 && isNameChar(line[start-1]) {
+		return false
+	}
+	if end < len(line) && isNameChar(line[end]) {
+		if line[end] != '.' || end+1 < len(line) && isNameChar(line[end+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// keepIP keeps the IPs which tell nothing about the host and the versions which look like IPs.
+func keepIP(line []byte, start, end int) bool {
+	return isUnspecificIP(string(line[start:end])) || isVersion(line, start)
+}
+
+// isVersion reports whether the match at start follows a version keyword, such as 'Release 23.2.1.100'.
+func isVersion(line []byte, start int) bool {
+	begin := start - _version_context_size
+	if begin < 0 {
+		begin = 0
+	}
+	return _version_context_regex.Match(line[begin:start])
+}
+
+// isUnspecificIP reports whether the IP is the loopback, unspecified or broadcast address, which tells nothing about the host.
+func isUnspecificIP(ip string) bool {
+	return strings.HasPrefix(ip, "127.") || ip == "0.0.0.0" || ip == "255.255.255.255"
+}
+
+// localHostnames returns the name of the local host and the names in /etc/hosts, except the localhost ones.
+func localHostnames() []string {
+	names := make(map[string]struct{})
+	add := func(name string) {
+		name = strings.TrimSuffix(name, ".")
+		if len(name) < _min_hostname_length || strings.HasPrefix(name, "localhost") || strings.HasPrefix(name, "ip6-") {
+			return
+		}
+		if _ip_regex.MatchString(name) {
+			return
+		}
+		names[name] = struct{}{}
+		if short, _, found := strings.Cut(name, "."); found && len(short) >= _min_hostname_length {
+			names[short] = struct{}{}
+		}
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		add(hostname)
+	}
+	if f, err := os.Open(_hosts_file); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			fields := strings.Fields(line)
+			for i := 1; i < len(fields); i++ {
+				add(fields[i])
+			}
+		}
+	}
+	res := make([]string, 0, len(names))
+	for name := range names {
+		res = append(res, name)
+	}
+	return res
+}
+
+// hostnamesPattern matches the longest name first, so that the full name is not replaced as the short name.
+func hostnamesPattern(names []string) string {
+	sorted := append([]string{}, names...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	quoted := make([]string, 0, len(sorted))
+	for _, name := range sorted {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	return `(?i)(?:` + strings.Join(quoted, "|") + `)`
+}
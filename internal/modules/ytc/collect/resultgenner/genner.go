@@ -12,6 +12,12 @@ type Genner interface {
 	GenReport() (reporter.ReportContent, error)
 }
 
+// UnpackedFilesRecorder is implemented by the genner which reports the files that could not be read into the package,
+// the keys are the paths relative to the package dir and the values are the errors.
+type UnpackedFilesRecorder interface {
+	RecordUnpackedFiles(files map[string]string)
+}
+
 type BaseGenner struct{}
 
 func (g BaseGenner) GenData(data interface{}, path string) error {
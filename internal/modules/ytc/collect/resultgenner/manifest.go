@@ -0,0 +1,233 @@
+package resultgenner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ytc/utils/fileutil"
+)
+
+const (
+	MANIFEST_FILE_NAME = "ytc-manifest.json"
+)
+
+// ManifestFile describes a regular file or a symlink in the package, the mtime is rounded to seconds as it is in the tar file.
+type ManifestFile struct {
+	Path    string    `json:"path"` // relative to the package dir
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"mtime"`
+	SHA256  string    `json:"sha256,omitempty"` // regular files only
+	Link    string    `json:"link,omitempty"`   // symlinks only
+	Item    string    `json:"item,omitempty"`   // the item which produced the file, it is empty for the reports and the data file
+	// the number of the matches replaced by each redaction rule which fired in the file
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// Manifest lists the files in the package, it is written into the package dir before packing.
+type Manifest struct {
+	Package string          `json:"package"`
+	Files   []*ManifestFile `json:"files"`
+
+	dir      string
+	recorded map[string]*ManifestFile
+	seen     map[string]struct{} // the files which have been listed by NewFiles
+	owners   map[string]string   // the items which wrote the files, they take precedence over the paths of SetItems
+	sha256   string              // the checksum of the written manifest file
+	mtx      sync.Mutex
+}
+
+func NewManifest(packageDir string) *Manifest {
+	return &Manifest{
+		Package:  path.Base(packageDir),
+		dir:      filepath.Clean(packageDir),
+		recorded: make(map[string]*ManifestFile),
+		seen:     make(map[string]struct{}),
+		owners:   make(map[string]string),
+	}
+}
+
+// NewFiles returns the paths relative to the package dir of the files which are not listed by the previous calls,
+// the files are not read, so that it can be called while the files of the running items are being written.
+func (m *Manifest) NewFiles() ([]string, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	var files []string
+	err := filepath.WalkDir(m.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(m.dir, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := m.seen[rel]; ok {
+			return nil
+		}
+		m.seen[rel] = struct{}{}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
+
+// AttributeFiles records that the files returned by NewFiles are written by the item.
+func (m *Manifest) AttributeFiles(files []string, item string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for _, rel := range files {
+		m.owners[rel] = item
+	}
+}
+
+// Record hashes the files in the package dir which are not recorded yet, so it should be called before the files are
+// archived and removed by the stream. The files which failed are returned as fileutil.FileErrors.
+func (m *Manifest) Record() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	failed := make(fileutil.FileErrors)
+	err := filepath.WalkDir(m.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(m.dir, p)
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := m.recorded[rel]; ok || rel == MANIFEST_FILE_NAME {
+			return nil
+		}
+		file, err := newManifestFile(p, rel, d)
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		if file != nil {
+			m.recorded[rel] = file
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(failed) != 0 {
+		return failed
+	}
+	return nil
+}
+
+// SetItems attributes the files to the items, the files attributed by AttributeFiles belong to their items, and the
+// others are attributed by itemPaths, which maps the paths relative to the package dir to the items, a file belongs to
+// the item of the longest path which is the file itself or one of its parent dirs.
+func (m *Manifest) SetItems(itemPaths map[string]string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for rel, file := range m.recorded {
+		if item, ok := m.owners[rel]; ok {
+			file.Item = item
+			continue
+		}
+		var matched string
+		for p, item := range itemPaths {
+			if (rel == p || strings.HasPrefix(rel, p+"/")) && len(p) > len(matched) {
+				matched, file.Item = p, item
+			}
+		}
+	}
+}
+
+// SetRedactions records the redaction rules which fired in the files, the keys of stats are the paths relative to
+// the package dir.
+func (m *Manifest) SetRedactions(stats map[string]map[string]int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for rel, file := range m.recorded {
+		if counts, ok := stats[rel]; ok {
+			file.Redactions = counts
+		}
+	}
+}
+
+// Write writes the recorded files into the package dir in lexical order.
+func (m *Manifest) Write() error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.Files = make([]*ManifestFile, 0, len(m.recorded))
+	for _, file := range m.recorded {
+		m.Files = append(m.Files, file)
+	}
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+	data, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := fileutil.WriteFile(path.Join(m.dir, MANIFEST_FILE_NAME), data); err != nil {
+		return err
+	}
+	m.sha256 = hashBytes(data)
+	return nil
+}
+
+// SHA256 returns the checksum of the manifest file, it is empty before the manifest is written.
+func (m *Manifest) SHA256() string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.sha256
+}
+
+// newManifestFile returns nil for the sockets, pipes and devices, which are not archived.
+func newManifestFile(p, rel string, d fs.DirEntry) (*ManifestFile, error) {
+	info, err := d.Info()
+	if err != nil {
+		return nil, err
+	}
+	file := &ManifestFile{
+		Path:    rel,
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		ModTime: info.ModTime().Round(time.Second),
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		file.Size = 0
+		file.Link, err = os.Readlink(p)
+		return file, err
+	case info.Mode().IsRegular():
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		file.SHA256, err = HashReader(f)
+		return file, err
+	}
+	return nil, nil
+}
+
+// HashReader returns the hex encoded SHA-256 of the content.
+func HashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,126 @@
+package resultgenner_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"ytc/internal/modules/ytc/collect/resultgenner"
+	"ytc/utils/fileutil"
+)
+
+func packWithManifest(t *testing.T, packageDir string) string {
+	manifest := resultgenner.NewManifest(packageDir)
+	if err := manifest.Record(); err != nil {
+		t.Fatal(err)
+	}
+	manifest.SetItems(map[string]string{"yasdb/log": "YashanDB-RunLog", "yasdb/log/alert.log": "YashanDB-AlertLog"})
+	if err := manifest.Write(); err != nil {
+		t.Fatal(err)
+	}
+	tarPath := packageDir + ".tar.gz"
+	if err := fileutil.TarGz(packageDir, tarPath); err != nil {
+		t.Fatal(err)
+	}
+	return tarPath
+}
+
+func TestVerifyPackage(t *testing.T) {
+	packageDir := path.Join(t.TempDir(), "ytc-20230101120000")
+	if err := os.MkdirAll(path.Join(packageDir, "yasdb", "log"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"yasdb/log/run.log":   "run log",
+		"yasdb/log/alert.log": "alert log",
+		"ytc-report.txt":      "report",
+	}
+	// the mtime in the tar file is rounded to seconds
+	mtime := time.Date(2023, 1, 1, 12, 0, 0, 600*int(time.Millisecond), time.Local)
+	for name, content := range files {
+		if err := os.WriteFile(path.Join(packageDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path.Join(packageDir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := resultgenner.VerifyPackage(packWithManifest(t, packageDir), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK() || res.Verified != 3 || res.Package != "ytc-20230101120000" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Items["yasdb/log/alert.log"] != "YashanDB-AlertLog" || res.Items["yasdb/log/run.log"] != "YashanDB-RunLog" || res.Items["ytc-report.txt"] != "" {
+		t.Fatalf("unexpected items: %v", res.Items)
+	}
+
+	// pack the modified files with the original manifest
+	if err := os.WriteFile(path.Join(packageDir, "yasdb/log/run.log"), []byte("run lo9"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path.Join(packageDir, "ytc-report.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(packageDir, "extra.txt"), []byte("extra"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tarPath := packageDir + ".tar.gz"
+	if err := fileutil.TarGz(packageDir, tarPath); err != nil {
+		t.Fatal(err)
+	}
+	res, err = resultgenner.VerifyPackage(tarPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.OK() || res.Verified != 1 || len(res.Missing) != 1 || res.Missing[0] != "ytc-report.txt" ||
+		len(res.Extra) != 1 || res.Extra[0] != "extra.txt" || len(res.Altered["yasdb/log/run.log"]) == 0 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for _, field := range res.Altered["yasdb/log/run.log"] {
+		if field == "sha256" {
+			return
+		}
+	}
+	t.Fatalf("the sha256 of run.log should be altered: %v", res.Altered)
+}
+
+func TestManifestAttributeFiles(t *testing.T) {
+	packageDir := path.Join(t.TempDir(), "ytc-20230101120000")
+	if err := os.MkdirAll(path.Join(packageDir, "perf", "awr"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := resultgenner.NewManifest(packageDir)
+	if err := os.WriteFile(path.Join(packageDir, "ytc-state.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := manifest.NewFiles(); err != nil || len(files) != 1 {
+		t.Fatalf("unexpected files: %v, err: %v", files, err)
+	}
+	for _, name := range []string{"perf/awr/1-1-1-2.sql", "perf/awr/awr.html"} {
+		if err := os.WriteFile(path.Join(packageDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := manifest.NewFiles()
+	if err != nil || len(files) != 2 {
+		t.Fatalf("unexpected files: %v, err: %v", files, err)
+	}
+	manifest.AttributeFiles(files, "YashanDB-AWR")
+	if err := manifest.Record(); err != nil {
+		t.Fatal(err)
+	}
+	manifest.SetItems(map[string]string{"perf": "YashanDB-SlowSQL", "ytc-state.json": "Host-CPU"})
+	if err := manifest.Write(); err != nil {
+		t.Fatal(err)
+	}
+	items := make(map[string]string)
+	for _, f := range manifest.Files {
+		items[f.Path] = f.Item
+	}
+	if items["perf/awr/1-1-1-2.sql"] != "YashanDB-AWR" || items["perf/awr/awr.html"] != "YashanDB-AWR" || items["ytc-state.json"] != "Host-CPU" {
+		t.Fatalf("unexpected items: %v", items)
+	}
+}
@@ -12,6 +12,10 @@ const (
 	REPORT_TYPE_HTML ReportType = "html"
 )
 
+var (
+	REPORT_TYPES = []ReportType{REPORT_TYPE_TXT, REPORT_TYPE_MD, REPORT_TYPE_HTML}
+)
+
 const (
 	FONT_SIZE_H1 FontSize = iota + 1
 	FONT_SIZE_H2
@@ -30,6 +34,18 @@ type ReportContent struct {
 	Graph    string
 }
 
+// ByType returns the content of the report type.
+func (c ReportContent) ByType(t ReportType) string {
+	switch t {
+	case REPORT_TYPE_MD:
+		return c.Markdown
+	case REPORT_TYPE_HTML:
+		return c.HTML
+	default:
+		return c.Txt
+	}
+}
+
 func GenTxtTitle(title string) string {
 	return fmt.Sprintf("%s\n", title)
 }
@@ -1,16 +1,20 @@
 package resultgenner
 
 import (
-	"errors"
+	"crypto/ed25519"
 	"fmt"
+	"io"
+	"os"
 	"path"
+	"path/filepath"
+	"strings"
 
-	"ytc/defs/bashdef"
 	"ytc/defs/runtimedef"
 	ytccollectcommons "ytc/internal/modules/ytc/collect/commons"
+	"ytc/internal/modules/ytc/collect/redact"
 	"ytc/internal/modules/ytc/collect/resultgenner/reporter"
 	"ytc/log"
-	"ytc/utils/execerutil"
+	"ytc/utils/cryptoutil"
 	"ytc/utils/fileutil"
 	"ytc/utils/stringutil"
 
@@ -21,6 +25,7 @@ import (
 const (
 	_REPORT_NAME_FORMATTER = "ytc-report-%s.%s"
 	_DATA_NAME_FORMATTER   = "ytc-%s.json"
+	_PACKAGE_SUFFIX        = ".tar.gz"
 
 	_DIR_BASE          = "base"
 	_DIR_DIAG          = "diag"
@@ -38,6 +43,12 @@ type BaseResultGenner struct {
 	PackageName  string
 	Timestamp    string
 	Genner       Genner
+	Stream       *fileutil.TarGzStream  // the files of the items have been archived by it while collecting, it writes GetResultPath with EncryptTarOpts
+	Manifest     *Manifest              // the files archived by the stream have been recorded in it
+	ItemPaths    map[string]string      // the paths relative to the package dir of the items, used to attribute the files in the manifest
+	EncryptKey   *cryptoutil.EncryptKey // encrypt the package into ytc-*.tar.gz.enc while archiving if it is set
+	SigningKey   ed25519.PrivateKey     // sign the package and write the signature next to it if it is set
+	Redactor     *redact.Redactor       // redact the text files before they are recorded in the manifest if it is set
 }
 
 func (g *BaseResultGenner) GenResult() (string, error) {
@@ -45,33 +56,96 @@ func (g *BaseResultGenner) GenResult() (string, error) {
 	if err := g.Mkdirs(); err != nil {
 		return stringutil.STR_EMPTY, err
 	}
+	g.recordUnpacked(g.checkFiles())
 	if err := g.Genner.GenData(g.Datas, g.genDataPath()); err != nil {
 		logger.Warnf("generate data failed: %s", err)
 	}
-	if err := g.writeReport(); err != nil {
+	if err := g.writeReport(reporter.REPORT_TYPES...); err != nil {
 		logger.Errorf("write report failed: %s", err)
 		logger.Errorf("cause: %s", yaserr.Cause(err))
 	}
+	if err := g.redact(); err != nil {
+		logger.Warnf("redact failed: %s", err)
+	}
+	if err := g.writeManifest(); err != nil {
+		logger.Warnf("write manifest failed: %s", err)
+	}
 	if err := g.tarResult(); err != nil {
 		logger.Errorf("tar result failed: %s", err)
 		return stringutil.STR_EMPTY, err
 	}
+	if err := g.signResult(); err != nil {
+		// the package has been written, it is kept unsigned and the caller tells it by the missing signature
+		logger.Errorf("sign result failed: %s", err)
+	}
+	if err := g.writeRedactionMapping(); err != nil {
+		logger.Errorf("write redaction mapping failed: %s", err)
+	}
 	if err := g.chownResult(); err != nil {
 		logger.Errorf("chown result failed: %s", err)
 	}
-	return g.genPackageTarPath(), nil
+	return g.GetResultPath(), nil
+}
+
+// GenReport only generates reports of the given types into the package dir, and returns the report paths.
+func (g *BaseResultGenner) GenReport(types ...reporter.ReportType) ([]string, error) {
+	if err := g.Mkdirs(); err != nil {
+		return nil, err
+	}
+	if err := g.writeReport(types...); err != nil {
+		return nil, err
+	}
+	if err := ytccollectcommons.ChownToExecuter(g.genPackageDir()); err != nil {
+		log.Module.Warnf("chown %s failed: %s", g.genPackageDir(), err)
+	}
+	var paths []string
+	for _, t := range types {
+		paths = append(paths, g.genReportPath(t))
+	}
+	return paths, nil
 }
 
 func (g *BaseResultGenner) GetPackageDir() string {
 	return g.genPackageDir()
 }
 
+func (g *BaseResultGenner) GetPackageTarPath() string {
+	return g.genPackageTarPath()
+}
+
+// GetResultPath returns the path of the final result, which is the encrypted one if EncryptKey is set.
+func (g *BaseResultGenner) GetResultPath() string {
+	if g.EncryptKey != nil {
+		return g.genPackageTarPath() + cryptoutil.ENCRYPTED_FILE_SUFFIX
+	}
+	return g.genPackageTarPath()
+}
+
+// EncryptTarOpts returns the options to encrypt the package while archiving it if key is set, so that the unencrypted
+// package never reaches the disk.
+func EncryptTarOpts(key *cryptoutil.EncryptKey) []fileutil.TarOpt {
+	if key == nil {
+		return nil
+	}
+	k := *key
+	return []fileutil.TarOpt{fileutil.WithWrapWriter(func(w io.Writer) (io.WriteCloser, error) {
+		return cryptoutil.NewEncryptWriter(w, k)
+	})}
+}
+
+// GenRedactionMappingPath returns the path of the mapping of the redaction tokens of the package, which is such as
+// ytc-20230101120000.tar.gz or the encrypted one, the mapping is not packed.
+func GenRedactionMappingPath(packagePath string) string {
+	p := strings.TrimSuffix(packagePath, cryptoutil.ENCRYPTED_FILE_SUFFIX)
+	return strings.TrimSuffix(p, _PACKAGE_SUFFIX) + redact.MAPPING_FILE_SUFFIX
+}
+
 func (g *BaseResultGenner) genPackageDir() string {
 	return path.Join(g.OutputDir, g.PackageName)
 }
 
 func (g *BaseResultGenner) genPackageTarName() string {
-	return fmt.Sprint(g.PackageName, ".tar.gz")
+	return fmt.Sprint(g.PackageName, _PACKAGE_SUFFIX)
 }
 
 func (g *BaseResultGenner) genPackageTarPath() string {
@@ -107,12 +181,14 @@ func (g *BaseResultGenner) genReportPath(reportType reporter.ReportType) string
 	return path.Join(g.genPackageDir(), name)
 }
 
-func (g *BaseResultGenner) writeReport() error {
-	executer := execerutil.NewExecer(log.Logger)
-	ret, _, stderr := executer.Exec(bashdef.CMD_BASH, "-c",
-		fmt.Sprintf("%s -r %s %s", bashdef.CMD_CP, runtimedef.GetStaticPath(), g.genReportStaticDir()))
-	if ret != 0 {
-		log.Module.Errorf("copy static failed: %s", stderr)
+func (g *BaseResultGenner) writeReport(types ...reporter.ReportType) error {
+	for _, t := range types {
+		if t != reporter.REPORT_TYPE_HTML || fs.IsDirExist(g.genReportStaticDir()) {
+			continue
+		}
+		if err := fileutil.CopyTree(runtimedef.GetStaticPath(), g.genReportStaticDir()); err != nil {
+			log.Module.Errorf("copy static failed: %s", err)
+		}
 	}
 
 	content, err := g.Genner.GenReport()
@@ -120,34 +196,135 @@ func (g *BaseResultGenner) writeReport() error {
 		err = yaserr.Wrapf(err, "genner generate report")
 		return err
 	}
-	txt := g.genReportPath(reporter.REPORT_TYPE_TXT)
-	if err := fileutil.WriteFile(txt, []byte(content.Txt)); err != nil {
-		err = yaserr.Wrapf(err, "write %s report", reporter.REPORT_TYPE_TXT)
-		return err
+	for _, t := range types {
+		if err := fileutil.WriteFile(g.genReportPath(t), []byte(content.ByType(t))); err != nil {
+			err = yaserr.Wrapf(err, "write %s report", t)
+			return err
+		}
 	}
-	markdown := g.genReportPath(reporter.REPORT_TYPE_MD)
-	if err := fileutil.WriteFile(markdown, []byte(content.Markdown)); err != nil {
-		err = yaserr.Wrapf(err, "write %s report", reporter.REPORT_TYPE_MD)
-		return err
+	return nil
+}
+
+// redact rewrites the files which are not archived yet, the static files of the html report are not redacted.
+func (g *BaseResultGenner) redact() error {
+	if g.Redactor == nil {
+		return nil
 	}
-	html := g.genReportPath(reporter.REPORT_TYPE_HTML)
-	if err := fileutil.WriteFile(html, []byte(content.HTML)); err != nil {
-		err = yaserr.Wrapf(err, "write %s report", reporter.REPORT_TYPE_HTML)
-		return err
+	return g.Redactor.Redact(_DIR_REPORT_STATIC)
+}
+
+// checkFiles redacts and records the collected files before the reports are written, the files which failed are
+// returned, so that they are listed in the reports as the files which are not packed.
+func (g *BaseResultGenner) checkFiles() fileutil.FileErrors {
+	failed := make(fileutil.FileErrors)
+	addFailed := func(err error) {
+		files, ok := err.(fileutil.FileErrors)
+		if !ok {
+			log.Module.Warnf("check files failed: %s", err)
+			return
+		}
+		for p, e := range files {
+			failed[p] = e
+		}
 	}
-	return nil
+	if err := g.redact(); err != nil {
+		addFailed(err)
+	}
+	if g.Manifest == nil {
+		g.Manifest = NewManifest(g.genPackageDir())
+	}
+	if err := g.Manifest.Record(); err != nil {
+		addFailed(err)
+	}
+	return failed
+}
+
+// recordUnpacked passes the files which are not packed to the genner if it reports them.
+func (g *BaseResultGenner) recordUnpacked(failed fileutil.FileErrors) {
+	recorder, ok := g.Genner.(UnpackedFilesRecorder)
+	if !ok || len(failed) == 0 {
+		return
+	}
+	files := make(map[string]string, len(failed))
+	for p, err := range failed {
+		rel, e := filepath.Rel(g.genPackageDir(), p)
+		if e != nil {
+			rel = p
+		}
+		files[filepath.ToSlash(rel)] = err.Error()
+	}
+	recorder.RecordUnpackedFiles(files)
 }
 
+// writeManifest records the files which are not archived yet and writes the manifest, which is archived with them.
+// The files which failed to be hashed are not listed.
+func (g *BaseResultGenner) writeManifest() error {
+	if g.Manifest == nil {
+		g.Manifest = NewManifest(g.genPackageDir())
+	}
+	if err := g.Manifest.Record(); err != nil {
+		log.Module.Warnf("record files into manifest failed: %s", err)
+	}
+	g.Manifest.SetItems(g.ItemPaths)
+	if g.Redactor != nil {
+		g.Manifest.SetRedactions(g.Redactor.Stats())
+	}
+	return g.Manifest.Write()
+}
+
+// tarResult archives the package dir into the final result and removes it, the package dir is kept if it failed.
+// The files which can not be read are skipped and logged.
 func (g *BaseResultGenner) tarResult() error {
-	command := fmt.Sprintf("cd %s;%s czvf %s %s;rm -rf %s", g.OutputDir, bashdef.CMD_TAR, g.genPackageTarName(), g.PackageName, g.PackageName)
-	executer := execerutil.NewExecer(log.Logger)
-	ret, _, stderr := executer.Exec(bashdef.CMD_BASH, "-c", command)
-	if ret != 0 {
-		return errors.New(stderr)
+	var err error
+	if g.Stream != nil {
+		err = g.Stream.Close()
+	} else {
+		err = fileutil.TarGz(g.genPackageDir(), g.GetResultPath(), EncryptTarOpts(g.EncryptKey)...)
+	}
+	if skipped, ok := err.(fileutil.FileErrors); ok {
+		for p, e := range skipped {
+			log.Module.Warnf("skip packing %s: %s", p, e)
+		}
+		g.recordUnpacked(skipped)
+	} else if err != nil {
+		return yaserr.Wrapf(err, "archive %s", g.genPackageDir())
+	}
+	return os.RemoveAll(g.genPackageDir())
+}
+
+// signResult signs the final result with the manifest, the result is kept without signature if it failed.
+func (g *BaseResultGenner) signResult() error {
+	if g.SigningKey == nil {
+		return nil
+	}
+	if err := SignPackage(g.GetResultPath(), g.Manifest.SHA256(), g.SigningKey); err != nil {
+		return yaserr.Wrapf(err, "sign %s", g.GetResultPath())
 	}
 	return nil
 }
 
+// writeRedactionMapping writes the mapping of the tokens next to the result, the package is still usable if it failed.
+func (g *BaseResultGenner) writeRedactionMapping() error {
+	if g.Redactor == nil {
+		return nil
+	}
+	_, err := g.Redactor.WriteMapping(GenRedactionMappingPath(g.GetResultPath()))
+	return err
+}
+
 func (g *BaseResultGenner) chownResult() error {
-	return ytccollectcommons.ChownToExecuter(g.genPackageTarPath())
+	if g.Redactor != nil {
+		mapping := GenRedactionMappingPath(g.GetResultPath())
+		if _, err := os.Stat(mapping); err == nil {
+			if err := ytccollectcommons.ChownToExecuter(mapping); err != nil {
+				return err
+			}
+		}
+	}
+	if g.SigningKey != nil {
+		if err := ytccollectcommons.ChownToExecuter(GenSignaturePath(g.GetResultPath())); err != nil {
+			return err
+		}
+	}
+	return ytccollectcommons.ChownToExecuter(g.GetResultPath())
 }
@@ -0,0 +1,143 @@
+package resultgenner
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"ytc/utils/cryptoutil"
+	"ytc/utils/fileutil"
+)
+
+const (
+	SIGNATURE_FILE_SUFFIX = ".sig"
+
+	_signature_version = "ytc-signature-v1"
+)
+
+var (
+	ErrSignatureNotFound  = errors.New("signature not found")
+	ErrUntrustedKey       = errors.New("the package is signed by an untrusted key")
+	ErrInvalidSignature   = errors.New("the signature is invalid")
+	ErrSignatureNotMatch  = errors.New("the package does not match its signature")
+	ErrHostNotMatch       = errors.New("the package is signed on another host than the one of the key")
+	ErrManifestNotWritten = errors.New("the manifest is not written, the package can not be signed without it")
+)
+
+// Signature is the detached signature of a result package, which is written next to it as <package>.sig.
+// The checksums of the package and its manifest are signed with the host and the time.
+type Signature struct {
+	Package        string    `json:"package"` // the base name of the signed package
+	Host           string    `json:"host"`
+	SignedAt       time.Time `json:"signedAt"`
+	PackageSHA256  string    `json:"packageSha256"`
+	ManifestSHA256 string    `json:"manifestSha256"`
+	KeyFingerprint string    `json:"keyFingerprint"`
+	Signature      string    `json:"signature"` // the base64 encoded ed25519 signature of the fields above
+}
+
+// GenSignaturePath returns the path of the signature of the package.
+func GenSignaturePath(packagePath string) string {
+	return packagePath + SIGNATURE_FILE_SUFFIX
+}
+
+// SignPackage signs the package with the checksum of its manifest, and writes the signature next to it.
+// ErrManifestNotWritten is returned if the checksum is empty.
+func SignPackage(packagePath, manifestSHA256 string, key ed25519.PrivateKey) error {
+	if len(manifestSHA256) == 0 {
+		return ErrManifestNotWritten
+	}
+	packageSHA256, err := hashFile(packagePath)
+	if err != nil {
+		return err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	sig := &Signature{
+		Package:        path.Base(packagePath),
+		Host:           host,
+		SignedAt:       time.Now().Round(time.Second),
+		PackageSHA256:  packageSHA256,
+		ManifestSHA256: manifestSHA256,
+		KeyFingerprint: cryptoutil.Fingerprint(key.Public().(ed25519.PublicKey)),
+	}
+	sig.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, sig.payload()))
+	data, err := json.MarshalIndent(sig, "", "    ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFile(GenSignaturePath(packagePath), data)
+}
+
+// VerifySignature checks the signature next to the package with the trusted keys, and returns the signature and the
+// key which signed it. The manifest in the package should be checked against Signature.ManifestSHA256 by the caller.
+// ErrHostNotMatch is returned with them if the key is trusted for another host.
+func VerifySignature(packagePath string, trusted []cryptoutil.TrustedKey) (*Signature, *cryptoutil.TrustedKey, error) {
+	data, err := os.ReadFile(GenSignaturePath(packagePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrSignatureNotFound
+		}
+		return nil, nil, err
+	}
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, nil, fmt.Errorf("parse %s: %w", GenSignaturePath(packagePath), err)
+	}
+	var key *cryptoutil.TrustedKey
+	for i := range trusted {
+		if trusted[i].Fingerprint == sig.KeyFingerprint {
+			key = &trusted[i]
+			break
+		}
+	}
+	if key == nil {
+		return &sig, nil, fmt.Errorf("%w: %s", ErrUntrustedKey, sig.KeyFingerprint)
+	}
+	signature, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil || !ed25519.Verify(key.PublicKey, sig.payload(), signature) {
+		return &sig, key, ErrInvalidSignature
+	}
+	packageSHA256, err := hashFile(packagePath)
+	if err != nil {
+		return &sig, key, err
+	}
+	if packageSHA256 != sig.PackageSHA256 {
+		return &sig, key, ErrSignatureNotMatch
+	}
+	if len(key.Host) != 0 && key.Host != sig.Host {
+		return &sig, key, fmt.Errorf("%w: signed on %s, the key is of %s", ErrHostNotMatch, sig.Host, key.Host)
+	}
+	return &sig, key, nil
+}
+
+// payload returns the signed content, one field per line.
+func (s *Signature) payload() []byte {
+	lines := []string{
+		_signature_version,
+		"package: " + s.Package,
+		"host: " + s.Host,
+		"signed_at: " + s.SignedAt.UTC().Format(time.RFC3339),
+		"package_sha256: " + s.PackageSHA256,
+		"manifest_sha256: " + s.ManifestSHA256,
+		"key: " + s.KeyFingerprint,
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func hashFile(fname string) (string, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return HashReader(f)
+}
@@ -0,0 +1,106 @@
+package resultgenner_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"ytc/internal/modules/ytc/collect/resultgenner"
+	"ytc/utils/cryptoutil"
+)
+
+func TestVerifySignature(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := path.Join(dir, "ytc-signing")
+	publicKey, privateKey, err := cryptoutil.GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyFile, err := cryptoutil.WriteSigningKeyFiles(keyFile, publicKey, privateKey, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cryptoutil.LoadSigningKey(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := cryptoutil.LoadTrustedKeys(publicKeyFile)
+	if err != nil || len(trusted) != 1 || trusted[0].Host != host {
+		t.Fatalf("unexpected trusted keys: %v, err: %v", trusted, err)
+	}
+
+	packagePath := path.Join(dir, "ytc-20230101120000.tar.gz")
+	if err := os.WriteFile(packagePath, []byte("package"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := resultgenner.SignPackage(packagePath, "manifest-sha256", privateKey); err != nil {
+		t.Fatal(err)
+	}
+	sig, key, err := resultgenner.VerifySignature(packagePath, trusted)
+	if err != nil || sig.ManifestSHA256 != "manifest-sha256" || key.Fingerprint != cryptoutil.Fingerprint(publicKey) {
+		t.Fatalf("verify signature err: %v", err)
+	}
+
+	// the manifest is not written
+	if err := resultgenner.SignPackage(packagePath, "", privateKey); err != resultgenner.ErrManifestNotWritten {
+		t.Errorf("expect manifest not written, got: %v", err)
+	}
+
+	// the key is trusted for another host
+	otherHost := []cryptoutil.TrustedKey{trusted[0]}
+	otherHost[0].Host = "another-" + host
+	if _, _, err := resultgenner.VerifySignature(packagePath, otherHost); !errors.Is(err, resultgenner.ErrHostNotMatch) {
+		t.Errorf("expect host not match, got: %v", err)
+	}
+
+	// signed by another key
+	_, otherKey, err := cryptoutil.GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPath := path.Join(dir, "ytc-20230102120000.tar.gz")
+	if err := os.WriteFile(otherPath, []byte("package"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := resultgenner.SignPackage(otherPath, "manifest-sha256", otherKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := resultgenner.VerifySignature(otherPath, trusted); !errors.Is(err, resultgenner.ErrUntrustedKey) {
+		t.Errorf("expect untrusted key, got: %v", err)
+	}
+
+	// the signed fields are modified
+	sigPath := resultgenner.GenSignaturePath(packagePath)
+	data, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var modified resultgenner.Signature
+	if err := json.Unmarshal(data, &modified); err != nil {
+		t.Fatal(err)
+	}
+	modified.Host = "host2"
+	modifiedData, _ := json.Marshal(modified)
+	if err := os.WriteFile(sigPath, modifiedData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := resultgenner.VerifySignature(packagePath, trusted); err != resultgenner.ErrInvalidSignature {
+		t.Errorf("expect invalid signature, got: %v", err)
+	}
+
+	// the package is modified
+	if err := os.WriteFile(sigPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(packagePath, []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := resultgenner.VerifySignature(packagePath, trusted); err != resultgenner.ErrSignatureNotMatch {
+		t.Errorf("expect signature not match, got: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package resultgenner
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"ytc/utils/cryptoutil"
+)
+
+var (
+	ErrManifestNotFound = errors.New(MANIFEST_FILE_NAME + " not found in the package, it may be packed by an early version")
+)
+
+// VerifyResult is the difference between the files in the package and its manifest.
+type VerifyResult struct {
+	Package  string
+	Verified int                 // the files which match the manifest
+	Missing  []string            // the files in the manifest but not in the package
+	Extra    []string            // the files in the package but not in the manifest
+	Altered  map[string][]string // the files whose size, mode, mtime, sha256 or link is different, with the different fields
+	Items    map[string]string   // the items which produced the files in the manifest
+	// the checksum of the manifest, which is signed in the signature of the package
+	ManifestSHA256 string
+}
+
+// OK returns true if the package matches the manifest.
+func (r *VerifyResult) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Altered) == 0
+}
+
+// VerifyPackage checks the files in the ytc-*.tar.gz against the manifest in it, the encrypted ytc-*.tar.gz.enc is
+// decrypted with key. The error is returned if the package can not be read or the manifest is not found, the
+// differences are returned in the result.
+func VerifyPackage(tarPath string, key *cryptoutil.DecryptKey) (*VerifyResult, error) {
+	actual, manifestData, err := readPackageFiles(tarPath, key)
+	if err != nil {
+		return nil, err
+	}
+	if manifestData == nil {
+		return nil, ErrManifestNotFound
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", MANIFEST_FILE_NAME, err)
+	}
+	res := &VerifyResult{
+		Package:        manifest.Package,
+		Altered:        make(map[string][]string),
+		Items:          make(map[string]string),
+		ManifestSHA256: hashBytes(manifestData),
+	}
+	for _, expected := range manifest.Files {
+		res.Items[expected.Path] = expected.Item
+		file, ok := actual[expected.Path]
+		if !ok {
+			res.Missing = append(res.Missing, expected.Path)
+			continue
+		}
+		delete(actual, expected.Path)
+		if fields := diffManifestFile(expected, file); len(fields) != 0 {
+			res.Altered[expected.Path] = fields
+			continue
+		}
+		res.Verified++
+	}
+	for p := range actual {
+		res.Extra = append(res.Extra, p)
+	}
+	sort.Strings(res.Missing)
+	sort.Strings(res.Extra)
+	return res, nil
+}
+
+// readPackageFiles hashes the files in the package, the paths are relative to the package dir.
+func readPackageFiles(tarPath string, key *cryptoutil.DecryptKey) (files map[string]*ManifestFile, manifest []byte, err error) {
+	f, err := cryptoutil.OpenFile(tarPath, key)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	files = make(map[string]*ManifestFile)
+	for {
+		header, e := tr.Next()
+		if e == io.EOF {
+			return
+		}
+		if e != nil {
+			err = e
+			return
+		}
+		// the entries are placed under the package dir, such as ytc-20230101120000/yasdb/log/alert.log
+		parts := strings.SplitN(strings.TrimPrefix(header.Name, "./"), "/", 2)
+		if header.Typeflag == tar.TypeDir || len(parts) != 2 || len(parts[1]) == 0 {
+			continue
+		}
+		rel := parts[1]
+		if rel == MANIFEST_FILE_NAME {
+			if manifest, err = io.ReadAll(tr); err != nil {
+				return
+			}
+			continue
+		}
+		file := &ManifestFile{
+			Path:    rel,
+			Size:    header.Size,
+			Mode:    header.FileInfo().Mode().String(),
+			ModTime: header.ModTime.Round(time.Second),
+			Link:    header.Linkname,
+		}
+		if header.Typeflag == tar.TypeReg {
+			if file.SHA256, err = HashReader(tr); err != nil {
+				return
+			}
+		}
+		files[rel] = file
+	}
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func diffManifestFile(expected, actual *ManifestFile) (fields []string) {
+	if expected.Size != actual.Size {
+		fields = append(fields, "size")
+	}
+	if expected.Mode != actual.Mode {
+		fields = append(fields, "mode")
+	}
+	if !expected.ModTime.Equal(actual.ModTime) {
+		fields = append(fields, "mtime")
+	}
+	if expected.SHA256 != actual.SHA256 {
+		fields = append(fields, "sha256")
+	}
+	if expected.Link != actual.Link {
+		fields = append(fields, "link")
+	}
+	return
+}
@@ -0,0 +1,140 @@
+package yasdb
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	constdef "ytc/defs/constants"
+	"ytc/defs/errdef"
+	"ytc/utils/processutil"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+	ini "gopkg.in/ini.v1"
+)
+
+const (
+	base_yasdb_process_format = `.*yasdb (?i:(nomount|mount|open))`
+)
+
+// FillFromCredentials fills the empty fields of yasdb env from a file of 'KEY=VALUE' lines,
+// the file must not be accessible by group or others.
+func (y *YasdbEnv) FillFromCredentials(fname string) error {
+	info, err := os.Stat(fname)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &errdef.ErrFileNotFound{Fname: fname}
+		}
+		return err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return errdef.NewErrInsecureFileMode(fname, info.Mode())
+	}
+	conf, err := ini.Load(fname)
+	if err != nil {
+		return yaserr.Wrapf(&errdef.ErrFileParseFailed{Fname: fname, Err: err}, "load credentials")
+	}
+	section := conf.Section(ini.DefaultSection)
+	password := y.YasdbPassword.Reveal()
+	fields := []struct {
+		key   string
+		value *string
+	}{
+		{key: constdef.YASDB_HOME, value: &y.YasdbHome},
+		{key: constdef.YASDB_DATA, value: &y.YasdbData},
+		{key: constdef.YASDB_USER, value: &y.YasdbUser},
+		{key: constdef.YASDB_PASSWORD, value: &password},
+		{key: constdef.YASDB_ADDRESS, value: &y.YasdbAddress},
+	}
+	for _, field := range fields {
+		if !stringutil.IsEmpty(*field.value) || !section.HasKey(field.key) {
+			continue
+		}
+		*field.value = strings.TrimSpace(section.Key(field.key).String())
+	}
+	y.YasdbPassword = pwdutil.NewSecret(password)
+	return nil
+}
+
+// FillFromProcess fills the empty YASDB_HOME and YASDB_DATA from the running yasdb process, YASDB_DATA is not filled
+// when yasdb is connected over TCP, since the local process is not the remote instance.
+func (y *YasdbEnv) FillFromProcess() {
+	if !stringutil.IsEmpty(y.YasdbHome) && (!stringutil.IsEmpty(y.YasdbData) || y.IsRemote()) {
+		return
+	}
+	processYasdbHome, processYasdbData := GetYasdbPathFromProcess()
+	if stringutil.IsEmpty(y.YasdbHome) {
+		y.YasdbHome = processYasdbHome
+	}
+	if stringutil.IsEmpty(y.YasdbData) && !y.IsRemote() {
+		y.YasdbData = processYasdbData
+	}
+}
+
+// GetYasdbPathFromProcess returns YASDB_HOME and YASDB_DATA of the first running yasdb process.
+func GetYasdbPathFromProcess() (yasdbHome string, yasdbData string) {
+	processes, err := processutil.ListAnyUserProcessByCmdline(base_yasdb_process_format, true)
+	if err != nil {
+		return
+	}
+	for _, p := range processes {
+		if home, data, ok := parseYasdbPath(p); ok {
+			return home, data
+		}
+	}
+	return
+}
+
+// DiscoverInstances returns YASDB_HOME and YASDB_DATA of all the running yasdb processes, the processes with the same
+// YASDB_DATA are listed once.
+func DiscoverInstances() ([]*YasdbEnv, error) {
+	processes, err := processutil.GetYasdbProcess("")
+	if err != nil {
+		return nil, err
+	}
+	var envs []*YasdbEnv
+	datas := make(map[string]struct{})
+	for _, p := range processes {
+		home, data, ok := parseYasdbPath(p)
+		if !ok {
+			continue
+		}
+		if _, ok := datas[data]; ok {
+			continue
+		}
+		datas[data] = struct{}{}
+		envs = append(envs, &YasdbEnv{YasdbHome: home, YasdbData: data})
+	}
+	return envs, nil
+}
+
+// GetYasdbHomeFromProcess returns YASDB_HOME of the running yasdb process of YASDB_DATA, it is empty if the process is not found.
+func GetYasdbHomeFromProcess(yasdbData string) string {
+	processes, err := processutil.GetYasdbProcess(regexp.QuoteMeta(yasdbData))
+	if err != nil {
+		return ""
+	}
+	for _, p := range processes {
+		if home, data, ok := parseYasdbPath(p); ok && path.Clean(data) == path.Clean(yasdbData) {
+			return home
+		}
+	}
+	return ""
+}
+
+// parseYasdbPath returns YASDB_HOME and YASDB_DATA in the command line of the yasdb process, YASDB_HOME is empty
+// if the command is not an absolute path.
+func parseYasdbPath(p processutil.Process) (yasdbHome string, yasdbData string, ok bool) {
+	fields := strings.Split(p.ReadableCmdline, "-D")
+	if len(fields) < 2 {
+		return
+	}
+	yasdbData = strings.TrimSpace(fields[1])
+	if full := strings.TrimSpace(p.FullCommand); path.IsAbs(full) {
+		yasdbHome = path.Dir(path.Dir(full))
+	}
+	return yasdbHome, yasdbData, true
+}
@@ -5,15 +5,22 @@ import (
 
 	constdef "ytc/defs/constants"
 	"ytc/defs/errdef"
+	"ytc/utils/pwdutil"
 	"ytc/utils/stringutil"
 	"ytc/utils/yasqlutil"
 )
 
 type YasdbEnv struct {
-	YasdbHome     string `json:"yasdbHome"`
-	YasdbData     string `json:"yasdbData"`
-	YasdbUser     string `json:"yasdbUser"`
-	YasdbPassword string `json:"yasdbPassword"`
+	YasdbHome     string         `json:"yasdbHome"`
+	YasdbData     string         `json:"yasdbData"`
+	YasdbUser     string         `json:"yasdbUser"`
+	YasdbPassword pwdutil.Secret `json:"-"`
+	YasdbAddress  string         `json:"yasdbAddress,omitempty"` // connect to yasdb over TCP with it if it is not empty
+}
+
+// IsRemote reports whether yasdb is connected over TCP, YASDB_DATA is not required then.
+func (y *YasdbEnv) IsRemote() bool {
+	return !stringutil.IsEmpty(y.YasdbAddress)
 }
 
 func (y *YasdbEnv) ValidYasdbHome() error {
@@ -44,7 +51,7 @@ func (y *YasdbEnv) ValidYasdbUser() error {
 }
 
 func (y *YasdbEnv) ValidYasdbPassword() error {
-	if stringutil.IsEmpty(y.YasdbPassword) {
+	if y.YasdbPassword.IsEmpty() {
 		return errdef.NewItemEmpty(constdef.YASDB_PASSWORD)
 	}
 	return nil
@@ -54,8 +61,10 @@ func (y *YasdbEnv) ValidYasdbUserAndPwd() error {
 	if err := y.ValidYasdbHome(); err != nil {
 		return err
 	}
-	if err := y.ValidYasdbData(); err != nil {
-		return err
+	if !y.IsRemote() {
+		if err := y.ValidYasdbData(); err != nil {
+			return err
+		}
 	}
 	if err := y.ValidYasdbUser(); err != nil {
 		return err
@@ -64,7 +73,10 @@ func (y *YasdbEnv) ValidYasdbUserAndPwd() error {
 	if err := y.ValidYasdbPassword(); err != nil {
 		return err
 	}
-	tx := yasqlutil.GetLocalInstance(y.YasdbUser, y.YasdbPassword, y.YasdbHome, y.YasdbData)
+	tx := yasqlutil.GetLocalInstance(y.YasdbUser, y.YasdbPassword.Reveal(), y.YasdbHome, y.YasdbData)
+	if y.IsRemote() {
+		tx = yasqlutil.GetRemoteInstance(y.YasdbUser, y.YasdbPassword.Reveal(), y.YasdbAddress, y.YasdbHome)
+	}
 	if err := tx.CheckPassword(); err != nil {
 		return err
 	}
@@ -0,0 +1,192 @@
+package yasdb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ytc/defs/errdef"
+	"ytc/utils/fileutil"
+	"ytc/utils/pwdutil"
+	"ytc/utils/stringutil"
+
+	"git.yasdb.com/go/yaserr"
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	DEFAULT_PROFILE = "default"
+
+	_profile_file_mode = 0600
+	_profile_key_ext   = ".key"
+)
+
+var (
+	_profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// profile is the stored yasdb env, the password is encrypted by pwdutil.Encrypt with the install key in
+// ProfileKeyPath of the profile file.
+type profile struct {
+	YasdbHome     string `toml:"yasdb_home"`
+	YasdbData     string `toml:"yasdb_data"`
+	YasdbUser     string `toml:"yasdb_user"`
+	YasdbPassword string `toml:"yasdb_password"`
+}
+
+type profileFile struct {
+	Profiles map[string]profile `toml:"profile"`
+}
+
+// ProfileKeyPath returns the install key file of the profile file, such as yasdb_profile.key of yasdb_profile.toml.
+func ProfileKeyPath(fname string) string {
+	return strings.TrimSuffix(fname, path.Ext(fname)) + _profile_key_ext
+}
+
+// ValidProfileName checks the name of a profile, it is used as a toml key.
+func ValidProfileName(name string) error {
+	if !_profileNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid profile name %s, only letters, digits, '_' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// LoadProfiles returns all profiles in fname, the passwords are decrypted.
+// An empty map is returned if fname does not exist.
+func LoadProfiles(fname string) (map[string]*YasdbEnv, error) {
+	stored, err := readProfileFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	envs := make(map[string]*YasdbEnv, len(stored.Profiles))
+	for name, p := range stored.Profiles {
+		env, err := p.toYasdbEnv(ProfileKeyPath(fname))
+		if err != nil {
+			return nil, yaserr.Wrapf(err, "profile %s", name)
+		}
+		envs[name] = env
+	}
+	return envs, nil
+}
+
+// LoadProfile returns the profile of name in fname.
+func LoadProfile(fname, name string) (*YasdbEnv, error) {
+	stored, err := readProfileFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := stored.Profiles[name]
+	if !ok {
+		return nil, errdef.NewErrProfileNotFound(name, fname)
+	}
+	env, err := p.toYasdbEnv(ProfileKeyPath(fname))
+	if err != nil {
+		return nil, yaserr.Wrapf(err, "profile %s", name)
+	}
+	return env, nil
+}
+
+// SaveProfile adds or replaces the profile of name in fname, the other profiles are kept.
+func SaveProfile(fname, name string, env *YasdbEnv) error {
+	if err := ValidProfileName(name); err != nil {
+		return err
+	}
+	stored, err := readProfileFile(fname)
+	if err != nil {
+		return err
+	}
+	p := profile{
+		YasdbHome: env.YasdbHome,
+		YasdbData: env.YasdbData,
+		YasdbUser: env.YasdbUser,
+	}
+	if !env.YasdbPassword.IsEmpty() {
+		installKey, err := pwdutil.LoadInstallKey(ProfileKeyPath(fname), true)
+		if err != nil {
+			return yaserr.Wrapf(err, "load install key")
+		}
+		if p.YasdbPassword, err = pwdutil.Encrypt(env.YasdbPassword.Reveal(), installKey); err != nil {
+			return yaserr.Wrapf(err, "encrypt password")
+		}
+	}
+	stored.Profiles[name] = p
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+	if err := encoder.Encode(stored); err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(fname, buf.Bytes(), _profile_file_mode)
+}
+
+// FillFromProfile fills the empty fields of yasdb env from the profile of name in fname.
+func (y *YasdbEnv) FillFromProfile(fname, name string) error {
+	env, err := LoadProfile(fname, name)
+	if err != nil {
+		return err
+	}
+	fill := func(v *string, value string) {
+		if stringutil.IsEmpty(*v) {
+			*v = value
+		}
+	}
+	fill(&y.YasdbHome, env.YasdbHome)
+	fill(&y.YasdbData, env.YasdbData)
+	fill(&y.YasdbUser, env.YasdbUser)
+	if y.YasdbPassword.IsEmpty() {
+		y.YasdbPassword = env.YasdbPassword
+	}
+	return nil
+}
+
+// SortedProfileNames returns the names of profiles in order.
+func SortedProfileNames(envs map[string]*YasdbEnv) []string {
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readProfileFile(fname string) (*profileFile, error) {
+	stored := &profileFile{Profiles: make(map[string]profile)}
+	if _, err := os.Stat(fname); err != nil {
+		if os.IsNotExist(err) {
+			return stored, nil
+		}
+		return nil, err
+	}
+	if _, err := toml.DecodeFile(fname, stored); err != nil {
+		return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: err}
+	}
+	if stored.Profiles == nil {
+		stored.Profiles = make(map[string]profile)
+	}
+	return stored, nil
+}
+
+func (p profile) toYasdbEnv(keyFile string) (*YasdbEnv, error) {
+	env := &YasdbEnv{
+		YasdbHome: p.YasdbHome,
+		YasdbData: p.YasdbData,
+		YasdbUser: p.YasdbUser,
+	}
+	if stringutil.IsEmpty(p.YasdbPassword) {
+		return env, nil
+	}
+	installKey, err := pwdutil.LoadInstallKey(keyFile, false)
+	if err != nil {
+		return nil, yaserr.Wrapf(err, "load install key")
+	}
+	password, err := pwdutil.Decrypt(p.YasdbPassword, installKey)
+	if err != nil {
+		return nil, yaserr.Wrapf(err, "decrypt password")
+	}
+	env.YasdbPassword = pwdutil.NewSecret(password)
+	return env, nil
+}
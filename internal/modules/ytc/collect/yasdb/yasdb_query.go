@@ -25,6 +25,11 @@ const (
 	QUERY_YASDB_INSTANCE_STATUS   = "select status,startup_time as startupTime from v$instance;"
 	QUERY_YASDB_DATABASE_STATUS   = "select status,open_mode as openMode from v$database"
 	QUERY_YASDB_PARAMETER_BY_NAME = "select name,value from v$parameter where name='%s'"
+	QUERY_YASDB_VERSION           = "select banner from v$version"
+
+	_where_snapshot_between = "BEGIN_INTERVAL_TIME >= TIMESTAMP('%s') and BEGIN_INTERVAL_TIME <= TIMESTAMP('%s')"
+	_where_slow_log_between = "START_TIME >= TIMESTAMP('%s') and START_TIME <= TIMESTAMP('%s')"
+	_where_sql_text         = "SQL_ID = ? and START_TIME = ? "
 )
 
 const (
@@ -40,6 +45,9 @@ var (
 	_instanceSelecter = &yasqlutil.SelectRaw{
 		RawSql: QUERY_YASDB_INSTANCE_STATUS,
 	}
+	_versionSelecter = &yasqlutil.SelectRaw{
+		RawSql: QUERY_YASDB_VERSION,
+	}
 
 	_wrmDatabaseInstanceSelecter = &yasqlutil.Select{
 		Table:   "sys.wrm$_database_instance",
@@ -96,6 +104,10 @@ type VInstance struct {
 	StartupTime string `json:"startupTime"`
 }
 
+type VVersion struct {
+	Banner string `json:"banner"`
+}
+
 type VDatabase struct {
 	Status   string `json:"status"`
 	OpenMode string `json:"openMode"`
@@ -176,6 +188,22 @@ func QueryInstance(tx *yasqlutil.Yasql) (*VInstance, error) {
 	return infos[0], nil
 }
 
+// QueryVersion returns the banners of v$version split with '\n', it is the version of yasdb when yasdb can not be executed locally.
+func QueryVersion(tx *yasqlutil.Yasql) (string, error) {
+	versions := make([]*VVersion, 0)
+	if err := tx.SelectRaw(_versionSelecter).Find(&versions).Error(); err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", yasqlutil.ErrRecordNotFound
+	}
+	banners := make([]string, 0, len(versions))
+	for _, v := range versions {
+		banners = append(banners, strings.TrimSpace(v.Banner))
+	}
+	return strings.Join(banners, "\n"), nil
+}
+
 func QueryWrmDatabaseInstance(tx *yasqlutil.Yasql) (*WrmDatabaseInstance, error) {
 	instances := make([]*WrmDatabaseInstance, 0)
 	err := tx.Select(_wrmDatabaseInstanceSelecter).Find(&instances).Error()
@@ -192,7 +220,7 @@ func QueryWrmDatabaseInstance(tx *yasqlutil.Yasql) (*WrmDatabaseInstance, error)
 func QueryWrmSnapsot(tx *yasqlutil.Yasql, start string, end string) ([]*WrmSnapshot, error) {
 	snaps := make([]*WrmSnapshot, 0)
 	err := tx.Select(_wrmSnapshotSelecter).
-		Where(fmt.Sprintf("BEGIN_INTERVAL_TIME >= TIMESTAMP('%s') and BEGIN_INTERVAL_TIME <= TIMESTAMP('%s')", start, end)).
+		Where(fmt.Sprintf(_where_snapshot_between, start, end)).
 		Find(&snaps).Error()
 	if err != nil {
 		return nil, err
@@ -207,7 +235,7 @@ func QueryWrmSnapsot(tx *yasqlutil.Yasql, start string, end string) ([]*WrmSnaps
 func QuerySlowLog(tx *yasqlutil.Yasql, start string, end string) ([]*SlowLog, error) {
 	slows := make([]*SlowLog, 0)
 	err := tx.Select(_SlowLogSelector).
-		Where(fmt.Sprintf("START_TIME >= TIMESTAMP('%s') and START_TIME <= TIMESTAMP('%s')", start, end)).
+		Where(fmt.Sprintf(_where_slow_log_between, start, end)).
 		Find(&slows).Error()
 	if err != nil {
 		return nil, err
@@ -221,9 +249,9 @@ func QuerySlowLog(tx *yasqlutil.Yasql, start string, end string) ([]*SlowLog, er
 }
 
 func (s *SlowLog) afterFind(tx *yasqlutil.Yasql) error {
-	newTx := yasqlutil.GetLocalInstance(tx.User, tx.Password, tx.YasqlHome, tx.YasdbData)
+	newTx := tx.Clone()
 	slowlogItems := []*SlowLog{}
-	if err := newTx.Select(_SqlTextSelector).Where("SQL_ID = ? and START_TIME = ? ", s.SQLID, s.StartTime).Find(&slowlogItems).Error(); err != nil {
+	if err := newTx.Select(_SqlTextSelector).Where(_where_sql_text, s.SQLID, s.StartTime).Find(&slowlogItems).Error(); err != nil {
 		return err
 	}
 	texts := []string{}
@@ -237,3 +265,42 @@ func (s *SlowLog) afterFind(tx *yasqlutil.Yasql) error {
 func (d *VDatabase) IsDatabaseInReadWwiteMode() bool {
 	return d.OpenMode == string(OPEN_MODE_READ_WRITE)
 }
+
+// the statements below are the same as the queries above, they are used to show the collection plan
+
+func ParameterSQL(item ParameterName) string {
+	return (&yasqlutil.SelectRaw{RawSql: fmt.Sprintf(QUERY_YASDB_PARAMETER_BY_NAME, item)}).SQL()
+}
+
+func AllParameterSQL() string {
+	return (&yasqlutil.SelectRaw{RawSql: QUERY_YASDB_ALL_PARAMETER}).SQL()
+}
+
+func DatabaseSQL() string {
+	return _databaseSelecter.SQL()
+}
+
+func InstanceSQL() string {
+	return _instanceSelecter.SQL()
+}
+
+func VersionSQL() string {
+	return _versionSelecter.SQL()
+}
+
+func WrmDatabaseInstanceSQL() string {
+	return _wrmDatabaseInstanceSelecter.SQL()
+}
+
+func WrmSnapshotSQL(start string, end string) string {
+	return _wrmSnapshotSelecter.SQL(fmt.Sprintf(_where_snapshot_between, start, end))
+}
+
+func SlowLogSQL(start string, end string) string {
+	return _SlowLogSelector.SQL(fmt.Sprintf(_where_slow_log_between, start, end))
+}
+
+// SqlTextSQL returns the statement to query the sql text of each slow log, the sql id and start time are left as '?'.
+func SqlTextSQL() string {
+	return _SqlTextSelector.SQL(_where_sql_text)
+}
@@ -0,0 +1,73 @@
+package ytcd
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"time"
+
+	"ytc/utils/fileutil"
+	"ytc/utils/stringutil"
+)
+
+// JobResult is the outcome of a scheduled collection.
+type JobResult struct {
+	Schedule  string    `json:"schedule"`
+	BeginTime time.Time `json:"beginTime"`
+	EndTime   time.Time `json:"endTime"`
+	Package   string    `json:"package,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type JobStatus struct {
+	Schedule string    `json:"schedule"`
+	Type     string    `json:"type"`
+	NextRun  time.Time `json:"nextRun"`
+}
+
+// Status is written by ytcd when it starts, reloads and finishes a job, and it is shown by 'ytcctl daemon status'.
+type Status struct {
+	Pid        int         `json:"pid"`
+	StartTime  time.Time   `json:"startTime"`
+	ReloadTime time.Time   `json:"reloadTime,omitempty"`
+	Running    string      `json:"running,omitempty"` // the schedule which is being collected
+	Jobs       []JobStatus `json:"jobs"`
+	LastResult *JobResult  `json:"lastResult,omitempty"`
+}
+
+func (r JobResult) Succeeded() bool {
+	return stringutil.IsEmpty(r.Error)
+}
+
+// NextJob returns the job which will be run first, returns nil if there is no job.
+func (s *Status) NextJob() (next *JobStatus) {
+	for i, job := range s.Jobs {
+		if next == nil || job.NextRun.Before(next.NextRun) {
+			next = &s.Jobs[i]
+		}
+	}
+	return
+}
+
+func WriteStatus(status *Status) error {
+	if err := os.MkdirAll(path.Dir(GetStatusFile()), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(status, "", "    ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteFileAtomic(GetStatusFile(), data, fileutil.DEFAULT_FILE_MODE)
+}
+
+func LoadStatus() (*Status, error) {
+	data, err := os.ReadFile(GetStatusFile())
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
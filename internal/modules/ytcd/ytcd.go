@@ -0,0 +1,88 @@
+// Package ytcd defines the runtime files of ytcd, which are shared by ytcd and 'ytcctl daemon'.
+package ytcd
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"ytc/defs/runtimedef"
+	"ytc/utils/fileutil"
+	"ytc/utils/processutil"
+)
+
+const (
+	APP_NAME = "ytcd"
+
+	_pid_file_name    = "ytcd.pid"
+	_status_file_name = "ytcd.status"
+	_out_file_name    = "ytcd.out"
+)
+
+func GetBin() string {
+	return path.Join(runtimedef.GetBinPath(), APP_NAME)
+}
+
+func GetPidFile() string {
+	return path.Join(runtimedef.GetRunPath(), _pid_file_name)
+}
+
+func GetStatusFile() string {
+	return path.Join(runtimedef.GetRunPath(), _status_file_name)
+}
+
+// GetOutFile returns the file which the stdout and stderr of ytcd are redirected to.
+func GetOutFile() string {
+	return path.Join(runtimedef.GetLogPath(), _out_file_name)
+}
+
+func WritePidFile(pid int) error {
+	if err := os.MkdirAll(runtimedef.GetRunPath(), 0755); err != nil {
+		return err
+	}
+	return fileutil.WritePidFile(GetPidFile(), pid)
+}
+
+func RemovePidFile() error {
+	if err := os.Remove(GetPidFile()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GetRunningPid returns the pid of the running ytcd, returns 0 if ytcd is not running.
+func GetRunningPid() (int, error) {
+	pidStr, err := fileutil.GetPidByPidFile(GetPidFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return 0, err
+	}
+	if !IsYtcdProcess(pid) {
+		// the pid file is left by a killed ytcd, or the pid has been reused by another process
+		return 0, nil
+	}
+	return pid, nil
+}
+
+// IsYtcdProcess reports whether the process of pid is running and it is ytcd.
+func IsYtcdProcess(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p := processutil.NewProcess(pid)
+	if _, running := p.IsRunning(); !running {
+		return false
+	}
+	items, err := p.GetCmdlineItems()
+	if err != nil || len(items) == 0 {
+		return false
+	}
+	return path.Base(strings.TrimSpace(items[0])) == APP_NAME
+}
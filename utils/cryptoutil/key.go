@@ -0,0 +1,126 @@
+// The cryptoutil package encrypts the collection results for transfer with a passphrase or a recipient public key,
+// and signs them with ed25519 keys.
+package cryptoutil
+
+import (
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"ytc/defs/errdef"
+	"ytc/utils/fileutil"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	PUBLIC_KEY_PEM_TYPE  = "YTC ENCRYPTION PUBLIC KEY"
+	PRIVATE_KEY_PEM_TYPE = "YTC ENCRYPTION PRIVATE KEY"
+
+	_key_size = curve25519.ScalarSize
+
+	_public_key_file_suffix = ".pub"
+)
+
+var (
+	ErrKeyNotGiven = errors.New("neither a public key nor a passphrase is given")
+)
+
+// EncryptKey is used to encrypt, the public key takes precedence over the passphrase.
+type EncryptKey struct {
+	PublicKey  []byte
+	Passphrase string
+}
+
+// DecryptKey is used to decrypt, the private key is tried if the package is encrypted with a public key.
+type DecryptKey struct {
+	PrivateKey []byte
+	Passphrase string
+}
+
+// GenerateKey generates an X25519 key pair.
+func GenerateKey() (publicKey, privateKey []byte, err error) {
+	privateKey = make([]byte, _key_size)
+	if _, err = io.ReadFull(rand.Reader, privateKey); err != nil {
+		return
+	}
+	publicKey, err = curve25519.X25519(privateKey, curve25519.Basepoint)
+	return
+}
+
+// WriteKeyFiles writes the PEM encoded private key into fname with mode 0600 and the public key into fname.pub,
+// the existing files are not overwritten.
+func WriteKeyFiles(fname string, publicKey, privateKey []byte) (publicKeyFile string, err error) {
+	publicKeyFile = fname + _public_key_file_suffix
+	if err = checkKeyFilesNotExist(fname, publicKeyFile); err != nil {
+		return
+	}
+	if err = writePEM(fname, &pem.Block{Type: PRIVATE_KEY_PEM_TYPE, Bytes: privateKey}, 0600); err != nil {
+		return
+	}
+	err = writePEM(publicKeyFile, &pem.Block{Type: PUBLIC_KEY_PEM_TYPE, Bytes: publicKey}, 0644)
+	return
+}
+
+// LoadPublicKey reads the public key written by WriteKeyFiles.
+func LoadPublicKey(fname string) ([]byte, error) {
+	return readPEM(fname, PUBLIC_KEY_PEM_TYPE)
+}
+
+// LoadPrivateKey reads the private key written by WriteKeyFiles, it must not be accessible by group or others.
+func LoadPrivateKey(fname string) ([]byte, error) {
+	if err := checkPrivateKeyFile(fname); err != nil {
+		return nil, err
+	}
+	return readPEM(fname, PRIVATE_KEY_PEM_TYPE)
+}
+
+func checkKeyFilesNotExist(fnames ...string) error {
+	for _, f := range fnames {
+		if _, err := os.Stat(f); err == nil {
+			return fmt.Errorf("%s already exists", f)
+		}
+	}
+	return nil
+}
+
+func checkPrivateKeyFile(fname string) error {
+	info, err := os.Stat(fname)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &errdef.ErrFileNotFound{Fname: fname}
+		}
+		return err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return errdef.NewErrInsecureFileMode(fname, info.Mode())
+	}
+	return nil
+}
+
+func writePEM(fname string, block *pem.Block, mode os.FileMode) error {
+	return fileutil.WriteFileAtomic(fname, pem.EncodeToMemory(block), mode)
+}
+
+func readPEMFile(fname string) ([]byte, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil && os.IsNotExist(err) {
+		return nil, &errdef.ErrFileNotFound{Fname: fname}
+	}
+	return data, err
+}
+
+func readPEM(fname, pemType string) ([]byte, error) {
+	data, err := readPEMFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemType || len(block.Bytes) != _key_size {
+		return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: fmt.Errorf("not a PEM encoded %s", pemType)}
+	}
+	return block.Bytes, nil
+}
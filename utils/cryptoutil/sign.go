@@ -0,0 +1,119 @@
+package cryptoutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"ytc/defs/errdef"
+)
+
+// The signing keys are the standard PKCS #8 and PKIX PEM, such as the ones generated by 'openssl genpkey -algorithm ed25519'.
+const (
+	SIGNING_PRIVATE_KEY_PEM_TYPE = "PRIVATE KEY"
+	SIGNING_PUBLIC_KEY_PEM_TYPE  = "PUBLIC KEY"
+
+	// the host where the signing key is generated, it is written into the header of the public key
+	PEM_HEADER_HOST = "Host"
+)
+
+// TrustedKey is an ed25519 public key trusted to sign the collection results.
+type TrustedKey struct {
+	PublicKey   ed25519.PublicKey
+	Host        string
+	Fingerprint string
+}
+
+// GenerateSigningKey generates an ed25519 key pair.
+func GenerateSigningKey() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// WriteSigningKeyFiles writes the private key into fname with mode 0600 and the public key with the host into fname.pub,
+// the existing files are not overwritten.
+func WriteSigningKeyFiles(fname string, publicKey ed25519.PublicKey, privateKey ed25519.PrivateKey, host string) (publicKeyFile string, err error) {
+	publicKeyFile = fname + _public_key_file_suffix
+	if err = checkKeyFilesNotExist(fname, publicKeyFile); err != nil {
+		return
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return
+	}
+	if err = writePEM(fname, &pem.Block{Type: SIGNING_PRIVATE_KEY_PEM_TYPE, Bytes: privateDER}, 0600); err != nil {
+		return
+	}
+	block := &pem.Block{Type: SIGNING_PUBLIC_KEY_PEM_TYPE, Headers: map[string]string{PEM_HEADER_HOST: host}, Bytes: publicDER}
+	err = writePEM(publicKeyFile, block, 0644)
+	return
+}
+
+// LoadSigningKey reads the ed25519 private key, it must not be accessible by group or others.
+func LoadSigningKey(fname string) (ed25519.PrivateKey, error) {
+	if err := checkPrivateKeyFile(fname); err != nil {
+		return nil, err
+	}
+	data, err := readPEMFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != SIGNING_PRIVATE_KEY_PEM_TYPE {
+		return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: fmt.Errorf("not a PEM encoded %s", SIGNING_PRIVATE_KEY_PEM_TYPE)}
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: err}
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: fmt.Errorf("%T is not an ed25519 private key", key)}
+	}
+	return privateKey, nil
+}
+
+// LoadTrustedKeys reads all the ed25519 public keys in the file, which are the public keys written by
+// WriteSigningKeyFiles appended one by one.
+func LoadTrustedKeys(fname string) ([]TrustedKey, error) {
+	data, err := readPEMFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var keys []TrustedKey
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			break
+		}
+		if block.Type != SIGNING_PUBLIC_KEY_PEM_TYPE {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: err}
+		}
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: fmt.Errorf("%T is not an ed25519 public key", key)}
+		}
+		keys = append(keys, TrustedKey{PublicKey: publicKey, Host: block.Headers[PEM_HEADER_HOST], Fingerprint: Fingerprint(publicKey)})
+	}
+	if len(keys) == 0 {
+		return nil, &errdef.ErrFileParseFailed{Fname: fname, Err: fmt.Errorf("no PEM encoded %s found", SIGNING_PUBLIC_KEY_PEM_TYPE)}
+	}
+	return keys, nil
+}
+
+// Fingerprint returns the SHA-256 of the public key, such as 'SHA256:<base64>'.
+func Fingerprint(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
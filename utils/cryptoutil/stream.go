@@ -0,0 +1,371 @@
+package cryptoutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The encrypted file is a header followed by the chunks sealed with AES-256-GCM, each chunk has 64KiB of plain text
+// except the last one. The nonce is the chunk counter with a flag of the last chunk, and the header is authenticated
+// with every chunk, so the reordered, truncated or modified chunks and header are detected.
+//
+//	magic "YTCENC1\n" | kind | passphrase: scrypt logN, salt | public key: ephemeral public key | chunks...
+const (
+	ENCRYPTED_FILE_SUFFIX = ".enc"
+
+	_magic = "YTCENC1\n"
+
+	_kind_passphrase byte = 1
+	_kind_public_key byte = 2
+
+	_salt_size        = 16
+	_scrypt_log_n     = 15
+	_scrypt_max_log_n = 20 // refuse the header which makes scrypt take too long
+	_scrypt_r         = 8
+	_scrypt_p         = 1
+	_file_key_size    = 32
+	_chunk_size       = 64 * 1024
+	_nonce_size       = 12
+	_hkdf_info        = "ytc package encryption"
+)
+
+var (
+	ErrKeyRequired   = errors.New("the file is encrypted, the private key or the passphrase is required")
+	ErrDecryptFailed = errors.New("decrypt failed, the key or the passphrase is wrong, or the file is modified or truncated")
+	ErrInvalidHeader = errors.New("invalid header of the encrypted file")
+)
+
+// NewEncryptWriter returns a writer which encrypts into w, it must be closed to write the last chunk,
+// and w is not closed by it.
+func NewEncryptWriter(w io.Writer, key EncryptKey) (io.WriteCloser, error) {
+	header, fileKey, err := newHeader(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, _chunk_size),
+		out:    make([]byte, 0, _chunk_size+aead.Overhead()),
+	}, nil
+}
+
+// NewDecryptReader returns a reader of the plain text of the file encrypted by NewEncryptWriter.
+func NewDecryptReader(r io.Reader, key DecryptKey) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, fileKey, err := readHeader(br, key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      br,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, _chunk_size+aead.Overhead()),
+	}, nil
+}
+
+// IsEncrypted reports whether the file is encrypted by NewEncryptWriter.
+func IsEncrypted(fname string) (bool, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	magic := make([]byte, len(_magic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+	return string(magic) == _magic, nil
+}
+
+// OpenFile opens the file for reading and decrypts it if it is encrypted, ErrKeyRequired is returned if key is nil.
+func OpenFile(fname string, key *DecryptKey) (io.ReadCloser, error) {
+	encrypted, err := IsEncrypted(fname)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return f, nil
+	}
+	if key == nil {
+		f.Close()
+		return nil, ErrKeyRequired
+	}
+	r, err := NewDecryptReader(f, *key)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &readCloser{Reader: r, Closer: f}, nil
+}
+
+// EncryptFile encrypts src into dst through a temporary file in the same dir, dst has the same mode as src.
+func EncryptFile(src, dst string, key EncryptKey) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(path.Dir(dst), "."+path.Base(dst)+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	w, err := NewEncryptWriter(tmp, key)
+	if err != nil {
+		tmp.Close()
+		return
+	}
+	if _, err = io.Copy(w, in); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = w.Close(); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	out     []byte
+	counter uint64
+	closed  bool
+}
+
+func (e *encryptWriter) Write(p []byte) (n int, err error) {
+	if e.closed {
+		return 0, os.ErrClosed
+	}
+	for len(p) > 0 {
+		// a full chunk is sealed only when more data comes, so the last chunk is empty only if the plain text is
+		if len(e.buf) == _chunk_size {
+			if err = e.seal(false); err != nil {
+				return
+			}
+		}
+		size := _chunk_size - len(e.buf)
+		if size > len(p) {
+			size = len(p)
+		}
+		e.buf = append(e.buf, p[:size]...)
+		p = p[size:]
+		n += size
+	}
+	return
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	e.out = e.aead.Seal(e.out[:0], chunkNonce(e.counter, last), e.buf, e.header)
+	e.buf = e.buf[:0]
+	e.counter++
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	plain   []byte
+	counter uint64
+	done    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	n, err := io.ReadFull(d.r, d.buf)
+	var last bool
+	switch err {
+	case nil:
+		_, err := d.r.Peek(1)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		last = err == io.EOF
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+	plain, err := d.aead.Open(d.buf[:0], chunkNonce(d.counter, last), d.buf[:n], d.header)
+	if err != nil {
+		return ErrDecryptFailed
+	}
+	d.plain, d.done = plain, last
+	d.counter++
+	return nil
+}
+
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, _nonce_size)
+	binary.BigEndian.PutUint64(nonce[_nonce_size-9:_nonce_size-1], counter)
+	if last {
+		nonce[_nonce_size-1] = 1
+	}
+	return nonce
+}
+
+func newAEAD(fileKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newHeader generates the header and the file key, the key is unique for each file since the salt and the ephemeral
+// key are random.
+func newHeader(key EncryptKey) (header, fileKey []byte, err error) {
+	header = []byte(_magic)
+	switch {
+	case len(key.PublicKey) != 0:
+		ephemeral := make([]byte, _key_size)
+		if _, err = io.ReadFull(rand.Reader, ephemeral); err != nil {
+			return
+		}
+		var ephemeralPublic []byte
+		if ephemeralPublic, err = curve25519.X25519(ephemeral, curve25519.Basepoint); err != nil {
+			return
+		}
+		header = append(append(header, _kind_public_key), ephemeralPublic...)
+		var shared []byte
+		if shared, err = curve25519.X25519(ephemeral, key.PublicKey); err != nil {
+			return
+		}
+		fileKey, err = deriveKey(shared, ephemeralPublic, key.PublicKey)
+	case len(key.Passphrase) != 0:
+		salt := make([]byte, _salt_size)
+		if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+			return
+		}
+		header = append(append(header, _kind_passphrase, _scrypt_log_n), salt...)
+		fileKey, err = scrypt.Key([]byte(key.Passphrase), salt, 1<<_scrypt_log_n, _scrypt_r, _scrypt_p, _file_key_size)
+	default:
+		err = ErrKeyNotGiven
+	}
+	return
+}
+
+func readHeader(r io.Reader, key DecryptKey) (header, fileKey []byte, err error) {
+	header = make([]byte, len(_magic)+1)
+	if _, err = io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(_magic)], []byte(_magic)) {
+		return nil, nil, ErrInvalidHeader
+	}
+	switch header[len(_magic)] {
+	case _kind_public_key:
+		ephemeralPublic := make([]byte, _key_size)
+		if _, err = io.ReadFull(r, ephemeralPublic); err != nil {
+			return nil, nil, ErrInvalidHeader
+		}
+		header = append(header, ephemeralPublic...)
+		if len(key.PrivateKey) == 0 {
+			return nil, nil, errors.New("the file is encrypted with a public key, the private key is required")
+		}
+		var publicKey, shared []byte
+		if publicKey, err = curve25519.X25519(key.PrivateKey, curve25519.Basepoint); err != nil {
+			return
+		}
+		if shared, err = curve25519.X25519(key.PrivateKey, ephemeralPublic); err != nil {
+			return nil, nil, ErrInvalidHeader
+		}
+		fileKey, err = deriveKey(shared, ephemeralPublic, publicKey)
+	case _kind_passphrase:
+		params := make([]byte, 1+_salt_size)
+		if _, err = io.ReadFull(r, params); err != nil || params[0] > _scrypt_max_log_n {
+			return nil, nil, ErrInvalidHeader
+		}
+		header = append(header, params...)
+		if len(key.Passphrase) == 0 {
+			return nil, nil, errors.New("the file is encrypted with a passphrase, the passphrase is required")
+		}
+		fileKey, err = scrypt.Key([]byte(key.Passphrase), params[1:], 1<<params[0], _scrypt_r, _scrypt_p, _file_key_size)
+	default:
+		return nil, nil, ErrInvalidHeader
+	}
+	return
+}
+
+// deriveKey derives the file key from the X25519 shared secret, both public keys are bound to it.
+func deriveKey(shared, ephemeralPublic, recipientPublic []byte) ([]byte, error) {
+	salt := append(append([]byte{}, ephemeralPublic...), recipientPublic...)
+	fileKey := make([]byte, _file_key_size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(_hkdf_info)), fileKey); err != nil {
+		return nil, err
+	}
+	return fileKey, nil
+}
@@ -0,0 +1,83 @@
+package cryptoutil_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"ytc/utils/cryptoutil"
+)
+
+func TestEncryptStream(t *testing.T) {
+	publicKey, privateKey, err := cryptoutil.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := cryptoutil.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptKeys := map[string]cryptoutil.EncryptKey{
+		"public key": {PublicKey: publicKey},
+		"passphrase": {Passphrase: "yasdb_123"},
+	}
+	decryptKeys := map[string]cryptoutil.DecryptKey{
+		"public key": {PrivateKey: privateKey},
+		"passphrase": {Passphrase: "yasdb_123"},
+	}
+	wrongKeys := map[string]cryptoutil.DecryptKey{
+		"public key": {PrivateKey: otherKey},
+		"passphrase": {Passphrase: "yasdb_1234"},
+	}
+	// empty, less than a chunk, exactly a chunk and more than a chunk
+	for _, size := range []int{0, 100, 64 * 1024, 200*1024 + 7} {
+		plain := make([]byte, size)
+		for i := range plain {
+			plain[i] = byte(i % 251)
+		}
+		for kind, key := range encryptKeys {
+			var buf bytes.Buffer
+			w, err := cryptoutil.NewEncryptWriter(&buf, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(plain); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			encrypted := buf.Bytes()
+
+			if got, err := decrypt(encrypted, decryptKeys[kind]); err != nil || !bytes.Equal(got, plain) {
+				t.Errorf("%s %d: decrypt err: %v, equal: %t", kind, size, err, bytes.Equal(got, plain))
+			}
+			if _, err := decrypt(encrypted, wrongKeys[kind]); err == nil {
+				t.Errorf("%s %d: decrypted with the wrong key", kind, size)
+			}
+			truncated := encrypted[:len(encrypted)-1]
+			if _, err := decrypt(truncated, decryptKeys[kind]); err == nil {
+				t.Errorf("%s %d: the truncated file is decrypted", kind, size)
+			}
+			if size > 64*1024 {
+				// drop the last chunk, the previous one is not sealed as the last
+				if _, err := decrypt(encrypted[:len(encrypted)-(size%(64*1024))-16], decryptKeys[kind]); err != cryptoutil.ErrDecryptFailed {
+					t.Errorf("%s %d: the file without the last chunk is decrypted: %v", kind, size, err)
+				}
+			}
+			modified := append([]byte{}, encrypted...)
+			modified[len(modified)-20] ^= 1
+			if _, err := decrypt(modified, decryptKeys[kind]); err == nil {
+				t.Errorf("%s %d: the modified file is decrypted", kind, size)
+			}
+		}
+	}
+}
+
+func decrypt(data []byte, key cryptoutil.DecryptKey) ([]byte, error) {
+	r, err := cryptoutil.NewDecryptReader(bytes.NewReader(data), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
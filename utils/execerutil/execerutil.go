@@ -1,18 +1,28 @@
 package execerutil
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
 	"git.yasdb.com/go/yaslog"
 	"git.yasdb.com/go/yasutil/execer"
 )
 
 type Execer struct {
 	execer.Execer
+	log yaslog.YasLog
 }
 
 func NewExecer(log yaslog.YasLog, opts ...execer.ExecerOpt) *Execer {
 	opts = append(opts, execer.WithPrintResult()) // default print result in debug mode
 	return &Execer{
 		Execer: *execer.NewExecer(log, opts...),
+		log:    log,
 	}
 }
 
@@ -24,6 +34,50 @@ func (e *Execer) EnvExec(env []string, bin string, arg ...string) (int, string,
 	return e.Execer.EnvExec(env, bin, arg...)
 }
 
+// ExecContext is the same as Exec, but the process and its children are killed when ctx is done.
+func (e *Execer) ExecContext(ctx context.Context, bin string, arg ...string) (int, string, string) {
+	return e.EnvExecContext(ctx, nil, bin, arg...)
+}
+
+// EnvExecContext is the same as EnvExec, but the process and its children are killed when ctx is done.
+func (e *Execer) EnvExecContext(ctx context.Context, env []string, bin string, arg ...string) (int, string, string) {
+	cmd := exec.Command(bin, arg...)
+	cmd.Env = append(os.Environ(), env...)
+	// run in a new process group, so that the children of bash can be killed together
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	e.log.Debugf("exec: %s %s", bin, strings.Join(arg, " "))
+	if err := cmd.Start(); err != nil {
+		e.log.Errorf("exec %s start failed: %s", bin, err.Error())
+		return -1, "", err.Error()
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-done:
+		}
+	}()
+	err := cmd.Wait()
+	if ctx.Err() != nil {
+		e.log.Errorf("exec %s stopped: %s", bin, ctx.Err().Error())
+		return -1, stdout.String(), ctx.Err().Error()
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), stdout.String(), stderr.String()
+		}
+		e.log.Errorf("exec %s err: %s", bin, err.Error())
+		return -1, stdout.String(), err.Error()
+	}
+	return 0, stdout.String(), stderr.String()
+}
+
 func (e *Execer) Daemonize(bin string, arg ...string) error {
 	return e.Execer.Daemonize(bin, arg...)
 }
@@ -0,0 +1,478 @@
+package fileutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileErrors records the files which failed, the other files are still processed.
+type FileErrors map[string]error
+
+func (e FileErrors) Error() string {
+	var files []string
+	for f := range e {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	var msgs []string
+	for _, f := range files {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", f, e[f].Error()))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// WrapWriter wraps the temporary tar file, the gzip stream is written through the returned writer, which is closed
+// before the tar file is renamed.
+type WrapWriter func(w io.Writer) (io.WriteCloser, error)
+
+// TarOpt is the option of TarGz and NewTarGzStream.
+type TarOpt func(o *tarOptions)
+
+type tarOptions struct {
+	wrap WrapWriter
+}
+
+// WithWrapWriter makes the gzip stream be written through the writer of wrap, such as an encrypting one,
+// so that nothing else reaches the tar file.
+func WithWrapWriter(wrap WrapWriter) TarOpt {
+	return func(o *tarOptions) {
+		o.wrap = wrap
+	}
+}
+
+// createTarFile creates the temporary tar file in the same directory as tarPath, and returns the writer
+// of the gzip stream, which is the wrapped one if WithWrapWriter is given.
+func createTarFile(tarPath string, opts []TarOpt) (*os.File, io.WriteCloser, error) {
+	var o tarOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tmp, err := os.CreateTemp(path.Dir(tarPath), "."+path.Base(tarPath)+".*")
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.wrap == nil {
+		return tmp, nopWriteCloser{tmp}, nil
+	}
+	w, err := o.wrap(tmp)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, nil, err
+	}
+	return tmp, w, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// closeTarFile closes the writers of the tar file in order and renames it to tarPath.
+func closeTarFile(tmp *os.File, tw *tar.Writer, gw *gzip.Writer, w io.WriteCloser, tarPath string) error {
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(DEFAULT_FILE_MODE); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), tarPath)
+}
+
+// TarGz archives dir into the gzip compressed tar file, the entries are placed under the base name of dir
+// in lexical order and the symlinks are archived as links. The files which can not be read are skipped and
+// returned as FileErrors after the tar file is created, the tar file is not created if writing it failed.
+func TarGz(dir, tarPath string, opts ...TarOpt) (err error) {
+	dir = filepath.Clean(dir)
+	tmp, w, err := createTarFile(tarPath, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tmp.Close()
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	failed := make(FileErrors)
+	walkErr := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		name, err := filepath.Rel(filepath.Dir(dir), p)
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		return addTarEntry(tw, p, filepath.ToSlash(name), d, failed)
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+	if err = closeTarFile(tmp, tw, gw, w, tarPath); err != nil {
+		return err
+	}
+	if len(failed) != 0 {
+		return failed
+	}
+	return nil
+}
+
+// TarGzTo archives the files or dirs of names in root into w as a gzip compressed tar stream, the entries are
+// placed relative to root. Unlike TarGz, it stops at the first file which failed, since the stream can not be
+// taken back.
+func TarGzTo(w io.Writer, root string, names []string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	failed := make(FileErrors)
+	for _, name := range names {
+		walkErr := filepath.WalkDir(path.Join(root, name), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			if err := addTarEntry(tw, p, filepath.ToSlash(rel), d, failed); err != nil {
+				return err
+			}
+			if len(failed) != 0 {
+				return failed
+			}
+			return nil
+		})
+		if walkErr != nil {
+			return walkErr
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// addTarEntry records the error of the file which can not be read, the error of writing the tar file is returned.
+func addTarEntry(tw *tar.Writer, p, name string, d fs.DirEntry, failed FileErrors) error {
+	info, err := d.Info()
+	if err != nil {
+		failed[p] = err
+		return nil
+	}
+	var link string
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		if link, err = os.Readlink(p); err != nil {
+			failed[p] = err
+			return nil
+		}
+	case !info.Mode().IsRegular() && !info.IsDir():
+		// sockets, pipes and devices are not collected
+		return nil
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		failed[p] = err
+		return nil
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if !info.Mode().IsRegular() {
+		return tw.WriteHeader(header)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		failed[p] = err
+		return nil
+	}
+	defer f.Close()
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	// the file may change after its size is taken, such as a live log, only the size in the header is archived and
+	// a short read is padded with zeros, so that the changing file does not abort the whole archive
+	r := &entryReader{r: f}
+	n, err := io.CopyN(tw, r, header.Size)
+	if err != nil && r.err == nil {
+		return fmt.Errorf("archive %s: %w", p, err)
+	}
+	if n < header.Size {
+		if _, err := io.CopyN(tw, zeroReader{}, header.Size-n); err != nil {
+			return fmt.Errorf("archive %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// entryReader records the error of reading the file, so that it is told from the error of writing the tar file.
+type entryReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *entryReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// TarGzStream archives the files of a dir into a gzip compressed tar file while the dir is being filled,
+// the archived files are removed from the dir, so that the peak disk usage is the size of the tar file plus the size
+// of the files which are not flushed yet.
+// It is written to a temporary file in the same directory as the tar file, which is renamed when closing.
+type TarGzStream struct {
+	dir      string
+	tarPath  string
+	tmp      *os.File
+	w        io.WriteCloser
+	gw       *gzip.Writer
+	tw       *tar.Writer
+	archived map[string]struct{} // the dirs and the files which could not be removed
+	closed   bool
+	err      error // the error of writing the tar file, nothing could be archived after it
+	mtx      sync.Mutex
+}
+
+func NewTarGzStream(dir, tarPath string, opts ...TarOpt) (*TarGzStream, error) {
+	tmp, w, err := createTarFile(tarPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	gw := gzip.NewWriter(w)
+	return &TarGzStream{
+		dir:      filepath.Clean(dir),
+		tarPath:  tarPath,
+		tmp:      tmp,
+		w:        w,
+		gw:       gw,
+		tw:       tar.NewWriter(gw),
+		archived: make(map[string]struct{}),
+	}, nil
+}
+
+// Flush archives the files in the dir in lexical order and removes them, the dirs are kept.
+// The files which failed are returned as FileErrors, they are archived again by the next flush.
+func (s *TarGzStream) Flush() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	return s.flush()
+}
+
+// Close archives the rest files and renames the tar file, it is still renamed if some files failed.
+func (s *TarGzStream) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return s.err
+	}
+	s.closed = true
+	flushErr := s.flush()
+	if _, ok := flushErr.(FileErrors); flushErr != nil && !ok {
+		s.tmp.Close()
+		os.Remove(s.tmp.Name())
+		return flushErr
+	}
+	if s.err = s.finish(); s.err != nil {
+		os.Remove(s.tmp.Name())
+		return s.err
+	}
+	return flushErr
+}
+
+func (s *TarGzStream) flush() error {
+	if s.err != nil {
+		return s.err
+	}
+	failed := make(FileErrors)
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		if _, ok := s.archived[p]; ok {
+			return nil
+		}
+		name, err := filepath.Rel(filepath.Dir(s.dir), p)
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		if err := addTarEntry(s.tw, p, filepath.ToSlash(name), d, failed); err != nil {
+			return err
+		}
+		if _, ok := failed[p]; ok {
+			return nil
+		}
+		if d.IsDir() {
+			s.archived[p] = struct{}{}
+			return nil
+		}
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			failed[p] = err
+			s.archived[p] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		s.err = err
+		return err
+	}
+	if len(failed) != 0 {
+		return failed
+	}
+	return nil
+}
+
+func (s *TarGzStream) finish() error {
+	defer s.tmp.Close()
+	return closeTarFile(s.tmp, s.tw, s.gw, s.w, s.tarPath)
+}
+
+// CopyTree copies the directory src to dest in lexical order, the permissions are kept and the symlinks are copied as links.
+// The failed files are skipped and returned as FileErrors.
+func CopyTree(src, dest string) error {
+	src = filepath.Clean(src)
+	failed := make(FileErrors)
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			failed[p] = err
+			return nil
+		}
+		if err := copyEntry(p, filepath.Join(dest, rel), d); err != nil {
+			failed[p] = err
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(failed) != 0 {
+		return failed
+	}
+	return nil
+}
+
+func copyEntry(src, dest string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		if err := os.MkdirAll(dest, info.Mode().Perm()); err != nil {
+			return err
+		}
+		// MkdirAll is affected by the umask
+		return os.Chmod(dest, info.Mode().Perm())
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dest)
+	case info.Mode().IsRegular():
+		return copyRegularFile(context.Background(), src, dest, info.Mode().Perm())
+	}
+	return nil
+}
+
+// CopyFileContext copies the regular file src to dest with its permissions, the copy stops once ctx is done
+// and the partial dest is removed.
+func CopyFileContext(ctx context.Context, src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := copyRegularFile(ctx, src, dest, info.Mode().Perm()); err != nil {
+		if ctx.Err() != nil {
+			_ = os.Remove(dest)
+		}
+		return err
+	}
+	return nil
+}
+
+// contextReader fails once ctx is done, so that the copy of a large file stops in time.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func copyRegularFile(ctx context.Context, src, dest string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, contextReader{ctx: ctx, r: in}); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dest, perm)
+}
@@ -0,0 +1,317 @@
+package fileutil_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"ytc/utils/fileutil"
+)
+
+func prepareTree(t *testing.T) string {
+	src := path.Join(t.TempDir(), "ytc-20230101120000")
+	for _, dir := range []string{"b dir", "a;dir"} {
+		if err := os.MkdirAll(path.Join(src, dir), 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path.Join(src, "a;dir", "run.log"), []byte("run log"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../a;dir/run.log", path.Join(src, "b dir", "link.log")); err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestTarGz(t *testing.T) {
+	src := prepareTree(t)
+	tarPath := src + ".tar.gz"
+	if err := fileutil.TarGz(src, tarPath); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+		switch header.Name {
+		case "ytc-20230101120000/b dir/link.log":
+			if header.Typeflag != tar.TypeSymlink || header.Linkname != "../a;dir/run.log" {
+				t.Fatalf("unexpected symlink header: %+v", header)
+			}
+		case "ytc-20230101120000/a;dir/run.log":
+			if header.Mode&0777 != 0600 {
+				t.Fatalf("unexpected mode: %o", header.Mode)
+			}
+		}
+	}
+	expected := []string{
+		"ytc-20230101120000/",
+		"ytc-20230101120000/a;dir/",
+		"ytc-20230101120000/a;dir/run.log",
+		"ytc-20230101120000/b dir/",
+		"ytc-20230101120000/b dir/link.log",
+	}
+	if len(names) != len(expected) {
+		t.Fatalf("unexpected entries: %v", names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("unexpected entries: %v", names)
+		}
+	}
+}
+
+func TestTarGzUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read the file without permissions")
+	}
+	src := prepareTree(t)
+	unreadable := path.Join(src, "a;dir", "secret.log")
+	if err := os.WriteFile(unreadable, []byte("secret"), 0); err != nil {
+		t.Fatal(err)
+	}
+	tarPath := src + ".tar.gz"
+	err := fileutil.TarGz(src, tarPath)
+	failed, ok := err.(fileutil.FileErrors)
+	if !ok || len(failed) != 1 || failed[unreadable] == nil {
+		t.Fatalf("the unreadable file should be returned, got %v", err)
+	}
+	f, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	expected := "ytc-20230101120000/ ytc-20230101120000/a;dir/ ytc-20230101120000/a;dir/run.log ytc-20230101120000/b dir/ ytc-20230101120000/b dir/link.log"
+	if strings.Join(names, " ") != expected {
+		t.Fatalf("unexpected entries: %v", names)
+	}
+}
+
+func TestTarGzGrowingFile(t *testing.T) {
+	src := prepareTree(t)
+	fname := path.Join(src, "a;dir", "run.log")
+	if err := os.WriteFile(fname, []byte(strings.Repeat("a", 1<<20)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// the live log is appended while it is archived
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		f, err := os.OpenFile(fname, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		chunk := []byte(strings.Repeat("a", 4096))
+		for i := 0; i < 4096; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				_, _ = f.Write(chunk)
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
+	tarPath := src + ".tar.gz"
+	for i := 0; i < 5; i++ {
+		if err := fileutil.TarGz(src, tarPath); err != nil {
+			t.Fatalf("the growing file should not abort the archive: %v", err)
+		}
+		f, err := os.Open(tarPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.Name != "ytc-20230101120000/a;dir/run.log" {
+				continue
+			}
+			content, err := io.ReadAll(tr)
+			if err != nil || int64(len(content)) != header.Size || strings.Trim(string(content), "a") != "" {
+				t.Fatalf("unexpected content of %d bytes with size %d, err: %v", len(content), header.Size, err)
+			}
+		}
+		f.Close()
+	}
+}
+
+func TestTarGzWrapWriter(t *testing.T) {
+	src := prepareTree(t)
+	tarPath := src + ".tar.gz.b64"
+	wrap := fileutil.WithWrapWriter(func(w io.Writer) (io.WriteCloser, error) {
+		return base64.NewEncoder(base64.StdEncoding, w), nil
+	})
+	if err := fileutil.TarGz(src, tarPath, wrap); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(base64.NewDecoder(base64.StdEncoding, f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	if len(names) != 5 {
+		t.Fatalf("unexpected entries: %v", names)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src := prepareTree(t)
+	dest := path.Join(t.TempDir(), "static")
+	if err := fileutil.CopyTree(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path.Join(dest, "a;dir", "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected mode: %s", info.Mode())
+	}
+	link, err := os.Readlink(path.Join(dest, "b dir", "link.log"))
+	if err != nil || link != "../a;dir/run.log" {
+		t.Fatalf("unexpected link: %s, err: %v", link, err)
+	}
+}
+
+func TestCopyFileContext(t *testing.T) {
+	src := path.Join(prepareTree(t), "a;dir", "run.log")
+	dest := path.Join(t.TempDir(), "run.log")
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := fileutil.CopyFileContext(ctx, src, dest); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	dest = path.Join(path.Dir(dest), "cancelled.log")
+	if err := fileutil.CopyFileContext(ctx, src, dest); err != context.Canceled {
+		t.Fatalf("the copy should be cancelled, got %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("the partial file should be removed, got %v", err)
+	}
+}
+
+func TestTarGzStream(t *testing.T) {
+	dir := path.Join(t.TempDir(), "ytc-20230101120000")
+	if err := os.MkdirAll(path.Join(dir, "log"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tarPath := dir + ".tar.gz"
+	stream, err := fileutil.NewTarGzStream(dir, tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := path.Join(dir, "log", "run.log")
+	if err := os.WriteFile(first, []byte("run log"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Fatalf("the archived file is not removed, err: %v", err)
+	}
+	if err := os.WriteFile(path.Join(dir, "ytc-20230101120000.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Flush(); err == nil {
+		t.Fatal("flush after close")
+	}
+	f, err := os.Open(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	expected := "ytc-20230101120000/ ytc-20230101120000/log/ ytc-20230101120000/log/run.log ytc-20230101120000/ytc-20230101120000.json"
+	if strings.Join(names, " ") != expected {
+		t.Fatalf("unexpected entries: %v", names)
+	}
+}
@@ -169,6 +169,13 @@ func GetPidByPidFile(filePath string) (string, error) {
 	return strconv.FormatUint(uint64(pidUint), 10), nil
 }
 
+// WritePidFile writes pid in the format which can be read by GetPidByPidFile.
+func WritePidFile(filePath string, pid int) error {
+	buffer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buffer, uint32(pid))
+	return os.WriteFile(filePath, buffer, DEFAULT_FILE_MODE)
+}
+
 func GetFileErrDescAndTips(err error) (string, string) {
 	if err == nil {
 		return "", ""
@@ -1,8 +1,11 @@
 package fileutil
 
 import (
+	"bufio"
+	"io"
 	"io/fs"
 	"os"
+	"path"
 )
 
 const (
@@ -12,3 +15,82 @@ const (
 func WriteFile(fname string, data []byte) error {
 	return os.WriteFile(fname, data, DEFAULT_FILE_MODE)
 }
+
+// WriteFileAtomic writes data to a temporary file in the same directory and renames it to fname,
+// so the readers never see a partially written file.
+func WriteFileAtomic(fname string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(path.Dir(fname), "."+path.Base(fname)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fname)
+}
+
+// KeepTail keeps the last limit bytes of the file from the first whole line in them, and writes the line returned by
+// header before them. It returns the bytes removed from the head, the file is not changed if it is not larger than limit.
+func KeepTail(fname string, limit int64, header func(removed int64) string) (removed int64, err error) {
+	info, err := os.Stat(fname)
+	if err != nil || info.Size() <= limit {
+		return
+	}
+	src, err := os.Open(fname)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+	removed = info.Size() - limit
+	r := bufio.NewReader(io.NewSectionReader(src, removed, limit))
+	prev := make([]byte, 1)
+	if _, err = src.ReadAt(prev, removed-1); err != nil {
+		return 0, err
+	}
+	if prev[0] != '\n' {
+		partial, e := r.ReadBytes('\n')
+		switch {
+		case e == nil && int64(len(partial)) < limit:
+			removed += int64(len(partial))
+		case e == nil || e == io.EOF:
+			// the tail is a part of one line, it is kept as is
+			r = bufio.NewReader(io.NewSectionReader(src, removed, limit))
+		default:
+			return 0, e
+		}
+	}
+	tmp, err := os.CreateTemp(path.Dir(fname), "."+path.Base(fname)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(header(removed) + "\n"); err == nil {
+		_, err = io.Copy(tmp, r)
+	}
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err = os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmp.Name(), fname); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
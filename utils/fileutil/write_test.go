@@ -0,0 +1,47 @@
+package fileutil_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"ytc/utils/fileutil"
+)
+
+func TestKeepTail(t *testing.T) {
+	header := func(removed int64) string {
+		return fmt.Sprintf("... %d bytes truncated ...", removed)
+	}
+	cases := []struct {
+		Content string
+		Limit   int64
+		Expect  string
+		Removed int64
+	}{
+		{Content: "line1\nline2\nline3\n", Limit: 100, Expect: "line1\nline2\nline3\n"},
+		{Content: "line1\nline2\nline3\n", Limit: 8, Expect: "... 12 bytes truncated ...\nline3\n", Removed: 12},
+		{Content: "line1\nline2\nline3\n", Limit: 12, Expect: "... 6 bytes truncated ...\nline2\nline3\n", Removed: 6},
+		{Content: "a very long line\n", Limit: 5, Expect: "... 12 bytes truncated ...\nline\n", Removed: 12},
+	}
+	for i, c := range cases {
+		fname := path.Join(t.TempDir(), "run.log")
+		if err := os.WriteFile(fname, []byte(c.Content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		removed, err := fileutil.KeepTail(fname, c.Limit, header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if removed != c.Removed || string(content) != c.Expect {
+			t.Errorf("case %d: expect: %q, removed: %d, got: %q, removed: %d", i, c.Expect, c.Removed, content, removed)
+		}
+		if info, err := os.Stat(fname); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("case %d: the mode is not kept, err: %v", i, err)
+		}
+	}
+}
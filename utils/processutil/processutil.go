@@ -150,6 +150,21 @@ func (p *Process) GetCmdlineItems() ([]string, error) {
 	return items, nil
 }
 
+// GetEnv returns the value of the environment variable key of the process, it is empty if not set.
+func (p *Process) GetEnv(key string) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", p.Pid))
+	if err != nil {
+		return "", err
+	}
+	prefix := key + "="
+	for _, item := range strings.Split(string(data), "\x00") {
+		if strings.HasPrefix(item, prefix) {
+			return strings.TrimPrefix(item, prefix), nil
+		}
+	}
+	return "", nil
+}
+
 func (p *Process) parseInt(key, value string) (int, error) {
 	key = strings.TrimSpace(key)
 	if len(value) == 0 {
@@ -0,0 +1,150 @@
+package pwdutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"ytc/defs/errdef"
+)
+
+const (
+	INSTALL_KEY_FILE_MODE = 0600
+
+	_host_key_label   = "ytc/yasdb-profile/v2"
+	_install_key_size = 32
+)
+
+var (
+	// the files of machine id, the first readable one is used
+	_machine_id_files = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+	ErrMachineIDNotFound = errors.New("machine id not found, can not generate the host key")
+	ErrDecryptFailed     = errors.New("decrypt failed, the value may be encrypted on another host or with another install key, or modified")
+)
+
+// LoadInstallKey reads the random key of the installation from fname, which is readable only by its owner. The key
+// is generated into fname with INSTALL_KEY_FILE_MODE if fname does not exist and create is true.
+func LoadInstallKey(fname string, create bool) ([]byte, error) {
+	info, err := os.Stat(fname)
+	if os.IsNotExist(err) {
+		if !create {
+			return nil, &errdef.ErrFileNotFound{Fname: fname}
+		}
+		return generateInstallKey(fname)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, errdef.NewErrInsecureFileMode(fname, info.Mode())
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != _install_key_size {
+		return nil, fmt.Errorf("invalid install key %s", fname)
+	}
+	return key, nil
+}
+
+// generateInstallKey creates fname exclusively, so that the key generated at the same time by another process is
+// not overwritten, and that one is loaded instead.
+func generateInstallKey(fname string) ([]byte, error) {
+	key := make([]byte, _install_key_size)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(fname, os.O_CREATE|os.O_EXCL|os.O_WRONLY, INSTALL_KEY_FILE_MODE)
+	if os.IsExist(err) {
+		return LoadInstallKey(fname, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		f.Close()
+		os.Remove(fname)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(fname)
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encrypt encrypts plain with AES-256-GCM under a key derived from the machine id of the current host and the
+// install key returned by LoadInstallKey, the result is base64 encoded and can only be decrypted on the same host
+// with the same install key. The machine id is readable by all the users, the install key keeps the others out.
+func Encrypt(plain string, installKey []byte) (string, error) {
+	gcm, err := newHostGCM(installKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the value returned by Encrypt.
+func Decrypt(encrypted string, installKey []byte) (string, error) {
+	gcm, err := newHostGCM(installKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrDecryptFailed
+	}
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", ErrDecryptFailed
+	}
+	return string(plain), nil
+}
+
+func newHostGCM(installKey []byte) (cipher.AEAD, error) {
+	if len(installKey) != _install_key_size {
+		return nil, fmt.Errorf("the install key should be %d bytes", _install_key_size)
+	}
+	key, err := hostKey(installKey)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// hostKey derives a 256-bit key from the machine id and the install key.
+func hostKey(installKey []byte) ([]byte, error) {
+	for _, fname := range _machine_id_files {
+		data, err := os.ReadFile(fname)
+		if err != nil {
+			continue
+		}
+		id := strings.TrimSpace(string(data))
+		if len(id) == 0 {
+			continue
+		}
+		key := sha256.Sum256(append([]byte(_host_key_label+":"+id+":"), installKey...))
+		return key[:], nil
+	}
+	return nil, ErrMachineIDNotFound
+}
@@ -2,6 +2,7 @@ package pwdutil
 
 import (
 	"regexp"
+	"strings"
 )
 
 // PrintLogDesensitize hides the password.
@@ -42,3 +43,24 @@ func regexpReplace(expr, input, repl string) string {
 	}
 	return r.ReplaceAllString(input, repl)
 }
+
+// DesensitizeArgs hides the values of the given flags in command line arguments,
+// both '--flag value' and '--flag=value' are supported.
+func DesensitizeArgs(args []string, flags ...string) []string {
+	res := make([]string, len(args))
+	copy(res, args)
+	for i := 0; i < len(res); i++ {
+		for _, flag := range flags {
+			if res[i] == flag && i+1 < len(res) {
+				res[i+1] = SECRET_MASK
+				i++
+				break
+			}
+			if strings.HasPrefix(res[i], flag+"=") {
+				res[i] = flag + "=" + SECRET_MASK
+				break
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,134 @@
+package pwdutil_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"ytc/utils/pwdutil"
+)
+
+func TestSecret(t *testing.T) {
+	type param struct {
+		User     string
+		Password pwdutil.Secret
+	}
+	p := param{User: "sys", Password: pwdutil.NewSecret("yasdb_123")}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := []string{
+		string(data),
+		fmt.Sprintf("%v", p),
+		fmt.Sprintf("%+v", p),
+		fmt.Sprintf("%#v", p),
+		fmt.Sprint(p.Password),
+	}
+	for _, output := range outputs {
+		if strings.Contains(output, "yasdb_123") {
+			t.Errorf("secret leaked: %s", output)
+		}
+	}
+	var decoded param
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Password.IsEmpty() {
+		t.Errorf("secret should not be unmarshaled")
+	}
+	if p.Password.Reveal() != "yasdb_123" {
+		t.Errorf("reveal: %s", p.Password.Reveal())
+	}
+}
+
+func TestDesensitizeArgs(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Args   []string
+		Expect []string
+	}{
+		{
+			Name:   "separated",
+			Args:   []string{"collect", "--yasdb-password", "yasdb_123", "-y"},
+			Expect: []string{"collect", "--yasdb-password", pwdutil.SECRET_MASK, "-y"},
+		},
+		{
+			Name:   "equal",
+			Args:   []string{"collect", "--yasdb-password=yasdb_123"},
+			Expect: []string{"collect", "--yasdb-password=" + pwdutil.SECRET_MASK},
+		},
+		{
+			Name:   "last",
+			Args:   []string{"collect", "--yasdb-password"},
+			Expect: []string{"collect", "--yasdb-password"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			res := pwdutil.DesensitizeArgs(c.Args, "--yasdb-password")
+			if strings.Join(res, " ") != strings.Join(c.Expect, " ") {
+				t.Errorf("expect: %v, got: %v", c.Expect, res)
+			}
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	keyFile := path.Join(t.TempDir(), "yasdb_profile.key")
+	if _, err := pwdutil.LoadInstallKey(keyFile, false); err == nil {
+		t.Fatal("the missing install key should not be generated")
+	}
+	installKey, err := pwdutil.LoadInstallKey(keyFile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != pwdutil.INSTALL_KEY_FILE_MODE {
+		t.Fatalf("the install key should be readable only by the owner: %v, %v", info, err)
+	}
+	encrypted, err := pwdutil.Encrypt("yasdb_123", installKey)
+	if errors.Is(err, pwdutil.ErrMachineIDNotFound) {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(encrypted, "yasdb_123") {
+		t.Errorf("password leaked: %s", encrypted)
+	}
+	loaded, err := pwdutil.LoadInstallKey(keyFile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := pwdutil.Decrypt(encrypted, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain != "yasdb_123" {
+		t.Errorf("decrypt: %s", plain)
+	}
+	modified := "A" + encrypted[1:]
+	if encrypted[0] == 'A' {
+		modified = "B" + encrypted[1:]
+	}
+	if _, err := pwdutil.Decrypt(modified, installKey); !errors.Is(err, pwdutil.ErrDecryptFailed) {
+		t.Errorf("modified value should not be decrypted, got: %v", err)
+	}
+	otherKey, err := pwdutil.LoadInstallKey(path.Join(t.TempDir(), "other.key"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pwdutil.Decrypt(encrypted, otherKey); !errors.Is(err, pwdutil.ErrDecryptFailed) {
+		t.Errorf("the value should not be decrypted with another install key, got: %v", err)
+	}
+	if err := os.Chmod(keyFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pwdutil.LoadInstallKey(keyFile, false); err == nil {
+		t.Errorf("the install key readable by the others should be refused")
+	}
+}
@@ -0,0 +1,57 @@
+package pwdutil
+
+import "encoding/json"
+
+const (
+	SECRET_MASK = "******"
+)
+
+// Secret holds a sensitive value such as a password.
+// It is always masked when printed, logged or marshaled, and it is never restored by unmarshaling,
+// so Reveal is the only way to get the real value.
+type Secret struct {
+	value string
+}
+
+func NewSecret(value string) Secret {
+	return Secret{value: value}
+}
+
+// Reveal returns the real value, do not print or log it.
+func (s Secret) Reveal() string {
+	return s.value
+}
+
+func (s Secret) IsEmpty() bool {
+	return len(s.value) == 0
+}
+
+// [Interface Func]
+func (s Secret) String() string {
+	if s.IsEmpty() {
+		return ""
+	}
+	return SECRET_MASK
+}
+
+// [Interface Func]
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// [Interface Func]
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// [Interface Func]
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// [Interface Func]
+// UnmarshalJSON drops the value, a secret is never read back from serialized data.
+func (s *Secret) UnmarshalJSON([]byte) error {
+	*s = Secret{}
+	return nil
+}
@@ -0,0 +1,44 @@
+package sizeutil
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	KB int64 = 1 << (10 * (iota + 1))
+	MB
+	GB
+	TB
+)
+
+const (
+	// 512, 100K, 10M, 1G, 1T, the unit is case insensitive and an optional 'B' is allowed, such as '10MB'
+	_size_format = `^(?i)([0-9]+)([KMGT]?)B?$`
+)
+
+var (
+	_sizeRegex = regexp.MustCompile(_size_format)
+	_units     = map[string]int64{"": 1, "K": KB, "M": MB, "G": GB, "T": TB}
+
+	ErrSizeInvalid = errors.New("size should be such as '512', '100K', '10M', '1G' or '1T'")
+)
+
+// ParseSize returns the bytes of a size such as '10M' or '1G'.
+func ParseSize(s string) (int64, error) {
+	matches := _sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if len(matches) != 3 {
+		return 0, ErrSizeInvalid
+	}
+	n, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, ErrSizeInvalid
+	}
+	unit := _units[strings.ToUpper(matches[2])]
+	if n > (1<<63-1)/unit {
+		return 0, ErrSizeInvalid
+	}
+	return n * unit, nil
+}
@@ -0,0 +1,38 @@
+package sizeutil_test
+
+import (
+	"testing"
+
+	"ytc/utils/sizeutil"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		Size   string
+		Expect int64
+		Err    bool
+	}{
+		{Size: "512", Expect: 512},
+		{Size: "100K", Expect: 100 * sizeutil.KB},
+		{Size: "10mb", Expect: 10 * sizeutil.MB},
+		{Size: "1G", Expect: sizeutil.GB},
+		{Size: "2T", Expect: 2 * sizeutil.TB},
+		{Size: "", Err: true},
+		{Size: "1.5G", Err: true},
+		{Size: "-1G", Err: true},
+		{Size: "1P", Err: true},
+		{Size: "99999999999T", Err: true},
+	}
+	for _, c := range cases {
+		res, err := sizeutil.ParseSize(c.Size)
+		if c.Err {
+			if err == nil {
+				t.Errorf("%s: expect error, got: %d", c.Size, res)
+			}
+			continue
+		}
+		if err != nil || res != c.Expect {
+			t.Errorf("%s: expect: %d, got: %d, err: %v", c.Size, c.Expect, res, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// The sqlutil package normalizes the sql statements.
+package sqlutil
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	_punctReplacer = strings.NewReplacer("( ", "(", " )", ")", " , ", ", ", ", ", ", ", " ,", ", ", ",", ", ")
+	_inListRegex   = regexp.MustCompile(`\bin ?\(\?(?:, \?)*\)`)
+	_valuesRegex   = regexp.MustCompile(`\bvalues ?\(\?(?:, \?)*\)(?:, ?\(\?(?:, \?)*\))*`)
+)
+
+// Fingerprint normalizes the sql, so that the statements which differ only in the literals have the same fingerprint:
+// the string and number literals and the bind variables are replaced with '?', the lists of them in IN and VALUES
+// are collapsed into '?+', the comments except the hints are removed, and the rest is lowercased with the blanks
+// collapsed and normalized around the brackets and commas. The quoted identifiers are kept as they are.
+func Fingerprint(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+	space := false
+	writeSpace := func() {
+		if space && b.Len() != 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+	}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case isSpace(c):
+			space = true
+			i++
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			space = true
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql)
+			} else {
+				end += i + 4
+			}
+			if i+2 < len(sql) && sql[i+2] == '+' {
+				writeSpace()
+				b.WriteString(strings.ToLower(sql[i:end]))
+			}
+			i = end
+			space = true
+		case c == '\'':
+			i = skipQuoted(sql, i, '\'')
+			writeSpace()
+			b.WriteByte('?')
+		case c == '"':
+			end := skipQuoted(sql, i, '"')
+			writeSpace()
+			b.WriteString(sql[i:end])
+			i = end
+		case c == ':' && i+1 < len(sql) && isIdentChar(sql[i+1]):
+			// bind variables such as :1 and :name
+			i++
+			for i < len(sql) && isIdentChar(sql[i]) {
+				i++
+			}
+			writeSpace()
+			b.WriteByte('?')
+		case isDigit(c) || c == '.' && i+1 < len(sql) && isDigit(sql[i+1]):
+			i = skipNumber(sql, i)
+			writeSpace()
+			b.WriteByte('?')
+		case isIdentChar(c):
+			start := i
+			for i < len(sql) && isIdentChar(sql[i]) {
+				i++
+			}
+			writeSpace()
+			b.WriteString(strings.ToLower(sql[start:i]))
+		default:
+			writeSpace()
+			b.WriteByte(c)
+			i++
+		}
+	}
+	res := _punctReplacer.Replace(strings.TrimRight(b.String(), "; "))
+	res = _inListRegex.ReplaceAllString(res, "in (?+)")
+	return _valuesRegex.ReplaceAllString(res, "values (?+)")
+}
+
+// skipQuoted returns the index after the quoted string beginning at i, the doubled quotes are escaped ones.
+func skipQuoted(sql string, i int, quote byte) int {
+	for i++; i < len(sql); i++ {
+		if sql[i] != quote {
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(sql)
+}
+
+// skipNumber returns the index after the number beginning at i, such as 10, 1.5, .5, 1e-3 and 0x1F.
+func skipNumber(sql string, i int) int {
+	if strings.HasPrefix(strings.ToLower(sql[i:]), "0x") {
+		i += 2
+		for i < len(sql) && strings.ContainsRune("0123456789abcdefABCDEF", rune(sql[i])) {
+			i++
+		}
+		return i
+	}
+	for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.') {
+		i++
+	}
+	if i < len(sql) && (sql[i] == 'e' || sql[i] == 'E') {
+		j := i + 1
+		if j < len(sql) && (sql[j] == '+' || sql[j] == '-') {
+			j++
+		}
+		if j < len(sql) && isDigit(sql[j]) {
+			i = j
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_' || c == '$' || c == '#' || c >= 0x80
+}
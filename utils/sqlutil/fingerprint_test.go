@@ -0,0 +1,27 @@
+package sqlutil_test
+
+import (
+	"testing"
+
+	"ytc/utils/sqlutil"
+)
+
+func TestFingerprint(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM t WHERE id = 10 AND name = 'it''s'":                    "select * from t where id = ? and name = ?",
+		"select  a,b\n from t\twhere x in (1, 2,3) -- comment\n;":             "select a, b from t where x in (?+)",
+		"insert into t(a, b) values (1, 'x'), (2.5, 'y');":                    "insert into t(a, b) values (?+)",
+		"update t set v = -1.5e-3, w = 0x1F where k = :1 and j = :name":       "update t set v = -?, w = ? where k = ? and j = ?",
+		"select /*+ INDEX(t idx1) */ \"Col 1\" from t1 /* c */ where c2 > .5": "select /*+ index(t idx1) */ \"Col 1\" from t1 where c2 > ?",
+		"SELECT count(*) FROM t WHERE s IN ('a')":                             "select count(*) from t where s in (?+)",
+		"select t2.c1 from t2 join t3 on t2.id = t3.id":                       "select t2.c1 from t2 join t3 on t2.id = t3.id",
+	}
+	for sql, expected := range cases {
+		if got := sqlutil.Fingerprint(sql); got != expected {
+			t.Errorf("%q: expected %q, got %q", sql, expected, got)
+		}
+	}
+	if sqlutil.Fingerprint("select * from t where id = 1") != sqlutil.Fingerprint("select *  from T\nwhere ID = 2") {
+		t.Errorf("the statements differing in the literals should have the same fingerprint")
+	}
+}
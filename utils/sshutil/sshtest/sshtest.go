@@ -0,0 +1,205 @@
+// Package sshtest provides an ssh server on the loopback interface for the tests, it runs the commands of the
+// sessions locally by sh as the current user, whoever the client logs in as.
+package sshtest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Server is an ssh server listening on 127.0.0.1 with a random port.
+type Server struct {
+	Password       string          // the password accepted, no password is accepted if it is empty
+	AuthorizedKeys []ssh.PublicKey // the public keys accepted
+
+	listener net.Listener
+	hostKey  ssh.Signer
+	wg       sync.WaitGroup
+	mtx      sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewServer starts a server with a generated host key.
+func NewServer(password string, authorizedKeys ...ssh.PublicKey) (*Server, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Password:       password,
+		AuthorizedKeys: authorizedKeys,
+		listener:       listener,
+		hostKey:        hostKey,
+		conns:          make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host returns the host of the server, which is always 127.0.0.1.
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// KnownHostsLine returns the line of the host key in the known_hosts file.
+func (s *Server) KnownHostsLine() string {
+	addr := knownhosts.Normalize(net.JoinHostPort(s.Host(), strconv.Itoa(s.Port())))
+	return knownhosts.Line([]string{addr}, s.hostKey.PublicKey())
+}
+
+// Close stops the server and closes the connections.
+func (s *Server) Close() error {
+	s.mtx.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mtx.Unlock()
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	conf := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if len(s.Password) != 0 && string(password) == s.Password {
+				return nil, nil
+			}
+			return nil, errors.New("password rejected")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, k := range s.AuthorizedKeys {
+				if bytes.Equal(k.Marshal(), key.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, errors.New("public key rejected")
+		},
+	}
+	conf.AddHostKey(s.hostKey)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mtx.Lock()
+		if s.closed {
+			s.mtx.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mtx.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn, conf)
+			s.mtx.Lock()
+			delete(s.conns, conn)
+			s.mtx.Unlock()
+		}()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn, conf *ssh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, conf)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			_ = newChan.Reject(ssh.UnknownChannelType, "only session is supported")
+			continue
+		}
+		ch, reqs, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handleSession(ch, reqs)
+		}()
+	}
+}
+
+// handleSession runs the command of the exec request, the other requests such as env and pty are rejected.
+func handleSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		if req.Type != "exec" {
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		go ssh.DiscardRequests(reqs)
+		status := run(ch, payload.Command)
+		_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+func run(ch ssh.Channel, command string) uint32 {
+	cmd := exec.Command("sh", "-c", command)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		fmt.Fprintln(ch.Stderr(), err)
+		return 255
+	}
+	cmd.Stdout = ch
+	cmd.Stderr = ch.Stderr()
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(ch.Stderr(), err)
+		return 255
+	}
+	go func() {
+		_, _ = io.Copy(stdin, ch)
+		stdin.Close()
+	}()
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+			return uint32(exitErr.ExitCode())
+		}
+		return 255
+	}
+	return 0
+}
@@ -0,0 +1,276 @@
+package sshutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"ytc/utils/userutil"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	DEFAULT_PORT    = 22
+	DEFAULT_TIMEOUT = 10 * time.Second
+
+	_env_ssh_auth_sock = "SSH_AUTH_SOCK"
+)
+
+var (
+	ErrNoAuthMethod = errors.New("no identity file, ssh agent or password is available")
+
+	// the identity files tried in turn when none is given, the same as ssh
+	_default_identity_files = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+)
+
+// Config is how to connect to a host, the zero values are replaced by the defaults of ssh.
+type Config struct {
+	Host                  string
+	Port                  int
+	User                  string
+	IdentityFile          string // the private key without passphrase, ~/.ssh/id_* are tried if it is empty
+	Password              string
+	KnownHosts            string // ~/.ssh/known_hosts if it is empty
+	InsecureIgnoreHostKey bool   // do not check the host key, only for the hosts in a trusted network
+	Timeout               time.Duration
+}
+
+// Client runs commands on a host over ssh.
+type Client struct {
+	client *ssh.Client
+	agent  net.Conn
+}
+
+// ExitError is returned when the remote command exits with a non-zero status.
+type ExitError struct {
+	Status int
+	Stderr string // the stderr of the command if it is not written to the caller
+}
+
+func (e *ExitError) Error() string {
+	if len(e.Stderr) == 0 {
+		return fmt.Sprintf("exit status %d", e.Status)
+	}
+	return fmt.Sprintf("exit status %d: %s", e.Status, e.Stderr)
+}
+
+// Addr returns host:port of the config.
+func (c Config) Addr() string {
+	port := c.Port
+	if port == 0 {
+		port = DEFAULT_PORT
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// Dial connects to the host and authenticates with the identity file, the ssh agent and the password in turn.
+func Dial(conf Config) (*Client, error) {
+	c := &Client{}
+	auths, err := c.authMethods(conf)
+	if err != nil {
+		c.closeAgent()
+		return nil, err
+	}
+	hostKeyCallback, err := hostKeyCallback(conf)
+	if err != nil {
+		c.closeAgent()
+		return nil, err
+	}
+	user := conf.User
+	if len(user) == 0 {
+		if user, err = userutil.GetCurrentUser(); err != nil {
+			c.closeAgent()
+			return nil, err
+		}
+	}
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	client, err := ssh.Dial("tcp", conf.Addr(), &ssh.ClientConfig{
+		User:            user,
+		Auth:            auths,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		c.closeAgent()
+		return nil, err
+	}
+	c.client = client
+	return c, nil
+}
+
+// Run runs the command on the host, the command is killed when ctx is done. The stdin is closed after it is
+// read to the end. An ExitError is returned if the command exits with a non-zero status.
+func (c *Client) Run(ctx context.Context, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if err := session.Start(cmd); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = session.Signal(ssh.SIGKILL)
+			// not all the servers support the signal, closing the session stops waiting anyway
+			_ = session.Close()
+		case <-done:
+		}
+	}()
+	err = session.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Status: exitErr.ExitStatus()}
+	}
+	return err
+}
+
+// Output runs the command on the host and returns its stdout, the stderr is returned in the ExitError.
+func (c *Client) Output(ctx context.Context, cmd string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	err := c.Run(ctx, cmd, nil, &stdout, &stderr)
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Stderr = strings.TrimSpace(stderr.String())
+	}
+	return stdout.Bytes(), err
+}
+
+func (c *Client) Close() error {
+	c.closeAgent()
+	return c.client.Close()
+}
+
+func (c *Client) authMethods(conf Config) ([]ssh.AuthMethod, error) {
+	var auths []ssh.AuthMethod
+	signers, err := loadSigners(conf.IdentityFile)
+	if err != nil {
+		return nil, err
+	}
+	if sock := os.Getenv(_env_ssh_auth_sock); len(sock) != 0 {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			c.agent = conn
+			agentSigners, err := agent.NewClient(conn).Signers()
+			if err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+	if len(signers) != 0 {
+		auths = append(auths, ssh.PublicKeys(signers...))
+	}
+	if len(conf.Password) != 0 {
+		auths = append(auths, ssh.Password(conf.Password))
+	}
+	if len(auths) == 0 {
+		return nil, ErrNoAuthMethod
+	}
+	return auths, nil
+}
+
+func (c *Client) closeAgent() {
+	if c.agent != nil {
+		c.agent.Close()
+		c.agent = nil
+	}
+}
+
+// loadSigners loads the identity file, or the default ones in ~/.ssh which exist if it is empty.
+func loadSigners(identityFile string) ([]ssh.Signer, error) {
+	if len(identityFile) != 0 {
+		signer, err := loadSigner(identityFile)
+		if err != nil {
+			return nil, err
+		}
+		return []ssh.Signer{signer}, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+	var signers []ssh.Signer
+	for _, name := range _default_identity_files {
+		fname := path.Join(home, ".ssh", name)
+		if _, err := os.Stat(fname); err != nil {
+			continue
+		}
+		// the default ones which can not be used, such as the keys with passphrase, are skipped as ssh does
+		if signer, err := loadSigner(fname); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	return signers, nil
+}
+
+func loadSigner(fname string) (ssh.Signer, error) {
+	key, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		var passphraseErr *ssh.PassphraseMissingError
+		if errors.As(err, &passphraseErr) {
+			return nil, fmt.Errorf("identity file %s is protected by a passphrase, add it to the ssh agent instead", fname)
+		}
+		return nil, fmt.Errorf("parse identity file %s: %w", fname, err)
+	}
+	return signer, nil
+}
+
+func hostKeyCallback(conf Config) (ssh.HostKeyCallback, error) {
+	if conf.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	knownHosts := conf.KnownHosts
+	if len(knownHosts) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		knownHosts = path.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("load known hosts %s: %w", knownHosts, err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) == 0 {
+				return fmt.Errorf("host key of %s is not in %s, add it by ssh-keyscan or connect to it by ssh once", hostname, knownHosts)
+			}
+			return fmt.Errorf("host key of %s does not match the one in %s, it may be changed or someone is intercepting the connection", hostname, knownHosts)
+		}
+		return err
+	}, nil
+}
+
+// Quote quotes s for the posix shell of the remote host.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,83 @@
+package sshutil_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"ytc/utils/sshutil"
+	"ytc/utils/sshutil/sshtest"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := path.Join(dir, "id_ed25519")
+	if err := os.WriteFile(identityFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := sshtest.NewServer("", sshPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	knownHosts := path.Join(dir, "known_hosts")
+	if err := os.WriteFile(knownHosts, []byte(server.KnownHostsLine()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SSH_AUTH_SOCK", "")
+	conf := sshutil.Config{Host: server.Host(), Port: server.Port(), User: "ytc", IdentityFile: identityFile, KnownHosts: knownHosts}
+	client, err := sshutil.Dial(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	arg := "it's a test"
+	out, err := client.Output(context.Background(), "echo "+sshutil.Quote(arg))
+	if err != nil || strings.TrimSpace(string(out)) != arg {
+		t.Errorf("unexpected output %q, err: %v", out, err)
+	}
+	var stdout strings.Builder
+	if err := client.Run(context.Background(), "read -r line; echo \"got $line\"", strings.NewReader("secret\n"), &stdout, nil); err != nil || stdout.String() != "got secret\n" {
+		t.Errorf("unexpected stdout %q, err: %v", stdout.String(), err)
+	}
+	_, err = client.Output(context.Background(), "echo failed >&2; exit 3")
+	var exitErr *sshutil.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Status != 3 || exitErr.Stderr != "failed" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the host key which is not known is rejected
+	if err := os.WriteFile(knownHosts, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sshutil.Dial(conf); err == nil || !strings.Contains(err.Error(), "is not in") {
+		t.Errorf("the unknown host key should be rejected, got %v", err)
+	}
+	conf.InsecureIgnoreHostKey = true
+	conf.IdentityFile = ""
+	conf.Password = "wrong"
+	if _, err := sshutil.Dial(conf); err == nil {
+		t.Error("the wrong password should be rejected")
+	}
+}
@@ -82,8 +82,10 @@ func (b *bar) run() {
 	defer b.progress.wg.Done()
 	for _, t := range b.tasks {
 		go func(t *task) {
+			b.progress.acquire()
 			t.start()
 			t.wait()
+			b.progress.release()
 			b.bar.Increment()
 		}(t)
 	}
@@ -2,6 +2,7 @@ package barutil
 
 import (
 	"fmt"
+	"io"
 	"sync"
 
 	mpb "github.com/vbauerster/mpb/v8"
@@ -14,6 +15,8 @@ type Progress struct {
 	wg          *sync.WaitGroup
 	bars        []*bar
 	width       int
+	output      io.Writer
+	limit       chan struct{} // the tokens of the running tasks, nil means no limit
 }
 
 func WithWidth(width int) ProgressOpt {
@@ -22,6 +25,22 @@ func WithWidth(width int) ProgressOpt {
 	}
 }
 
+// WithOutput sets the writer of the progress bars, the default one is os.Stdout.
+func WithOutput(w io.Writer) ProgressOpt {
+	return func(p *Progress) {
+		p.output = w
+	}
+}
+
+// WithConcurrency limits the number of the tasks running at the same time in all the bars.
+func WithConcurrency(n int) ProgressOpt {
+	return func(p *Progress) {
+		if n > 0 {
+			p.limit = make(chan struct{}, n)
+		}
+	}
+}
+
 func NewProgress(opts ...ProgressOpt) *Progress {
 	group := new(sync.WaitGroup)
 	p := &Progress{
@@ -36,6 +55,9 @@ func NewProgress(opts ...ProgressOpt) *Progress {
 	if p.width != 0 {
 		mpbOpt = append(mpbOpt, mpb.WithWidth(p.width))
 	}
+	if p.output != nil {
+		mpbOpt = append(mpbOpt, mpb.WithOutput(p.output))
+	}
 	progress := mpb.New(mpbOpt...)
 	p.mpbProgress = progress
 	return p
@@ -53,6 +75,18 @@ func (p *Progress) AddBar(name string, namedWorker map[string]func() error) {
 	p.bars = append(p.bars, bar)
 }
 
+func (p *Progress) acquire() {
+	if p.limit != nil {
+		p.limit <- struct{}{}
+	}
+}
+
+func (p *Progress) release() {
+	if p.limit != nil {
+		<-p.limit
+	}
+}
+
 func (p *Progress) Start() {
 	for _, bar := range p.bars {
 		bar.draw()
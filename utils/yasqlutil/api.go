@@ -1,13 +1,19 @@
 package yasqlutil
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"os/exec"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
 
+	"ytc/defs/runtimedef"
 	"ytc/log"
+	"ytc/utils/stringutil"
 
 	"git.yasdb.com/go/yasutil/fs"
 )
@@ -20,7 +26,7 @@ func GetLocalInstance(user string, password string, yasqlHome string, yasdbData
 	return &Yasql{
 		Mutex:        &sync.Mutex{},
 		User:         user,
-		Password:     Quote(password),
+		Password:     password,
 		YasdbData:    yasdbData,
 		YasqlHome:    yasqlHome,
 		ConnectLocal: true,
@@ -37,7 +43,7 @@ func GetInstance(user string, password string, ip string, port uint, yasqlHome s
 	return &Yasql{
 		Mutex:        &sync.Mutex{},
 		User:         user,
-		Password:     Quote(password),
+		Password:     password,
 		Ip:           ip,
 		Port:         port,
 		YasqlHome:    yasqlHome,
@@ -46,6 +52,86 @@ func GetInstance(user string, password string, ip string, port uint, yasqlHome s
 	}
 }
 
+// GetRemoteInstance returns the yasql which connects to the yasdb of the address such as '192.168.1.2:1688' over TCP,
+// the invalid address is returned as the error of the yasql.
+func GetRemoteInstance(user string, password string, address string, yasqlHome string) *Yasql {
+	ip, port, err := ParseAddress(address)
+	tx := GetInstance(user, password, ip, port, yasqlHome)
+	if err != nil {
+		tx.err = err
+	}
+	return tx
+}
+
+// ParseAddress splits the address of yasdb such as '192.168.1.2:1688' or '[fe80::1]:1688' into the ip and the port.
+func ParseAddress(address string) (string, uint, error) {
+	ip, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(ip) == 0 {
+		return "", 0, fmt.Errorf("missing host in address %s", address)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return "", 0, fmt.Errorf("invalid port in address %s", address)
+	}
+	return ip, uint(port), nil
+}
+
+// Clone returns a yasql with the same connection and context as tx, its statement is empty.
+func (tx *Yasql) Clone() *Yasql {
+	return &Yasql{
+		Mutex:                 &sync.Mutex{},
+		User:                  tx.User,
+		Password:              tx.Password,
+		Ip:                    tx.Ip,
+		Port:                  tx.Port,
+		YasqlHome:             tx.YasqlHome,
+		YasdbData:             tx.YasdbData,
+		ConnectLocal:          tx.ConnectLocal,
+		SqlStatement:          &SqlStatement{},
+		IsGetTableNotExistErr: tx.IsGetTableNotExistErr,
+		ctx:                   tx.ctx,
+	}
+}
+
+// WithContext sets the context of the yasql commands, the running yasql is killed when it is done.
+func (tx *Yasql) WithContext(ctx context.Context) *Yasql {
+	tx.ctx = ctx
+	return tx
+}
+
+func (tx *Yasql) Context() context.Context {
+	if tx.ctx == nil {
+		return context.Background()
+	}
+	return tx.ctx
+}
+
+// Command returns a yasql command with the given args, such as '-c <sql>' or '-f <file>'.
+// Only the user is given in the connect string, the password is written to stdin when yasql asks for it,
+// so that it is not visible in the process list.
+func (tx *Yasql) Command(ctx context.Context, args ...string) *exec.Cmd {
+	yasqlBin := path.Join(tx.YasqlHome, BIN_PATH, YASQL_BIN)
+	env := []string{fmt.Sprintf("%s=%s", LIB_KEY, path.Join(tx.YasqlHome, LIB_PATH)), runtimedef.GetChildEnv()}
+	// the IPv6 address is enclosed in brackets
+	connectStr := tx.User + "@" + net.JoinHostPort(tx.Ip, strconv.FormatUint(uint64(tx.Port), 10))
+	if tx.ConnectLocal {
+		env = append(env, fmt.Sprintf("%s=%s", YASDB_DATA, tx.YasdbData))
+		connectStr = tx.User
+	}
+	cmd := exec.CommandContext(ctx, yasqlBin, append([]string{connectStr}, args...)...)
+	cmd.Env = env
+	cmd.Stdin = strings.NewReader(tx.Password + stringutil.STR_NEWLINE)
+	return cmd
+}
+
+// TrimPasswordPrompt removes the password prompt which yasql writes before the output.
+func TrimPasswordPrompt(stdout string) string {
+	return _yasqlPasswordPromptRegex.ReplaceAllString(stdout, "")
+}
+
 func (tx *Yasql) Error() error {
 	return tx.err
 }
@@ -86,6 +172,23 @@ func (tx *Yasql) Select(s *Select) *Yasql {
 	return tx
 }
 
+// SQL returns the statement which is queried by tx.Select(s).Where(where...).Find(), it is not executed.
+func (s *Select) SQL(where ...string) string {
+	stmt := &SqlStatement{
+		Select: str(SELECT, strings.Join(s.Columns, COMMA), FROM, s.Table),
+		Where:  where,
+	}
+	stmt.buildSql()
+	return stmt.Sql.String()
+}
+
+// SQL returns the statement which is queried by tx.SelectRaw(s).Find(), it is not executed.
+func (s *SelectRaw) SQL() string {
+	stmt := &SqlStatement{Select: strings.ReplaceAll(s.RawSql, ";", "")}
+	stmt.buildSql()
+	return stmt.Sql.String()
+}
+
 // Limits:
 // 1.sql & colTypes required.
 // 2.if table has a column, of which type is not string type -> use s.ColTypes
@@ -0,0 +1,38 @@
+package yasqlutil_test
+
+import (
+	"context"
+	"testing"
+
+	"ytc/utils/yasqlutil"
+)
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		address string
+		ip      string
+		port    uint
+	}{
+		{"192.168.1.2:1688", "192.168.1.2", 1688},
+		{"[fe80::1]:1688", "fe80::1", 1688},
+	}
+	for _, c := range cases {
+		ip, port, err := yasqlutil.ParseAddress(c.address)
+		if err != nil || ip != c.ip || port != c.port {
+			t.Errorf("%s: unexpected %s %d, err: %v", c.address, ip, port, err)
+		}
+	}
+	for _, address := range []string{"fe80::1:1688", "192.168.1.2", ":1688", "192.168.1.2:0"} {
+		if _, _, err := yasqlutil.ParseAddress(address); err == nil {
+			t.Errorf("%s: the invalid address is parsed", address)
+		}
+	}
+}
+
+func TestCommandConnectString(t *testing.T) {
+	tx := yasqlutil.GetRemoteInstance("sys", "pwd", "[fe80::1]:1688", t.TempDir())
+	cmd := tx.Command(context.Background(), "-c", "select 1")
+	if len(cmd.Args) < 2 || cmd.Args[1] != "sys@[fe80::1]:1688" {
+		t.Errorf("unexpected args: %v", cmd.Args)
+	}
+}
@@ -2,10 +2,10 @@ package yasqlutil
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
-	"path"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -57,8 +57,13 @@ const (
 	_yasqlErrPattern = ".*YASQL-[0-9]{5}.*"
 	_yasqlErrUser    = "please input user name"
 	_yasqlErrValue   = "Enter value for"
+
+	// yasql asks for the password when it is absent from the connect string
+	_yasqlPasswordPrompt = `(?i)^\s*(please\s+)?(input|enter)\s+(the\s+)?password\s*:?[ \t]*\n?`
 )
 
+var _yasqlPasswordPromptRegex = regexp.MustCompile(_yasqlPasswordPrompt)
+
 var (
 	ASC  Order = "asc"
 	DESC Order = "desc"
@@ -78,6 +83,7 @@ type Yasql struct {
 	HostId                string // carry host id
 	YasdbId               string // carry yasdb id
 	IsGetTableNotExistErr bool   // 如果为true 不将table view not exist err 转换为没有权限,如果为false  table view not exist err 转换为没有权限
+	ctx                   context.Context
 }
 
 type Select struct {
@@ -197,21 +203,12 @@ func (tx *Yasql) exec() (int, string, string) {
 	}
 	log.Yasql.Info(FormatInfo(sql, start))
 	code := cmd.ProcessState.ExitCode()
-	return code, stdout.String(), stderr.String()
+	return code, TrimPasswordPrompt(stdout.String()), stderr.String()
 }
 
 func (tx *Yasql) genCmd() *exec.Cmd {
 	defer tx.resetSqlStatement()
-	yasqlBin := path.Join(tx.YasqlHome, BIN_PATH, YASQL_BIN)
-	env := []string{fmt.Sprintf("%s=%s", LIB_KEY, path.Join(tx.YasqlHome, LIB_PATH))}
-	connectStr := fmt.Sprintf("%s/%s@%s:%d", tx.User, tx.Password, tx.Ip, tx.Port)
-	if tx.ConnectLocal {
-		env = append(env, fmt.Sprintf("%s=%s", YASDB_DATA, tx.YasdbData))
-		connectStr = fmt.Sprintf("%s/%s", tx.User, tx.Password)
-	}
-	cmd := exec.Command(yasqlBin, connectStr, "-c", tx.SqlStatement.Sql.String())
-	cmd.Env = env
-	return cmd
+	return tx.Command(tx.Context(), "-c", tx.SqlStatement.Sql.String())
 }
 
 // string contact